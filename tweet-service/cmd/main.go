@@ -2,12 +2,14 @@ package main
 
 import (
 	tweet "MussaShaukenov/twitter-clone-go/tweet-service/internal"
+	"MussaShaukenov/twitter-clone-go/tweet-service/pkg/utils"
 	"context"
 	"errors"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -36,12 +38,12 @@ func main() {
 
 	// Set up dependencies
 	config, err := setUpDependencies()
-	defer config.postgres.Close()
-	defer config.redis.Close()
-
 	if err != nil {
-		config.logger.Fatal("failed to set up dependencies: ", err)
+		panic(err)
 	}
+	defer config.postgres.Close()
+	defer config.redis.Close()
+	defer config.mongo.Disconnect(context.Background())
 
 	// Initialize apps
 	err = initializeApps(config)
@@ -101,7 +103,6 @@ func setUpDependencies() (*Config, error) {
 
 	// Mongo setup
 	mongoClient, err := mongoSetUp(os.Getenv("MONGO_URI"))
-	defer mongoClient.Disconnect(context.Background())
 	if err != nil {
 		sugar.Fatal("tweet-service: failed to connect to mongo: ", err)
 	}
@@ -120,14 +121,24 @@ func setUpDependencies() (*Config, error) {
 }
 
 func initializeApps(config *Config) error {
+	// the client address is taken from the gateway's headers only when the
+	// request came through the gateway
+	err := utils.SetTrustedProxies(strings.Split(os.Getenv("TRUSTED_PROXIES"), ","))
+	if err != nil {
+		return err
+	}
+
 	cfg := &tweet.Config{
 		Postgres: config.postgres,
 		Redis:    config.redis,
 		Logger:   config.logger,
 		Router:   config.router,
 		Mongo:    config.mongo.Database("twitter-clone"),
+
+		UserServiceURL: os.Getenv("USER_SERVICE_URL"),
+		InternalToken:  os.Getenv("INTERNAL_API_TOKEN"),
 	}
-	_, err := tweet.InitializeTweetApp(cfg)
+	_, err = tweet.InitializeTweetApp(cfg)
 	if err != nil {
 		return err
 	}
@@ -157,7 +168,8 @@ func mongoSetUp(uri string) (*mongo.Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 	err = client.Ping(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,165 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// client talks to the internal API of user-service.
+type client struct {
+	baseURL       string
+	internalToken string
+	httpClient    *http.Client
+}
+
+func NewUserServiceClient(baseURL, internalToken string) *client {
+	return &client{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		internalToken: internalToken,
+		httpClient:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// ResolveSession returns the caller a session token or API key belongs to.
+func (c *client) ResolveSession(token string) (*domain.Principal, error) {
+	if token == "" {
+		return nil, domain.ErrUnauthenticated
+	}
+
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/internal/auth/session", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUserServiceError, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, domain.ErrUnauthenticated
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, &domain.RateLimitError{RetryAfter: resp.Header.Get("Retry-After")}
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: status %d", domain.ErrUserServiceError, resp.StatusCode)
+	}
+
+	var principal domain.Principal
+	if err = json.NewDecoder(resp.Body).Decode(&principal); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUserServiceError, err)
+	}
+	return &principal, nil
+}
+
+// RecordAudit stores an audit record of a privileged action in user-service.
+func (c *client) RecordAudit(record domain.AuditRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/internal/audit", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Internal-Token", c.internalToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrUserServiceError, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("%w: status %d", domain.ErrUserServiceError, resp.StatusCode)
+	}
+	return nil
+}
+
+// Notify reports an event to user-service, which tells the user it concerns.
+func (c *client) Notify(event domain.NotificationEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/internal/notifications", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Internal-Token", c.internalToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrUserServiceError, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%w: status %d", domain.ErrUserServiceError, resp.StatusCode)
+	}
+	return nil
+}
+
+// DispatchWebhook reports an event to user-service, which delivers it to the
+// webhooks of the user it concerns.
+func (c *client) DispatchWebhook(event domain.WebhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/internal/webhooks/events", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Internal-Token", c.internalToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrUserServiceError, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%w: status %d", domain.ErrUserServiceError, resp.StatusCode)
+	}
+	return nil
+}
+
+// Relations returns the users whose tweets are shown to or hidden from userID.
+func (c *client) Relations(userID int) (*domain.Relations, error) {
+	url := fmt.Sprintf("%s/internal/users/%d/relations", c.baseURL, userID)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Internal-Token", c.internalToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUserServiceError, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", domain.ErrUserServiceError, resp.StatusCode)
+	}
+
+	var relations domain.Relations
+	if err = json.NewDecoder(resp.Body).Decode(&relations); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrUserServiceError, err)
+	}
+	return &relations, nil
+}
@@ -0,0 +1,119 @@
+package accounts
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase"
+	"MussaShaukenov/twitter-clone-go/tweet-service/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AccountController serves account operations to user-service. The steps of
+// the account deletion saga answer 204 on success, also when there was
+// nothing left to do.
+type AccountController struct {
+	useCase usecase.AccountUseCase
+}
+
+func NewAccountController(useCase usecase.AccountUseCase) *AccountController {
+	return &AccountController{
+		useCase: useCase,
+	}
+}
+
+func (c *AccountController) DeactivateHandler(w http.ResponseWriter, r *http.Request) {
+	c.run(w, r, "deactivate", c.useCase.Deactivate)
+}
+
+func (c *AccountController) ReactivateHandler(w http.ResponseWriter, r *http.Request) {
+	c.run(w, r, "reactivate", c.useCase.Reactivate)
+}
+
+func (c *AccountController) ProtectHandler(w http.ResponseWriter, r *http.Request) {
+	c.run(w, r, "protect", c.useCase.Protect)
+}
+
+func (c *AccountController) UnprotectHandler(w http.ResponseWriter, r *http.Request) {
+	c.run(w, r, "unprotect", c.useCase.Unprotect)
+}
+
+func (c *AccountController) PurgeHandler(w http.ResponseWriter, r *http.Request) {
+	c.run(w, r, "purge", func(userID int) error {
+		return c.useCase.Purge(r.Context(), userID)
+	})
+}
+
+// ExportHandler returns the user's tweets for their data export.
+func (c *AccountController) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tweets, err := c.useCase.Export(r.Context(), userID)
+	if err != nil {
+		log.Printf("failed to export tweets of user %d: %v", userID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = utils.WriteJson(w, http.StatusOK, map[string]interface{}{"tweets": tweets}, nil); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ExportLikesHandler returns the tweets the user liked for their data export.
+func (c *AccountController) ExportLikesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	likes, err := c.useCase.ExportLikes(userID)
+	if err != nil {
+		log.Printf("failed to export likes of user %d: %v", userID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = utils.WriteJson(w, http.StatusOK, map[string]interface{}{"likes": likes}, nil); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ActivityHandler sums up what every author tweeted since the time in the
+// since query parameter, for user-service to suggest whom to follow.
+func (c *AccountController) ActivityHandler(w http.ResponseWriter, r *http.Request) {
+	since, err := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, "since must be an RFC 3339 time", http.StatusBadRequest)
+		return
+	}
+
+	activity, err := c.useCase.Activity(since)
+	if err != nil {
+		log.Printf("failed to list author activity: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = utils.WriteJson(w, http.StatusOK, map[string]interface{}{"authors": activity}, nil); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (c *AccountController) run(w http.ResponseWriter, r *http.Request, action string, step func(userID int) error) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = step(userID); err != nil {
+		log.Printf("failed to %s user %d: %v", action, userID, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
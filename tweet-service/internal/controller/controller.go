@@ -9,6 +9,18 @@ type TweetController interface {
 	UpdateTweetHandler(w http.ResponseWriter, r *http.Request)
 	DeleteTweetHandler(w http.ResponseWriter, r *http.Request)
 	GetUserTweetsHandler(w http.ResponseWriter, r *http.Request)
+	AdminDeleteTweetHandler(w http.ResponseWriter, r *http.Request)
+}
+
+type AccountController interface {
+	DeactivateHandler(w http.ResponseWriter, r *http.Request)
+	ReactivateHandler(w http.ResponseWriter, r *http.Request)
+	ProtectHandler(w http.ResponseWriter, r *http.Request)
+	UnprotectHandler(w http.ResponseWriter, r *http.Request)
+	PurgeHandler(w http.ResponseWriter, r *http.Request)
+	ExportHandler(w http.ResponseWriter, r *http.Request)
+	ExportLikesHandler(w http.ResponseWriter, r *http.Request)
+	ActivityHandler(w http.ResponseWriter, r *http.Request)
 }
 
 type TweetTagController interface {
@@ -24,3 +36,7 @@ type TweetStatsController interface {
 	RemoveLikeHandler(w http.ResponseWriter, r *http.Request)
 	RemoveDislikeHandler(w http.ResponseWriter, r *http.Request)
 }
+
+type StreamController interface {
+	StreamHandler(w http.ResponseWriter, r *http.Request)
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type contextKey string
+
+const principalContextKey contextKey = "principal"
+
+// SessionResolver resolves a session token to the user it belongs to.
+type SessionResolver interface {
+	ResolveSession(token string) (*domain.Principal, error)
+}
+
+type Auth struct {
+	resolver SessionResolver
+}
+
+func NewAuth(resolver SessionResolver) *Auth {
+	return &Auth{
+		resolver: resolver,
+	}
+}
+
+// Authenticate rejects requests without a valid session token or API key and
+// stores the caller in the request context.
+func (a *Auth) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.resolve(w, r, next)
+	})
+}
+
+// Identify resolves the caller like Authenticate when the request carries
+// credentials and lets anonymous requests through.
+func (a *Auth) Identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sessionToken(r) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		a.resolve(w, r, next)
+	})
+}
+
+func (a *Auth) resolve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	principal, err := a.resolver.ResolveSession(sessionToken(r))
+	if err != nil {
+		var rateLimitErr *domain.RateLimitError
+		switch {
+		case errors.Is(err, domain.ErrUnauthenticated):
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		case errors.As(err, &rateLimitErr):
+			w.Header().Set("Retry-After", rateLimitErr.RetryAfter)
+			http.Error(w, rateLimitErr.Error(), http.StatusTooManyRequests)
+		default:
+			log.Println("could not resolve session:", err)
+			http.Error(w, "could not authenticate request", http.StatusBadGateway)
+		}
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), principalContextKey, principal)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequireScope must run after Authenticate or Identify. API keys need the
+// scope; sessions and anonymous callers are let through.
+func (a *Auth) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if principal, ok := CurrentPrincipal(r.Context()); ok && !principal.HasScope(scope) {
+				http.Error(w, "api key lacks the "+scope+" scope", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireVerifiedEmail must run after Authenticate. It rejects users who have
+// not verified their email address yet.
+func (a *Auth) RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := CurrentPrincipal(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !principal.EmailVerified {
+			http.Error(w, "verify your email address to use this feature", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequirePermission must run after Authenticate. It lets through callers
+// whose roles grant the permission.
+func (a *Auth) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := CurrentPrincipal(r.Context())
+			if !ok {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !principal.HasPermission(permission) {
+				log.Printf("user %d denied %s on %s", principal.UserID, permission, r.URL.Path)
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TokenFromQuery takes the token from the access_token query parameter of
+// requests without an Authorization header, for clients that cannot set it.
+func TokenFromQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CurrentPrincipal returns the caller stored by Authenticate.
+func CurrentPrincipal(ctx context.Context) (*domain.Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey).(*domain.Principal)
+	return principal, ok
+}
+
+func sessionToken(r *http.Request) string {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return header
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeResolver map[string]*domain.Principal
+
+func (f fakeResolver) ResolveSession(token string) (*domain.Principal, error) {
+	if principal, ok := f[token]; ok {
+		return principal, nil
+	}
+	return nil, domain.ErrUnauthenticated
+}
+
+func TestScopesAndPermissions(t *testing.T) {
+	auth := NewAuth(fakeResolver{
+		"user-session": {UserID: 1, AuthType: "session"},
+		"mod-session":  {UserID: 2, AuthType: "session", Permissions: []string{domain.PermissionDeleteAnyTweet}},
+		"read-key":     {UserID: 3, AuthType: "api_key", Scopes: []string{domain.ScopeTweetsRead}},
+		"mod-key":      {UserID: 2, AuthType: "api_key", Permissions: []string{domain.PermissionDeleteAnyTweet}},
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	write := auth.Authenticate(auth.RequireScope(domain.ScopeTweetsWrite)(ok))
+	read := auth.Identify(auth.RequireScope(domain.ScopeTweetsRead)(ok))
+	moderate := auth.Authenticate(auth.RequirePermission(domain.PermissionDeleteAnyTweet)(ok))
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		token   string
+		want    int
+	}{
+		{"session can write", write, "user-session", http.StatusOK},
+		{"read key can not write", write, "read-key", http.StatusForbidden},
+		{"read key can read", read, "read-key", http.StatusOK},
+		{"anonymous can read", read, "", http.StatusOK},
+		{"unknown token can not read", read, "bogus", http.StatusUnauthorized},
+		{"moderator can moderate", moderate, "mod-session", http.StatusOK},
+		{"user can not moderate", moderate, "user-session", http.StatusForbidden},
+		{"api key never moderates", moderate, "mod-key", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("got status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -1,41 +1,85 @@
 package controller
 
 import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
 	"github.com/go-chi/chi/v5"
 	"net/http"
 )
 
-func RegisterTweetRoutes(ctrl TweetController) http.Handler {
+func RegisterTweetRoutes(ctrl TweetController, auth *middleware.Auth) http.Handler {
 	router := chi.NewRouter()
 
-	router.Post("/", ctrl.CreateTweetHandler)
-	router.Get("/{id}", ctrl.GetTweetByIdHandler)
-	router.Get("/", ctrl.ListTweetsHandler)
-	router.Patch("/{id}", ctrl.UpdateTweetHandler)
-	router.Delete("/{id}", ctrl.DeleteTweetHandler)
-	router.Get("/{user_id}", ctrl.GetUserTweetsHandler)
+	read := router.With(auth.Identify, auth.RequireScope(domain.ScopeTweetsRead))
+	write := router.With(auth.Authenticate, auth.RequireScope(domain.ScopeTweetsWrite))
+
+	write.With(auth.RequireVerifiedEmail).Post("/", ctrl.CreateTweetHandler)
+	read.Get("/{id}", ctrl.GetTweetByIdHandler)
+	read.Get("/", ctrl.ListTweetsHandler)
+	write.Patch("/{id}", ctrl.UpdateTweetHandler)
+	write.Delete("/{id}", ctrl.DeleteTweetHandler)
+	router.With(auth.Authenticate, auth.RequirePermission(domain.PermissionDeleteAnyTweet)).
+		Delete("/admin/{id}", ctrl.AdminDeleteTweetHandler)
+	// Registered apart from /{id}, which would otherwise take its requests
+	read.Get("/users/{user_id}", ctrl.GetUserTweetsHandler)
 
 	return router
 }
 
-func RegisterTagsRoutes(ctrl TweetTagController) http.Handler {
+func RegisterTagsRoutes(ctrl TweetTagController, auth *middleware.Auth) http.Handler {
 	router := chi.NewRouter()
 
-	router.Get("/", ctrl.ListTagsHandler)
-	router.Post("/{tweet_id}/tags", ctrl.AddTweetTagHandler)
-	router.Get("/{tweet_id}/tags", ctrl.GetTweetTagsHandler)
+	read := router.With(auth.Identify, auth.RequireScope(domain.ScopeTweetsRead))
+	write := router.With(auth.Authenticate, auth.RequireScope(domain.ScopeTweetsWrite))
+
+	read.Get("/", ctrl.ListTagsHandler)
+	write.Post("/{tweet_id}/tags", ctrl.AddTweetTagHandler)
+	read.Get("/{tweet_id}/tags", ctrl.GetTweetTagsHandler)
 
 	return router
 }
 
-func RegisterStatsRoutes(ctrl TweetStatsController) http.Handler {
+func RegisterStatsRoutes(ctrl TweetStatsController, auth *middleware.Auth) http.Handler {
 	router := chi.NewRouter()
 
-	router.Get("/{tweet_id}/stats", ctrl.GetTweetStatsHandler)
-	router.Post("/{tweet_id}/like", ctrl.AddLikeHandler)
+	// Likes tell the author who liked the tweet, so they need a caller
+	read := router.With(auth.Identify, auth.RequireScope(domain.ScopeTweetsRead))
+	like := router.With(auth.Authenticate, auth.RequireScope(domain.ScopeTweetsWrite))
+
+	read.Get("/{tweet_id}/stats", ctrl.GetTweetStatsHandler)
+	like.Post("/{tweet_id}/like", ctrl.AddLikeHandler)
 	router.Post("/{tweet_id}/dislike", ctrl.AddDislikeHandler)
-	router.Delete("/{tweet_id}/like", ctrl.RemoveLikeHandler)
+	like.Delete("/{tweet_id}/like", ctrl.RemoveLikeHandler)
 	router.Delete("/{tweet_id}/dislike", ctrl.RemoveDislikeHandler)
 
 	return router
 }
+
+// RegisterStreamRoutes serves the event stream. Browsers cannot set headers
+// on EventSource and WebSocket requests, so the token may come in the query.
+func RegisterStreamRoutes(ctrl StreamController, auth *middleware.Auth) http.Handler {
+	router := chi.NewRouter()
+
+	router.With(middleware.TokenFromQuery, auth.Authenticate, auth.RequireScope(domain.ScopeTweetsRead)).
+		Get("/", ctrl.StreamHandler)
+
+	return router
+}
+
+// RegisterInternalRoutes serves the endpoints user-service calls. They are
+// not routed through the public gateway.
+func RegisterInternalRoutes(ctrl AccountController, internalToken string) http.Handler {
+	router := chi.NewRouter()
+	router.Use(middleware.RequireInternalToken(internalToken))
+
+	router.Put("/users/{user_id}/deactivation", ctrl.DeactivateHandler)
+	router.Delete("/users/{user_id}/deactivation", ctrl.ReactivateHandler)
+	router.Put("/users/{user_id}/protection", ctrl.ProtectHandler)
+	router.Delete("/users/{user_id}/protection", ctrl.UnprotectHandler)
+	router.Delete("/users/{user_id}", ctrl.PurgeHandler)
+	router.Get("/users/{user_id}/tweets", ctrl.ExportHandler)
+	router.Get("/users/{user_id}/likes", ctrl.ExportLikesHandler)
+	router.Get("/activity", ctrl.ActivityHandler)
+
+	return router
+}
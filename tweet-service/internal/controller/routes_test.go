@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeResolver map[string]*domain.Principal
+
+func (f fakeResolver) ResolveSession(token string) (*domain.Principal, error) {
+	if principal, ok := f[token]; ok {
+		return principal, nil
+	}
+	return nil, domain.ErrUnauthenticated
+}
+
+// fakeTweetController answers every request it is reached with 200.
+type fakeTweetController struct {
+	TweetController
+}
+
+func (fakeTweetController) UpdateTweetHandler(w http.ResponseWriter, r *http.Request) {}
+func (fakeTweetController) DeleteTweetHandler(w http.ResponseWriter, r *http.Request) {}
+
+func TestTweetChangesNeedWriteAccess(t *testing.T) {
+	auth := middleware.NewAuth(fakeResolver{
+		"session":  {UserID: 1, AuthType: "session"},
+		"read-key": {UserID: 1, AuthType: "api_key", Scopes: []string{domain.ScopeTweetsRead}},
+	})
+	router := RegisterTweetRoutes(fakeTweetController{}, auth)
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"read key", "read-key", http.StatusForbidden},
+		{"session", "session", http.StatusOK},
+	}
+	for _, tt := range tests {
+		for _, method := range []string{http.MethodPatch, http.MethodDelete} {
+			r := httptest.NewRequest(method, "/1", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("%s %s: got %d, want %d", tt.name, method, w.Code, tt.want)
+			}
+		}
+	}
+}
+
+// fakeTagController answers every request it is reached with 200.
+type fakeTagController struct {
+	TweetTagController
+}
+
+func (fakeTagController) AddTweetTagHandler(w http.ResponseWriter, r *http.Request)  {}
+func (fakeTagController) GetTweetTagsHandler(w http.ResponseWriter, r *http.Request) {}
+
+type fakeStatsController struct {
+	TweetStatsController
+}
+
+func (fakeStatsController) GetTweetStatsHandler(w http.ResponseWriter, r *http.Request) {}
+
+func TestTagsAndStatsNeedTheirScopes(t *testing.T) {
+	auth := middleware.NewAuth(fakeResolver{
+		"session":  {UserID: 1, AuthType: "session"},
+		"read-key": {UserID: 1, AuthType: "api_key", Scopes: []string{domain.ScopeTweetsRead}},
+		"dm-key":   {UserID: 1, AuthType: "api_key", Scopes: []string{domain.ScopeMessagesRead}},
+	})
+	tags := RegisterTagsRoutes(fakeTagController{}, auth)
+	stats := RegisterStatsRoutes(fakeStatsController{}, auth)
+
+	tests := []struct {
+		router http.Handler
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{tags, http.MethodPost, "/1/tags?tag_id=2", "", http.StatusUnauthorized},
+		{tags, http.MethodPost, "/1/tags?tag_id=2", "read-key", http.StatusForbidden},
+		{tags, http.MethodPost, "/1/tags?tag_id=2", "session", http.StatusOK},
+		{tags, http.MethodGet, "/1/tags", "", http.StatusOK},
+		{tags, http.MethodGet, "/1/tags", "dm-key", http.StatusForbidden},
+		{stats, http.MethodGet, "/1/stats", "", http.StatusOK},
+		{stats, http.MethodGet, "/1/stats", "dm-key", http.StatusForbidden},
+		{stats, http.MethodGet, "/1/stats", "read-key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		tt.router.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s %s as %q: got %d, want %d", tt.method, tt.path, tt.token, w.Code, tt.want)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package stats
 
 import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase"
 	"MussaShaukenov/twitter-clone-go/tweet-service/pkg/utils"
 	"context"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"net/http"
 	"strconv"
@@ -26,8 +29,13 @@ func (c *TweetStatsController) GetTweetStatsHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
-	stats, err := c.useCase.GetTweetStats(context.Background(), tweetID)
+	viewer, _ := middleware.CurrentPrincipal(r.Context())
+	stats, err := c.useCase.GetTweetStats(context.Background(), tweetID, viewer)
 	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFoundX) {
+			http.Error(w, "tweet not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -46,8 +54,13 @@ func (c *TweetStatsController) AddLikeHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	err = c.useCase.AddLike(context.Background(), tweetID)
+	principal, _ := middleware.CurrentPrincipal(r.Context())
+	err = c.useCase.AddLike(context.Background(), tweetID, principal)
 	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFoundX) {
+			http.Error(w, "tweet not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -86,7 +99,8 @@ func (c *TweetStatsController) RemoveLikeHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	err = c.useCase.RemoveLike(context.Background(), tweetID)
+	principal, _ := middleware.CurrentPrincipal(r.Context())
+	err = c.useCase.RemoveLike(context.Background(), tweetID, principal)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -120,7 +134,7 @@ func (c *TweetStatsController) RemoveDislikeHandler(w http.ResponseWriter, r *ht
 }
 
 func getTweetID(r *http.Request) (int64, error) {
-	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	id, err := strconv.Atoi(chi.URLParam(r, "tweet_id"))
 	if err != nil {
 		return 0, err
 	}
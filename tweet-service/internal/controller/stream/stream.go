@@ -0,0 +1,156 @@
+package stream
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase"
+	streamUC "MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase/stream"
+	"MussaShaukenov/twitter-clone-go/tweet-service/pkg/websocket"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// heartbeatInterval keeps idle streams from being closed by proxies.
+	heartbeatInterval = 25 * time.Second
+	// retryDelay is how long SSE clients wait before reconnecting.
+	retryDelay = 3 * time.Second
+	// writeTimeout drops SSE clients that stopped reading.
+	writeTimeout = 10 * time.Second
+)
+
+type StreamController struct {
+	useCase usecase.StreamUseCase
+}
+
+func NewStreamController(useCase usecase.StreamUseCase) *StreamController {
+	return &StreamController{
+		useCase: useCase,
+	}
+}
+
+// StreamHandler streams new timeline tweets, like and dislike counts and the
+// caller's notifications and direct messages, over WebSocket when the request
+// asks to upgrade and as Server-Sent Events otherwise. Clients resume after
+// the event in the Last-Event-ID header or the last_event_id query parameter.
+func (c *StreamController) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	principal, _ := middleware.CurrentPrincipal(r.Context())
+	events, err := c.useCase.Open(principal, lastEventID)
+	if err != nil {
+		if errors.Is(err, streamUC.ErrInvalidEventID) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("could not open stream for user %d: %v", principal.UserID, err)
+		http.Error(w, "could not open stream", http.StatusInternalServerError)
+		return
+	}
+	defer events.Close()
+
+	if websocket.IsUpgrade(r) {
+		serveWebSocket(w, r, events)
+		return
+	}
+	serveSSE(w, r, events)
+}
+
+func serveSSE(w http.ResponseWriter, r *http.Request, events usecase.EventStream) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx must pass events on at once
+	w.WriteHeader(http.StatusOK)
+
+	write := func(format string, args ...any) error {
+		// Each write gets its own deadline instead of the server's, which
+		// would end the stream
+		if err := rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	if err := write("retry: %d\n\n", retryDelay.Milliseconds()); err != nil {
+		return
+	}
+	for {
+		event, err := next(r.Context(), events)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			err = write(": ping\n\n")
+		case err != nil:
+			// Lagging clients reconnect and resume on their own
+			return
+		default:
+			err = write("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func serveWebSocket(w http.ResponseWriter, r *http.Request, events usecase.EventStream) {
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	// The request context is not canceled for hijacked connections, so the
+	// client closing the connection is noticed by reading it
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		defer cancel()
+		_ = conn.ReadUntilClose()
+	}()
+
+	for {
+		event, err := next(ctx, events)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			err = conn.Ping()
+		case errors.Is(err, streamUC.ErrLagged):
+			conn.Close(websocket.CloseTryAgain, "stream fell behind")
+			return
+		case err != nil:
+			conn.Close(websocket.CloseGoingAway, "")
+			return
+		default:
+			err = writeMessage(conn, event)
+		}
+		if err != nil {
+			conn.Close(websocket.CloseGoingAway, "")
+			return
+		}
+	}
+}
+
+func writeMessage(conn *websocket.Conn, event *domain.StreamEvent) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return conn.WriteText(message)
+}
+
+// next waits for the next event, failing with context.DeadlineExceeded when
+// a heartbeat is due.
+func next(ctx context.Context, events usecase.EventStream) (*domain.StreamEvent, error) {
+	ctx, cancel := context.WithTimeout(ctx, heartbeatInterval)
+	defer cancel()
+	return events.Next(ctx)
+}
@@ -1,9 +1,14 @@
 package tags
 
 import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase"
 	"MussaShaukenov/twitter-clone-go/tweet-service/pkg/utils"
+	"errors"
+	"github.com/go-chi/chi/v5"
 	"net/http"
+	"strconv"
 )
 
 type TweetTags struct {
@@ -17,20 +22,25 @@ func NewTweetTagsController(useCase usecase.TweetTagUseCase) *TweetTags {
 }
 
 func (c *TweetTags) AddTweetTagHandler(w http.ResponseWriter, r *http.Request) {
-	tweetId, err := utils.GetIdFromQueryParam(w, r)
+	tweetId, err := strconv.Atoi(chi.URLParam(r, "tweet_id"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	tagId, err := utils.GetIdFromQueryParam(w, r)
+	tagId, err := strconv.Atoi(r.URL.Query().Get("tag_id"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = c.useCase.AddTag(int64(tweetId), int64(tagId))
+	actor, _ := middleware.CurrentPrincipal(r.Context())
+	err = c.useCase.AddTag(int64(tweetId), int64(tagId), actor)
 	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFoundX) {
+			http.Error(w, "tweet not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -43,14 +53,19 @@ func (c *TweetTags) AddTweetTagHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (c *TweetTags) GetTweetTagsHandler(w http.ResponseWriter, r *http.Request) {
-	tweetId, err := utils.GetIdFromQueryParam(w, r)
+	tweetId, err := strconv.Atoi(chi.URLParam(r, "tweet_id"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	tags, err := c.useCase.GetTweetTags(int64(tweetId))
+	viewer, _ := middleware.CurrentPrincipal(r.Context())
+	tags, err := c.useCase.GetTweetTags(int64(tweetId), viewer)
 	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFoundX) {
+			http.Error(w, "tweet not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -1,9 +1,12 @@
 package tweets
 
 import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/dto"
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase"
 	"MussaShaukenov/twitter-clone-go/tweet-service/pkg/utils"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -32,6 +35,13 @@ func (c *controller) CreateTweetHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	log.Println("controller input 2:", input)
+
+	// Tweets are always posted as the authenticated user, and through the
+	// app of the API key if one was used
+	principal, _ := middleware.CurrentPrincipal(r.Context())
+	input.UserId = principal.UserID
+	input.AppName = principal.AppName
+
 	// Call useCase
 	err = c.service.Create(input)
 	if err != nil {
@@ -56,9 +66,14 @@ func (c *controller) GetTweetByIdHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// GetTweet record
-	tweet, err := c.service.Get(int64(id))
+	// GetTweet record, as far as the caller may read it
+	principal, _ := middleware.CurrentPrincipal(r.Context())
+	tweet, err := c.service.View(int64(id), principal)
 	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFoundX) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -72,7 +87,8 @@ func (c *controller) GetTweetByIdHandler(w http.ResponseWriter, r *http.Request)
 }
 func (c *controller) ListTweetsHandler(w http.ResponseWriter, r *http.Request) {
 	// GetTweet records
-	tweets, err := c.service.List()
+	principal, _ := middleware.CurrentPrincipal(r.Context())
+	tweets, err := c.service.List(principal)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -92,13 +108,6 @@ func (c *controller) UpdateTweetHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// GetTweet record
-	tweet, err := c.service.Get(int64(id))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
 	var in dto.UpdateTweetDto
 	err = utils.ReadJson(w, r, &in)
 	if err != nil {
@@ -106,20 +115,14 @@ func (c *controller) UpdateTweetHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Update existing tweet fields if input is not nil
-	if in.Title != nil {
-		tweet.Title = *in.Title
-	}
-	if in.Content != nil {
-		tweet.Content = *in.Content
-	}
-	if in.Topic != nil {
-		tweet.Topic = *in.Topic
-	}
-
-	// UpdateTweets record
-	res, err := c.service.Update(*tweet)
+	// UpdateTweets record, if it is the caller's
+	principal, _ := middleware.CurrentPrincipal(r.Context())
+	res, err := c.service.Update(int64(id), in, principal)
 	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFoundX) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, "error 1", http.StatusInternalServerError)
 		return
 	}
@@ -140,22 +143,51 @@ func (c *controller) DeleteTweetHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validation
-	_, err = c.service.Get(int64(id))
+	// DeleteTweet record, if it is the caller's
+	principal, _ := middleware.CurrentPrincipal(r.Context())
+	err = c.service.Delete(id, principal)
 	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFoundX) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// DeleteTweet record
-	err = c.service.Delete(id)
+	// Return response
+	err = utils.WriteJson(w, http.StatusOK, `{"message": "tweet successfully deleted"}`, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+}
 
-	// Return response
-	err = utils.WriteJson(w, http.StatusOK, `{"message": "tweet successfully deleted"}`, nil)
+// AdminDeleteTweetHandler lets moderators delete any tweet.
+func (c *controller) AdminDeleteTweetHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := middleware.CurrentPrincipal(r.Context())
+	err = c.service.AdminDelete(id, principal, utils.ClientIP(r))
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFoundX) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, domain.ErrPermissionDenied) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{"message": "tweet successfully deleted"}
+	err = utils.WriteJson(w, http.StatusOK, response, nil)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -169,8 +201,13 @@ func (c *controller) GetUserTweetsHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	tweets, err := c.service.GetUserTweets(userId)
+	principal, _ := middleware.CurrentPrincipal(r.Context())
+	tweets, err := c.service.GetUserTweets(userId, principal)
 	if err != nil {
+		if errors.Is(err, domain.ErrProtectedAuthor) || errors.Is(err, domain.ErrBlockedAuthor) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/dto"
+	"encoding/json"
 	"time"
 )
 
@@ -13,7 +14,158 @@ type Tweet struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserId    int
+	AppName   string // developer app the tweet was posted through, empty for users
 	Tags      []Tag
+
+	AuthorProtected bool // only the author and approved followers may read it
+}
+
+// Scopes an API key needs for the tweet endpoints, and for the events
+// about the user's conversations on the stream.
+const (
+	ScopeTweetsRead   = "tweets:read"
+	ScopeTweetsWrite  = "tweets:write"
+	ScopeMessagesRead = "messages:read"
+)
+
+// Permissions granted by user-service roles that tweet-service checks.
+const PermissionDeleteAnyTweet = "tweets:delete_any"
+
+// AuditRecord describes a privileged action; it is stored by user-service.
+type AuditRecord struct {
+	ActorID    int                    `json:"actor_id"`
+	Action     string                 `json:"action"`
+	TargetType string                 `json:"target_type"`
+	TargetID   string                 `json:"target_id"`
+	Details    map[string]interface{} `json:"details"`
+	Service    string                 `json:"service"`
+	IP         string                 `json:"ip"`
+}
+
+// Notification types reported to user-service.
+const (
+	NotificationLike    = "like"
+	NotificationReply   = "reply"
+	NotificationMention = "mention"
+)
+
+// NotificationEvent is something a user did to another user's tweet, which
+// user-service tells that user about. Mentions name the users by username.
+type NotificationEvent struct {
+	Type        string   `json:"type"`
+	ActorID     int      `json:"actor_id"`
+	RecipientID int      `json:"recipient_id,omitempty"`
+	TweetID     *int64   `json:"tweet_id"`
+	Usernames   []string `json:"usernames,omitempty"`
+}
+
+// Types of the events streamed to clients.
+const (
+	EventTweet        = "tweet"        // a new tweet in the timeline
+	EventStats        = "stats"        // new like and dislike counts of a tweet
+	EventNotification = "notification" // a new notification, from user-service
+)
+
+// Events delivered to the webhooks of a user's developer apps by
+// user-service. Each concerns the user the event is reported for.
+const (
+	WebhookTweetCreated = "tweet.created" // the user posted a tweet
+	WebhookTweetDeleted = "tweet.deleted" // a tweet of the user was deleted
+	WebhookLikeCreated  = "like.created"  // someone liked a tweet of the user
+)
+
+// WebhookEvent is something that happened to a user, reported to
+// user-service for the webhooks of the user's apps.
+type WebhookEvent struct {
+	Type   string          `json:"type"`
+	UserID int             `json:"user_id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Events about a user's conversations come from user-service too: "message",
+// "message_deleted", "messages_read" and "conversation_invite". They are
+// streamed as they are.
+
+// StreamEvent is pushed to the clients streaming the timeline. Events for a
+// single user carry the user's id; the others go to everyone who may read
+// them. ID orders the events and lets clients resume after it.
+type StreamEvent struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	UserID int             `json:"user_id,omitempty"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Principal is the caller of an authenticated request, as resolved by user-service.
+type Principal struct {
+	UserID        int      `json:"user_id"`
+	Username      string   `json:"username"`
+	EmailVerified bool     `json:"email_verified"`
+	AuthType      string   `json:"auth_type"` // "session" or "api_key"
+	AppName       string   `json:"app_name"`
+	Scopes        []string `json:"scopes"`
+	Roles         []string `json:"roles"`
+	Permissions   []string `json:"permissions"`
+}
+
+// HasPermission reports whether the caller's roles grant permission. API keys
+// never carry the owner's permissions.
+func (p *Principal) HasPermission(permission string) bool {
+	if p.AuthType == "api_key" {
+		return false
+	}
+	for _, perm := range p.Permissions {
+		if perm == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether the caller may use scope. Sessions act with the
+// user's full rights, API keys only within their scopes.
+func (p *Principal) HasScope(scope string) bool {
+	if p.AuthType != "api_key" {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Relations are the users and words whose tweets are shown to or hidden from
+// a user, as kept by user-service.
+type Relations struct {
+	ProtectedFollowing []int `json:"protected_following"` // protected users the user follows
+	Blocked            []int `json:"blocked"`             // users the user blocked or was blocked by
+	Muted              []int `json:"muted"`
+
+	MutedWords []*MutedWord `json:"muted_words"`
+}
+
+// Where a muted word hides tweets.
+const (
+	MuteScopeTimeline   = "timeline"   // the tweet listings only
+	MuteScopeEverywhere = "everywhere" // also the tweets of a single user
+)
+
+// MutedWord is a keyword, #hashtag or phrase whose tweets a user does not want
+// to read, until it expires.
+type MutedWord struct {
+	Phrase    string     `json:"phrase"`
+	Scope     string     `json:"scope"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// AuthorActivity sums up what a user tweeted recently.
+type AuthorActivity struct {
+	UserID      int       `json:"user_id"`
+	TweetCount  int       `json:"tweet_count"`
+	LastTweetAt time.Time `json:"last_tweet_at"`
+	Tags        []string  `json:"tags"` // distinct tags of the tweets
 }
 
 type Tag struct {
@@ -21,6 +173,13 @@ type Tag struct {
 	Name string
 }
 
+// Like is a user's like of a tweet.
+type Like struct {
+	TweetID int64
+	UserID  int
+	LikedAt time.Time
+}
+
 type TweetStats struct {
 	TweetID    int64     `bson:"tweet_id"`
 	Likes      int64     `bson:"likes"`
@@ -44,6 +203,7 @@ func ConvertToDto(tweet *Tweet) *dto.TweetDto {
 		Title:   tweet.Title,
 		Content: tweet.Content,
 		Topic:   tweet.Topic,
+		AppName: tweet.AppName,
 	}
 }
 
@@ -55,5 +215,6 @@ func ConvertToGetTweetResponseDto(tweet *Tweet) *dto.GetTweetResponse {
 		Topic:     tweet.Topic,
 		CreatedAt: tweet.CreatedAt,
 		UpdatedAt: tweet.UpdatedAt,
+		AppName:   tweet.AppName,
 	}
 }
@@ -3,5 +3,19 @@ package domain
 import "errors"
 
 var (
-	ErrRecordNotFoundX = errors.New("record not found")
+	ErrRecordNotFoundX  = errors.New("record not found")
+	ErrUnauthenticated  = errors.New("unauthenticated")
+	ErrUserServiceError = errors.New("user-service request failed")
+	ErrProtectedAuthor  = errors.New("the author's tweets are protected")
+	ErrBlockedAuthor    = errors.New("the author blocked you or was blocked by you")
+	ErrPermissionDenied = errors.New("permission denied")
 )
+
+// RateLimitError is returned when the caller's API key is over its rate limit.
+type RateLimitError struct {
+	RetryAfter string // seconds, as sent by user-service
+}
+
+func (e *RateLimitError) Error() string {
+	return "rate limit exceeded"
+}
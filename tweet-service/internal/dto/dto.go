@@ -10,6 +10,7 @@ type TweetDto struct {
 	CreatedAt time.Time `json:"created_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty,omitempty"`
 	UserId    int       `json:"user_id"`
+	AppName   string    `json:"app_name,omitempty"`
 }
 
 type TagDto struct {
@@ -30,4 +31,53 @@ type GetTweetResponse struct {
 	Topic     string    `json:"topic"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	AppName   string    `json:"app_name,omitempty"`
+}
+
+// ExportedTweet is a tweet as it appears in its author's data export.
+type ExportedTweet struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Topic     string    `json:"topic"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	AppName   string    `json:"app_name,omitempty"`
+	Tags      []string  `json:"tags"`
+	Likes     int64     `json:"likes"`
+	Dislikes  int64     `json:"dislikes"`
+}
+
+// ExportedLike is a like as it appears in the liker's data export.
+type ExportedLike struct {
+	TweetID int64     `json:"tweet_id"`
+	LikedAt time.Time `json:"liked_at"`
+}
+
+// StreamedTweet is a new tweet as pushed to the clients streaming the timeline.
+type StreamedTweet struct {
+	TweetDto
+	AuthorProtected bool `json:"author_protected"`
+}
+
+// StreamedStats are the counts of a tweet pushed when it is liked or disliked.
+// They carry the author, so they only reach those who may read the tweet.
+type StreamedStats struct {
+	TweetID         int64 `json:"tweet_id"`
+	UserId          int   `json:"user_id"`
+	AuthorProtected bool  `json:"author_protected"`
+	Likes           int64 `json:"likes"`
+	Dislikes        int64 `json:"dislikes"`
+}
+
+// DeletedTweet is the data of a tweet.deleted webhook event.
+type DeletedTweet struct {
+	ID     int64 `json:"id"`
+	UserId int   `json:"user_id"`
+}
+
+// LikedTweet is the data of a like.created webhook event.
+type LikedTweet struct {
+	TweetID int64 `json:"tweet_id"`
+	LikerID int   `json:"liker_id"`
 }
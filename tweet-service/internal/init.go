@@ -1,16 +1,28 @@
 package tweet
 
 import (
+	userClient "MussaShaukenov/twitter-clone-go/tweet-service/internal/clients/users"
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/controller"
+	accountCtrl "MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/accounts"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/middleware"
 	statsCtrl "MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/stats"
+	streamCtrl "MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/stream"
 	tagCtrl "MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/tags"
 	tweetCtrl "MussaShaukenov/twitter-clone-go/tweet-service/internal/controller/tweets"
+	eventRepo "MussaShaukenov/twitter-clone-go/tweet-service/internal/repository/events"
+	likeRepo "MussaShaukenov/twitter-clone-go/tweet-service/internal/repository/likes"
+	relationsRepo "MussaShaukenov/twitter-clone-go/tweet-service/internal/repository/relations"
 	statsRepo "MussaShaukenov/twitter-clone-go/tweet-service/internal/repository/stats"
 	tagRepo "MussaShaukenov/twitter-clone-go/tweet-service/internal/repository/tags"
 	tweetRepo "MussaShaukenov/twitter-clone-go/tweet-service/internal/repository/tweets"
+	accountUc "MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase/accounts"
 	statsUc "MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase/stats"
+	streamUc "MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase/stream"
 	tagUc "MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase/tags"
 	tweetUc "MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase/tweets"
+	"MussaShaukenov/twitter-clone-go/tweet-service/pkg/utils"
+	"context"
+	"fmt"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 	"net/http"
@@ -27,24 +39,50 @@ type Config struct {
 	Logger   *zap.SugaredLogger
 	Router   *chi.Mux
 	Mongo    *mongo.Database
+
+	UserServiceURL string
+	InternalToken  string // shared with user-service for its internal endpoints
 }
 
 func InitializeTweetApp(config *Config) (http.Handler, error) {
+	// the internal endpoints purge and deactivate accounts, so their token
+	// must not be guessable
+	if err := utils.CheckSecret(config.InternalToken); err != nil {
+		return nil, fmt.Errorf("the internal API token %w", err)
+	}
+
 	tweetRepository := tweetRepo.NewTweetRepository(config.Postgres, config.Redis, 10*time.Minute)
 	tagsRepository := tagRepo.NewTagsRepository(config.Postgres)
 	statsRepository := statsRepo.NewTweetStatsRepository(config.Mongo)
+	likesRepository := likeRepo.NewLikesRepository(config.Postgres)
+	relationsRepository := relationsRepo.NewRelationsRepository(config.Redis, 30*time.Second)
+	eventRepository := eventRepo.NewEventRepository(config.Redis)
+
+	userServiceClient := userClient.NewUserServiceClient(config.UserServiceURL, config.InternalToken)
+
+	tweetUseCase := tweetUc.NewTweetUseCase(
+		tweetRepository, relationsRepository, userServiceClient, userServiceClient, userServiceClient, eventRepository,
+		userServiceClient)
+	tagsUseCase := tagUc.NewTagsUseCase(tagsRepository, tweetRepository, tweetUseCase)
+	statsUseCase := statsUc.NewTweetStatsUseCase(
+		statsRepository, likesRepository, tweetRepository, tweetUseCase, userServiceClient, eventRepository, userServiceClient)
+	streamUseCase := streamUc.NewStreamUseCase(eventRepository, tweetUseCase)
+	go streamUseCase.Run(context.Background())
+	accountUseCase := accountUc.NewAccountUseCase(tweetRepository, tagsRepository, statsRepository, likesRepository)
 
-	tweetUseCase := tweetUc.NewTweetUseCase(tweetRepository)
-	tagsUseCase := tagUc.NewTagsUseCase(tagsRepository)
-	statsUseCase := statsUc.NewTweetStatsUseCase(statsRepository)
+	auth := middleware.NewAuth(userServiceClient)
 
 	tweetController := tweetCtrl.NewController(tweetUseCase)
 	tagsController := tagCtrl.NewTweetTagsController(tagsUseCase)
 	statsController := statsCtrl.NewTweetStatsController(statsUseCase)
+	accountController := accountCtrl.NewAccountController(accountUseCase)
+	streamController := streamCtrl.NewStreamController(streamUseCase)
 
-	config.Router.Mount("/tweets", controller.RegisterTweetRoutes(tweetController))
-	config.Router.Mount("/tweets/tags", controller.RegisterTagsRoutes(tagsController))
-	config.Router.Mount("/tweets/stats", controller.RegisterStatsRoutes(statsController))
+	config.Router.Mount("/tweets", controller.RegisterTweetRoutes(tweetController, auth))
+	config.Router.Mount("/tweets/tags", controller.RegisterTagsRoutes(tagsController, auth))
+	config.Router.Mount("/tweets/stats", controller.RegisterStatsRoutes(statsController, auth))
+	config.Router.Mount("/tweets/stream", controller.RegisterStreamRoutes(streamController, auth))
+	config.Router.Mount("/internal", controller.RegisterInternalRoutes(accountController, config.InternalToken))
 
 	return config.Router, nil
 }
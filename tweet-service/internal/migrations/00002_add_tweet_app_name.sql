@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tweets
+    ADD COLUMN app_name VARCHAR(64); -- developer app the tweet was posted through
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tweets
+    DROP COLUMN IF EXISTS app_name;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Users belong to user-service, which deletes them through the deletion saga.
+-- tweet-service only keeps their ids.
+ALTER TABLE tweets
+    DROP CONSTRAINT IF EXISTS fk_user;
+CREATE INDEX IF NOT EXISTS tweets_user_id_idx ON tweets (user_id);
+
+-- Authors whose account is deactivated; their tweets are hidden until the
+-- account is reactivated or purged
+CREATE TABLE IF NOT EXISTS deactivated_authors
+(
+    user_id        INT PRIMARY KEY,
+    deactivated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS deactivated_authors;
+DROP INDEX IF EXISTS tweets_user_id_idx;
+ALTER TABLE tweets
+    ADD CONSTRAINT fk_user
+        FOREIGN KEY (user_id) REFERENCES users (id)
+            ON DELETE CASCADE
+            ON UPDATE CASCADE;
+-- +goose StatementEnd
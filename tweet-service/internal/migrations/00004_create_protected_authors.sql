@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Authors whose account is protected; their tweets are only shown to the
+-- author and the followers they approved, which user-service keeps
+CREATE TABLE IF NOT EXISTS protected_authors
+(
+    user_id      INT PRIMARY KEY,
+    protected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS protected_authors;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Who liked which tweet; a user likes a tweet at most once. The counts in the
+-- stats are kept in step with these rows
+CREATE TABLE IF NOT EXISTS tweet_likes
+(
+    tweet_id BIGINT NOT NULL REFERENCES tweets (id) ON DELETE CASCADE,
+    user_id  INT    NOT NULL,
+    liked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tweet_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS tweet_likes_user_idx ON tweet_likes (user_id, liked_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS tweet_likes;
+-- +goose StatementEnd
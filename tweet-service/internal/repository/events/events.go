@@ -0,0 +1,124 @@
+package events
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Events are appended to a Redis stream, which keeps the recent ones for
+// clients that resume, and published on a channel every instance of the
+// service listens to. user-service publishes notifications the same way.
+const (
+	timelineStream = "events:timeline"
+	channel        = "events"
+
+	timelineMaxLen = 10000
+	userMaxLen     = 500
+)
+
+type repository struct {
+	RedisClient *redis.Client
+}
+
+func NewEventRepository(redisClient *redis.Client) *repository {
+	return &repository{
+		RedisClient: redisClient,
+	}
+}
+
+// Publish stores the event, which gets its id from the stream, and
+// publishes it to the listening instances.
+func (r *repository) Publish(event *domain.StreamEvent) error {
+	ctx := context.Background()
+	stream, maxLen := timelineStream, int64(timelineMaxLen)
+	if event.UserID != 0 {
+		stream, maxLen = userStream(event.UserID), userMaxLen
+	}
+
+	id, err := r.RedisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"type": event.Type, "data": string(event.Data)},
+	}).Result()
+	if err != nil {
+		return err
+	}
+	event.ID = id
+
+	message, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return r.RedisClient.Publish(ctx, channel, message).Err()
+}
+
+// Since returns up to limit events of the timeline and of the user stored
+// after the given ids of each, oldest first in each.
+func (r *repository) Since(userID int, afterTimelineID, afterOwnID string, limit int) ([]*domain.StreamEvent, error) {
+	timeline, err := r.readStream(timelineStream, 0, afterTimelineID, limit)
+	if err != nil {
+		return nil, err
+	}
+	own, err := r.readStream(userStream(userID), userID, afterOwnID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return append(timeline, own...), nil
+}
+
+func (r *repository) readStream(stream string, userID int, afterID string, limit int) ([]*domain.StreamEvent, error) {
+	messages, err := r.RedisClient.XRangeN(context.Background(), stream, "("+afterID, "+", int64(limit)).Result()
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*domain.StreamEvent, 0, len(messages))
+	for _, message := range messages {
+		eventType, _ := message.Values["type"].(string)
+		data, _ := message.Values["data"].(string)
+		events = append(events, &domain.StreamEvent{
+			ID:     message.ID,
+			Type:   eventType,
+			UserID: userID,
+			Data:   json.RawMessage(data),
+		})
+	}
+	return events, nil
+}
+
+// Listen calls handle with each event published until ctx is done or the
+// subscription fails.
+func (r *repository) Listen(ctx context.Context, handle func(event *domain.StreamEvent)) error {
+	pubsub := r.RedisClient.Subscribe(ctx, channel)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case message, ok := <-messages:
+			if !ok {
+				return fmt.Errorf("subscription to %s closed", channel)
+			}
+			var event domain.StreamEvent
+			if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+				log.Printf("could not decode event: %v", err)
+				continue
+			}
+			handle(&event)
+		}
+	}
+}
+
+func userStream(userID int) string {
+	return fmt.Sprintf("events:user:%d", userID)
+}
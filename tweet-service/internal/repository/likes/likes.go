@@ -0,0 +1,73 @@
+package likes
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type repository struct {
+	Db *pgxpool.Pool
+}
+
+func NewLikesRepository(db *pgxpool.Pool) *repository {
+	return &repository{
+		Db: db,
+	}
+}
+
+// Like records that the user liked the tweet and reports whether they had
+// not already.
+func (pg *repository) Like(tweetID int64, userID int) (bool, error) {
+	query := `
+			INSERT INTO tweet_likes (tweet_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT (tweet_id, user_id) DO NOTHING`
+
+	result, err := pg.Db.Exec(context.Background(), query, tweetID, userID)
+	if err != nil {
+		return false, fmt.Errorf("error liking tweet: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// Unlike removes the like of the tweet by the user and reports whether there
+// was one.
+func (pg *repository) Unlike(tweetID int64, userID int) (bool, error) {
+	query := `DELETE FROM tweet_likes WHERE tweet_id = $1 AND user_id = $2`
+
+	result, err := pg.Db.Exec(context.Background(), query, tweetID, userID)
+	if err != nil {
+		return false, fmt.Errorf("error unliking tweet: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// ListUserLikes returns the likes of the user, oldest first.
+func (pg *repository) ListUserLikes(userID int) ([]*domain.Like, error) {
+	query := `
+			SELECT tweet_id, user_id, liked_at FROM tweet_likes
+			WHERE user_id = $1
+			ORDER BY liked_at, tweet_id`
+
+	rows, err := pg.Db.Query(context.Background(), query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var likes []*domain.Like
+	for rows.Next() {
+		var like domain.Like
+		if err = rows.Scan(&like.TweetID, &like.UserID, &like.LikedAt); err != nil {
+			return nil, err
+		}
+		likes = append(likes, &like)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return likes, nil
+}
@@ -0,0 +1,54 @@
+package relations
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// repository caches the relations of each user that user-service returned, so
+// listing tweets does not ask user-service every time.
+type repository struct {
+	RedisClient *redis.Client
+	CacheTTL    time.Duration
+}
+
+func NewRelationsRepository(redisClient *redis.Client, cacheTTL time.Duration) *repository {
+	return &repository{
+		RedisClient: redisClient,
+		CacheTTL:    cacheTTL,
+	}
+}
+
+// Get returns the cached relations of the user, or ErrRecordNotFoundX.
+func (r *repository) Get(userID int) (*domain.Relations, error) {
+	cached, err := r.RedisClient.Get(context.Background(), cacheKey(userID)).Result()
+	if err == redis.Nil {
+		return nil, domain.ErrRecordNotFoundX
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var relations domain.Relations
+	if err = json.Unmarshal([]byte(cached), &relations); err != nil {
+		return nil, err
+	}
+	return &relations, nil
+}
+
+func (r *repository) Set(userID int, relations *domain.Relations) error {
+	data, err := json.Marshal(relations)
+	if err != nil {
+		return err
+	}
+	return r.RedisClient.Set(context.Background(), cacheKey(userID), data, r.CacheTTL).Err()
+}
+
+func cacheKey(userID int) string {
+	return fmt.Sprintf("relations:%d", userID)
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
 	"context"
+	"time"
 )
 
 type TweetRepository interface {
@@ -12,6 +13,18 @@ type TweetRepository interface {
 	List() ([]*domain.Tweet, error)
 	Delete(id int) error
 	GetUserTweets(id int) ([]*domain.Tweet, error)
+	HideAuthor(userID int) error
+	ShowAuthor(userID int) error
+	ProtectAuthor(userID int) error
+	UnprotectAuthor(userID int) error
+	ListUserTweetIDs(userID int) ([]int64, error)
+	ListAuthorActivity(since time.Time) ([]*domain.AuthorActivity, error)
+	DeleteUserTweets(userID int) error
+}
+
+type RelationsRepository interface {
+	Get(userID int) (*domain.Relations, error)
+	Set(userID int, relations *domain.Relations) error
 }
 
 type TweetTagRepository interface {
@@ -24,4 +37,17 @@ type TweetStatsRepo interface {
 	GetTweetStats(ctx context.Context, tweetID int64) (*domain.TweetStats, error)
 	UpdateLikes(ctx context.Context, tweetID int64, likesChange int64) error
 	UpdateDislikes(ctx context.Context, tweetID int64, dislikesChange int64) error
+	DeleteTweetStats(ctx context.Context, tweetIDs []int64) error
+}
+
+type LikeRepository interface {
+	Like(tweetID int64, userID int) (bool, error)
+	Unlike(tweetID int64, userID int) (bool, error)
+	ListUserLikes(userID int) ([]*domain.Like, error)
+}
+
+type EventRepository interface {
+	Publish(event *domain.StreamEvent) error
+	Since(userID int, afterTimelineID, afterOwnID string, limit int) ([]*domain.StreamEvent, error)
+	Listen(ctx context.Context, handle func(event *domain.StreamEvent)) error
 }
@@ -56,3 +56,12 @@ func (repo *repository) UpdateDislikes(ctx context.Context, tweetID int64, disli
 	)
 	return err
 }
+
+// DeleteTweetStats removes the stats of the given tweets.
+func (repo *repository) DeleteTweetStats(ctx context.Context, tweetIDs []int64) error {
+	if len(tweetIDs) == 0 {
+		return nil
+	}
+	_, err := repo.collection.DeleteMany(ctx, bson.M{"tweet_id": bson.M{"$in": tweetIDs}})
+	return err
+}
@@ -24,8 +24,7 @@ func (pg *repository) AddTag(tweetId int64, tagId int64) error {
 
 	_, err := pg.Db.Exec(context.Background(), query, tweetId, tagId)
 	if err != nil {
-		fmt.Errorf("error adding tag to tweet: %v", err)
-		return err
+		return fmt.Errorf("error adding tag to tweet: %w", err)
 	}
 	return nil
 }
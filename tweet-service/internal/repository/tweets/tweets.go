@@ -9,7 +9,7 @@ import (
 	"log"
 	"time"
 
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 )
@@ -47,11 +47,12 @@ func (pg *repository) Insert(in *domain.Tweet) error {
 	log.Println("in: ", in)
 
 	query := `
-			INSERT INTO tweets (title, content, topic, user_id, created_at, updated_at) 
-			VALUES ($1, $2, $3, $4, NOW(), NOW())`
+			INSERT INTO tweets (title, content, topic, user_id, app_name, created_at, updated_at) 
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), NOW(), NOW())
+			RETURNING id`
 
-	args := []interface{}{in.Title, in.Content, in.Topic, in.UserId}
-	_, err := pg.Db.Exec(context.Background(), query, args...)
+	args := []interface{}{in.Title, in.Content, in.Topic, in.UserId, in.AppName}
+	err := pg.Db.QueryRow(context.Background(), query, args...).Scan(&in.ID)
 	if err != nil {
 		return err
 	}
@@ -71,8 +72,10 @@ func (pg *repository) Insert(in *domain.Tweet) error {
 
 func (pg *repository) Get(id int64) (*domain.Tweet, error) {
 	query := `
-				SELECT id, title, content, topic, created_at FROM tweets
-				WHERE id = $1`
+				SELECT id, title, content, topic, user_id, COALESCE(app_name, ''), created_at,
+				       user_id IN (SELECT user_id FROM protected_authors)
+				FROM tweets
+				WHERE id = $1 AND user_id NOT IN (SELECT user_id FROM deactivated_authors)`
 
 	var tweet domain.Tweet
 
@@ -81,21 +84,60 @@ func (pg *repository) Get(id int64) (*domain.Tweet, error) {
 		&tweet.Title,
 		&tweet.Content,
 		&tweet.Topic,
+		&tweet.UserId,
+		&tweet.AppName,
 		&tweet.CreatedAt,
+		&tweet.AuthorProtected,
 	)
 
 	if err != nil {
-		switch {
-		case errors.Is(err, pgx.ErrNoRows):
-			return nil, domain.ErrRecordNotFoundX
-		default:
-			return nil, err
-		}
+		return nil, notFound(err)
 	}
 
+	if err = pg.loadTags([]*domain.Tweet{&tweet}); err != nil {
+		return nil, err
+	}
 	return &tweet, nil
 }
 
+// loadTags sets the tags of the tweets. They are read apart from the tweets,
+// so that the cached listing does not go stale when a tweet is tagged.
+func (pg *repository) loadTags(tweets []*domain.Tweet) error {
+	if len(tweets) == 0 {
+		return nil
+	}
+	byID := make(map[int64]*domain.Tweet, len(tweets))
+	ids := make([]int64, 0, len(tweets))
+	for _, tweet := range tweets {
+		tweet.Tags = nil
+		byID[tweet.ID] = tweet
+		ids = append(ids, tweet.ID)
+	}
+
+	query := `
+		SELECT tt.tweet_id, t.id, t.name
+		FROM tweet_tags tt
+		JOIN tags t ON t.id = tt.tag_id
+		WHERE tt.tweet_id = ANY($1)
+		ORDER BY t.id`
+
+	rows, err := pg.Db.Query(context.Background(), query, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load tweet tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tweetID int64
+		var tag domain.Tag
+		if err = rows.Scan(&tweetID, &tag.ID, &tag.Name); err != nil {
+			return err
+		}
+		byID[tweetID].Tags = append(byID[tweetID].Tags, tag)
+	}
+	return rows.Err()
+}
+
 func (pg *repository) List() ([]*domain.Tweet, error) {
 	ctx := context.Background()
 	cacheKey := "tweets:list"
@@ -107,12 +149,19 @@ func (pg *repository) List() ([]*domain.Tweet, error) {
 		err := json.Unmarshal([]byte(cachedTweets), &tweets)
 		if err == nil {
 			log.Println("Cache hit")
+			if err = pg.loadTags(tweets); err != nil {
+				return nil, err
+			}
 			return tweets, nil
 		}
 	}
 	//log.Println("simulating long query")
 	//time.Sleep(5 * time.Second)
-	query := `SELECT id, title, content, topic, created_at FROM tweets`
+	query := `
+		SELECT id, title, content, topic, user_id, COALESCE(app_name, ''), created_at,
+		       user_id IN (SELECT user_id FROM protected_authors)
+		FROM tweets
+		WHERE user_id NOT IN (SELECT user_id FROM deactivated_authors)`
 
 	rows, err := pg.Db.Query(context.Background(), query)
 	if err != nil {
@@ -128,7 +177,10 @@ func (pg *repository) List() ([]*domain.Tweet, error) {
 			&tweet.Title,
 			&tweet.Content,
 			&tweet.Topic,
+			&tweet.UserId,
+			&tweet.AppName,
 			&tweet.CreatedAt,
+			&tweet.AuthorProtected,
 		)
 		if err != nil {
 			return nil, err
@@ -147,6 +199,9 @@ func (pg *repository) List() ([]*domain.Tweet, error) {
 		log.Println("Cache miss")
 	}
 
+	if err = pg.loadTags(tweets); err != nil {
+		return nil, err
+	}
 	return tweets, nil
 }
 
@@ -166,12 +221,7 @@ func (pg *repository) Update(in *domain.Tweet) (*domain.Tweet, error) {
 		&in.UpdatedAt,
 	)
 	if err != nil {
-		switch {
-		case errors.Is(err, pgx.ErrNoRows):
-			return nil, domain.ErrRecordNotFoundX
-		default:
-			return nil, err
-		}
+		return nil, notFound(err)
 	}
 
 	// Delete cache
@@ -214,10 +264,12 @@ func (pg *repository) Delete(id int) error {
 
 func (pg *repository) GetUserTweets(id int) ([]*domain.Tweet, error) {
 	query := `
-			SELECT * FROM tweets
-			WHERE user_id = $1`
+			SELECT id, title, content, topic, user_id, COALESCE(app_name, ''), created_at, updated_at,
+			       user_id IN (SELECT user_id FROM protected_authors)
+			FROM tweets
+			WHERE user_id = $1 AND user_id NOT IN (SELECT user_id FROM deactivated_authors)`
 
-	rows, err := pg.Db.Query(context.Background(), query)
+	rows, err := pg.Db.Query(context.Background(), query, id)
 	if err != nil {
 		return nil, err
 	}
@@ -225,24 +277,161 @@ func (pg *repository) GetUserTweets(id int) ([]*domain.Tweet, error) {
 
 	var tweets []*domain.Tweet
 	for rows.Next() {
-		var tweet *domain.Tweet
+		var tweet domain.Tweet
 		err = rows.Scan(
 			&tweet.ID,
 			&tweet.Title,
 			&tweet.Content,
 			&tweet.Topic,
 			&tweet.UserId,
+			&tweet.AppName,
 			&tweet.CreatedAt,
 			&tweet.UpdatedAt,
+			&tweet.AuthorProtected,
 		)
 		if err != nil {
 			return nil, err
 		}
-		tweets = append(tweets, tweet)
+		tweets = append(tweets, &tweet)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
+	if err = pg.loadTags(tweets); err != nil {
+		return nil, err
+	}
 	return tweets, nil
 }
+
+// HideAuthor hides the tweets of a deactivated user.
+func (pg *repository) HideAuthor(userID int) error {
+	query := `INSERT INTO deactivated_authors (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING`
+	if _, err := pg.Db.Exec(context.Background(), query, userID); err != nil {
+		return err
+	}
+	return pg.resetCache()
+}
+
+// ShowAuthor shows the tweets of a reactivated user again.
+func (pg *repository) ShowAuthor(userID int) error {
+	query := `DELETE FROM deactivated_authors WHERE user_id = $1`
+	if _, err := pg.Db.Exec(context.Background(), query, userID); err != nil {
+		return err
+	}
+	return pg.resetCache()
+}
+
+// ProtectAuthor limits the tweets of the user to the user's approved followers.
+func (pg *repository) ProtectAuthor(userID int) error {
+	query := `INSERT INTO protected_authors (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING`
+	if _, err := pg.Db.Exec(context.Background(), query, userID); err != nil {
+		return err
+	}
+	return pg.resetCache()
+}
+
+// UnprotectAuthor makes the tweets of the user public again.
+func (pg *repository) UnprotectAuthor(userID int) error {
+	query := `DELETE FROM protected_authors WHERE user_id = $1`
+	if _, err := pg.Db.Exec(context.Background(), query, userID); err != nil {
+		return err
+	}
+	return pg.resetCache()
+}
+
+// ListAuthorActivity sums up the tweets of every visible author who tweeted
+// since the given time.
+func (pg *repository) ListAuthorActivity(since time.Time) ([]*domain.AuthorActivity, error) {
+	query := `
+		SELECT t.user_id, COUNT(DISTINCT t.id), MAX(t.created_at),
+		       COALESCE(ARRAY_AGG(DISTINCT g.name) FILTER (WHERE g.name IS NOT NULL), '{}')
+		FROM tweets t
+		LEFT JOIN tweet_tags tt ON tt.tweet_id = t.id
+		LEFT JOIN tags g ON g.id = tt.tag_id
+		WHERE t.created_at >= $1
+		  AND t.user_id NOT IN (SELECT user_id FROM deactivated_authors)
+		GROUP BY t.user_id`
+
+	rows, err := pg.Db.Query(context.Background(), query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activity := []*domain.AuthorActivity{}
+	for rows.Next() {
+		var a domain.AuthorActivity
+		if err = rows.Scan(&a.UserID, &a.TweetCount, &a.LastTweetAt, &a.Tags); err != nil {
+			return nil, err
+		}
+		activity = append(activity, &a)
+	}
+	return activity, rows.Err()
+}
+
+// ListUserTweetIDs returns the ids of all tweets of the user, hidden or not.
+func (pg *repository) ListUserTweetIDs(userID int) ([]int64, error) {
+	rows, err := pg.Db.Query(context.Background(), `SELECT id FROM tweets WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DeleteUserTweets deletes all tweets of the user with their tags, and
+// forgets that the user was deactivated or protected.
+func (pg *repository) DeleteUserTweets(userID int) error {
+	ctx := context.Background()
+	tx, err := pg.Db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := []string{
+		`DELETE FROM tweet_tags WHERE tweet_id IN (SELECT id FROM tweets WHERE user_id = $1)`,
+		`DELETE FROM tweets WHERE user_id = $1`,
+		`DELETE FROM deactivated_authors WHERE user_id = $1`,
+		`DELETE FROM protected_authors WHERE user_id = $1`,
+	}
+	for _, query := range queries {
+		if _, err = tx.Exec(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+	return pg.resetCache()
+}
+
+// resetCache drops the cached tweet list and builds it again.
+func (pg *repository) resetCache() error {
+	if err := pg.RedisClient.Del(context.Background(), "tweets:list").Err(); err != nil {
+		return fmt.Errorf("failed to delete cache: %w", err)
+	}
+	if err := pg.RebuildCache(); err != nil {
+		return fmt.Errorf("failed to rebuild cache: %w", err)
+	}
+	return nil
+}
+
+// notFound turns the error of a query for a single tweet that matched no row
+// into ErrRecordNotFoundX.
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrRecordNotFoundX
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package tweets
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestNotFound(t *testing.T) {
+	if err := notFound(fmt.Errorf("scan: %w", pgx.ErrNoRows)); !errors.Is(err, domain.ErrRecordNotFoundX) {
+		t.Errorf("got %v for no rows, want ErrRecordNotFoundX", err)
+	}
+	other := errors.New("connection reset")
+	if err := notFound(other); err != other {
+		t.Errorf("got %v, want the error unchanged", err)
+	}
+}
+
+// testRepository returns a repository on a schema of its own in the database
+// at TEST_DATABASE_URL, and skips the test if it is not set.
+func testRepository(t *testing.T) *repository {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	schema := fmt.Sprintf("tweets_test_%d", time.Now().UnixNano())
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close(ctx)
+	if _, err = admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn, err := pgx.Connect(context.Background(), url)
+		if err != nil {
+			return
+		}
+		defer conn.Close(context.Background())
+		_, _ = conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	db, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	_, err = db.Exec(ctx, `
+		CREATE TABLE tweets (
+			id bigserial PRIMARY KEY, title varchar(155) NOT NULL, content varchar(300) NOT NULL,
+			topic varchar(64), user_id INT NOT NULL, app_name TEXT,
+			created_at timestamptz NOT NULL DEFAULT now(), updated_at timestamp DEFAULT now());
+		CREATE TABLE tags (id SERIAL PRIMARY KEY, name VARCHAR(255) NOT NULL);
+		CREATE TABLE tweet_tags (id SERIAL PRIMARY KEY, tweet_id INT NOT NULL, tag_id INT NOT NULL);
+		CREATE TABLE deactivated_authors (user_id INT PRIMARY KEY);
+		CREATE TABLE protected_authors (user_id INT PRIMARY KEY);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &repository{Db: db}
+}
+
+func TestGetHidesMissingAndDeactivated(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	var active, deactivated int64
+	insert := `INSERT INTO tweets (title, content, user_id) VALUES ('t', 'c', $1) RETURNING id`
+	if err := repo.Db.QueryRow(ctx, insert, 1).Scan(&active); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Db.QueryRow(ctx, insert, 2).Scan(&deactivated); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Db.Exec(ctx, `INSERT INTO deactivated_authors (user_id) VALUES (2)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if tweet, err := repo.Get(active); err != nil || tweet.ID != active {
+		t.Fatalf("Get(%d) = %v, %v", active, tweet, err)
+	}
+	for _, id := range []int64{deactivated, active + deactivated + 100} {
+		if _, err := repo.Get(id); !errors.Is(err, domain.ErrRecordNotFoundX) {
+			t.Errorf("Get(%d): got %v, want ErrRecordNotFoundX", id, err)
+		}
+	}
+	missing := &domain.Tweet{ID: active + deactivated + 100, Title: "t", Content: "c"}
+	if _, err := repo.Update(missing); !errors.Is(err, domain.ErrRecordNotFoundX) {
+		t.Errorf("Update of a missing tweet: got %v, want ErrRecordNotFoundX", err)
+	}
+}
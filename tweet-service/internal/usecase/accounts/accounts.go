@@ -0,0 +1,151 @@
+package accounts
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/dto"
+	repo "MussaShaukenov/twitter-clone-go/tweet-service/internal/repository"
+	"context"
+	"log"
+	"time"
+)
+
+// useCase carries out the tweet-service side of account operations run by
+// user-service: the steps of the account deletion saga, which are all
+// idempotent, the data export and the activity behind follow suggestions.
+type useCase struct {
+	tweetRepo repo.TweetRepository
+	tagRepo   repo.TweetTagRepository
+	statsRepo repo.TweetStatsRepo
+	likeRepo  repo.LikeRepository
+}
+
+func NewAccountUseCase(
+	tweetRepo repo.TweetRepository,
+	tagRepo repo.TweetTagRepository,
+	statsRepo repo.TweetStatsRepo,
+	likeRepo repo.LikeRepository,
+) *useCase {
+	return &useCase{
+		tweetRepo: tweetRepo,
+		tagRepo:   tagRepo,
+		statsRepo: statsRepo,
+		likeRepo:  likeRepo,
+	}
+}
+
+func (uc *useCase) Deactivate(userID int) error {
+	return uc.tweetRepo.HideAuthor(userID)
+}
+
+func (uc *useCase) Reactivate(userID int) error {
+	return uc.tweetRepo.ShowAuthor(userID)
+}
+
+func (uc *useCase) Protect(userID int) error {
+	return uc.tweetRepo.ProtectAuthor(userID)
+}
+
+func (uc *useCase) Unprotect(userID int) error {
+	return uc.tweetRepo.UnprotectAuthor(userID)
+}
+
+// Purge deletes all tweets and likes of the user. Stats are deleted first:
+// they are found through the tweets, so a retry after a failure still finds
+// them.
+func (uc *useCase) Purge(ctx context.Context, userID int) error {
+	if err := uc.purgeLikes(ctx, userID); err != nil {
+		return err
+	}
+	ids, err := uc.tweetRepo.ListUserTweetIDs(userID)
+	if err != nil {
+		return err
+	}
+	if err = uc.statsRepo.DeleteTweetStats(ctx, ids); err != nil {
+		return err
+	}
+	if err = uc.tweetRepo.DeleteUserTweets(userID); err != nil {
+		return err
+	}
+
+	log.Printf("purged %d tweets of user %d", len(ids), userID)
+	return nil
+}
+
+// purgeLikes takes back the likes of the user. Each like is removed before
+// its count, so a retry does not count it off twice.
+func (uc *useCase) purgeLikes(ctx context.Context, userID int) error {
+	likes, err := uc.likeRepo.ListUserLikes(userID)
+	if err != nil {
+		return err
+	}
+	for _, like := range likes {
+		removed, err := uc.likeRepo.Unlike(like.TweetID, userID)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			continue
+		}
+		if err = uc.statsRepo.UpdateLikes(ctx, like.TweetID, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export returns every tweet of the user with its tags and reactions, for the
+// user's data export.
+func (uc *useCase) Export(ctx context.Context, userID int) ([]*dto.ExportedTweet, error) {
+	tweets, err := uc.tweetRepo.GetUserTweets(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := make([]*dto.ExportedTweet, 0, len(tweets))
+	for _, tweet := range tweets {
+		tags, err := uc.tagRepo.GetTweetTags(tweet.ID)
+		if err != nil {
+			return nil, err
+		}
+		stats, err := uc.statsRepo.GetTweetStats(ctx, tweet.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		exported = append(exported, &dto.ExportedTweet{
+			ID:        tweet.ID,
+			Title:     tweet.Title,
+			Content:   tweet.Content,
+			Topic:     tweet.Topic,
+			CreatedAt: tweet.CreatedAt,
+			UpdatedAt: tweet.UpdatedAt,
+			AppName:   tweet.AppName,
+			Tags:      names,
+			Likes:     stats.Likes,
+			Dislikes:  stats.Dislikes,
+		})
+	}
+	return exported, nil
+}
+
+// ExportLikes returns the tweets the user liked, for the user's data export.
+func (uc *useCase) ExportLikes(userID int) ([]*dto.ExportedLike, error) {
+	likes, err := uc.likeRepo.ListUserLikes(userID)
+	if err != nil {
+		return nil, err
+	}
+	exported := make([]*dto.ExportedLike, 0, len(likes))
+	for _, like := range likes {
+		exported = append(exported, &dto.ExportedLike{TweetID: like.TweetID, LikedAt: like.LikedAt})
+	}
+	return exported, nil
+}
+
+// Activity sums up what every author tweeted since the given time.
+func (uc *useCase) Activity(since time.Time) ([]*domain.AuthorActivity, error) {
+	return uc.tweetRepo.ListAuthorActivity(since)
+}
@@ -2,21 +2,79 @@ package stats
 
 import (
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/dto"
 	repo "MussaShaukenov/twitter-clone-go/tweet-service/internal/repository"
 	"context"
+	"encoding/json"
+	"log"
 )
 
+// Notifier reports events to user-service, which tells the users they concern.
+type Notifier interface {
+	Notify(event domain.NotificationEvent) error
+}
+
+// Publisher pushes events to the clients streaming the timeline.
+type Publisher interface {
+	Publish(event *domain.StreamEvent) error
+}
+
+// Readers decides whether a user may read a tweet.
+type Readers interface {
+	Readable(tweet *domain.Tweet, viewer *domain.Principal) (bool, error)
+}
+
+// Webhooks reports events to user-service, which delivers them to the
+// webhooks of the apps of the user they concern.
+type Webhooks interface {
+	DispatchWebhook(event domain.WebhookEvent) error
+}
+
 type useCase struct {
-	repo repo.TweetStatsRepo
+	repo      repo.TweetStatsRepo
+	likeRepo  repo.LikeRepository
+	tweetRepo repo.TweetRepository
+	readers   Readers
+	notifier  Notifier
+	publisher Publisher
+	webhooks  Webhooks
 }
 
-func NewTweetStatsUseCase(repo repo.TweetStatsRepo) *useCase {
+func NewTweetStatsUseCase(
+	repo repo.TweetStatsRepo,
+	likeRepo repo.LikeRepository,
+	tweetRepo repo.TweetRepository,
+	readers Readers,
+	notifier Notifier,
+	publisher Publisher,
+	webhooks Webhooks,
+) *useCase {
 	return &useCase{
-		repo: repo,
+		repo:      repo,
+		likeRepo:  likeRepo,
+		tweetRepo: tweetRepo,
+		readers:   readers,
+		notifier:  notifier,
+		publisher: publisher,
+		webhooks:  webhooks,
 	}
 }
 
-func (uc *useCase) GetTweetStats(ctx context.Context, tweetID int64) (*domain.TweetStats, error) {
+// GetTweetStats returns the counts of the tweet. Tweets viewer may not read
+// are reported as not found.
+func (uc *useCase) GetTweetStats(ctx context.Context, tweetID int64, viewer *domain.Principal) (*domain.TweetStats, error) {
+	tweet, err := uc.tweetRepo.Get(tweetID)
+	if err != nil {
+		return nil, err
+	}
+	readable, err := uc.readers.Readable(tweet, viewer)
+	if err != nil {
+		return nil, err
+	}
+	if !readable {
+		return nil, domain.ErrRecordNotFoundX
+	}
+
 	tweetStats, err := uc.repo.GetTweetStats(ctx, tweetID)
 	if err != nil {
 		return nil, err
@@ -24,27 +82,79 @@ func (uc *useCase) GetTweetStats(ctx context.Context, tweetID int64) (*domain.Tw
 	return tweetStats, nil
 }
 
-func (uc *useCase) AddLike(ctx context.Context, tweetID int64) error {
-	err := uc.repo.UpdateLikes(ctx, tweetID, 1)
+// AddLike counts a like of the tweet by liker and tells the author about it.
+// Liking a tweet again changes nothing, and tweets liker may not read are
+// reported as not found.
+func (uc *useCase) AddLike(ctx context.Context, tweetID int64, liker *domain.Principal) error {
+	tweet, err := uc.tweetRepo.Get(tweetID)
+	if err != nil {
+		return err
+	}
+	readable, err := uc.readers.Readable(tweet, liker)
+	if err != nil {
+		return err
+	}
+	if !readable {
+		return domain.ErrRecordNotFoundX
+	}
+
+	added, err := uc.likeRepo.Like(tweetID, liker.UserID)
+	if err != nil || !added {
+		return err
+	}
+	err = uc.repo.UpdateLikes(ctx, tweetID, 1)
 	if err != nil {
 		return err
 	}
+	uc.publish(ctx, tweetID)
+
+	// the like stands even if the author cannot be told about it
+	err = uc.notifier.Notify(domain.NotificationEvent{
+		Type:        domain.NotificationLike,
+		ActorID:     liker.UserID,
+		RecipientID: tweet.UserId,
+		TweetID:     &tweetID,
+	})
+	if err != nil {
+		log.Printf("failed to notify like of tweet %d: %v", tweetID, err)
+	}
+	uc.dispatchLike(tweet, liker)
 	return nil
 }
 
+// dispatchLike reports the like to the webhooks of the author.
+func (uc *useCase) dispatchLike(tweet *domain.Tweet, liker *domain.Principal) {
+	data, err := json.Marshal(dto.LikedTweet{TweetID: tweet.ID, LikerID: liker.UserID})
+	if err != nil {
+		log.Printf("failed to encode like of tweet %d: %v", tweet.ID, err)
+		return
+	}
+	err = uc.webhooks.DispatchWebhook(domain.WebhookEvent{Type: domain.WebhookLikeCreated, UserID: tweet.UserId, Data: data})
+	if err != nil {
+		log.Printf("failed to dispatch like of tweet %d to webhooks: %v", tweet.ID, err)
+	}
+}
+
 func (uc *useCase) AddDislike(ctx context.Context, tweetID int64) error {
 	err := uc.repo.UpdateDislikes(ctx, tweetID, 1)
 	if err != nil {
 		return err
 	}
+	uc.publish(ctx, tweetID)
 	return nil
 }
 
-func (uc *useCase) RemoveLike(ctx context.Context, tweetID int64) error {
-	err := uc.repo.UpdateLikes(ctx, tweetID, -1)
+// RemoveLike takes back the like of the tweet by liker, if they liked it.
+func (uc *useCase) RemoveLike(ctx context.Context, tweetID int64, liker *domain.Principal) error {
+	removed, err := uc.likeRepo.Unlike(tweetID, liker.UserID)
+	if err != nil || !removed {
+		return err
+	}
+	err = uc.repo.UpdateLikes(ctx, tweetID, -1)
 	if err != nil {
 		return err
 	}
+	uc.publish(ctx, tweetID)
 	return nil
 }
 
@@ -53,5 +163,35 @@ func (uc *useCase) RemoveDislike(ctx context.Context, tweetID int64) error {
 	if err != nil {
 		return err
 	}
+	uc.publish(ctx, tweetID)
 	return nil
 }
+
+// publish streams the new counts of the tweet. The change stands even if it
+// cannot be streamed.
+func (uc *useCase) publish(ctx context.Context, tweetID int64) {
+	tweet, err := uc.tweetRepo.Get(tweetID)
+	if err != nil {
+		log.Printf("could not read tweet %d to stream its stats: %v", tweetID, err)
+		return
+	}
+	stats, err := uc.repo.GetTweetStats(ctx, tweetID)
+	if err != nil {
+		log.Printf("could not read stats of tweet %d to stream them: %v", tweetID, err)
+		return
+	}
+	data, err := json.Marshal(dto.StreamedStats{
+		TweetID:         tweetID,
+		UserId:          tweet.UserId,
+		AuthorProtected: tweet.AuthorProtected,
+		Likes:           stats.Likes,
+		Dislikes:        stats.Dislikes,
+	})
+	if err != nil {
+		log.Printf("could not encode stats of tweet %d: %v", tweetID, err)
+		return
+	}
+	if err = uc.publisher.Publish(&domain.StreamEvent{Type: domain.EventStats, Data: data}); err != nil {
+		log.Printf("could not stream stats of tweet %d: %v", tweetID, err)
+	}
+}
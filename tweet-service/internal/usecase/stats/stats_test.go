@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/repository"
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeStatsRepo struct {
+	repository.TweetStatsRepo
+	likes map[int64]int64
+}
+
+func (r *fakeStatsRepo) GetTweetStats(ctx context.Context, tweetID int64) (*domain.TweetStats, error) {
+	return &domain.TweetStats{TweetID: tweetID, Likes: r.likes[tweetID]}, nil
+}
+
+func (r *fakeStatsRepo) UpdateLikes(ctx context.Context, tweetID int64, likesChange int64) error {
+	r.likes[tweetID] += likesChange
+	return nil
+}
+
+type like struct {
+	tweetID int64
+	userID  int
+}
+
+type fakeLikeRepo map[like]bool
+
+func (r fakeLikeRepo) Like(tweetID int64, userID int) (bool, error) {
+	if r[like{tweetID, userID}] {
+		return false, nil
+	}
+	r[like{tweetID, userID}] = true
+	return true, nil
+}
+
+func (r fakeLikeRepo) Unlike(tweetID int64, userID int) (bool, error) {
+	if !r[like{tweetID, userID}] {
+		return false, nil
+	}
+	delete(r, like{tweetID, userID})
+	return true, nil
+}
+
+func (r fakeLikeRepo) ListUserLikes(userID int) ([]*domain.Like, error) {
+	return nil, nil
+}
+
+type fakeTweetRepo struct {
+	repository.TweetRepository
+}
+
+func (fakeTweetRepo) Get(id int64) (*domain.Tweet, error) {
+	return &domain.Tweet{ID: id, UserId: 5}, nil
+}
+
+// fakeReaders lets everyone but the users in hidden read the tweets.
+type fakeReaders map[int]bool
+
+func (f fakeReaders) Readable(tweet *domain.Tweet, viewer *domain.Principal) (bool, error) {
+	return viewer == nil || !f[viewer.UserID], nil
+}
+
+type recorder struct {
+	notifications []domain.NotificationEvent
+	webhooks      []domain.WebhookEvent
+	events        []*domain.StreamEvent
+}
+
+func (r *recorder) Notify(event domain.NotificationEvent) error {
+	r.notifications = append(r.notifications, event)
+	return nil
+}
+
+func (r *recorder) DispatchWebhook(event domain.WebhookEvent) error {
+	r.webhooks = append(r.webhooks, event)
+	return nil
+}
+
+func (r *recorder) Publish(event *domain.StreamEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestLikesCountOncePerUser(t *testing.T) {
+	stats, rec := &fakeStatsRepo{likes: map[int64]int64{}}, &recorder{}
+	uc := NewTweetStatsUseCase(stats, fakeLikeRepo{}, fakeTweetRepo{}, fakeReaders{}, rec, rec, rec)
+	ctx := context.Background()
+	liker := &domain.Principal{UserID: 2}
+
+	for i := 0; i < 3; i++ {
+		if err := uc.AddLike(ctx, 1, liker); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats.likes[1] != 1 || len(rec.notifications) != 1 || len(rec.webhooks) != 1 || len(rec.events) != 1 {
+		t.Errorf("liking three times: %d likes, %d notifications, %d webhooks, %d events, want one of each",
+			stats.likes[1], len(rec.notifications), len(rec.webhooks), len(rec.events))
+	}
+
+	// only a user who liked the tweet can take a like back, and only once
+	for _, user := range []int{3, 2, 2} {
+		if err := uc.RemoveLike(ctx, 1, &domain.Principal{UserID: user}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats.likes[1] != 0 {
+		t.Errorf("likes after unliking = %d, want 0", stats.likes[1])
+	}
+}
+
+func TestLikeNeedsReadableTweet(t *testing.T) {
+	stats, rec := &fakeStatsRepo{likes: map[int64]int64{}}, &recorder{}
+	uc := NewTweetStatsUseCase(stats, fakeLikeRepo{}, fakeTweetRepo{}, fakeReaders{6: true}, rec, rec, rec)
+
+	err := uc.AddLike(context.Background(), 1, &domain.Principal{UserID: 6})
+	if !errors.Is(err, domain.ErrRecordNotFoundX) {
+		t.Fatalf("got %v, want not found", err)
+	}
+	if stats.likes[1] != 0 || len(rec.notifications) != 0 {
+		t.Error("counted a like of a tweet the user may not read")
+	}
+}
+
+func TestStatsNeedReadableTweet(t *testing.T) {
+	stats, rec := &fakeStatsRepo{likes: map[int64]int64{1: 4}}, &recorder{}
+	uc := NewTweetStatsUseCase(stats, fakeLikeRepo{}, fakeTweetRepo{}, fakeReaders{6: true}, rec, rec, rec)
+
+	if _, err := uc.GetTweetStats(context.Background(), 1, &domain.Principal{UserID: 6}); !errors.Is(err, domain.ErrRecordNotFoundX) {
+		t.Fatalf("got %v, want not found", err)
+	}
+	got, err := uc.GetTweetStats(context.Background(), 1, &domain.Principal{UserID: 2})
+	if err != nil || got.Likes != 4 {
+		t.Fatalf("got %v, %v, want 4 likes", got, err)
+	}
+}
@@ -0,0 +1,309 @@
+package stream
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// bufferSize is how many events a stream holds for a slow client. A
+	// client that falls further behind is cut off and has to resume.
+	bufferSize = 64
+	// maxReplay is how many missed events a resuming client gets at most.
+	maxReplay = 500
+
+	reconnectDelay = time.Second
+)
+
+var (
+	ErrInvalidEventID = errors.New("invalid last event id")
+	ErrLagged         = errors.New("stream fell behind; resume from the last event id")
+)
+
+// Timeline decides which new tweets a viewer may read in the timeline.
+type Timeline interface {
+	Visible(tweet *domain.Tweet, viewer *domain.Principal) (bool, error)
+}
+
+// useCase fans the events published by every instance out to the streams
+// open on this one.
+type useCase struct {
+	events   repository.EventRepository
+	timeline Timeline
+
+	mu      sync.Mutex
+	streams map[*stream]struct{}
+}
+
+func NewStreamUseCase(events repository.EventRepository, timeline Timeline) *useCase {
+	return &useCase{
+		events:   events,
+		timeline: timeline,
+		streams:  make(map[*stream]struct{}),
+	}
+}
+
+// Run listens for published events until ctx is done, subscribing again
+// whenever the subscription fails.
+func (uc *useCase) Run(ctx context.Context) {
+	for {
+		err := uc.events.Listen(ctx, uc.dispatch)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("event subscription failed, subscribing again: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (uc *useCase) dispatch(event *domain.StreamEvent) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	for s := range uc.streams {
+		if event.UserID != 0 && event.UserID != s.viewer.UserID {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			s.lag()
+		}
+	}
+}
+
+// Open starts streaming events to viewer. With lastEventID set, the events
+// stored after it are sent first.
+func (uc *useCase) Open(viewer *domain.Principal, lastEventID string) (usecase.EventStream, error) {
+	// A new client starts from now in both streams
+	last := cursor{origin: strconv.FormatInt(time.Now().UnixMilli(), 10) + "-0"}
+	if lastEventID != "" {
+		var err error
+		if last, err = parseCursor(lastEventID); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &stream{
+		uc:     uc,
+		viewer: viewer,
+		last:   last,
+		events: make(chan *domain.StreamEvent, bufferSize),
+		lagged: make(chan struct{}),
+	}
+	// Subscribe before reading the stored events, so none fall in between;
+	// those received twice are skipped by id.
+	uc.mu.Lock()
+	uc.streams[s] = struct{}{}
+	uc.mu.Unlock()
+
+	if lastEventID != "" {
+		missed, err := uc.events.Since(viewer.UserID, last.timelineID(), last.ownID(), maxReplay)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		sort.Slice(missed, func(i, j int) bool {
+			return compareEventIDs(missed[i].ID, missed[j].ID) < 0
+		})
+		s.missed = missed
+	}
+	return s, nil
+}
+
+type stream struct {
+	uc     *useCase
+	viewer *domain.Principal
+	last   cursor // ids of the last events sent
+
+	missed []*domain.StreamEvent // stored events to send before the live ones
+	events chan *domain.StreamEvent
+
+	lagOnce sync.Once
+	lagged  chan struct{}
+}
+
+// Next returns the next event the viewer may see. It fails with ErrLagged
+// once the client fell too far behind, or with the error of ctx.
+func (s *stream) Next(ctx context.Context) (*domain.StreamEvent, error) {
+	for {
+		var event *domain.StreamEvent
+		if len(s.missed) > 0 {
+			event, s.missed = s.missed[0], s.missed[1:]
+		} else {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-s.lagged:
+				return nil, ErrLagged
+			case event = <-s.events:
+			}
+		}
+
+		// Each Redis stream numbers its events on its own, so they are
+		// told apart by the stream they came from
+		lastID := &s.last.timeline
+		if event.UserID != 0 {
+			lastID = &s.last.own
+		}
+		if *lastID != "" && compareEventIDs(event.ID, *lastID) <= 0 {
+			continue
+		}
+		*lastID = event.ID
+		if s.shows(event) {
+			// The event is shared with the other streams
+			sent := *event
+			sent.ID = s.last.String()
+			return &sent, nil
+		}
+	}
+}
+
+// shows reports whether the viewer may see the event. Tweets, and the stats
+// of tweets, are filtered like the timeline listing; the others are the
+// viewer's own, but API keys only get those about messages with messages:read.
+func (s *stream) shows(event *domain.StreamEvent) bool {
+	var tweet *domain.Tweet
+	switch event.Type {
+	case domain.EventTweet:
+		var streamed dto.StreamedTweet
+		if err := json.Unmarshal(event.Data, &streamed); err != nil {
+			log.Printf("could not decode tweet event %s: %v", event.ID, err)
+			return false
+		}
+		tweet = &domain.Tweet{
+			ID:              int64(streamed.ID),
+			Title:           streamed.Title,
+			Content:         streamed.Content,
+			Topic:           streamed.Topic,
+			UserId:          streamed.UserId,
+			AuthorProtected: streamed.AuthorProtected,
+		}
+	case domain.EventStats:
+		var streamed dto.StreamedStats
+		if err := json.Unmarshal(event.Data, &streamed); err != nil {
+			log.Printf("could not decode stats event %s: %v", event.ID, err)
+			return false
+		}
+		tweet = &domain.Tweet{ID: streamed.TweetID, UserId: streamed.UserId, AuthorProtected: streamed.AuthorProtected}
+	case domain.EventNotification:
+		return true
+	default:
+		// The other events user-service publishes carry the user's messages
+		// and group invites, which the tweets:read scope does not cover.
+		return s.viewer.HasScope(domain.ScopeMessagesRead)
+	}
+	visible, err := s.uc.timeline.Visible(tweet, s.viewer)
+	if err != nil {
+		log.Printf("could not check whether user %d may read tweet %d: %v", s.viewer.UserID, tweet.ID, err)
+		return false
+	}
+	return visible
+}
+
+func (s *stream) lag() {
+	s.lagOnce.Do(func() { close(s.lagged) })
+}
+
+// Close stops the stream.
+func (s *stream) Close() {
+	s.uc.mu.Lock()
+	delete(s.uc.streams, s)
+	s.uc.mu.Unlock()
+}
+
+// cursor is how far a client got in both streams it reads: the timeline and
+// its own. Clients get it as the event id, "<timeline id>.<own id>", and
+// resume each stream where they left off. A stream the client got nothing
+// from yet is resumed from origin, when the client first connected.
+type cursor struct {
+	timeline string
+	own      string
+	origin   string
+}
+
+// parseCursor reads an event id sent to a client. A single stream id, as sent
+// before the streams had their own cursors, applies to both.
+func parseCursor(id string) (cursor, error) {
+	timeline, own, ok := strings.Cut(id, ".")
+	if !ok {
+		own = timeline
+	}
+	if _, _, err := parseEventID(timeline); err != nil {
+		return cursor{}, ErrInvalidEventID
+	}
+	if _, _, err := parseEventID(own); err != nil {
+		return cursor{}, ErrInvalidEventID
+	}
+	return cursor{timeline: timeline, own: own}, nil
+}
+
+func (c cursor) timelineID() string {
+	return cmp.Or(c.timeline, c.origin, "0-0")
+}
+
+func (c cursor) ownID() string {
+	return cmp.Or(c.own, c.origin, "0-0")
+}
+
+func (c cursor) String() string {
+	return c.timelineID() + "." + c.ownID()
+}
+
+// parseEventID splits a Redis stream id, "<milliseconds>-<sequence>".
+func parseEventID(id string) (ms, seq uint64, err error) {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return 0, 0, ErrInvalidEventID
+	}
+	if ms, err = strconv.ParseUint(msPart, 10, 64); err != nil {
+		return 0, 0, ErrInvalidEventID
+	}
+	if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+		return 0, 0, ErrInvalidEventID
+	}
+	return ms, seq, nil
+}
+
+// compareEventIDs orders event ids; invalid ones come first.
+func compareEventIDs(a, b string) int {
+	aMs, aSeq, aErr := parseEventID(a)
+	bMs, bSeq, bErr := parseEventID(b)
+	switch {
+	case aErr != nil || bErr != nil:
+		if aErr != nil && bErr != nil {
+			return 0
+		}
+		if aErr != nil {
+			return -1
+		}
+		return 1
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq != bSeq:
+		if aSeq < bSeq {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
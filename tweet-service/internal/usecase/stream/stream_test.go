@@ -0,0 +1,170 @@
+package stream
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/usecase"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareEventIDs(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1700000000000-0", "1700000000000-0", 0},
+		{"1700000000000-1", "1700000000000-0", 1},
+		{"999-5", "1000-0", -1},
+		{"1000-10", "1000-9", 1},
+		{"garbage", "1-0", -1},
+	}
+	for _, tt := range tests {
+		if got := compareEventIDs(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareEventIDs(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+type fakeEvents struct {
+	repository.EventRepository
+	stored []*domain.StreamEvent
+}
+
+func (f *fakeEvents) Since(userID int, afterTimelineID, afterOwnID string, limit int) ([]*domain.StreamEvent, error) {
+	return f.stored, nil
+}
+
+// hiddenAuthors shows the viewer every tweet but those of the authors in it.
+type hiddenAuthors map[int]bool
+
+func (h hiddenAuthors) Visible(tweet *domain.Tweet, viewer *domain.Principal) (bool, error) {
+	return !h[tweet.UserId], nil
+}
+
+func nextIDs(t *testing.T, s usecase.EventStream, n int) []string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var ids []string
+	for i := 0; i < n; i++ {
+		event, err := s.Next(ctx)
+		if err != nil {
+			t.Fatalf("after events %v: %v", ids, err)
+		}
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func equalIDs(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var stats = json.RawMessage(`{"tweet_id":1,"user_id":5}`)
+
+func TestStreamResumesWithoutDuplicates(t *testing.T) {
+	events := &fakeEvents{stored: []*domain.StreamEvent{
+		{ID: "2-0", Type: domain.EventStats, Data: stats},
+		{ID: "1-1", Type: domain.EventStats, Data: stats},
+		{ID: "1-5", Type: domain.EventNotification, UserID: 7},
+	}}
+	uc := NewStreamUseCase(events, hiddenAuthors{})
+
+	// a single id, as sent before each stream had its own, resumes both
+	s, err := uc.Open(&domain.Principal{UserID: 7}, "1-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// Published while the stored events were read, and for someone else
+	uc.dispatch(&domain.StreamEvent{ID: "2-0", Type: domain.EventStats, Data: stats})
+	uc.dispatch(&domain.StreamEvent{ID: "1-5", Type: domain.EventNotification, UserID: 7})
+	uc.dispatch(&domain.StreamEvent{ID: "2-5", Type: domain.EventNotification, UserID: 8})
+	uc.dispatch(&domain.StreamEvent{ID: "3-0", Type: domain.EventNotification, UserID: 7})
+
+	want := []string{"1-1.1-0", "1-1.1-5", "2-0.1-5", "2-0.3-0"}
+	if got := nextIDs(t, s, len(want)); !equalIDs(got, want) {
+		t.Fatalf("got events %v, want %v", got, want)
+	}
+}
+
+func TestStreamsHaveTheirOwnCursors(t *testing.T) {
+	uc := NewStreamUseCase(&fakeEvents{}, hiddenAuthors{})
+	s, err := uc.Open(&domain.Principal{UserID: 7}, "5-0.1-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// the own event is new although its id is below the timeline's
+	uc.dispatch(&domain.StreamEvent{ID: "2-0", Type: domain.EventNotification, UserID: 7})
+	uc.dispatch(&domain.StreamEvent{ID: "4-0", Type: domain.EventStats, Data: stats})
+	uc.dispatch(&domain.StreamEvent{ID: "6-0", Type: domain.EventStats, Data: stats})
+
+	want := []string{"5-0.2-0", "6-0.2-0"}
+	if got := nextIDs(t, s, len(want)); !equalIDs(got, want) {
+		t.Fatalf("got events %v, want %v", got, want)
+	}
+}
+
+func TestStatsOfHiddenTweetsAreNotStreamed(t *testing.T) {
+	uc := NewStreamUseCase(&fakeEvents{}, hiddenAuthors{5: true})
+	s, err := uc.Open(&domain.Principal{UserID: 7}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	uc.dispatch(&domain.StreamEvent{ID: "9000000000000-0", Type: domain.EventStats, Data: stats})
+	uc.dispatch(&domain.StreamEvent{ID: "9000000000000-1", Type: domain.EventStats,
+		Data: json.RawMessage(`{"tweet_id":2,"user_id":6}`)})
+
+	if got := nextIDs(t, s, 1); !strings.HasPrefix(got[0], "9000000000000-1.") {
+		t.Fatalf("got event %v, want only the stats of the readable tweet", got)
+	}
+}
+
+func TestOpenRejectsInvalidEventID(t *testing.T) {
+	uc := NewStreamUseCase(&fakeEvents{}, hiddenAuthors{})
+	for _, id := range []string{"yesterday", "1-0.", "1-0.garbage"} {
+		if _, err := uc.Open(&domain.Principal{UserID: 7}, id); err != ErrInvalidEventID {
+			t.Errorf("Open(%q): got %v, want ErrInvalidEventID", id, err)
+		}
+	}
+}
+
+func TestMessagesNeedTheirScope(t *testing.T) {
+	uc := NewStreamUseCase(&fakeEvents{}, hiddenAuthors{})
+	for _, tc := range []struct {
+		viewer *domain.Principal
+		want   string
+	}{
+		{&domain.Principal{UserID: 7, AuthType: "session"}, ".9000000000000-1"},
+		{&domain.Principal{UserID: 7, AuthType: "api_key", Scopes: []string{domain.ScopeTweetsRead, domain.ScopeMessagesRead}}, ".9000000000000-1"},
+		{&domain.Principal{UserID: 7, AuthType: "api_key", Scopes: []string{domain.ScopeTweetsRead}}, ".9000000000000-2"},
+	} {
+		s, err := uc.Open(tc.viewer, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		uc.dispatch(&domain.StreamEvent{ID: "9000000000000-1", Type: "message", UserID: 7})
+		uc.dispatch(&domain.StreamEvent{ID: "9000000000000-2", Type: domain.EventNotification, UserID: 7})
+		if got := nextIDs(t, s, 1); !strings.HasSuffix(got[0], tc.want) {
+			t.Errorf("%s with scopes %v: got event %v, want %s", tc.viewer.AuthType, tc.viewer.Scopes, got, tc.want)
+		}
+		s.Close()
+	}
+}
@@ -1,22 +1,38 @@
 package tags
 
 import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/dto"
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/repository"
 	"fmt"
 )
 
+// Readers decides whether a user may read a tweet.
+type Readers interface {
+	Readable(tweet *domain.Tweet, viewer *domain.Principal) (bool, error)
+}
+
 type tweetUseCase struct {
 	tweetTagRepository repository.TweetTagRepository
+	tweetRepository    repository.TweetRepository
+	readers            Readers
 }
 
-func NewTagsUseCase(tweetTagRepository repository.TweetTagRepository) *tweetUseCase {
+func NewTagsUseCase(
+	tweetTagRepository repository.TweetTagRepository,
+	tweetRepository repository.TweetRepository,
+	readers Readers,
+) *tweetUseCase {
 	return &tweetUseCase{
 		tweetTagRepository: tweetTagRepository,
+		tweetRepository:    tweetRepository,
+		readers:            readers,
 	}
 }
 
-func (uc *tweetUseCase) AddTag(tweetId int64, tagId int64) error {
+// AddTag tags the tweet. Only its author may, and to anyone else the tweet
+// is reported as not found, as when changing it.
+func (uc *tweetUseCase) AddTag(tweetId int64, tagId int64, actor *domain.Principal) error {
 	if tweetId < 1 {
 		return fmt.Errorf("invalid tweetId: %v", tweetId)
 	}
@@ -24,18 +40,40 @@ func (uc *tweetUseCase) AddTag(tweetId int64, tagId int64) error {
 		return fmt.Errorf("invalid tagId: %v", tagId)
 	}
 
-	err := uc.tweetTagRepository.AddTag(tweetId, tagId)
+	tweet, err := uc.tweetRepository.Get(tweetId)
+	if err != nil {
+		return err
+	}
+	if tweet.UserId != actor.UserID {
+		return domain.ErrRecordNotFoundX
+	}
+
+	err = uc.tweetTagRepository.AddTag(tweetId, tagId)
 	if err != nil {
 		return fmt.Errorf("could not add tag to tweet: %w", err)
 	}
 	return nil
 }
 
-func (uc *tweetUseCase) GetTweetTags(tweetId int64) ([]*dto.TagDto, error) {
+// GetTweetTags returns the tags of the tweet. Tweets viewer may not read are
+// reported as not found.
+func (uc *tweetUseCase) GetTweetTags(tweetId int64, viewer *domain.Principal) ([]*dto.TagDto, error) {
 	if tweetId < 1 {
 		return nil, fmt.Errorf("invalid tweetId: %v", tweetId)
 	}
 
+	tweet, err := uc.tweetRepository.Get(tweetId)
+	if err != nil {
+		return nil, err
+	}
+	readable, err := uc.readers.Readable(tweet, viewer)
+	if err != nil {
+		return nil, err
+	}
+	if !readable {
+		return nil, domain.ErrRecordNotFoundX
+	}
+
 	tags, err := uc.tweetTagRepository.GetTweetTags(tweetId)
 	if err != nil {
 		return nil, fmt.Errorf("could not get tags of tweet: %w", err)
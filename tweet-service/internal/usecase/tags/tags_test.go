@@ -0,0 +1,69 @@
+package tags
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/repository"
+	"errors"
+	"testing"
+)
+
+type fakeTagRepo struct {
+	repository.TweetTagRepository
+	added []int64
+}
+
+func (r *fakeTagRepo) AddTag(tweetId int64, tagId int64) error {
+	r.added = append(r.added, tagId)
+	return nil
+}
+
+func (r *fakeTagRepo) GetTweetTags(tweetId int64) ([]*domain.Tag, error) {
+	return []*domain.Tag{{ID: 1, Name: "#go"}}, nil
+}
+
+// fakeTweetRepo holds tweet 1, by user 5.
+type fakeTweetRepo struct {
+	repository.TweetRepository
+}
+
+func (fakeTweetRepo) Get(id int64) (*domain.Tweet, error) {
+	if id != 1 {
+		return nil, domain.ErrRecordNotFoundX
+	}
+	return &domain.Tweet{ID: id, UserId: 5, AuthorProtected: true}, nil
+}
+
+// fakeReaders lets only the author and the users in it read the tweet.
+type fakeReaders map[int]bool
+
+func (f fakeReaders) Readable(tweet *domain.Tweet, viewer *domain.Principal) (bool, error) {
+	return viewer != nil && (viewer.UserID == tweet.UserId || f[viewer.UserID]), nil
+}
+
+func TestTagsOfHiddenTweets(t *testing.T) {
+	uc := NewTagsUseCase(&fakeTagRepo{}, fakeTweetRepo{}, fakeReaders{6: true})
+
+	for _, viewer := range []*domain.Principal{nil, {UserID: 7}} {
+		if _, err := uc.GetTweetTags(1, viewer); !errors.Is(err, domain.ErrRecordNotFoundX) {
+			t.Errorf("viewer %v: got %v, want not found", viewer, err)
+		}
+	}
+	if tags, err := uc.GetTweetTags(1, &domain.Principal{UserID: 6}); err != nil || len(tags) != 1 {
+		t.Errorf("follower: got %v, %v", tags, err)
+	}
+}
+
+func TestOnlyAuthorTagsTweet(t *testing.T) {
+	tags := &fakeTagRepo{}
+	uc := NewTagsUseCase(tags, fakeTweetRepo{}, fakeReaders{6: true})
+
+	if err := uc.AddTag(1, 2, &domain.Principal{UserID: 6}); !errors.Is(err, domain.ErrRecordNotFoundX) {
+		t.Errorf("got %v, want not found", err)
+	}
+	if err := uc.AddTag(1, 2, &domain.Principal{UserID: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tags.added) != 1 {
+		t.Errorf("added tags %v, want only the author's", tags.added)
+	}
+}
@@ -0,0 +1,49 @@
+package tweets
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"log"
+	"regexp"
+)
+
+// Notifier reports events to user-service, which tells the users they concern.
+type Notifier interface {
+	Notify(event domain.NotificationEvent) error
+}
+
+// mentionPattern finds @username, but not the domain of an email address.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@.])@([A-Za-z0-9_]+)`)
+
+// mentionedUsernames returns the usernames mentioned in the text, each once,
+// in order of appearance.
+func mentionedUsernames(text string) []string {
+	var usernames []string
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		if username := match[1]; !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+// notifyMentions tells the users mentioned in a new tweet. The tweet stands
+// even if they cannot be told.
+func (uc *tweetUseCase) notifyMentions(tweet *domain.Tweet) {
+	usernames := mentionedUsernames(tweet.Title + " " + tweet.Content)
+	if len(usernames) == 0 {
+		return
+	}
+
+	tweetID := tweet.ID
+	err := uc.notifier.Notify(domain.NotificationEvent{
+		Type:      domain.NotificationMention,
+		ActorID:   tweet.UserId,
+		TweetID:   &tweetID,
+		Usernames: usernames,
+	})
+	if err != nil {
+		log.Printf("failed to notify mentions in tweet %d: %v", tweet.ID, err)
+	}
+}
@@ -0,0 +1,24 @@
+package tweets
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMentionedUsernames(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"hello @alice and @bob_2!", []string{"alice", "bob_2"}},
+		{"@alice first, @alice again", []string{"alice"}},
+		{"mail me at carol@example.com", nil},
+		{"(@dave) @@eve", []string{"dave"}},
+		{"no mentions here", nil},
+	}
+	for _, tt := range tests {
+		if got := mentionedUsernames(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mentionedUsernames(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
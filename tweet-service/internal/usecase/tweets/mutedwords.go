@@ -0,0 +1,116 @@
+package tweets
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// word is a word of a text; hashtag tells whether it was written as #word.
+type word struct {
+	text    string
+	hashtag bool
+}
+
+// splitWords splits text into words of letters, digits and underscores.
+func splitWords(text string) []word {
+	var words []word
+	var current strings.Builder
+	hashtag := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			words = append(words, word{text: current.String(), hashtag: hashtag})
+			current.Reset()
+		}
+		hashtag = false
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			current.WriteRune(r)
+		case r == '#' && current.Len() == 0:
+			hashtag = true
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
+
+// mutedPhrase matches a muted word against the words of a tweet. Case is
+// folded. A #hashtag only matches the hashtag; a plain word matches both the
+// word and the hashtag. A phrase matches its words in a row.
+type mutedPhrase struct {
+	words      []word
+	everywhere bool
+	expiresAt  *time.Time
+}
+
+func newMutedPhrases(mutedWords []*domain.MutedWord) []mutedPhrase {
+	phrases := make([]mutedPhrase, 0, len(mutedWords))
+	for _, m := range mutedWords {
+		words := splitWords(m.Phrase)
+		if len(words) == 0 {
+			continue
+		}
+		phrases = append(phrases, mutedPhrase{
+			words:      words,
+			everywhere: m.Scope == domain.MuteScopeEverywhere,
+			expiresAt:  m.ExpiresAt,
+		})
+	}
+	return phrases
+}
+
+func (p mutedPhrase) active(now time.Time) bool {
+	return p.expiresAt == nil || p.expiresAt.After(now)
+}
+
+func (p mutedPhrase) matches(words []word) bool {
+	for i := 0; i+len(p.words) <= len(words); i++ {
+		if p.matchesAt(words[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p mutedPhrase) matchesAt(words []word) bool {
+	for j, muted := range p.words {
+		w := words[j]
+		if muted.hashtag && !w.hashtag {
+			return false
+		}
+		if !strings.EqualFold(muted.text, w.text) {
+			return false
+		}
+	}
+	return true
+}
+
+// mutesTweet reports whether any active phrase matches the title, content,
+// topic or one of the tags of the tweet. Tags count as hashtags. Outside the
+// timeline only phrases muted everywhere apply.
+func mutesTweet(phrases []mutedPhrase, tweet *domain.Tweet, timeline bool, now time.Time) bool {
+	if len(phrases) == 0 {
+		return false
+	}
+	fields := [][]word{splitWords(tweet.Title), splitWords(tweet.Content), splitWords(tweet.Topic)}
+	for _, tag := range tweet.Tags {
+		fields = append(fields, splitWords("#"+strings.TrimPrefix(tag.Name, "#")))
+	}
+	for _, p := range phrases {
+		if !p.active(now) || (!timeline && !p.everywhere) {
+			continue
+		}
+		for _, words := range fields {
+			if p.matches(words) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package tweets
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestMutedWordsMatching(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	phrases := newMutedPhrases([]*domain.MutedWord{
+		{Phrase: "spoiler", Scope: domain.MuteScopeTimeline},
+		{Phrase: "#GoLang", Scope: domain.MuteScopeEverywhere},
+		{Phrase: "hot take", Scope: domain.MuteScopeTimeline},
+		{Phrase: "expired", Scope: domain.MuteScopeEverywhere, ExpiresAt: &past},
+	})
+
+	tests := []struct {
+		content  string
+		timeline bool
+		want     bool
+	}{
+		{"Huge SPOILER ahead!", true, true},
+		{"spoilers are fine", true, false},
+		{"#spoiler alert", true, true},
+		{"Huge spoiler ahead", false, false},
+		{"learning #golang today", false, true},
+		{"learning golang today", true, false},
+		{"my Hot   take: tabs", true, true},
+		{"hot and take", true, false},
+		{"this one expired", true, false},
+	}
+	for _, tt := range tests {
+		tweet := &domain.Tweet{Content: tt.content}
+		if got := mutesTweet(phrases, tweet, tt.timeline, now); got != tt.want {
+			t.Errorf("mutesTweet(%q, timeline=%v) = %v, want %v", tt.content, tt.timeline, got, tt.want)
+		}
+	}
+}
+
+func TestMutedWordsMatchTags(t *testing.T) {
+	now := time.Now()
+	phrases := newMutedPhrases([]*domain.MutedWord{
+		{Phrase: "#golang", Scope: domain.MuteScopeTimeline},
+		{Phrase: "spoiler", Scope: domain.MuteScopeTimeline},
+	})
+
+	tests := []struct {
+		tags []domain.Tag
+		want bool
+	}{
+		{[]domain.Tag{{Name: "GoLang"}}, true},
+		{[]domain.Tag{{Name: "news"}, {Name: "#Spoiler"}}, true},
+		{[]domain.Tag{{Name: "golang_jobs"}}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		tweet := &domain.Tweet{Content: "nothing to see", Tags: tt.tags}
+		if got := mutesTweet(phrases, tweet, true, now); got != tt.want {
+			t.Errorf("mutesTweet(tags %v) = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+}
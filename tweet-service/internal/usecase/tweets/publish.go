@@ -0,0 +1,48 @@
+package tweets
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/dto"
+	"encoding/json"
+	"log"
+)
+
+// Publisher pushes events to the clients streaming the timeline.
+type Publisher interface {
+	Publish(event *domain.StreamEvent) error
+}
+
+// publish streams a new tweet to the timeline. The tweet stands even if it
+// cannot be streamed.
+func (uc *tweetUseCase) publish(tweet *domain.Tweet) {
+	data, err := json.Marshal(dto.StreamedTweet{TweetDto: tweetDto(tweet), AuthorProtected: tweet.AuthorProtected})
+	if err != nil {
+		log.Printf("could not encode tweet %d: %v", tweet.ID, err)
+		return
+	}
+	if err = uc.publisher.Publish(&domain.StreamEvent{Type: domain.EventTweet, Data: data}); err != nil {
+		log.Printf("could not stream tweet %d: %v", tweet.ID, err)
+	}
+}
+
+// tweetDto is how a new tweet is streamed and sent to webhooks.
+func tweetDto(tweet *domain.Tweet) dto.TweetDto {
+	return dto.TweetDto{
+		ID:        int(tweet.ID),
+		Title:     tweet.Title,
+		Content:   tweet.Content,
+		Topic:     tweet.Topic,
+		CreatedAt: tweet.CreatedAt,
+		UserId:    tweet.UserId,
+		AppName:   tweet.AppName,
+	}
+}
+
+// Visible reports whether viewer may read the tweet in the timeline.
+func (uc *tweetUseCase) Visible(tweet *domain.Tweet, viewer *domain.Principal) (bool, error) {
+	visible, err := uc.readersFor(viewer).filter([]*domain.Tweet{tweet})
+	if err != nil {
+		return false, err
+	}
+	return len(visible) == 1, nil
+}
@@ -7,15 +7,44 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 )
 
+// Audited actions taken in tweet-service.
+const ActionDeleteAnyTweet = "tweet.delete"
+
+// Auditor records privileged actions.
+type Auditor interface {
+	RecordAudit(record domain.AuditRecord) error
+}
+
 type tweetUseCase struct {
-	tweetRepository repository.TweetRepository
+	tweetRepository     repository.TweetRepository
+	relationsRepository repository.RelationsRepository
+	auditor             Auditor
+	audience            Audience
+	notifier            Notifier
+	publisher           Publisher
+	webhooks            Webhooks
 }
 
-func NewTweetUseCase(tweetRepository repository.TweetRepository) *tweetUseCase {
+func NewTweetUseCase(
+	tweetRepository repository.TweetRepository,
+	relationsRepository repository.RelationsRepository,
+	auditor Auditor,
+	audience Audience,
+	notifier Notifier,
+	publisher Publisher,
+	webhooks Webhooks,
+) *tweetUseCase {
 	return &tweetUseCase{
-		tweetRepository: tweetRepository,
+		tweetRepository:     tweetRepository,
+		relationsRepository: relationsRepository,
+		auditor:             auditor,
+		audience:            audience,
+		notifier:            notifier,
+		publisher:           publisher,
+		webhooks:            webhooks,
 	}
 }
 
@@ -32,11 +61,23 @@ func (uc *tweetUseCase) Create(dto dto.TweetDto) error {
 	}
 	log.Println("usecase dto:", dto)
 	tweet := domain.ConvertFromDto(dto.ID, dto.Title, dto.Content, dto.Topic, dto.UserId)
+	tweet.AppName = dto.AppName
 	log.Println("usecase tweet:", tweet)
 	err := uc.tweetRepository.Insert(tweet)
 	if err != nil {
 		return err
 	}
+	uc.notifyMentions(tweet)
+
+	// read back for what Insert does not know, such as when the tweet was
+	// posted and whether its author is protected
+	created, err := uc.tweetRepository.Get(tweet.ID)
+	if err != nil {
+		log.Printf("could not read tweet %d back to stream it: %v", tweet.ID, err)
+		return nil
+	}
+	uc.publish(created)
+	uc.dispatch(domain.WebhookTweetCreated, created, tweetDto(created))
 	return nil
 }
 
@@ -54,12 +95,39 @@ func (uc *tweetUseCase) Get(id int64) (*dto.TweetDto, error) {
 	return domain.ConvertToDto(tweet), nil
 }
 
-func (uc *tweetUseCase) List() ([]*dto.TweetDto, error) {
+// View returns a tweet to viewer, who is nil when anonymous. Tweets the viewer
+// may not read are reported as not found; muted ones are still returned.
+func (uc *tweetUseCase) View(id int64, viewer *domain.Principal) (*dto.TweetDto, error) {
+	if id < 1 {
+		return nil, fmt.Errorf("invalid ID: %v", id)
+	}
+
+	tweet, err := uc.tweetRepository.Get(id)
+	if err != nil {
+		log.Println("could not get a tweet")
+		return nil, err
+	}
+	a, err := uc.readersFor(viewer).access(tweet)
+	if err != nil {
+		return nil, err
+	}
+	if a == blocked || a == protected {
+		return nil, domain.ErrRecordNotFoundX
+	}
+	return domain.ConvertToDto(tweet), nil
+}
+
+// List returns the tweets viewer may read.
+func (uc *tweetUseCase) List(viewer *domain.Principal) ([]*dto.TweetDto, error) {
 	tweets, err := uc.tweetRepository.List()
 	if err != nil {
 		log.Println("could not list tweets")
 		return nil, err
 	}
+	tweets, err = uc.readersFor(viewer).filter(tweets)
+	if err != nil {
+		return nil, err
+	}
 	var result []*dto.TweetDto
 	for _, tweet := range tweets {
 		result = append(result, domain.ConvertToDto(tweet))
@@ -67,8 +135,23 @@ func (uc *tweetUseCase) List() ([]*dto.TweetDto, error) {
 	return result, nil
 }
 
-func (uc *tweetUseCase) Update(in dto.TweetDto) (*dto.GetTweetResponse, error) {
-	tweet := domain.ConvertFromDto(in.ID, in.Title, in.Content, in.Topic, in.UserId)
+// Update changes the fields set in the input of a tweet of actor. Other
+// users' tweets are reported as not found.
+func (uc *tweetUseCase) Update(id int64, in dto.UpdateTweetDto, actor *domain.Principal) (*dto.GetTweetResponse, error) {
+	tweet, err := uc.ownTweet(id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Title != nil {
+		tweet.Title = *in.Title
+	}
+	if in.Content != nil {
+		tweet.Content = *in.Content
+	}
+	if in.Topic != nil {
+		tweet.Topic = *in.Topic
+	}
 	updatedTweet, err := uc.tweetRepository.Update(tweet)
 	if err != nil {
 		log.Println("could not update the updatedTweet")
@@ -78,27 +161,100 @@ func (uc *tweetUseCase) Update(in dto.TweetDto) (*dto.GetTweetResponse, error) {
 	return domain.ConvertToGetTweetResponseDto(updatedTweet), nil
 }
 
-func (uc *tweetUseCase) Delete(id int) error {
+// Delete deletes a tweet of actor. Other users' tweets are reported as not
+// found; moderators remove those with AdminDelete.
+func (uc *tweetUseCase) Delete(id int, actor *domain.Principal) error {
+	tweet, err := uc.ownTweet(int64(id), actor)
+	if err != nil {
+		return err
+	}
+	err = uc.tweetRepository.Delete(id)
+	if err != nil {
+		return fmt.Errorf("could not delete: %w", err)
+	}
+	uc.dispatch(domain.WebhookTweetDeleted, tweet, dto.DeletedTweet{ID: tweet.ID, UserId: tweet.UserId})
+	return nil
+}
+
+// ownTweet returns a tweet of actor, and not found for anyone else's.
+func (uc *tweetUseCase) ownTweet(id int64, actor *domain.Principal) (*domain.Tweet, error) {
+	if id < 1 {
+		return nil, fmt.Errorf("invalid ID: %v", id)
+	}
+	tweet, err := uc.tweetRepository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if actor == nil || tweet.UserId != actor.UserID {
+		return nil, domain.ErrRecordNotFoundX
+	}
+	return tweet, nil
+}
+
+// AdminDelete deletes any user's tweet on behalf of a moderator. The deletion
+// is recorded first, and without a record the tweet is not deleted.
+func (uc *tweetUseCase) AdminDelete(id int, actor *domain.Principal, ip string) error {
 	if id < 1 {
 		return fmt.Errorf("invalid ID: %v", id)
 	}
+	if actor == nil || !actor.HasPermission(domain.PermissionDeleteAnyTweet) {
+		return domain.ErrPermissionDenied
+	}
 
-	err := uc.tweetRepository.Delete(id)
+	tweet, err := uc.tweetRepository.Get(int64(id))
 	if err != nil {
+		return err
+	}
+
+	err = uc.auditor.RecordAudit(domain.AuditRecord{
+		ActorID:    actor.UserID,
+		Action:     ActionDeleteAnyTweet,
+		TargetType: "tweet",
+		TargetID:   strconv.Itoa(id),
+		Details:    map[string]interface{}{"author_id": tweet.UserId, "title": tweet.Title},
+		Service:    "tweet-service",
+		IP:         ip,
+	})
+	if err != nil {
+		log.Printf("could not record deletion of tweet %d by user %d: %v", id, actor.UserID, err)
+		return err
+	}
+	if err = uc.tweetRepository.Delete(id); err != nil {
 		return fmt.Errorf("could not delete: %w", err)
 	}
+	uc.dispatch(domain.WebhookTweetDeleted, tweet, dto.DeletedTweet{ID: tweet.ID, UserId: tweet.UserId})
 	return nil
 }
 
-func (uc *tweetUseCase) GetUserTweets(id int) ([]*dto.TweetDto, error) {
+// GetUserTweets returns the tweets of a user. Those of a protected user are
+// only returned to the user and their approved followers, and none are
+// returned across a block. Muting the user does not hide them here; only
+// words muted everywhere do.
+func (uc *tweetUseCase) GetUserTweets(id int, viewer *domain.Principal) ([]*dto.TweetDto, error) {
 	if id < 1 {
-		fmt.Errorf("invalid ID: %v", id)
+		return nil, fmt.Errorf("invalid ID: %v", id)
 	}
 	tweets, err := uc.tweetRepository.GetUserTweets(id)
 	if err != nil {
 		log.Printf("could not get tweets of user %v", id)
 		return nil, err
 	}
+	if len(tweets) > 0 {
+		readers := uc.readersFor(viewer)
+		a, err := readers.access(tweets[0])
+		if err != nil {
+			return nil, err
+		}
+		switch a {
+		case blocked:
+			return nil, domain.ErrBlockedAuthor
+		case protected:
+			return nil, domain.ErrProtectedAuthor
+		}
+		if tweets, err = readers.withoutMutedWords(tweets, false); err != nil {
+			return nil, err
+		}
+	}
 	var result []*dto.TweetDto
 	for _, tweet := range tweets {
 		result = append(result, domain.ConvertToDto(tweet))
@@ -0,0 +1,142 @@
+package tweets
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/repository"
+	"errors"
+	"testing"
+)
+
+type fakeTweetRepo struct {
+	repository.TweetRepository
+	tweets map[int64]*domain.Tweet
+}
+
+func (r *fakeTweetRepo) Get(id int64) (*domain.Tweet, error) {
+	tweet, ok := r.tweets[id]
+	if !ok {
+		return nil, domain.ErrRecordNotFoundX
+	}
+	copied := *tweet
+	return &copied, nil
+}
+
+func (r *fakeTweetRepo) Update(in *domain.Tweet) (*domain.Tweet, error) {
+	r.tweets[in.ID] = in
+	return in, nil
+}
+
+func (r *fakeTweetRepo) Delete(id int) error {
+	delete(r.tweets, int64(id))
+	return nil
+}
+
+type fakeWebhooks struct {
+	events []domain.WebhookEvent
+}
+
+func (f *fakeWebhooks) DispatchWebhook(event domain.WebhookEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func newTweetRepo() *fakeTweetRepo {
+	return &fakeTweetRepo{tweets: map[int64]*domain.Tweet{1: {ID: 1, Title: "hello", UserId: 5}}}
+}
+
+func TestOnlyAuthorChangesTweet(t *testing.T) {
+	repo := newTweetRepo()
+	webhooks := &fakeWebhooks{}
+	uc := NewTweetUseCase(repo, nil, nil, nil, nil, nil, webhooks)
+	title := "edited"
+	in := dto.UpdateTweetDto{Title: &title}
+
+	for _, actor := range []*domain.Principal{nil, {UserID: 6}} {
+		if _, err := uc.Update(1, in, actor); !errors.Is(err, domain.ErrRecordNotFoundX) {
+			t.Errorf("update by %+v: got %v, want not found", actor, err)
+		}
+		if err := uc.Delete(1, actor); !errors.Is(err, domain.ErrRecordNotFoundX) {
+			t.Errorf("delete by %+v: got %v, want not found", actor, err)
+		}
+	}
+	if repo.tweets[1].Title != "hello" || len(webhooks.events) != 0 {
+		t.Fatal("a non-author changed the tweet")
+	}
+
+	author := &domain.Principal{UserID: 5}
+	if _, err := uc.Update(1, in, author); err != nil {
+		t.Fatal(err)
+	}
+	if repo.tweets[1].Title != "edited" || repo.tweets[1].UserId != 5 {
+		t.Errorf("tweet after update = %+v", repo.tweets[1])
+	}
+	if err := uc.Delete(1, author); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := repo.tweets[1]; ok {
+		t.Error("the author could not delete the tweet")
+	}
+}
+
+type fakeAuditor struct {
+	records []domain.AuditRecord
+	err     error
+}
+
+func (f *fakeAuditor) RecordAudit(record domain.AuditRecord) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.records = append(f.records, record)
+	return nil
+}
+
+func TestAdminDelete(t *testing.T) {
+	moderator := &domain.Principal{UserID: 2, AuthType: "session", Permissions: []string{domain.PermissionDeleteAnyTweet}}
+
+	t.Run("needs the permission", func(t *testing.T) {
+		repo, auditor := newTweetRepo(), &fakeAuditor{}
+		uc := NewTweetUseCase(repo, nil, auditor, nil, nil, nil, &fakeWebhooks{})
+		for _, actor := range []*domain.Principal{
+			nil,
+			{UserID: 5, AuthType: "session"},
+			{UserID: 2, AuthType: "api_key", Permissions: moderator.Permissions},
+		} {
+			if err := uc.AdminDelete(1, actor, "203.0.113.7"); !errors.Is(err, domain.ErrPermissionDenied) {
+				t.Errorf("actor %+v: got %v, want permission denied", actor, err)
+			}
+		}
+		if _, ok := repo.tweets[1]; !ok || len(auditor.records) != 0 {
+			t.Error("deleted without the permission")
+		}
+	})
+
+	t.Run("is recorded", func(t *testing.T) {
+		repo, auditor, webhooks := newTweetRepo(), &fakeAuditor{}, &fakeWebhooks{}
+		uc := NewTweetUseCase(repo, nil, auditor, nil, nil, nil, webhooks)
+		if err := uc.AdminDelete(1, moderator, "203.0.113.7"); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := repo.tweets[1]; ok {
+			t.Error("tweet not deleted")
+		}
+		if len(auditor.records) != 1 || auditor.records[0].ActorID != 2 || auditor.records[0].TargetID != "1" {
+			t.Errorf("audit records = %+v", auditor.records)
+		}
+		if len(webhooks.events) != 1 || webhooks.events[0].Type != domain.WebhookTweetDeleted {
+			t.Errorf("webhook events = %+v", webhooks.events)
+		}
+	})
+
+	t.Run("is not done without a record", func(t *testing.T) {
+		repo, webhooks := newTweetRepo(), &fakeWebhooks{}
+		uc := NewTweetUseCase(repo, nil, &fakeAuditor{err: errors.New("user-service down")}, nil, nil, nil, webhooks)
+		if err := uc.AdminDelete(1, moderator, "203.0.113.7"); err == nil {
+			t.Fatal("deleted although the audit failed")
+		}
+		if _, ok := repo.tweets[1]; !ok || len(webhooks.events) != 0 {
+			t.Error("tweet deleted or announced without an audit record")
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package tweets
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Audience tells whose tweets are shown to or hidden from a user.
+type Audience interface {
+	Relations(userID int) (*domain.Relations, error)
+}
+
+// access is what a viewer may do with a tweet.
+type access int
+
+const (
+	readable access = iota
+	muted           // readable when asked for, but left out of listings
+	blocked
+	protected
+)
+
+// readers decides which tweets a viewer may read. Anonymous viewers only read
+// public tweets. Signed in viewers read their own tweets, never those of users
+// on either side of a block, and those of protected authors only if they
+// follow them. Muted users and words hide tweets from listings. The viewer's
+// relations are loaded on first use.
+type readers struct {
+	uc        *tweetUseCase
+	viewer    *domain.Principal // nil for anonymous requests
+	relations *relationSet
+}
+
+type relationSet struct {
+	protectedFollowing map[int]bool
+	blocked            map[int]bool
+	muted              map[int]bool
+	mutedWords         []mutedPhrase
+}
+
+func (uc *tweetUseCase) readersFor(viewer *domain.Principal) *readers {
+	return &readers{uc: uc, viewer: viewer}
+}
+
+func (r *readers) access(tweet *domain.Tweet) (access, error) {
+	if r.viewer == nil {
+		if tweet.AuthorProtected {
+			return protected, nil
+		}
+		return readable, nil
+	}
+	if tweet.UserId == r.viewer.UserID {
+		return readable, nil
+	}
+
+	if err := r.load(); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case r.relations.blocked[tweet.UserId]:
+		return blocked, nil
+	case tweet.AuthorProtected && !r.relations.protectedFollowing[tweet.UserId]:
+		return protected, nil
+	case r.relations.muted[tweet.UserId]:
+		return muted, nil
+	}
+	return readable, nil
+}
+
+// Readable reports whether viewer may read the tweet when asked for it, as
+// View does: tweets of muted users and words count.
+func (uc *tweetUseCase) Readable(tweet *domain.Tweet, viewer *domain.Principal) (bool, error) {
+	a, err := uc.readersFor(viewer).access(tweet)
+	if err != nil {
+		return false, err
+	}
+	return a == readable || a == muted, nil
+}
+
+func (r *readers) load() error {
+	if r.relations != nil {
+		return nil
+	}
+	relations, err := r.uc.relations(r.viewer.UserID)
+	if err != nil {
+		return err
+	}
+	r.relations = &relationSet{
+		protectedFollowing: idSet(relations.ProtectedFollowing),
+		blocked:            idSet(relations.Blocked),
+		muted:              idSet(relations.Muted),
+		mutedWords:         newMutedPhrases(relations.MutedWords),
+	}
+	return nil
+}
+
+// filter returns the tweets the viewer may read in the timeline listing.
+func (r *readers) filter(tweets []*domain.Tweet) ([]*domain.Tweet, error) {
+	visible := make([]*domain.Tweet, 0, len(tweets))
+	for _, tweet := range tweets {
+		a, err := r.access(tweet)
+		if err != nil {
+			return nil, err
+		}
+		if a == readable && !r.mutesWords(tweet, true) {
+			visible = append(visible, tweet)
+		}
+	}
+	return visible, nil
+}
+
+// withoutMutedWords leaves out the tweets that match words the viewer muted
+// everywhere, or also in the timeline if timeline is set.
+func (r *readers) withoutMutedWords(tweets []*domain.Tweet, timeline bool) ([]*domain.Tweet, error) {
+	if r.viewer == nil {
+		return tweets, nil
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	visible := make([]*domain.Tweet, 0, len(tweets))
+	for _, tweet := range tweets {
+		if !r.mutesWords(tweet, timeline) {
+			visible = append(visible, tweet)
+		}
+	}
+	return visible, nil
+}
+
+// mutesWords reports whether the tweet matches a word the viewer muted. The
+// viewer's own tweets are never hidden.
+func (r *readers) mutesWords(tweet *domain.Tweet, timeline bool) bool {
+	if r.viewer == nil || r.relations == nil || tweet.UserId == r.viewer.UserID {
+		return false
+	}
+	return mutesTweet(r.relations.mutedWords, tweet, timeline, time.Now())
+}
+
+// relations returns the relations of the user, from the cache if they were
+// loaded recently. A block or a new follow may thus take up to the cache TTL
+// to apply.
+func (uc *tweetUseCase) relations(userID int) (*domain.Relations, error) {
+	relations, err := uc.relationsRepository.Get(userID)
+	if err == nil {
+		return relations, nil
+	}
+	if !errors.Is(err, domain.ErrRecordNotFoundX) {
+		log.Printf("could not read cached relations of user %d: %v", userID, err)
+	}
+
+	relations, err = uc.audience.Relations(userID)
+	if err != nil {
+		log.Printf("could not get relations of user %d: %v", userID, err)
+		return nil, fmt.Errorf("could not check who may read the tweets: %w", err)
+	}
+	if err = uc.relationsRepository.Set(userID, relations); err != nil {
+		log.Printf("could not cache relations of user %d: %v", userID, err)
+	}
+	return relations, nil
+}
+
+func idSet(ids []int) map[int]bool {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
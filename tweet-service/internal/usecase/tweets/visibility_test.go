@@ -0,0 +1,103 @@
+package tweets
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"testing"
+)
+
+type fakeAudience struct {
+	relations map[int]*domain.Relations
+	calls     int
+}
+
+func (f *fakeAudience) Relations(userID int) (*domain.Relations, error) {
+	f.calls++
+	if relations, ok := f.relations[userID]; ok {
+		return relations, nil
+	}
+	return &domain.Relations{}, nil
+}
+
+type fakeRelationsRepo map[int]*domain.Relations
+
+func (f fakeRelationsRepo) Get(userID int) (*domain.Relations, error) {
+	if relations, ok := f[userID]; ok {
+		return relations, nil
+	}
+	return nil, domain.ErrRecordNotFoundX
+}
+
+func (f fakeRelationsRepo) Set(userID int, relations *domain.Relations) error {
+	f[userID] = relations
+	return nil
+}
+
+func TestTweetsAreFilteredForViewer(t *testing.T) {
+	audience := &fakeAudience{relations: map[int]*domain.Relations{
+		2: {ProtectedFollowing: []int{10}, Blocked: []int{6}, Muted: []int{7}},
+		6: {Blocked: []int{2}},
+	}}
+	uc := NewTweetUseCase(nil, fakeRelationsRepo{}, nil, audience, nil, nil, nil)
+
+	tweets := []*domain.Tweet{
+		{ID: 1, UserId: 5},
+		{ID: 2, UserId: 10, AuthorProtected: true},
+		{ID: 3, UserId: 11, AuthorProtected: true},
+		{ID: 4, UserId: 2, AuthorProtected: true},
+		{ID: 5, UserId: 6},
+		{ID: 6, UserId: 7},
+	}
+
+	tests := []struct {
+		name   string
+		viewer *domain.Principal
+		want   []int64
+	}{
+		{"anonymous sees public tweets", nil, []int64{1, 5, 6}},
+		{"viewer sees followed protected authors, not blocked or muted ones", &domain.Principal{UserID: 2}, []int64{1, 2, 4}},
+		{"blocked user does not see the blocker", &domain.Principal{UserID: 6}, []int64{1, 5, 6}},
+		{"author sees own protected tweets", &domain.Principal{UserID: 11}, []int64{1, 3, 5, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visible, err := uc.readersFor(tt.viewer).filter(tweets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, tweet := range visible {
+				got = append(got, tweet.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got tweets %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got tweets %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRelationsAreCachedPerUser(t *testing.T) {
+	audience := &fakeAudience{}
+	uc := NewTweetUseCase(nil, fakeRelationsRepo{}, nil, audience, nil, nil, nil)
+	tweets := []*domain.Tweet{{ID: 1, UserId: 2}, {ID: 2, UserId: 3}}
+
+	for i := 0; i < 3; i++ {
+		if _, err := uc.readersFor(&domain.Principal{UserID: 1}).filter(tweets); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if audience.calls != 1 {
+		t.Fatalf("asked user-service %d times, want 1", audience.calls)
+	}
+
+	if _, err := uc.readersFor(nil).filter(tweets); err != nil {
+		t.Fatal(err)
+	}
+	if audience.calls != 1 {
+		t.Fatalf("asked user-service for an anonymous viewer")
+	}
+}
@@ -0,0 +1,27 @@
+package tweets
+
+import (
+	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
+	"encoding/json"
+	"log"
+)
+
+// Webhooks reports events to user-service, which delivers them to the
+// webhooks of the apps of the user they concern.
+type Webhooks interface {
+	DispatchWebhook(event domain.WebhookEvent) error
+}
+
+// dispatch reports an event about a tweet to the webhooks of its author. The
+// change stands even if they cannot be told.
+func (uc *tweetUseCase) dispatch(eventType string, tweet *domain.Tweet, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("could not encode %s event of tweet %d: %v", eventType, tweet.ID, err)
+		return
+	}
+	err = uc.webhooks.DispatchWebhook(domain.WebhookEvent{Type: eventType, UserID: tweet.UserId, Data: encoded})
+	if err != nil {
+		log.Printf("could not dispatch %s event of tweet %d to webhooks: %v", eventType, tweet.ID, err)
+	}
+}
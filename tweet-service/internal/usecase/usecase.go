@@ -4,27 +4,51 @@ import (
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/domain"
 	"MussaShaukenov/twitter-clone-go/tweet-service/internal/dto"
 	"context"
+	"time"
 )
 
 type TweetUseCase interface {
 	Create(dto dto.TweetDto) error
 	Get(id int64) (*dto.TweetDto, error)
-	List() ([]*dto.TweetDto, error)
-	Update(in dto.TweetDto) (*dto.GetTweetResponse, error)
-	Delete(id int) error
-	GetUserTweets(id int) ([]*dto.TweetDto, error)
+	View(id int64, viewer *domain.Principal) (*dto.TweetDto, error)
+	List(viewer *domain.Principal) ([]*dto.TweetDto, error)
+	Update(id int64, in dto.UpdateTweetDto, actor *domain.Principal) (*dto.GetTweetResponse, error)
+	Delete(id int, actor *domain.Principal) error
+	GetUserTweets(id int, viewer *domain.Principal) ([]*dto.TweetDto, error)
+	AdminDelete(id int, actor *domain.Principal, ip string) error
+}
+
+type AccountUseCase interface {
+	Deactivate(userID int) error
+	Reactivate(userID int) error
+	Protect(userID int) error
+	Unprotect(userID int) error
+	Purge(ctx context.Context, userID int) error
+	Export(ctx context.Context, userID int) ([]*dto.ExportedTweet, error)
+	ExportLikes(userID int) ([]*dto.ExportedLike, error)
+	Activity(since time.Time) ([]*domain.AuthorActivity, error)
 }
 
 type TweetStatsUseCase interface {
-	GetTweetStats(ctx context.Context, tweetID int64) (*domain.TweetStats, error)
-	AddLike(ctx context.Context, tweetID int64) error
+	GetTweetStats(ctx context.Context, tweetID int64, viewer *domain.Principal) (*domain.TweetStats, error)
+	AddLike(ctx context.Context, tweetID int64, liker *domain.Principal) error
 	AddDislike(ctx context.Context, tweetID int64) error
-	RemoveLike(ctx context.Context, tweetID int64) error
+	RemoveLike(ctx context.Context, tweetID int64, liker *domain.Principal) error
 	RemoveDislike(ctx context.Context, tweetID int64) error
 }
 
 type TweetTagUseCase interface {
-	AddTag(tweetId int64, tagId int64) error
-	GetTweetTags(tweetId int64) ([]*dto.TagDto, error)
+	AddTag(tweetId int64, tagId int64, actor *domain.Principal) error
+	GetTweetTags(tweetId int64, viewer *domain.Principal) ([]*dto.TagDto, error)
 	ListTags() ([]*dto.TagDto, error)
 }
+
+type StreamUseCase interface {
+	Open(viewer *domain.Principal, lastEventID string) (EventStream, error)
+}
+
+// EventStream is the events one client receives, in order.
+type EventStream interface {
+	Next(ctx context.Context) (*domain.StreamEvent, error)
+	Close()
+}
@@ -3,16 +3,111 @@ package utils
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"golang.org/x/crypto/bcrypt"
 	"math/rand"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
 var ErrInvalidId = errors.New("invalid ID value")
 
+// MinSecretLength is the shortest shared secret accepted.
+const MinSecretLength = 32
+
+// CheckSecret returns an error if secret is too short to be safe, or is still
+// a placeholder anyone reading the repository knows.
+func CheckSecret(secret string) error {
+	if len(secret) < MinSecretLength {
+		return fmt.Errorf("must be at least %d characters", MinSecretLength)
+	}
+	if strings.HasPrefix(strings.ToLower(secret), "change-me") {
+		return errors.New("must not be the example placeholder")
+	}
+	return nil
+}
+
+// trustedProxies are the networks of the proxies, such as the nginx gateway,
+// whose X-Real-IP and X-Forwarded-For headers ClientIP believes.
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies sets the proxies ClientIP takes the client address from,
+// as IP addresses or CIDR ranges. With none set the headers are ignored, as
+// anyone reaching the service directly could set them.
+func SetTrustedProxies(proxies []string) error {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	trustedProxies = networks
+	return nil
+}
+
+func trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client. The headers set by the nginx
+// gateway are only believed when the request came from a trusted proxy.
+func ClientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !trustedProxy(remote) {
+		return remote
+	}
+
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		// proxies append the address they got the request from, so the
+		// client is the last address that is not one of them
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop != "" && (i == 0 || !trustedProxy(hop)) {
+				return hop
+			}
+		}
+	}
+	return remote
+}
+
 func ReadJson(w http.ResponseWriter, r *http.Request, in interface{}) error {
 	body := r.Body
 	defer body.Close()
@@ -134,3 +134,17 @@ func TestMatchesPassword(t *testing.T) {
 		t.Errorf("expected password match to be true")
 	}
 }
+
+func TestCheckSecret(t *testing.T) {
+	for secret, ok := range map[string]bool{
+		"":                             false,
+		"too-short":                    false,
+		"change-me-internal-api-token": false,
+		"change-me-but-long-enough-to-pass-the-length": false,
+		"a-secret-of-thirty-two-characters":            true,
+	} {
+		if err := CheckSecret(secret); (err == nil) != ok {
+			t.Errorf("CheckSecret(%q) = %v", secret, err)
+		}
+	}
+}
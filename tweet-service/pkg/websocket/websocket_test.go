@@ -0,0 +1,52 @@
+package websocket
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// the example of RFC 6455, section 1.3
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("AcceptKey = %q", got)
+	}
+}
+
+func TestWriteFrameLengths(t *testing.T) {
+	tests := []struct {
+		length int
+		header []byte
+	}{
+		{5, []byte{0x81, 5}},
+		{300, []byte{0x81, 126, 0x01, 0x2C}},
+		{70000, []byte{0x81, 127, 0, 0, 0, 0, 0, 0x01, 0x11, 0x70}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeFrame(&buf, opText, make([]byte, tt.length)); err != nil {
+			t.Fatalf("writeFrame: %v", err)
+		}
+		if got := buf.Bytes()[:len(tt.header)]; !bytes.Equal(got, tt.header) {
+			t.Errorf("header of %d byte frame = %x, want %x", tt.length, got, tt.header)
+		}
+		if buf.Len() != len(tt.header)+tt.length {
+			t.Errorf("%d byte frame is %d bytes long", tt.length, buf.Len())
+		}
+	}
+}
+
+func TestReadFrameUnmasks(t *testing.T) {
+	// a masked "Hello" from RFC 6455, section 5.7
+	frame := []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58}
+	opcode, payload, err := readFrame(bytes.NewReader(frame))
+	if err != nil {
+		t.Fatalf("readFrame: %v", err)
+	}
+	if opcode != opText || string(payload) != "Hello" {
+		t.Fatalf("readFrame = %x %q, want a text frame with Hello", opcode, payload)
+	}
+
+	if _, _, err = readFrame(bytes.NewReader([]byte{0x81, 0x05, 'H', 'e', 'l', 'l', 'o'})); err != ErrUnmasked {
+		t.Fatalf("readFrame of unmasked frame error = %v, want ErrUnmasked", err)
+	}
+}
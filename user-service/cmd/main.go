@@ -2,11 +2,19 @@ package main
 
 import (
 	user "MussaShaukenov/twitter-clone-go/user-service/internal"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/oidc"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/passwords"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
 	"MussaShaukenov/twitter-clone-go/user-service/pkg/database"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/mailer"
+	httpUtils "MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -20,6 +28,7 @@ type Config struct {
 	db     *pgxpool.Pool
 	logger *zap.SugaredLogger
 	redis  *redis.Client
+	mailer *mailer.Queue
 	addr   string
 	router *chi.Mux
 }
@@ -33,14 +42,18 @@ func main() {
 
 	// Set up dependencies
 	config, err := setUpDependencies()
-	defer config.db.Close()
-
 	if err != nil {
-		config.logger.Fatal(err)
+		panic(err)
 	}
+	defer config.db.Close()
+	defer config.redis.Close()
+	defer config.mailer.Close()
 
 	// Initialize apps
 	err = initializeApps(config)
+	if err != nil {
+		config.logger.Fatal(err)
+	}
 
 	// Serve the root router
 	err = Serve(config)
@@ -88,29 +101,57 @@ func setUpDependencies() (*Config, error) {
 	if err != nil {
 		sugar.Fatal("user-service: error connecting to redis")
 	}
-	defer redisClient.Close()
 	sugar.Info("user-service connected to redis")
 
+	mailQueue, err := mailerSetUp(sugar)
+	if err != nil {
+		return nil, err
+	}
+
 	router := chi.NewRouter()
 
 	return &Config{
 		logger: logger.Sugar(),
 		db:     db,
 		redis:  redisClient,
+		mailer: mailQueue,
 		addr:   ":8002",
 		router: router,
 	}, nil
 }
 
 func initializeApps(config *Config) error {
+	oidcProviders, err := oidc.LoadProviders(os.Getenv("OIDC_PROVIDERS_FILE"))
+	if err != nil {
+		return err
+	}
+
+	// the client address is taken from the gateway's headers only when the
+	// request came through the gateway
+	err = httpUtils.SetTrustedProxies(strings.Split(os.Getenv("TRUSTED_PROXIES"), ","))
+	if err != nil {
+		return err
+	}
+
 	cfg := &user.Config{
 		Db:     config.db,
 		Logger: config.logger,
 		Redis:  config.redis,
+		Mailer: config.mailer,
+		AppURL: os.Getenv("APP_URL"),
 		Router: config.router,
+
+		VerificationSecret: os.Getenv("EMAIL_VERIFICATION_SECRET"),
+		PasswordPolicy:     passwordPolicy(),
+		Argon2Params:       argon2Params(),
+		OIDCProviders:      oidcProviders,
+		InternalToken:      os.Getenv("INTERNAL_API_TOKEN"),
+		BootstrapAdmins:    bootstrapAdmins(),
+		BlobDir:            blobDir(),
+		TweetServiceURL:    os.Getenv("TWEET_SERVICE_URL"),
 	}
 	// Initialize the user service
-	_, err := user.InitializeUserApp(cfg)
+	_, err = user.InitializeUserApp(cfg)
 	if err != nil {
 		return err
 	}
@@ -134,3 +175,83 @@ func redisSetUp(logger *zap.SugaredLogger) (*redis.Client, error) {
 
 	return redisClient, nil
 }
+
+func mailerSetUp(logger *zap.SugaredLogger) (*mailer.Queue, error) {
+	from := os.Getenv("MAIL_FROM")
+
+	var transport mailer.Mailer
+	switch os.Getenv("MAIL_DRIVER") {
+	case "file":
+		dir := os.Getenv("MAIL_SINK_DIR")
+		logger.Info("writing emails to directory: ", dir)
+		fileMailer, err := mailer.NewFileMailer(dir, from)
+		if err != nil {
+			return nil, err
+		}
+		transport = fileMailer
+	default:
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
+		}
+		logger.Info("sending emails through smtp relay: ", os.Getenv("SMTP_HOST"))
+		transport = mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		})
+	}
+
+	return mailer.NewQueue(transport, mailer.DefaultQueueOptions, logger), nil
+}
+
+// passwordPolicy returns the default password policy with any overrides from the environment.
+func passwordPolicy() passwords.Policy {
+	policy := passwords.DefaultPolicy
+	if minLength, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil {
+		policy.MinLength = minLength
+	}
+	if maxLength, err := strconv.Atoi(os.Getenv("PASSWORD_MAX_LENGTH")); err == nil {
+		policy.MaxLength = maxLength
+	}
+	if minEntropy, err := strconv.ParseFloat(os.Getenv("PASSWORD_MIN_ENTROPY_BITS"), 64); err == nil {
+		policy.MinEntropyBits = minEntropy
+	}
+	return policy
+}
+
+// argon2Params returns the default argon2id parameters with any overrides from the environment.
+func argon2Params() utils.Argon2Params {
+	params := utils.DefaultArgon2Params
+	if memory, err := strconv.ParseUint(os.Getenv("ARGON2_MEMORY_KIB"), 10, 32); err == nil {
+		params.Memory = uint32(memory)
+	}
+	if iterations, err := strconv.ParseUint(os.Getenv("ARGON2_ITERATIONS"), 10, 32); err == nil {
+		params.Iterations = uint32(iterations)
+	}
+	if parallelism, err := strconv.ParseUint(os.Getenv("ARGON2_PARALLELISM"), 10, 8); err == nil {
+		params.Parallelism = uint8(parallelism)
+	}
+	return params
+}
+
+// bootstrapAdmins reads the comma separated BOOTSTRAP_ADMINS usernames.
+func bootstrapAdmins() []string {
+	var usernames []string
+	for _, username := range strings.Split(os.Getenv("BOOTSTRAP_ADMINS"), ",") {
+		if username = strings.TrimSpace(username); username != "" {
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+// blobDir returns the directory for uploaded files, ./tmp/blobs unless BLOB_DIR is set.
+func blobDir() string {
+	if dir := os.Getenv("BLOB_DIR"); dir != "" {
+		return dir
+	}
+	return "./tmp/blobs"
+}
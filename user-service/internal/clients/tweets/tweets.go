@@ -0,0 +1,136 @@
+package tweets
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// client talks to the internal API of tweet-service.
+type client struct {
+	baseURL       string
+	internalToken string
+	httpClient    *http.Client
+}
+
+func NewTweetServiceClient(baseURL, internalToken string) *client {
+	return &client{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		internalToken: internalToken,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// DeactivateAuthor hides the user's tweets while the account is deactivated.
+func (c *client) DeactivateAuthor(userID int) error {
+	return c.do(http.MethodPut, fmt.Sprintf("/internal/users/%d/deactivation", userID))
+}
+
+// ReactivateAuthor shows the user's tweets again.
+func (c *client) ReactivateAuthor(userID int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/internal/users/%d/deactivation", userID))
+}
+
+// PurgeAuthor deletes the user's tweets, their tags and stats.
+func (c *client) PurgeAuthor(userID int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/internal/users/%d", userID))
+}
+
+// ProtectAuthor hides the user's tweets from everyone but their followers.
+func (c *client) ProtectAuthor(userID int) error {
+	return c.do(http.MethodPut, fmt.Sprintf("/internal/users/%d/protection", userID))
+}
+
+// UnprotectAuthor shows the user's tweets to everyone again.
+func (c *client) UnprotectAuthor(userID int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/internal/users/%d/protection", userID))
+}
+
+// ExportTweets returns the user's tweets with their tags and reactions.
+func (c *client) ExportTweets(userID int) ([]*dto.ExportedTweet, error) {
+	path := fmt.Sprintf("/internal/users/%d/tweets", userID)
+	resp, err := c.send(http.MethodGet, path, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Tweets []*dto.ExportedTweet `json:"tweets"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: %s %s: %v", domain.ErrTweetServiceError, http.MethodGet, path, err)
+	}
+	return body.Tweets, nil
+}
+
+// ExportLikes returns the tweets the user liked.
+func (c *client) ExportLikes(userID int) ([]*dto.ExportedLike, error) {
+	path := fmt.Sprintf("/internal/users/%d/likes", userID)
+	resp, err := c.send(http.MethodGet, path, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Likes []*dto.ExportedLike `json:"likes"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: %s %s: %v", domain.ErrTweetServiceError, http.MethodGet, path, err)
+	}
+	return body.Likes, nil
+}
+
+// AuthorActivity sums up what every author tweeted since the given time.
+func (c *client) AuthorActivity(since time.Time) ([]*domain.AuthorActivity, error) {
+	path := "/internal/activity?since=" + url.QueryEscape(since.UTC().Format(time.RFC3339))
+	resp, err := c.send(http.MethodGet, path, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Authors []*domain.AuthorActivity `json:"authors"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: %s %s: %v", domain.ErrTweetServiceError, http.MethodGet, path, err)
+	}
+	return body.Authors, nil
+}
+
+// do sends a request without a body. All these calls are idempotent and
+// answer 204 on success.
+func (c *client) do(method, path string) error {
+	resp, err := c.send(method, path, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// send sends a request without a body and fails unless the response has the
+// expected status. The caller closes the body.
+func (c *client) send(method, path string, expected int) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Internal-Token", c.internalToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrTweetServiceError, err)
+	}
+	if resp.StatusCode != expected {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s %s: status %d", domain.ErrTweetServiceError, method, path, resp.StatusCode)
+	}
+	return resp, nil
+}
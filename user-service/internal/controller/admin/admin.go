@@ -0,0 +1,217 @@
+package admin
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository/roles"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	adminUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/admin"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type AdminController struct {
+	useCase usecase.AdminUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewAdminController(adminUC usecase.AdminUseCase, logger *zap.SugaredLogger) *AdminController {
+	return &AdminController{
+		useCase: adminUC,
+		logger:  logger,
+	}
+}
+
+func (ctrl *AdminController) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	actor, _ := middleware.CurrentUser(r.Context())
+	users, err := ctrl.useCase.ListUsers(actor, utils.ClientIP(r))
+	if err != nil {
+		ctrl.logger.Errorw("failed to list users", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, users, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *AdminController) SuspendHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	var input dto.SuspendUserRequest
+	err = utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := middleware.CurrentUser(r.Context())
+	err = ctrl.useCase.Suspend(actor, userID, input, utils.ClientIP(r))
+	if err != nil {
+		ctrl.logger.Errorw("failed to suspend user", "userID", userID, "error", err)
+		writeAdminError(w, err)
+		return
+	}
+
+	ctrl.writeMessage(w, "user suspended")
+}
+
+func (ctrl *AdminController) UnsuspendHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := middleware.CurrentUser(r.Context())
+	err = ctrl.useCase.Unsuspend(actor, userID, utils.ClientIP(r))
+	if err != nil {
+		ctrl.logger.Errorw("failed to unsuspend user", "userID", userID, "error", err)
+		writeAdminError(w, err)
+		return
+	}
+
+	ctrl.writeMessage(w, "user unsuspended")
+}
+
+func (ctrl *AdminController) AssignRoleHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	var input dto.AssignRoleRequest
+	err = utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := middleware.CurrentUser(r.Context())
+	err = ctrl.useCase.AssignRole(actor, userID, input, utils.ClientIP(r))
+	if err != nil {
+		ctrl.logger.Errorw("failed to assign role", "userID", userID, "role", input.Role, "error", err)
+		writeAdminError(w, err)
+		return
+	}
+
+	ctrl.writeMessage(w, "role assigned")
+}
+
+func (ctrl *AdminController) RevokeRoleHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+	role := chi.URLParam(r, "role")
+
+	actor, _ := middleware.CurrentUser(r.Context())
+	err = ctrl.useCase.RevokeRole(actor, userID, role, utils.ClientIP(r))
+	if err != nil {
+		ctrl.logger.Errorw("failed to revoke role", "userID", userID, "role", role, "error", err)
+		writeAdminError(w, err)
+		return
+	}
+
+	ctrl.writeMessage(w, "role revoked")
+}
+
+func (ctrl *AdminController) ListAuditHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	var beforeID int64
+	if value := r.URL.Query().Get("before"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid before", http.StatusBadRequest)
+			return
+		}
+		beforeID = parsed
+	}
+
+	records, err := ctrl.useCase.ListAudit(limit, beforeID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list audit records", "error", err)
+		writeAdminError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, records, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// RecordAuditHandler stores audit records reported by other services.
+func (ctrl *AdminController) RecordAuditHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.RecordAuditRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	record, err := ctrl.useCase.RecordAudit(input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to record audit", "action", input.Action, "error", err)
+		writeAdminError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusCreated, record, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *AdminController) writeMessage(w http.ResponseWriter, message string) {
+	err := utils.WriteJson(w, http.StatusOK, map[string]string{"message": message}, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, adminUC.ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, adminUC.ErrCannotTargetSelf), errors.Is(err, adminUC.ErrInsufficientRights):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, adminUC.ErrLastAdmin):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, adminUC.ErrReasonRequired),
+		errors.Is(err, adminUC.ErrInvalidAuditRecord),
+		errors.Is(err, adminUC.ErrInvalidAuditPageSize),
+		errors.Is(err, roles.ErrUnknownRole):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
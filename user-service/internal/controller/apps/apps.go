@@ -0,0 +1,172 @@
+package apps
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository/apps"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	appsUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/apps"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type AppController struct {
+	useCase usecase.AppUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewAppController(appUC usecase.AppUseCase, logger *zap.SugaredLogger) *AppController {
+	return &AppController{
+		useCase: appUC,
+		logger:  logger,
+	}
+}
+
+func (ctrl *AppController) CreateAppHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.CreateAppRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	app, err := ctrl.useCase.CreateApp(user.ID, input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to create app", "error", err)
+		writeAppError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusCreated, app, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *AppController) ListAppsHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	apps, err := ctrl.useCase.ListApps(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list apps", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, apps, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *AppController) CreateKeyHandler(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid app id", http.StatusBadRequest)
+		return
+	}
+
+	var input dto.CreateAPIKeyRequest
+	err = utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	rawKey, key, err := ctrl.useCase.CreateKey(user.ID, appID, input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to create api key", "appID", appID, "error", err)
+		writeAppError(w, err)
+		return
+	}
+
+	response := dto.CreateAPIKeyResponse{
+		ID:        key.ID,
+		Key:       rawKey,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		RateLimit: key.RateLimit,
+		CreatedAt: key.CreatedAt,
+	}
+	err = utils.WriteJson(w, http.StatusCreated, response, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *AppController) ListKeysHandler(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid app id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	keys, err := ctrl.useCase.ListKeys(user.ID, appID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list api keys", "appID", appID, "error", err)
+		writeAppError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, keys, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *AppController) RevokeKeyHandler(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid app id", http.StatusBadRequest)
+		return
+	}
+	keyID, err := strconv.Atoi(chi.URLParam(r, "key_id"))
+	if err != nil {
+		http.Error(w, "invalid key id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	err = ctrl.useCase.RevokeKey(user.ID, appID, keyID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to revoke api key", "appID", appID, "keyID", keyID, "error", err)
+		writeAppError(w, err)
+		return
+	}
+
+	response := map[string]string{"message": "api key revoked"}
+	err = utils.WriteJson(w, http.StatusOK, response, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeAppError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, appsUC.ErrAppNotFound), errors.Is(err, appsUC.ErrKeyNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apps.ErrAppNameTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, appsUC.ErrInvalidAppName),
+		errors.Is(err, appsUC.ErrNoScopes),
+		errors.Is(err, appsUC.ErrUnknownScope),
+		errors.Is(err, appsUC.ErrInvalidRateLimit):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
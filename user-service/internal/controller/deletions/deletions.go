@@ -0,0 +1,62 @@
+package deletions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	deletionsUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/deletions"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type DeletionController struct {
+	useCase usecase.DeletionUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewDeletionController(deletionUC usecase.DeletionUseCase, logger *zap.SugaredLogger) *DeletionController {
+	return &DeletionController{
+		useCase: deletionUC,
+		logger:  logger,
+	}
+}
+
+// DeleteAccountHandler deactivates the caller's account and schedules it for
+// deletion. The session used for the request is signed out with all others.
+func (ctrl *DeletionController) DeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.DeleteAccountRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	deletion, err := ctrl.useCase.RequestDeletion(user.ID, input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to request account deletion", "userID", user.ID, "error", err)
+		switch {
+		case errors.Is(err, deletionsUC.ErrWrongPassword):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, deletionsUC.ErrDeletionUnavailable):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := map[string]interface{}{
+		"message":  "account deactivated; sign in again before purge_after to cancel the deletion",
+		"deletion": deletion,
+	}
+	err = utils.WriteJson(w, http.StatusAccepted, response, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,103 @@
+package exports
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	exportsUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/exports"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ExportController struct {
+	useCase usecase.ExportUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewExportController(exportUC usecase.ExportUseCase, logger *zap.SugaredLogger) *ExportController {
+	return &ExportController{
+		useCase: exportUC,
+		logger:  logger,
+	}
+}
+
+// RequestExportHandler schedules an archive of the caller's data. The archive
+// is built in the background; its state is polled with GetExportHandler.
+func (ctrl *ExportController) RequestExportHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	export, err := ctrl.useCase.RequestExport(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to request data export", "userID", user.ID, "error", err)
+		writeExportError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusAccepted, export, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *ExportController) GetExportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid export id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	export, err := ctrl.useCase.GetExport(user.ID, id)
+	if err != nil {
+		ctrl.logger.Errorw("failed to get data export", "userID", user.ID, "exportID", id, "error", err)
+		writeExportError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, export, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// DownloadHandler serves an archive to whoever holds a valid download link,
+// so it also works from a plain browser link without a session.
+func (ctrl *ExportController) DownloadHandler(w http.ResponseWriter, r *http.Request) {
+	file, export, err := ctrl.useCase.OpenDownload(r.URL.Query().Get("token"))
+	if err != nil {
+		ctrl.logger.Warnw("failed to open data export download", "error", err)
+		writeExportError(w, err)
+		return
+	}
+	defer file.Close()
+
+	name := fmt.Sprintf("data-export-%d.zip", export.ID)
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	modified := time.Time{}
+	if export.CompletedAt != nil {
+		modified = *export.CompletedAt
+	}
+	http.ServeContent(w, r, name, modified, file)
+}
+
+func writeExportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, exportsUC.ErrExportNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, exportsUC.ErrInvalidDownloadLink):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, exportsUC.ErrExportExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
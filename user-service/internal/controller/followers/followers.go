@@ -1,9 +1,13 @@
 package followers
 
 import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
 	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
 	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	followersUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/followers"
 	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 	"net/http"
@@ -32,15 +36,21 @@ func (ctrl *FollowerController) FollowHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	err = ctrl.useCase.Follow(input.FollowerID, input.FollowedID)
+	// The authenticated user is always the follower
+	user, _ := middleware.CurrentUser(r.Context())
+	requested, err := ctrl.useCase.Follow(user.ID, input.FollowedID)
 	if err != nil {
 		ctrl.logger.Errorw("failed to follow", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeFollowerError(w, err)
 		return
 	}
 
-	response := map[string]string{"message": "successfully followed"}
-	err = utils.WriteJson(w, http.StatusCreated, response, nil)
+	// A protected user has to approve the follow first
+	status, response := http.StatusCreated, map[string]string{"message": "successfully followed"}
+	if requested {
+		status, response = http.StatusAccepted, map[string]string{"message": "follow request sent"}
+	}
+	err = utils.WriteJson(w, status, response, nil)
 	if err != nil {
 		ctrl.logger.Errorw("failed to write json", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -58,10 +68,11 @@ func (ctrl *FollowerController) UnfollowHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err = ctrl.useCase.Unfollow(input.FollowerID, input.FollowedID)
+	user, _ := middleware.CurrentUser(r.Context())
+	err = ctrl.useCase.Unfollow(user.ID, input.FollowedID)
 	if err != nil {
 		ctrl.logger.Errorw("failed to unfollow", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeFollowerError(w, err)
 		return
 	}
 
@@ -74,43 +85,84 @@ func (ctrl *FollowerController) UnfollowHandler(w http.ResponseWriter, r *http.R
 	}
 }
 
+// GetFollowersHandler lists the followers of a user a page at a time. The
+// limit and cursor query parameters pick the page.
 func (ctrl *FollowerController) GetFollowersHandler(w http.ResponseWriter, r *http.Request) {
-	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	ctrl.listFollows(w, r, ctrl.useCase.GetFollowers)
+}
+
+// GetFollowingHandler lists the users a user follows a page at a time.
+func (ctrl *FollowerController) GetFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.listFollows(w, r, ctrl.useCase.GetFollowing)
+}
+
+func (ctrl *FollowerController) listFollows(
+	w http.ResponseWriter, r *http.Request,
+	list func(userID, viewerID int, cursor string, limit int) ([]*domain.FollowEntry, string, error),
+) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
 		ctrl.logger.Errorw("failed to get userID", "error", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	users, err := ctrl.useCase.GetFollowers(userID)
+	limit := followersUC.DefaultFollowPageSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	viewerID := 0
+	if viewer, ok := middleware.CurrentUser(r.Context()); ok {
+		viewerID = viewer.ID
+	}
+
+	entries, nextCursor, err := list(userID, viewerID, r.URL.Query().Get("cursor"), limit)
 	if err != nil {
-		ctrl.logger.Errorw("failed to get followers", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		ctrl.logger.Errorw("failed to list follows", "userID", userID, "error", err)
+		switch {
+		case errors.Is(err, followersUC.ErrInvalidID),
+			errors.Is(err, followersUC.ErrInvalidCursor),
+			errors.Is(err, followersUC.ErrInvalidPageSize):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, followersUC.ErrUserNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
 		return
 	}
 
-	err = utils.WriteJson(w, http.StatusOK, users, nil)
+	err = utils.WriteJson(w, http.StatusOK, dto.NewFollowList(entries, nextCursor), nil)
 	if err != nil {
 		ctrl.logger.Errorw("failed to write json", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
 	}
 }
 
-func (ctrl *FollowerController) GetFollowingHandler(w http.ResponseWriter, r *http.Request) {
-	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+func (ctrl *FollowerController) IsFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	followerID, err := strconv.Atoi(chi.URLParam(r, "followerID"))
 	if err != nil {
-		ctrl.logger.Errorw("failed to get userID", "error", err)
+		ctrl.logger.Errorw("failed to get followerID", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	followedID, err := strconv.Atoi(chi.URLParam(r, "followedID"))
+	if err != nil {
+		ctrl.logger.Errorw("failed to get followedID", "error", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	users, err := ctrl.useCase.GetFollowing(userID)
+
+	isFollowing, err := ctrl.useCase.IsFollowing(followerID, followedID)
 	if err != nil {
-		ctrl.logger.Errorw("failed to get following", "error", err)
+		ctrl.logger.Errorw("failed to check following status", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	err = utils.WriteJson(w, http.StatusOK, users, nil)
+	err = utils.WriteJson(w, http.StatusOK, map[string]bool{"is_following": isFollowing}, nil)
 	if err != nil {
 		ctrl.logger.Errorw("failed to write json", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -118,31 +170,116 @@ func (ctrl *FollowerController) GetFollowingHandler(w http.ResponseWriter, r *ht
 	}
 }
 
-func (ctrl *FollowerController) IsFollowingHandler(w http.ResponseWriter, r *http.Request) {
-	followerID, err := strconv.Atoi(chi.URLParam(r, "followerID"))
+// IncomingRequestsHandler lists the pending requests to follow the caller.
+func (ctrl *FollowerController) IncomingRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	requests, err := ctrl.useCase.IncomingRequests(user.ID)
 	if err != nil {
-		ctrl.logger.Errorw("failed to get followerID", "error", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		ctrl.logger.Errorw("failed to list incoming follow requests", "userID", user.ID, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	followedID, err := strconv.Atoi(chi.URLParam(r, "followedID"))
+
+	err = utils.WriteJson(w, http.StatusOK, dto.NewPendingFollowRequests(requests), nil)
 	if err != nil {
-		ctrl.logger.Errorw("failed to get followedID", "error", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// OutgoingRequestsHandler lists the caller's pending requests to follow others.
+func (ctrl *FollowerController) OutgoingRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	requests, err := ctrl.useCase.OutgoingRequests(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list outgoing follow requests", "userID", user.ID, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	isFollowing, err := ctrl.useCase.IsFollowing(followerID, followedID)
+	err = utils.WriteJson(w, http.StatusOK, dto.NewPendingFollowRequests(requests), nil)
 	if err != nil {
-		ctrl.logger.Errorw("failed to check following status", "error", err)
+		ctrl.logger.Errorw("failed to write json", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *FollowerController) ApproveRequestHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.answerRequest(w, r, ctrl.useCase.ApproveRequest, "follow request approved")
+}
+
+func (ctrl *FollowerController) RejectRequestHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.answerRequest(w, r, ctrl.useCase.RejectRequest, "follow request rejected")
+}
+
+func (ctrl *FollowerController) CancelRequestHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.answerRequest(w, r, ctrl.useCase.CancelRequest, "follow request cancelled")
+}
+
+// answerRequest runs action for the caller and the user in the id URL param.
+func (ctrl *FollowerController) answerRequest(
+	w http.ResponseWriter,
+	r *http.Request,
+	action func(userID, otherID int) error,
+	message string,
+) {
+	otherID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
 		return
 	}
 
-	err = utils.WriteJson(w, http.StatusOK, map[string]bool{"is_following": isFollowing}, nil)
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = action(user.ID, otherID); err != nil {
+		ctrl.logger.Errorw("failed to answer follow request", "userID", user.ID, "otherID", otherID, "error", err)
+		writeFollowerError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, map[string]string{"message": message}, nil)
 	if err != nil {
 		ctrl.logger.Errorw("failed to write json", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// RelationsHandler returns the users whose tweets are shown to or hidden
+// from the user, so tweet-service can filter what the user reads.
+func (ctrl *FollowerController) RelationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	relations, err := ctrl.useCase.Relations(userID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to get relations", "userID", userID, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	err = utils.WriteJson(w, http.StatusOK, relations, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeFollowerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, followersUC.ErrIdsCannotBeTheSame), errors.Is(err, followersUC.ErrInvalidIDs),
+		errors.Is(err, followersUC.ErrInvalidID):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, followersUC.ErrFollowerNotFound), errors.Is(err, followersUC.ErrFolloweeNotFound),
+		errors.Is(err, followersUC.ErrFollowRequestNotFound), errors.Is(err, followersUC.ErrNotFollowing),
+		errors.Is(err, followersUC.ErrNotBlocked), errors.Is(err, followersUC.ErrNotMuted):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, followersUC.ErrBlocked):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, followersUC.ErrorAlreadyFollowing), errors.Is(err, followersUC.ErrFollowRequestPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
@@ -0,0 +1,81 @@
+package followers
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+func (ctrl *FollowerController) BlockHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.changeRelation(w, r, "block", ctrl.useCase.Block)
+}
+
+func (ctrl *FollowerController) UnblockHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.changeRelation(w, r, "unblock", ctrl.useCase.Unblock)
+}
+
+func (ctrl *FollowerController) MuteHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.changeRelation(w, r, "mute", ctrl.useCase.Mute)
+}
+
+func (ctrl *FollowerController) UnmuteHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.changeRelation(w, r, "unmute", ctrl.useCase.Unmute)
+}
+
+// ListBlockedHandler lists the users the caller blocked.
+func (ctrl *FollowerController) ListBlockedHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.listRelation(w, r, "blocked", ctrl.useCase.ListBlocked)
+}
+
+// ListMutedHandler lists the users the caller muted.
+func (ctrl *FollowerController) ListMutedHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.listRelation(w, r, "muted", ctrl.useCase.ListMuted)
+}
+
+// changeRelation runs action for the caller and the user in the id URL param
+// and answers 204.
+func (ctrl *FollowerController) changeRelation(
+	w http.ResponseWriter,
+	r *http.Request,
+	name string,
+	action func(userID, targetID int) error,
+) {
+	targetID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = action(user.ID, targetID); err != nil {
+		ctrl.logger.Errorw("failed to "+name, "userID", user.ID, "targetID", targetID, "error", err)
+		writeFollowerError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ctrl *FollowerController) listRelation(
+	w http.ResponseWriter,
+	r *http.Request,
+	name string,
+	list func(userID int) ([]*domain.User, error),
+) {
+	user, _ := middleware.CurrentUser(r.Context())
+	users, err := list(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list "+name+" users", "userID", user.ID, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, dto.NewPublicProfiles(users), nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,208 @@
+package messages
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+// CreateGroupHandler starts a group with the caller as its admin and
+// invites the given users.
+func (ctrl *MessageController) CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.CreateGroupRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	conversation, err := ctrl.useCase.CreateGroup(user.ID, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to create group", "userID", user.ID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusCreated, conversation)
+}
+
+func (ctrl *MessageController) ConversationHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	conversation, err := ctrl.useCase.GetConversation(user.ID, conversationID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to get conversation", "userID", user.ID, "conversationID", conversationID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, conversation)
+}
+
+func (ctrl *MessageController) RenameHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+	var input dto.RenameGroupRequest
+	if err = utils.ReadJson(w, r, &input); err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	conversation, err := ctrl.useCase.Rename(user.ID, conversationID, input.Name)
+	if err != nil {
+		ctrl.logger.Errorw("failed to rename group", "userID", user.ID, "conversationID", conversationID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, conversation)
+}
+
+// InviteHandler invites a user to a group the caller is an admin of.
+func (ctrl *MessageController) InviteHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+	var input dto.InviteRequest
+	if err = utils.ReadJson(w, r, &input); err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = ctrl.useCase.Invite(user.ID, conversationID, input.UserID); err != nil {
+		ctrl.logger.Errorw("failed to invite to group", "userID", user.ID, "conversationID", conversationID, "inviteeID", input.UserID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InvitesHandler lists the groups the caller is invited to.
+func (ctrl *MessageController) InvitesHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	invites, err := ctrl.useCase.Invites(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list group invites", "userID", user.ID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, invites)
+}
+
+func (ctrl *MessageController) AcceptInviteHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	conversation, err := ctrl.useCase.AcceptInvite(user.ID, conversationID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to accept group invite", "userID", user.ID, "conversationID", conversationID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, conversation)
+}
+
+func (ctrl *MessageController) DeclineInviteHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = ctrl.useCase.DeclineInvite(user.ID, conversationID); err != nil {
+		ctrl.logger.Errorw("failed to decline group invite", "userID", user.ID, "conversationID", conversationID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ctrl *MessageController) LeaveHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = ctrl.useCase.Leave(user.ID, conversationID); err != nil {
+		ctrl.logger.Errorw("failed to leave group", "userID", user.ID, "conversationID", conversationID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveMemberHandler removes a member from a group the caller is an admin
+// of.
+func (ctrl *MessageController) RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+	memberID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = ctrl.useCase.RemoveMember(user.ID, conversationID, memberID); err != nil {
+		ctrl.logger.Errorw("failed to remove group member", "userID", user.ID, "conversationID", conversationID, "memberID", memberID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetRoleHandler makes a member of a group the caller is an admin of an
+// admin or a plain member.
+func (ctrl *MessageController) SetRoleHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+	memberID, err := strconv.Atoi(chi.URLParam(r, "user_id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+	var input dto.SetRoleRequest
+	if err = utils.ReadJson(w, r, &input); err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = ctrl.useCase.SetRole(user.ID, conversationID, memberID, input.Role); err != nil {
+		ctrl.logger.Errorw("failed to set group role", "userID", user.ID, "conversationID", conversationID, "memberID", memberID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,245 @@
+package messages
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	messagesUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/messages"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type MessageController struct {
+	useCase usecase.MessageUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewMessageController(messageUC usecase.MessageUseCase, logger *zap.SugaredLogger) *MessageController {
+	return &MessageController{
+		useCase: messageUC,
+		logger:  logger,
+	}
+}
+
+// SendHandler sends a message to a user, in their direct conversation with
+// the caller.
+func (ctrl *MessageController) SendHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.SendMessageRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	message, err := ctrl.useCase.Send(user.ID, input.RecipientID, input.Body)
+	if err != nil {
+		ctrl.logger.Errorw("failed to send message", "senderID", user.ID, "recipientID", input.RecipientID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusCreated, message)
+}
+
+// PostHandler sends a message in one of the caller's conversations.
+func (ctrl *MessageController) PostHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+	var input dto.PostMessageRequest
+	if err = utils.ReadJson(w, r, &input); err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	message, err := ctrl.useCase.Post(user.ID, conversationID, input.Body)
+	if err != nil {
+		ctrl.logger.Errorw("failed to send message", "senderID", user.ID, "conversationID", conversationID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusCreated, message)
+}
+
+// ConversationsHandler lists the caller's conversations, the most recently
+// active first. limit and offset pick the page.
+func (ctrl *MessageController) ConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, ok := queryInt(w, r, "limit", messagesUC.DefaultPageSize)
+	if !ok {
+		return
+	}
+	offset, ok := queryInt(w, r, "offset", 0)
+	if !ok {
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	page, err := ctrl.useCase.Conversations(user.ID, limit, offset)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list conversations", "userID", user.ID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, page)
+}
+
+// MessagesHandler lists the history of a conversation, newest first. limit
+// and cursor pick the page.
+func (ctrl *MessageController) MessagesHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+	limit, ok := queryInt(w, r, "limit", messagesUC.DefaultPageSize)
+	if !ok {
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	page, err := ctrl.useCase.Messages(user.ID, conversationID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list messages", "userID", user.ID, "conversationID", conversationID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, page)
+}
+
+func (ctrl *MessageController) DeleteMessageHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+	messageID, err := strconv.ParseInt(chi.URLParam(r, "message_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid message id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = ctrl.useCase.DeleteMessage(user.ID, conversationID, messageID); err != nil {
+		ctrl.logger.Errorw("failed to delete message", "userID", user.ID, "messageID", messageID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MarkReadHandler marks a conversation read up to its latest message.
+func (ctrl *MessageController) MarkReadHandler(w http.ResponseWriter, r *http.Request) {
+	conversationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid conversation id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = ctrl.useCase.MarkRead(user.ID, conversationID); err != nil {
+		ctrl.logger.Errorw("failed to mark conversation read", "userID", user.ID, "conversationID", conversationID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ctrl *MessageController) SettingsHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	settings, err := ctrl.useCase.Settings(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to get message settings", "userID", user.ID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, settings)
+}
+
+// UpdateSettingsHandler changes who may message the caller.
+func (ctrl *MessageController) UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.UpdateMessageSettingsRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	settings, err := ctrl.useCase.UpdateSettings(user.ID, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to update message settings", "userID", user.ID, "error", err)
+		writeMessageError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, settings)
+}
+
+func (ctrl *MessageController) writeJson(w http.ResponseWriter, status int, data interface{}) {
+	err := utils.WriteJson(w, status, data, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// queryInt reads an integer query parameter, answering the request if it is
+// not one.
+func queryInt(w http.ResponseWriter, r *http.Request, name string, fallback int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, true
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		http.Error(w, "invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return parsed, true
+}
+
+func writeMessageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, messagesUC.ErrConversationNotFound),
+		errors.Is(err, messagesUC.ErrMessageNotFound),
+		errors.Is(err, messagesUC.ErrRecipientNotFound),
+		errors.Is(err, messagesUC.ErrInviteNotFound),
+		errors.Is(err, messagesUC.ErrNotMember):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, messagesUC.ErrBlocked),
+		errors.Is(err, messagesUC.ErrRecipientRestricted),
+		errors.Is(err, messagesUC.ErrNotSender),
+		errors.Is(err, messagesUC.ErrNotAdmin),
+		errors.Is(err, messagesUC.ErrSystemMessage):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, messagesUC.ErrAlreadyMember),
+		errors.Is(err, messagesUC.ErrAlreadyInvited),
+		errors.Is(err, messagesUC.ErrGroupFull),
+		errors.Is(err, messagesUC.ErrLastAdmin):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, messagesUC.ErrInvalidRecipient),
+		errors.Is(err, messagesUC.ErrEmptyMessage),
+		errors.Is(err, messagesUC.ErrMessageTooLong),
+		errors.Is(err, messagesUC.ErrInvalidCursor),
+		errors.Is(err, messagesUC.ErrInvalidPageSize),
+		errors.Is(err, messagesUC.ErrInvalidOffset),
+		errors.Is(err, messagesUC.ErrNoSettings),
+		errors.Is(err, messagesUC.ErrInvalidName),
+		errors.Is(err, messagesUC.ErrInvalidRole),
+		errors.Is(err, messagesUC.ErrNotGroup):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type contextKey string
+
+const (
+	userContextKey  contextKey = "user"
+	tokenContextKey contextKey = "token"
+	grantContextKey contextKey = "grant"
+)
+
+// Authenticator resolves a session token to its user.
+type Authenticator interface {
+	Authenticate(sessionToken string) (*domain.User, error)
+}
+
+// KeyResolver resolves an API key to what it grants.
+type KeyResolver interface {
+	ResolveAPIKey(rawKey string) (*domain.APIKeyGrant, error)
+}
+
+type Auth struct {
+	authenticator Authenticator
+	keys          KeyResolver
+	logger        *zap.SugaredLogger
+}
+
+func NewAuth(authenticator Authenticator, keys KeyResolver, logger *zap.SugaredLogger) *Auth {
+	return &Auth{
+		authenticator: authenticator,
+		keys:          keys,
+		logger:        logger,
+	}
+}
+
+// Authenticate rejects requests without a valid session token or API key and
+// stores the authenticated user in the request context. For API keys the
+// grant is stored as well, so RequireScope can check it.
+func (a *Auth) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := SessionToken(r)
+		if strings.HasPrefix(token, domain.APIKeyPrefix) {
+			a.authenticateKey(w, r, token, next)
+			return
+		}
+
+		user, err := a.authenticator.Authenticate(token)
+		if err != nil {
+			a.logger.Warnw("unauthenticated request", "path", r.URL.Path, "error", err)
+			writeUnauthenticated(w, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		ctx = context.WithValue(ctx, tokenContextKey, token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Identify is Authenticate for endpoints that anyone may call: requests
+// without credentials pass through anonymously, while requests with
+// credentials must carry valid ones.
+func (a *Auth) Identify(next http.Handler) http.Handler {
+	authenticated := a.Authenticate(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if SessionToken(r) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
+func (a *Auth) authenticateKey(w http.ResponseWriter, r *http.Request, rawKey string, next http.Handler) {
+	grant, err := a.keys.ResolveAPIKey(rawKey)
+	if err != nil {
+		var throttleErr *domain.ThrottleError
+		if errors.As(err, &throttleErr) {
+			w.Header().Set("Retry-After", utils.RetryAfterSeconds(throttleErr.RetryAfter))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		a.logger.Warnw("request with unusable api key", "path", r.URL.Path, "error", err)
+		writeUnauthenticated(w, err)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), userContextKey, grant.User)
+	ctx = context.WithValue(ctx, grantContextKey, grant)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequireScope must run after Authenticate. Requests made with an API key
+// need the scope; session requests act with the user's full rights.
+func (a *Auth) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if grant, ok := CurrentGrant(r.Context()); ok && !grant.Key.HasScope(scope) {
+				http.Error(w, "api key lacks the "+scope+" scope", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission must run after Authenticate. It lets through users whose
+// roles grant the permission. Privileged endpoints are never open to API keys.
+func (a *Auth) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := CurrentUser(r.Context())
+			if !ok {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if _, isKey := CurrentGrant(r.Context()); isKey || !user.HasPermission(permission) {
+				a.logger.Warnw("permission denied", "userID", user.ID, "permission", permission, "path", r.URL.Path)
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession must run after Authenticate. It keeps API keys away from
+// account management.
+func (a *Auth) RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := CurrentGrant(r.Context()); ok {
+			http.Error(w, "api keys can not be used for this endpoint", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireVerifiedEmail must run after Authenticate. It rejects users who have
+// not verified their email address yet.
+func (a *Auth) RequireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := CurrentUser(r.Context())
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !user.EmailVerified {
+			http.Error(w, "verify your email address to use this feature", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeUnauthenticated(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrAccountSuspended) || errors.Is(err, domain.ErrAccountDeactivated) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// SessionToken reads the session token from the Authorization header. Both a
+// bare token and the "Bearer <token>" form are accepted.
+func SessionToken(r *http.Request) string {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return header
+}
+
+// CurrentUser returns the user stored by Authenticate.
+func CurrentUser(ctx context.Context) (*domain.User, bool) {
+	user, ok := ctx.Value(userContextKey).(*domain.User)
+	return user, ok
+}
+
+// CurrentGrant returns the API key grant stored by Authenticate, if the
+// request was made with an API key.
+func CurrentGrant(ctx context.Context) (*domain.APIKeyGrant, bool) {
+	grant, ok := ctx.Value(grantContextKey).(*domain.APIKeyGrant)
+	return grant, ok
+}
+
+// CurrentToken returns the session token stored by Authenticate.
+func CurrentToken(ctx context.Context) string {
+	token, _ := ctx.Value(tokenContextKey).(string)
+	return token
+}
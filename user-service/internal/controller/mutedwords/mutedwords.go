@@ -0,0 +1,100 @@
+package mutedwords
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository/mutedwords"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	mutedWordsUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/mutedwords"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type MutedWordController struct {
+	useCase usecase.MutedWordUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewMutedWordController(mutedWordUC usecase.MutedWordUseCase, logger *zap.SugaredLogger) *MutedWordController {
+	return &MutedWordController{
+		useCase: mutedWordUC,
+		logger:  logger,
+	}
+}
+
+func (ctrl *MutedWordController) ListHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	words, err := ctrl.useCase.List(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list muted words", "userID", user.ID, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, words, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *MutedWordController) AddHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.AddMutedWordRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	word, err := ctrl.useCase.Add(user.ID, input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to mute word", "userID", user.ID, "error", err)
+		writeMutedWordError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusCreated, word, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *MutedWordController) RemoveHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid muted word id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = ctrl.useCase.Remove(user.ID, id); err != nil {
+		ctrl.logger.Errorw("failed to unmute word", "userID", user.ID, "id", id, "error", err)
+		writeMutedWordError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeMutedWordError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, mutedWordsUC.ErrInvalidPhrase),
+		errors.Is(err, mutedWordsUC.ErrUnknownScope),
+		errors.Is(err, mutedWordsUC.ErrExpiryInPast),
+		errors.Is(err, mutedWordsUC.ErrTooManyMutedWords):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, mutedWordsUC.ErrMutedWordNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, mutedwords.ErrMutedWordExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
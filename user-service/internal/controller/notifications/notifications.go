@@ -0,0 +1,183 @@
+package notifications
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	notificationsUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/notifications"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type NotificationController struct {
+	useCase usecase.NotificationUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewNotificationController(notificationUC usecase.NotificationUseCase, logger *zap.SugaredLogger) *NotificationController {
+	return &NotificationController{
+		useCase: notificationUC,
+		logger:  logger,
+	}
+}
+
+// ListHandler lists the caller's notifications, most recent first. limit and
+// offset pick the page.
+func (ctrl *NotificationController) ListHandler(w http.ResponseWriter, r *http.Request) {
+	limit := notificationsUC.DefaultPageSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	offset := 0
+	if value := r.URL.Query().Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = parsed
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	page, err := ctrl.useCase.List(user.ID, limit, offset)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list notifications", "userID", user.ID, "error", err)
+		writeNotificationError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, page)
+}
+
+func (ctrl *NotificationController) UnreadCountHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	count, err := ctrl.useCase.UnreadCount(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to count unread notifications", "userID", user.ID, "error", err)
+		writeNotificationError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, map[string]int{"unread_count": count})
+}
+
+func (ctrl *NotificationController) MarkReadHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid notification id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = ctrl.useCase.MarkRead(user.ID, id); err != nil {
+		ctrl.logger.Errorw("failed to mark notification read", "userID", user.ID, "id", id, "error", err)
+		writeNotificationError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ctrl *NotificationController) MarkAllReadHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	if err := ctrl.useCase.MarkAllRead(user.ID); err != nil {
+		ctrl.logger.Errorw("failed to mark notifications read", "userID", user.ID, "error", err)
+		writeNotificationError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreferencesHandler tells for every notification type whether the caller
+// gets it.
+func (ctrl *NotificationController) PreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	preferences, err := ctrl.useCase.Preferences(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to get notification preferences", "userID", user.ID, "error", err)
+		writeNotificationError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, preferences)
+}
+
+// UpdatePreferencesHandler turns notification types on or off, given as a
+// map such as {"like": false}. Types left out keep their setting.
+func (ctrl *NotificationController) UpdatePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	var input map[string]bool
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	preferences, err := ctrl.useCase.UpdatePreferences(user.ID, input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to update notification preferences", "userID", user.ID, "error", err)
+		writeNotificationError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, preferences)
+}
+
+// RecordEventHandler takes the events other services report, such as likes
+// and mentions in tweet-service.
+func (ctrl *NotificationController) RecordEventHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.NotificationEventRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if input.Type == domain.NotificationMention && input.TweetID != nil {
+		err = ctrl.useCase.NotifyMentions(input.ActorID, *input.TweetID, input.Usernames)
+	} else {
+		err = ctrl.useCase.Notify(&domain.NotificationEvent{
+			Type:        input.Type,
+			RecipientID: input.RecipientID,
+			ActorID:     input.ActorID,
+			TweetID:     input.TweetID,
+		})
+	}
+	if err != nil {
+		ctrl.logger.Errorw("failed to record notification event", "type", input.Type, "error", err)
+		writeNotificationError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (ctrl *NotificationController) writeJson(w http.ResponseWriter, status int, data interface{}) {
+	err := utils.WriteJson(w, status, data, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeNotificationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, notificationsUC.ErrNotificationNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, notificationsUC.ErrUnknownType),
+		errors.Is(err, notificationsUC.ErrInvalidEvent),
+		errors.Is(err, notificationsUC.ErrInvalidPageSize),
+		errors.Is(err, notificationsUC.ErrInvalidOffset):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
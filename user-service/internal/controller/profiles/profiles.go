@@ -0,0 +1,217 @@
+package profiles
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	profilesUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/profiles"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// imageField is the multipart form field holding an uploaded image.
+const imageField = "image"
+
+type ProfileController struct {
+	useCase usecase.ProfileUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewProfileController(profileUC usecase.ProfileUseCase, logger *zap.SugaredLogger) *ProfileController {
+	return &ProfileController{
+		useCase: profileUC,
+		logger:  logger,
+	}
+}
+
+func (ctrl *ProfileController) GetProfileHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := ctrl.useCase.GetProfile(id)
+	if err != nil {
+		ctrl.logger.Errorw("failed to get profile", "userID", id, "error", err)
+		writeProfileError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, profile, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *ProfileController) GetProfileByUsernameHandler(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+	profile, err := ctrl.useCase.GetProfileByUsername(username)
+	if err != nil {
+		ctrl.logger.Errorw("failed to get profile", "username", username, "error", err)
+		writeProfileError(w, err)
+		return
+	}
+
+	// A previous username redirects to the account's current one
+	if profile.Username != username {
+		http.Redirect(w, r, "/users/by-username/"+url.PathEscape(profile.Username), http.StatusMovedPermanently)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, profile, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *ProfileController) GetOwnProfileHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	profile, err := ctrl.useCase.GetOwnProfile(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to get own profile", "userID", user.ID, "error", err)
+		writeProfileError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, profile, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *ProfileController) UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.UpdateProfileRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	profile, err := ctrl.useCase.UpdateProfile(user.ID, input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to update profile", "userID", user.ID, "error", err)
+		writeProfileError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, profile, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// SetProtectionHandler makes the caller's account protected or public.
+func (ctrl *ProfileController) SetProtectionHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.SetProtectionRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	profile, err := ctrl.useCase.SetProtection(user.ID, input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to set account protection", "userID", user.ID, "error", err)
+		writeProfileError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, profile, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (ctrl *ProfileController) UploadAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.uploadImage(w, r, profilesUC.MaxAvatarSize, ctrl.useCase.UploadAvatar)
+}
+
+func (ctrl *ProfileController) UploadBannerHandler(w http.ResponseWriter, r *http.Request) {
+	ctrl.uploadImage(w, r, profilesUC.MaxBannerSize, ctrl.useCase.UploadBanner)
+}
+
+func (ctrl *ProfileController) uploadImage(
+	w http.ResponseWriter,
+	r *http.Request,
+	maxSize int64,
+	upload func(userID int, r io.Reader) (*dto.OwnProfile, error),
+) {
+	// Leave room for the multipart framing around the file itself
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+64<<10)
+	file, _, err := r.FormFile(imageField)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, profilesUC.ErrImageTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "an image must be uploaded in the \""+imageField+"\" form field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	user, _ := middleware.CurrentUser(r.Context())
+	profile, err := upload(user.ID, file)
+	if err != nil {
+		ctrl.logger.Errorw("failed to upload profile image", "userID", user.ID, "error", err)
+		writeProfileError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, profile, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// MediaHandler serves uploaded profile images.
+func (ctrl *ProfileController) MediaHandler(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "kind") + "/" + chi.URLParam(r, "name")
+	file, contentType, err := ctrl.useCase.OpenMedia(key)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	defer file.Close()
+
+	// Keys are never reused, so the content behind a key never changes
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(w, r, "", time.Time{}, file)
+}
+
+func writeProfileError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, profilesUC.ErrProfileNotFound), errors.Is(err, profilesUC.ErrMediaNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, profilesUC.ErrImageTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, profilesUC.ErrProtectionUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case profilesUC.IsValidationError(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -1,14 +1,38 @@
 package controller
 
 import (
+	adminCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/admin"
+	appCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/apps"
+	deletionCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/deletions"
+	exportCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/exports"
 	followerCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/followers"
+	messageCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/messages"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	mutedWordCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/mutedwords"
+	notificationCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/notifications"
+	profileCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/profiles"
+	searchCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/search"
+	securityCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/security"
+	suggestionCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/suggestions"
 	userCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/users"
+	webhookCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/webhooks"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func RegisterUserRoutes(ctrl *userCtrl.UserController) http.Handler {
+func RegisterUserRoutes(
+	ctrl *userCtrl.UserController,
+	profiles *profileCtrl.ProfileController,
+	deletions *deletionCtrl.DeletionController,
+	exports *exportCtrl.ExportController,
+	mutedWords *mutedWordCtrl.MutedWordController,
+	suggestions *suggestionCtrl.SuggestionController,
+	search *searchCtrl.SearchController,
+	security *securityCtrl.SecurityController,
+	auth *middleware.Auth,
+) http.Handler {
 	router := chi.NewRouter()
 
 	router.Post("/register", ctrl.RegisterHandler)
@@ -16,19 +40,171 @@ func RegisterUserRoutes(ctrl *userCtrl.UserController) http.Handler {
 	router.Post("/authorize2fa", ctrl.Authorize2FAHandler)
 	router.Post("/verifyotp", ctrl.VerifyOTPHandler)
 	router.Post("/logout", ctrl.LogoutHandler)
+	router.Post("/password/forgot", ctrl.ForgotPasswordHandler)
+	router.Post("/password/reset", ctrl.ResetPasswordHandler)
+	router.Post("/verify-email", ctrl.VerifyEmailHandler)
+	router.Post("/verify-email/resend", ctrl.ResendVerificationHandler)
+	router.Get("/oidc/providers", ctrl.OIDCProvidersHandler)
+	router.Get("/oidc/{provider}/login", ctrl.OIDCLoginHandler)
+	router.Get("/oidc/{provider}/callback", ctrl.OIDCCallbackHandler)
 	router.Get("/", ctrl.ListHandler)
+	router.With(auth.Identify).Get("/search", search.SearchHandler)
+	router.Get("/{id}", profiles.GetProfileHandler)
+	router.Get("/by-username/{username}", profiles.GetProfileByUsernameHandler)
+	router.Get("/media/{kind}/{name}", profiles.MediaHandler)
+	router.Get("/exports/download", exports.DownloadHandler)
+
+	router.Group(func(r chi.Router) {
+		r.Use(auth.Authenticate, auth.RequireSession)
+		r.Get("/me/suggestions", suggestions.SuggestionsHandler)
+		r.Post("/me/password", ctrl.ChangePasswordHandler)
+		r.Patch("/me/username", ctrl.ChangeUsernameHandler)
+		r.Get("/me", profiles.GetOwnProfileHandler)
+		r.Patch("/me", profiles.UpdateProfileHandler)
+		r.Put("/me/protection", profiles.SetProtectionHandler)
+		r.Delete("/me", deletions.DeleteAccountHandler)
+		r.Put("/me/avatar", profiles.UploadAvatarHandler)
+		r.Put("/me/banner", profiles.UploadBannerHandler)
+		r.Post("/me/export", exports.RequestExportHandler)
+		r.Get("/me/exports/{id}", exports.GetExportHandler)
+		r.Get("/me/muted-words", mutedWords.ListHandler)
+		r.Post("/me/muted-words", mutedWords.AddHandler)
+		r.Delete("/me/muted-words/{id}", mutedWords.RemoveHandler)
+		r.Get("/me/security-events", security.EventsHandler)
+	})
 
 	return router
 }
 
-func RegisterFollowerRoutes(ctrl *followerCtrl.FollowerController) http.Handler {
+func RegisterFollowerRoutes(ctrl *followerCtrl.FollowerController, auth *middleware.Auth) http.Handler {
 	router := chi.NewRouter()
 
-	router.Post("/follow", ctrl.FollowHandler)
-	router.Post("/unfollow", ctrl.UnfollowHandler)
-	router.Get("/followers/{id}", ctrl.GetFollowersHandler)
-	router.Get("/following/{id}", ctrl.GetFollowingHandler)
+	follows := auth.RequireScope(domain.ScopeFollowsWrite)
+	router.With(auth.Authenticate, follows, auth.RequireVerifiedEmail).Post("/follow", ctrl.FollowHandler)
+	router.With(auth.Authenticate, follows).Post("/unfollow", ctrl.UnfollowHandler)
+	router.With(auth.Identify).Get("/followers/{id}", ctrl.GetFollowersHandler)
+	router.With(auth.Identify).Get("/following/{id}", ctrl.GetFollowingHandler)
 	router.Get("/isfollowing", ctrl.IsFollowingHandler)
 
+	router.Group(func(r chi.Router) {
+		r.Use(auth.Authenticate)
+		r.Get("/requests", ctrl.IncomingRequestsHandler)
+		r.Get("/requests/outgoing", ctrl.OutgoingRequestsHandler)
+		r.With(follows).Post("/requests/{id}/approve", ctrl.ApproveRequestHandler)
+		r.With(follows).Post("/requests/{id}/reject", ctrl.RejectRequestHandler)
+		r.With(follows).Delete("/requests/outgoing/{id}", ctrl.CancelRequestHandler)
+		r.Get("/blocks", ctrl.ListBlockedHandler)
+		r.With(follows).Put("/blocks/{id}", ctrl.BlockHandler)
+		r.With(follows).Delete("/blocks/{id}", ctrl.UnblockHandler)
+		r.Get("/mutes", ctrl.ListMutedHandler)
+		r.With(follows).Put("/mutes/{id}", ctrl.MuteHandler)
+		r.With(follows).Delete("/mutes/{id}", ctrl.UnmuteHandler)
+	})
+
+	return router
+}
+
+// RegisterNotificationRoutes serves the caller's notifications. They tell who
+// follows, likes and mentions the user, so API keys do not get them.
+func RegisterNotificationRoutes(ctrl *notificationCtrl.NotificationController, auth *middleware.Auth) http.Handler {
+	router := chi.NewRouter()
+	router.Use(auth.Authenticate, auth.RequireSession)
+
+	router.Get("/", ctrl.ListHandler)
+	router.Get("/unread-count", ctrl.UnreadCountHandler)
+	router.Post("/{id}/read", ctrl.MarkReadHandler)
+	router.Post("/read-all", ctrl.MarkAllReadHandler)
+	router.Get("/preferences", ctrl.PreferencesHandler)
+	router.Patch("/preferences", ctrl.UpdatePreferencesHandler)
+
+	return router
+}
+
+// RegisterMessageRoutes serves the caller's direct messages and groups.
+func RegisterMessageRoutes(ctrl *messageCtrl.MessageController, auth *middleware.Auth) http.Handler {
+	router := chi.NewRouter()
+	router.Use(auth.Authenticate)
+
+	read := router.With(auth.RequireScope(domain.ScopeMessagesRead))
+	write := router.With(auth.RequireScope(domain.ScopeMessagesWrite))
+
+	write.With(auth.RequireVerifiedEmail).Post("/", ctrl.SendHandler)
+	read.Get("/conversations", ctrl.ConversationsHandler)
+	read.Get("/conversations/{id}/messages", ctrl.MessagesHandler)
+	write.Post("/conversations/{id}/messages", ctrl.PostHandler)
+	write.Delete("/conversations/{id}/messages/{message_id}", ctrl.DeleteMessageHandler)
+	write.Post("/conversations/{id}/read", ctrl.MarkReadHandler)
+	write.With(auth.RequireVerifiedEmail).Post("/groups", ctrl.CreateGroupHandler)
+	read.Get("/conversations/{id}", ctrl.ConversationHandler)
+	write.Patch("/conversations/{id}", ctrl.RenameHandler)
+	write.Post("/conversations/{id}/invites", ctrl.InviteHandler)
+	write.Post("/conversations/{id}/leave", ctrl.LeaveHandler)
+	write.Delete("/conversations/{id}/members/{user_id}", ctrl.RemoveMemberHandler)
+	write.Put("/conversations/{id}/members/{user_id}/role", ctrl.SetRoleHandler)
+	read.Get("/invites", ctrl.InvitesHandler)
+	write.Post("/invites/{id}/accept", ctrl.AcceptInviteHandler)
+	write.Post("/invites/{id}/decline", ctrl.DeclineInviteHandler)
+	read.Get("/settings", ctrl.SettingsHandler)
+	write.Patch("/settings", ctrl.UpdateSettingsHandler)
+
+	return router
+}
+
+// RegisterAppRoutes serves the developer app registry. Apps, keys and
+// webhooks are managed with a session only, never with an API key.
+func RegisterAppRoutes(ctrl *appCtrl.AppController, webhooks *webhookCtrl.WebhookController, auth *middleware.Auth) http.Handler {
+	router := chi.NewRouter()
+	router.Use(auth.Authenticate, auth.RequireSession)
+
+	router.With(auth.RequireVerifiedEmail).Post("/", ctrl.CreateAppHandler)
+	router.Get("/", ctrl.ListAppsHandler)
+	router.With(auth.RequireVerifiedEmail).Post("/{id}/keys", ctrl.CreateKeyHandler)
+	router.Get("/{id}/keys", ctrl.ListKeysHandler)
+	router.Delete("/{id}/keys/{key_id}", ctrl.RevokeKeyHandler)
+	router.With(auth.RequireVerifiedEmail).Post("/{id}/webhooks", webhooks.CreateWebhookHandler)
+	router.Get("/{id}/webhooks", webhooks.ListWebhooksHandler)
+	router.Delete("/{id}/webhooks/{webhook_id}", webhooks.DeleteWebhookHandler)
+	router.Get("/{id}/webhooks/{webhook_id}/deliveries", webhooks.DeliveriesHandler)
+	router.Post("/{id}/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver", webhooks.RedeliverHandler)
+
+	return router
+}
+
+// RegisterAdminRoutes serves the privileged endpoints. Each one needs its
+// permission and writes an audit record.
+func RegisterAdminRoutes(ctrl *adminCtrl.AdminController, auth *middleware.Auth) http.Handler {
+	router := chi.NewRouter()
+	router.Use(auth.Authenticate)
+
+	router.With(auth.RequirePermission(domain.PermissionListUsers)).Get("/users", ctrl.ListUsersHandler)
+	router.With(auth.RequirePermission(domain.PermissionSuspendUsers)).Post("/users/{id}/suspend", ctrl.SuspendHandler)
+	router.With(auth.RequirePermission(domain.PermissionSuspendUsers)).Post("/users/{id}/unsuspend", ctrl.UnsuspendHandler)
+	router.With(auth.RequirePermission(domain.PermissionManageRoles)).Post("/users/{id}/roles", ctrl.AssignRoleHandler)
+	router.With(auth.RequirePermission(domain.PermissionManageRoles)).Delete("/users/{id}/roles/{role}", ctrl.RevokeRoleHandler)
+	router.With(auth.RequirePermission(domain.PermissionReadAudit)).Get("/audit", ctrl.ListAuditHandler)
+
+	return router
+}
+
+// RegisterInternalRoutes serves the endpoints other services call. They are
+// not routed through the public gateway.
+func RegisterInternalRoutes(
+	userCtrl *userCtrl.UserController,
+	adminCtrl *adminCtrl.AdminController,
+	followerCtrl *followerCtrl.FollowerController,
+	notificationCtrl *notificationCtrl.NotificationController,
+	webhookCtrl *webhookCtrl.WebhookController,
+	auth *middleware.Auth,
+	internalToken string,
+) http.Handler {
+	router := chi.NewRouter()
+	internal := middleware.RequireInternalToken(internalToken)
+
+	router.With(auth.Authenticate).Get("/auth/session", userCtrl.SessionHandler)
+	router.With(internal).Post("/audit", adminCtrl.RecordAuditHandler)
+	router.With(internal).Get("/users/{id}/relations", followerCtrl.RelationsHandler)
+	router.With(internal).Post("/notifications", notificationCtrl.RecordEventHandler)
+	router.With(internal).Post("/webhooks/events", webhookCtrl.DispatchHandler)
+
 	return router
 }
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	searchUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/search"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type SearchController struct {
+	useCase usecase.SearchUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewSearchController(searchUC usecase.SearchUseCase, logger *zap.SugaredLogger) *SearchController {
+	return &SearchController{
+		useCase: searchUC,
+		logger:  logger,
+	}
+}
+
+// SearchHandler finds users by username or name. q is the text searched for;
+// mode=autocomplete matches the start of a name being typed, as for
+// @mentions. limit and offset pick the page.
+func (ctrl *SearchController) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	autocomplete := query.Get("mode") == "autocomplete"
+
+	limit := searchUC.DefaultPageSize
+	if autocomplete {
+		limit = searchUC.DefaultAutocompleteSize
+	}
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	offset := 0
+	if value := query.Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = parsed
+	}
+	viewerID := 0
+	if viewer, ok := middleware.CurrentUser(r.Context()); ok {
+		viewerID = viewer.ID
+	}
+
+	results, err := ctrl.useCase.SearchUsers(viewerID, query.Get("q"), autocomplete, limit, offset)
+	if err != nil {
+		switch {
+		case errors.Is(err, searchUC.ErrQueryTooShort),
+			errors.Is(err, searchUC.ErrQueryTooLong),
+			errors.Is(err, searchUC.ErrInvalidPageSize),
+			errors.Is(err, searchUC.ErrInvalidOffset):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			ctrl.logger.Errorw("failed to search users", "error", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, results, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
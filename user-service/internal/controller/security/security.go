@@ -0,0 +1,75 @@
+package security
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	securityUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/security"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type SecurityController struct {
+	useCase usecase.SecurityUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewSecurityController(securityUC usecase.SecurityUseCase, logger *zap.SugaredLogger) *SecurityController {
+	return &SecurityController{
+		useCase: securityUC,
+		logger:  logger,
+	}
+}
+
+// EventsHandler lists the caller's security events, such as sign-ins and
+// password changes, newest first. limit and offset pick the page.
+func (ctrl *SecurityController) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, ok := queryInt(w, r, "limit", securityUC.DefaultPageSize)
+	if !ok {
+		return
+	}
+	offset, ok := queryInt(w, r, "offset", 0)
+	if !ok {
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	page, err := ctrl.useCase.Events(user.ID, limit, offset)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list security events", "userID", user.ID, "error", err)
+		writeSecurityError(w, err)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, page, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// queryInt reads an integer query parameter, answering the request if it is
+// not one.
+func queryInt(w http.ResponseWriter, r *http.Request, name string, fallback int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, true
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		http.Error(w, "invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return parsed, true
+}
+
+func writeSecurityError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, securityUC.ErrInvalidPageSize), errors.Is(err, securityUC.ErrInvalidOffset):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,38 @@
+package suggestions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type SuggestionController struct {
+	useCase usecase.SuggestionUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewSuggestionController(suggestionUC usecase.SuggestionUseCase, logger *zap.SugaredLogger) *SuggestionController {
+	return &SuggestionController{
+		useCase: suggestionUC,
+		logger:  logger,
+	}
+}
+
+// SuggestionsHandler lists accounts the caller could follow, best first.
+func (ctrl *SuggestionController) SuggestionsHandler(w http.ResponseWriter, r *http.Request) {
+	user, _ := middleware.CurrentUser(r.Context())
+	suggestions, err := ctrl.useCase.Suggestions(user.ID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to get follow suggestions", "userID", user.ID, "error", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, suggestions, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
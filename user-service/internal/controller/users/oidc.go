@@ -0,0 +1,93 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/oidc"
+	deletionsUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/deletions"
+	usersUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/users"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+func (ctrl *UserController) OIDCProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	providers := ctrl.useCase.OIDCProviders()
+	if providers == nil {
+		providers = []string{}
+	}
+
+	err := utils.WriteJson(w, http.StatusOK, map[string][]string{"providers": providers}, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// OIDCLoginHandler redirects the user to the identity provider's sign-in page.
+func (ctrl *UserController) OIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+
+	authURL, err := ctrl.useCase.BeginOIDCLogin(r.Context(), provider)
+	if err != nil {
+		ctrl.logger.Errorw("failed to start oidc login", "provider", provider, "error", err)
+		if errors.Is(err, oidc.ErrUnknownProvider) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallbackHandler is where the identity provider sends the user back to.
+// It answers with a session token like the password login does.
+func (ctrl *UserController) OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+	query := r.URL.Query()
+
+	if providerErr := query.Get("error"); providerErr != "" {
+		ctrl.logger.Warnw("identity provider returned an error", "provider", provider, "error", providerErr)
+		http.Error(w, usersUC.ErrOIDCLoginFailed.Error(), http.StatusUnauthorized)
+		return
+	}
+	if query.Get("state") == "" || query.Get("code") == "" {
+		http.Error(w, "state and code are required", http.StatusBadRequest)
+		return
+	}
+
+	token, err := ctrl.useCase.CompleteOIDCLogin(
+		r.Context(), provider, query.Get("state"), query.Get("code"), utils.ClientIP(r), r.UserAgent())
+	if err != nil {
+		ctrl.logger.Errorw("failed to complete oidc login", "provider", provider, "error", err)
+		switch {
+		case errors.Is(err, oidc.ErrUnknownProvider):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, usersUC.ErrInvalidOIDCState),
+			errors.Is(err, usersUC.ErrOIDCLoginFailed),
+			errors.Is(err, usersUC.ErrOIDCEmailNotVerified):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, usersUC.ErrOIDCAgeUnknown), errors.Is(err, usersUC.ErrAgeRestrict):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, domain.ErrAccountSuspended), errors.Is(err, domain.ErrAccountDeactivated):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, deletionsUC.ErrDeletionInProgress), errors.Is(err, deletionsUC.ErrDeletionUnavailable):
+			writeAuthError(w, err)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := map[string]string{
+		"message": "successfully authorized",
+		"token":   token,
+	}
+	err = utils.WriteJson(w, http.StatusOK, response, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
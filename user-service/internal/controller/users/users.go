@@ -1,9 +1,16 @@
 package users
 
 import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
 	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/passwords"
 	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	deletionsUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/deletions"
+	usersUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/users"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usernames"
 	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
 	"go.uber.org/zap"
 	"net/http"
 )
@@ -30,11 +37,19 @@ func (ctrl *UserController) RegisterHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	ctrl.logger.Infow("incoming input", "input", input)
+	ctrl.logger.Infow("incoming input", "username", input.Username)
 
 	err = ctrl.useCase.Register(input)
 	if err != nil {
 		ctrl.logger.Errorw("failed to register user", "error", err)
+		if errors.Is(err, usernames.ErrTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if passwords.IsRejection(err) || usernames.IsRejection(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -59,12 +74,20 @@ func (ctrl *UserController) AuthorizeHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	ctrl.logger.Infow("incoming input", "input", input)
+	ctrl.logger.Infow("incoming input", "username", input.Username)
 
-	token, err := ctrl.useCase.Authorize(input)
+	token, err := ctrl.useCase.Authorize(input, utils.ClientIP(r), r.UserAgent())
+	if errors.Is(err, usersUC.ErrOTPRequired) {
+		err = utils.WriteJson(w, http.StatusAccepted, map[string]string{"message": err.Error()}, nil)
+		if err != nil {
+			ctrl.logger.Errorw("failed to write json", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
 	if err != nil {
 		ctrl.logger.Errorw("failed to authorize", "error", err)
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		writeAuthError(w, err)
 		return
 	}
 
@@ -91,14 +114,16 @@ func (ctrl *UserController) Authorize2FAHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	ctrl.logger.Infow("incoming input", "input", input)
+	ctrl.logger.Infow("incoming input", "username", input.Username)
 
-	otp := ctrl.useCase.Authorize2FA(input.Username)
-	response := map[string]string{
-		"message": "successfully authorized",
-		"otp":     otp,
+	err = ctrl.useCase.Authorize2FA(input.Username, utils.ClientIP(r), r.UserAgent())
+	if err != nil {
+		ctrl.logger.Errorw("failed to send otp", "error", err)
 	}
 
+	// Respond the same way whether or not the user exists
+	response := map[string]string{"message": "if the account exists, a verification code has been sent to its email"}
+
 	err = utils.WriteJson(w, http.StatusOK, response, nil)
 	if err != nil {
 		ctrl.logger.Errorw("failed to write json", "error", err)
@@ -117,12 +142,12 @@ func (ctrl *UserController) VerifyOTPHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	ctrl.logger.Infow("incoming input", "input", input)
+	ctrl.logger.Infow("incoming input", "email", input.Email)
 
-	token, err := ctrl.useCase.VerifyOTP(input.Email, input.OTP)
+	token, err := ctrl.useCase.VerifyOTP(input.Email, input.OTP, utils.ClientIP(r), r.UserAgent())
 	if err != nil {
 		ctrl.logger.Errorw("failed to verify otp", "error", err)
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		writeAuthError(w, err)
 		return
 	}
 
@@ -141,14 +166,14 @@ func (ctrl *UserController) VerifyOTPHandler(w http.ResponseWriter, r *http.Requ
 }
 
 func (ctrl *UserController) LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	token := r.Header.Get("Authorization")
+	token := middleware.SessionToken(r)
 	if token == "" {
 		ctrl.logger.Error("token is required")
 		http.Error(w, "token is required", http.StatusBadRequest)
 		return
 	}
 
-	err := ctrl.useCase.Logout(token)
+	err := ctrl.useCase.Logout(token, utils.ClientIP(r), r.UserAgent())
 	if err != nil {
 		ctrl.logger.Errorw("failed to logout", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -171,10 +196,245 @@ func (ctrl *UserController) ListHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = utils.WriteJson(w, http.StatusOK, users, nil)
+	err = utils.WriteJson(w, http.StatusOK, dto.NewPublicProfiles(users), nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ctrl *UserController) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.ForgotPasswordRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = ctrl.useCase.ForgotPassword(input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to start password reset", "error", err)
+	}
+
+	// Respond the same way whether or not the email is registered
+	response := map[string]string{"message": "if the email is registered, a password reset link has been sent to it"}
+	err = utils.WriteJson(w, http.StatusAccepted, response, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ctrl *UserController) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.ResetPasswordRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = ctrl.useCase.ResetPassword(input, utils.ClientIP(r), r.UserAgent())
+	if err != nil {
+		ctrl.logger.Errorw("failed to reset password", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, map[string]string{"message": "password has been reset"}, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ctrl *UserController) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.ChangePasswordRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	token, err := ctrl.useCase.ChangePassword(user.ID, input, utils.ClientIP(r), r.UserAgent())
+	if err != nil {
+		ctrl.logger.Errorw("failed to change password", "userID", user.ID, "error", err)
+		var throttleErr *domain.ThrottleError
+		switch {
+		case errors.As(err, &throttleErr):
+			writeAuthError(w, err)
+		case errors.Is(err, usersUC.ErrWrongCurrentPassword):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	response := map[string]string{
+		"message": "password changed, all other sessions were signed out",
+		"token":   token,
+	}
+	err = utils.WriteJson(w, http.StatusOK, response, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ctrl *UserController) ChangeUsernameHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.ChangeUsernameRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	username, err := ctrl.useCase.ChangeUsername(user.ID, input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to change username", "userID", user.ID, "error", err)
+		switch {
+		case errors.Is(err, usernames.ErrTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, usersUC.ErrUsernameCooldown):
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		case usernames.IsRejection(err), errors.Is(err, usersUC.ErrUsernameUnchanged):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := map[string]string{
+		"message":  "username changed",
+		"username": username,
+	}
+	err = utils.WriteJson(w, http.StatusOK, response, nil)
 	if err != nil {
 		ctrl.logger.Errorw("failed to write json", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
+
+func (ctrl *UserController) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.VerifyEmailRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = ctrl.useCase.VerifyEmail(input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to verify email", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = utils.WriteJson(w, http.StatusOK, map[string]string{"message": "email verified"}, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (ctrl *UserController) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.ResendVerificationRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = ctrl.useCase.ResendVerification(input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to resend verification", "error", err)
+	}
+
+	// Respond the same way whether or not the email is registered
+	response := map[string]string{"message": "if the email is registered and unverified, a new verification link has been sent"}
+	err = utils.WriteJson(w, http.StatusAccepted, response, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// SessionHandler describes the caller of an authenticated request. Other
+// services use it to resolve the tokens they receive.
+func (ctrl *UserController) SessionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.CurrentUser(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	response := dto.SessionResponse{
+		UserID:        user.ID,
+		Username:      user.Username,
+		EmailVerified: user.EmailVerified,
+		AuthType:      "session",
+		Roles:         user.Roles,
+		Permissions:   user.Permissions,
+	}
+	if grant, ok := middleware.CurrentGrant(r.Context()); ok {
+		// API keys never carry the owner's privileges
+		response.AuthType = "api_key"
+		response.AppName = grant.App.Name
+		response.Scopes = grant.Key.Scopes
+		response.Roles = nil
+		response.Permissions = nil
+	}
+	err := utils.WriteJson(w, http.StatusOK, response, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeAuthError maps a failed login or OTP check to a response. Throttled
+// callers get 429, locked accounts 423, both with a Retry-After header.
+func writeAuthError(w http.ResponseWriter, err error) {
+	var throttleErr *domain.ThrottleError
+	switch {
+	case errors.As(err, &throttleErr):
+		w.Header().Set("Retry-After", utils.RetryAfterSeconds(throttleErr.RetryAfter))
+		if throttleErr.Locked {
+			http.Error(w, throttleErr.Error(), http.StatusLocked)
+			return
+		}
+		http.Error(w, throttleErr.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, usersUC.ErrOTPInvalidated):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, domain.ErrAccountSuspended), errors.Is(err, domain.ErrAccountDeactivated):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, deletionsUC.ErrDeletionInProgress):
+		http.Error(w, err.Error(), http.StatusGone)
+	case errors.Is(err, deletionsUC.ErrDeletionUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,216 @@
+package webhooks
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usecase"
+	webhooksUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/webhooks"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/utils"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type WebhookController struct {
+	useCase usecase.WebhookUseCase
+	logger  *zap.SugaredLogger
+}
+
+func NewWebhookController(webhookUC usecase.WebhookUseCase, logger *zap.SugaredLogger) *WebhookController {
+	return &WebhookController{
+		useCase: webhookUC,
+		logger:  logger,
+	}
+}
+
+// CreateWebhookHandler registers an endpoint for events of the caller's app.
+// The response holds the signing secret, which is not shown again.
+func (ctrl *WebhookController) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid app id", http.StatusBadRequest)
+		return
+	}
+
+	var input dto.CreateWebhookRequest
+	err = utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	webhook, err := ctrl.useCase.CreateWebhook(user.ID, appID, input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to create webhook", "appID", appID, "error", err)
+		writeWebhookError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusCreated, webhook)
+}
+
+func (ctrl *WebhookController) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid app id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	webhooks, err := ctrl.useCase.ListWebhooks(user.ID, appID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list webhooks", "appID", appID, "error", err)
+		writeWebhookError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, webhooks)
+}
+
+func (ctrl *WebhookController) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid app id", http.StatusBadRequest)
+		return
+	}
+	webhookID, err := strconv.Atoi(chi.URLParam(r, "webhook_id"))
+	if err != nil {
+		http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	if err = ctrl.useCase.DeleteWebhook(user.ID, appID, webhookID); err != nil {
+		ctrl.logger.Errorw("failed to delete webhook", "appID", appID, "webhookID", webhookID, "error", err)
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeliveriesHandler lists the delivery log of a webhook, newest first.
+// status filters it, status=dead listing the deliveries that gave up; limit
+// and offset pick the page.
+func (ctrl *WebhookController) DeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid app id", http.StatusBadRequest)
+		return
+	}
+	webhookID, err := strconv.Atoi(chi.URLParam(r, "webhook_id"))
+	if err != nil {
+		http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+	limit, ok := queryInt(w, r, "limit", webhooksUC.DefaultPageSize)
+	if !ok {
+		return
+	}
+	offset, ok := queryInt(w, r, "offset", 0)
+	if !ok {
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	page, err := ctrl.useCase.Deliveries(user.ID, appID, webhookID, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		ctrl.logger.Errorw("failed to list webhook deliveries", "appID", appID, "webhookID", webhookID, "error", err)
+		writeWebhookError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusOK, page)
+}
+
+// RedeliverHandler sends the payload of a past delivery again.
+func (ctrl *WebhookController) RedeliverHandler(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid app id", http.StatusBadRequest)
+		return
+	}
+	webhookID, err := strconv.Atoi(chi.URLParam(r, "webhook_id"))
+	if err != nil {
+		http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+	deliveryID, err := strconv.ParseInt(chi.URLParam(r, "delivery_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid delivery id", http.StatusBadRequest)
+		return
+	}
+
+	user, _ := middleware.CurrentUser(r.Context())
+	delivery, err := ctrl.useCase.Redeliver(user.ID, appID, webhookID, deliveryID)
+	if err != nil {
+		ctrl.logger.Errorw("failed to redeliver webhook delivery", "webhookID", webhookID, "deliveryID", deliveryID, "error", err)
+		writeWebhookError(w, err)
+		return
+	}
+	ctrl.writeJson(w, http.StatusAccepted, delivery)
+}
+
+// DispatchHandler takes the events other services report, such as new
+// tweets and likes in tweet-service, for the webhooks of the user they
+// concern.
+func (ctrl *WebhookController) DispatchHandler(w http.ResponseWriter, r *http.Request) {
+	var input dto.WebhookEventRequest
+
+	err := utils.ReadJson(w, r, &input)
+	if err != nil {
+		ctrl.logger.Errorw("failed to read json", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = ctrl.useCase.Dispatch(input.UserID, input.Type, input.Data); err != nil {
+		ctrl.logger.Errorw("failed to dispatch webhook event", "type", input.Type, "userID", input.UserID, "error", err)
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (ctrl *WebhookController) writeJson(w http.ResponseWriter, status int, data interface{}) {
+	err := utils.WriteJson(w, status, data, nil)
+	if err != nil {
+		ctrl.logger.Errorw("failed to write json", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// queryInt reads an integer query parameter, answering the request if it is
+// not one.
+func queryInt(w http.ResponseWriter, r *http.Request, name string, fallback int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, true
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		http.Error(w, "invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return parsed, true
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, webhooksUC.ErrAppNotFound),
+		errors.Is(err, webhooksUC.ErrWebhookNotFound),
+		errors.Is(err, webhooksUC.ErrDeliveryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, webhooksUC.ErrTooManyWebhooks):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, webhooksUC.ErrInvalidURL),
+		errors.Is(err, webhooksUC.ErrNoEvents),
+		errors.Is(err, webhooksUC.ErrUnknownEvent),
+		errors.Is(err, webhooksUC.ErrInvalidStatus),
+		errors.Is(err, webhooksUC.ErrInvalidPageSize),
+		errors.Is(err, webhooksUC.ErrInvalidOffset):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
@@ -1,20 +1,67 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 )
 
 type User struct {
-	ID           int       `json:"id"`
-	FirstName    string    `json:"firstName"`
-	LastName     string    `json:"lastName"`
-	Email        string    `json:"email"`
-	Age          int       `json:"age"`
-	Username     string    `json:"username"`
-	Password     string    `json:"-"`
-	IsFirstLogin bool      `json:"isFirstLogin"` // New field to track first login
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID                int        `json:"id"`
+	FirstName         string     `json:"firstName"`
+	LastName          string     `json:"lastName"`
+	Email             string     `json:"email"`
+	Age               int        `json:"age"`
+	Username          string     `json:"username"`
+	Bio               string     `json:"bio"`
+	Location          string     `json:"location"`
+	Website           string     `json:"website"`
+	AvatarKey         string     `json:"-"` // blob key of the avatar image, empty if unset
+	BannerKey         string     `json:"-"`
+	Protected         bool       `json:"protected"` // tweets and follows need the user's approval
+	FollowersCount    int        `json:"followersCount"`
+	FollowingCount    int        `json:"followingCount"`
+	Password          string     `json:"-"`
+	IsFirstLogin      bool       `json:"isFirstLogin"` // New field to track first login
+	EmailVerified     bool       `json:"emailVerified"`
+	EmailVerifiedAt   *time.Time `json:"emailVerifiedAt,omitempty"`
+	SuspendedAt       *time.Time `json:"suspendedAt,omitempty"`
+	UsernameChangedAt *time.Time `json:"-"`
+	DeactivatedAt     *time.Time `json:"-"`
+	SuspendedReason   string     `json:"suspendedReason,omitempty"`
+	Roles             []string   `json:"roles,omitempty"`
+	Permissions       []string   `json:"-"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// IsDeactivated reports whether the user asked to delete the account and it
+// is waiting out the grace period.
+func (u *User) IsDeactivated() bool {
+	return u.DeactivatedAt != nil
+}
+
+// CheckActive returns why the user may not sign in or act, or nil.
+func (u *User) CheckActive() error {
+	switch {
+	case u.IsSuspended():
+		return ErrAccountSuspended
+	case u.IsDeactivated():
+		return ErrAccountDeactivated
+	}
+	return nil
+}
+
+func (u *User) HasPermission(permission string) bool {
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
 }
 
 type Follower struct {
@@ -23,6 +70,23 @@ type Follower struct {
 	CreatedAt  time.Time
 }
 
+// FollowEntry is a user in a follower or following list. EdgeID and
+// FollowedAt identify the follow, and together they are where the next page
+// starts.
+type FollowEntry struct {
+	User           *User
+	EdgeID         int
+	FollowedAt     time.Time
+	FollowedByUser bool // the user reading the list follows this user
+}
+
+// FollowCursor is the position after which a follower or following list
+// continues.
+type FollowCursor struct {
+	FollowedAt time.Time
+	EdgeID     int
+}
+
 func ConvertFromDto(id int, firstName, lastName, email, username, password string, age int) *User {
 	return &User{
 		ID:        id,
@@ -34,3 +98,426 @@ func ConvertFromDto(id int, firstName, lastName, email, username, password strin
 		Password:  password,
 	}
 }
+
+// Identity links an account at an external OpenID Connect provider to a user.
+type Identity struct {
+	ID        int
+	UserID    int
+	Provider  string
+	Subject   string // the provider's stable user id (sub claim)
+	Email     string
+	CreatedAt time.Time
+}
+
+// OIDCLogin is the state of a login started at an external provider, kept
+// until the provider redirects back.
+type OIDCLogin struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// APIKeyPrefix starts every API key, which tells them apart from session tokens.
+const APIKeyPrefix = "tck_"
+
+// Scopes an API key can be granted.
+const (
+	ScopeTweetsRead    = "tweets:read"
+	ScopeTweetsWrite   = "tweets:write"
+	ScopeFollowsWrite  = "follows:write"
+	ScopeMessagesRead  = "messages:read"
+	ScopeMessagesWrite = "messages:write"
+)
+
+var KnownScopes = []string{
+	ScopeTweetsRead, ScopeTweetsWrite, ScopeFollowsWrite, ScopeMessagesRead, ScopeMessagesWrite,
+}
+
+// DeveloperApp is a bot or integration registered by a user.
+type DeveloperApp struct {
+	ID          int       `json:"id"`
+	OwnerID     int       `json:"owner_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// APIKey lets a developer app act on behalf of its owner within its scopes.
+// Only the hash of the key is stored; Prefix identifies it in listings.
+type APIKey struct {
+	ID        int        `json:"id"`
+	AppID     int        `json:"app_id"`
+	Prefix    string     `json:"prefix"`
+	Hash      string     `json:"-"`
+	Scopes    []string   `json:"scopes"`
+	RateLimit int        `json:"rate_limit"` // requests per minute
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyGrant is what an API key resolves to: the key, its app and the user
+// the app acts for.
+type APIKeyGrant struct {
+	Key  *APIKey
+	App  *DeveloperApp
+	User *User
+}
+
+// Events a webhook can subscribe to. Each concerns the owner of the app the
+// webhook belongs to.
+const (
+	WebhookTweetCreated    = "tweet.created"    // the owner posted a tweet
+	WebhookTweetDeleted    = "tweet.deleted"    // a tweet of the owner was deleted
+	WebhookFollowerCreated = "follower.created" // someone started following the owner
+	WebhookLikeCreated     = "like.created"     // someone liked a tweet of the owner
+)
+
+var KnownWebhookEvents = []string{
+	WebhookTweetCreated, WebhookTweetDeleted, WebhookFollowerCreated, WebhookLikeCreated,
+}
+
+// Webhook is an endpoint a developer app gets its owner's events at. Every
+// delivery is signed with Secret.
+type Webhook struct {
+	ID        int       `json:"id"`
+	AppID     int       `json:"app_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (w *Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses of a webhook delivery.
+const (
+	DeliveryPending   = "pending"   // waiting for its first or next attempt
+	DeliveryDelivered = "delivered" // the endpoint accepted it
+	DeliveryDead      = "dead"      // gave up after too many attempts
+)
+
+// WebhookDelivery is one event sent, or being sent, to a webhook.
+type WebhookDelivery struct {
+	ID            int64           `json:"id"`
+	WebhookID     int             `json:"webhook_id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastStatus    *int            `json:"last_status,omitempty"` // HTTP status of the last attempt
+	LastError     string          `json:"last_error,omitempty"`
+	RedeliveryOf  *int64          `json:"redelivery_of,omitempty"`
+	NextAttemptAt time.Time       `json:"-"`
+	LastAttemptAt *time.Time      `json:"last_attempt_at,omitempty"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// Roles and the permissions they grant, seeded by the migrations.
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+
+	PermissionListUsers      = "users:list"
+	PermissionSuspendUsers   = "users:suspend"
+	PermissionDeleteAnyTweet = "tweets:delete_any"
+	PermissionManageRoles    = "roles:manage"
+	PermissionReadAudit      = "audit:read"
+)
+
+// AuditRecord describes a privileged action, who took it and on what.
+type AuditRecord struct {
+	ID         int64                  `json:"id"`
+	ActorID    *int                   `json:"actor_id"`
+	Action     string                 `json:"action"`
+	TargetType string                 `json:"target_type"`
+	TargetID   string                 `json:"target_id"`
+	Details    map[string]interface{} `json:"details"`
+	Service    string                 `json:"service"`
+	IP         string                 `json:"ip"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// Statuses of an account deletion saga.
+const (
+	DeletionPending   = "pending"   // account deactivated, waiting out the grace period
+	DeletionPurging   = "purging"   // purge started; it can no longer be cancelled
+	DeletionCompleted = "completed" // all data is gone
+	DeletionCancelled = "cancelled" // the user came back or deactivation was rolled back
+	DeletionFailed    = "failed"    // purge gave up after too many attempts
+)
+
+// AccountDeletion is the state of the saga that deletes a user's account
+// across the services.
+type AccountDeletion struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"`
+	Status        string     `json:"status"`
+	Step          string     `json:"step,omitempty"` // last purge step that completed
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	RequestedAt   time.Time  `json:"requested_at"`
+	PurgeAfter    time.Time  `json:"purge_after"`
+	NextAttemptAt time.Time  `json:"-"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+}
+
+// Ways a session can be created, as recorded in the session history.
+const (
+	SessionMethodPassword       = "password"
+	SessionMethodOTP            = "otp"
+	SessionMethodPasswordChange = "password_change"
+	SessionMethodOIDC           = "oidc"
+)
+
+// SessionRecord is one sign-in in a user's session history.
+type SessionRecord struct {
+	ID        int64     `json:"id"`
+	UserID    int       `json:"-"`
+	Method    string    `json:"method"`
+	IP        string    `json:"ip,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Types of security events, the account activity users can review.
+const (
+	SecurityLogin           = "login"
+	SecurityLoginFailed     = "login_failed" // a wrong password or OTP
+	SecurityOTPIssued       = "otp_issued"
+	SecurityOTPVerified     = "otp_verified"
+	SecurityLogout          = "logout"
+	SecurityPasswordChanged = "password_changed"
+	SecurityPasswordReset   = "password_reset"
+)
+
+// SecurityEvent is one security event of an account, with the client it came
+// from.
+type SecurityEvent struct {
+	ID        int64     `json:"id"`
+	UserID    int       `json:"-"`
+	Type      string    `json:"type"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Data export statuses.
+const (
+	ExportPending  = "pending"
+	ExportBuilding = "building"
+	ExportReady    = "ready"
+	ExportFailed   = "failed"
+	ExportExpired  = "expired"
+)
+
+// DataExport is a requested archive of a user's personal data.
+type DataExport struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"-"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"-"`
+	LastError     string     `json:"-"`
+	BlobKey       string     `json:"-"`
+	SizeBytes     int64      `json:"size_bytes,omitempty"`
+	RequestedAt   time.Time  `json:"requested_at"`
+	NextAttemptAt time.Time  `json:"-"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+}
+
+// FollowRequest is a pending request to follow a protected user. User is the
+// other side of the request: the requester for incoming requests and the
+// target for outgoing ones.
+type FollowRequest struct {
+	User        *User
+	RequestedAt time.Time
+}
+
+// Relations are the users and words whose tweets are shown to or hidden from
+// a user, as needed by tweet-service to filter what the user reads.
+type Relations struct {
+	ProtectedFollowing []int `json:"protected_following"` // protected users the user follows
+	Blocked            []int `json:"blocked"`             // users the user blocked or was blocked by
+	Muted              []int `json:"muted"`
+
+	MutedWords []*MutedWord `json:"muted_words"`
+}
+
+// Where a muted word hides tweets.
+const (
+	MuteScopeTimeline   = "timeline"   // the tweet listings only
+	MuteScopeEverywhere = "everywhere" // also the tweets of a single user
+)
+
+// MutedWord is a keyword, hashtag or phrase whose tweets a user does not want
+// to read, until it expires.
+type MutedWord struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"-"`
+	Phrase    string     `json:"phrase"`
+	Scope     string     `json:"scope"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AuthorActivity sums up what a user tweeted recently, as reported by
+// tweet-service.
+type AuthorActivity struct {
+	UserID      int       `json:"user_id"`
+	TweetCount  int       `json:"tweet_count"`
+	LastTweetAt time.Time `json:"last_tweet_at"`
+	Tags        []string  `json:"tags"`
+}
+
+// Suggestion is an account recommended to a user to follow, with why.
+type Suggestion struct {
+	UserID        int        `json:"user_id"`
+	Score         float64    `json:"score"`
+	MutualFollows int        `json:"mutual_follows"` // followed by this many of the user's followings
+	SharedTags    []string   `json:"shared_tags,omitempty"`
+	LastTweetAt   *time.Time `json:"last_tweet_at,omitempty"`
+}
+
+// UserSearch is a search for users by username or name. With Prefix set, the
+// text is what was typed so far, as for @mention autocomplete.
+type UserSearch struct {
+	Text     string
+	ViewerID int // the user searching, or 0 when anonymous
+	Prefix   bool
+	Limit    int
+	Offset   int
+}
+
+// UserMatch is a user found by a search.
+type UserMatch struct {
+	User           *User
+	Score          float64
+	FollowedByUser bool // the user searching follows this user
+}
+
+// Notification types. Each can be turned off by the user.
+const (
+	NotificationFollow  = "follow"
+	NotificationLike    = "like"
+	NotificationReply   = "reply"
+	NotificationMention = "mention"
+)
+
+var NotificationTypes = []string{NotificationFollow, NotificationLike, NotificationReply, NotificationMention}
+
+// Types of the events streamed to a user's clients by tweet-service.
+const (
+	EventNotification   = "notification"        // the user was notified
+	EventMessage        = "message"             // a new message in one of the user's conversations
+	EventMessageDeleted = "message_deleted"     // its sender deleted a message
+	EventMessagesRead   = "messages_read"       // a member read a conversation
+	EventInvite         = "conversation_invite" // the user was invited to a group
+)
+
+// NotificationEvent is something a user did that another user is told about.
+type NotificationEvent struct {
+	Type        string
+	RecipientID int
+	ActorID     int
+	TweetID     *int64 // the tweet liked, replied to or mentioned in
+}
+
+// Notification tells a user about one or more similar events, such as every
+// like of a tweet since the user last read it.
+type Notification struct {
+	ID         int
+	UserID     int
+	Type       string
+	TweetID    *int64
+	ActorIDs   []int // the most recent actors, newest first
+	ActorCount int
+	ReadAt     *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time // when the last actor was added
+}
+
+// Conversation is a conversation as one of its members sees it. Direct
+// conversations are between two users; groups have a name, admins and
+// members.
+type Conversation struct {
+	ID            int
+	IsGroup       bool
+	Name          string
+	Members       []*ConversationMember // every member, the user included
+	LastMessage   *Message              // nil when every message was deleted
+	UnreadCount   int                   // messages of the others the user has not read
+	CreatedAt     time.Time
+	LastMessageAt *time.Time
+}
+
+// Member returns the member with the user id, or nil.
+func (c *Conversation) Member(userID int) *ConversationMember {
+	for _, member := range c.Members {
+		if member.UserID == userID {
+			return member
+		}
+	}
+	return nil
+}
+
+// Roles of the members of a group. Admins manage the group.
+const (
+	ConversationRoleAdmin  = "admin"
+	ConversationRoleMember = "member"
+)
+
+// ConversationMember is a member of a conversation and how far they read it.
+type ConversationMember struct {
+	UserID            int
+	Role              string
+	LastReadMessageID int64
+	JoinedAt          time.Time
+}
+
+// Events of the system messages that tell the members of a group about a
+// change to it.
+const (
+	GroupCreated       = "group_created"
+	GroupRenamed       = "group_renamed"  // the body is the new name
+	GroupMemberJoined  = "member_joined"  // an invited user accepted
+	GroupMemberLeft    = "member_left"    // a member left
+	GroupMemberRemoved = "member_removed" // an admin removed the target
+	GroupRoleChanged   = "role_changed"   // the target got the role in the body
+)
+
+// Message is a message sent in a conversation. System messages have an
+// event, and their sender is the user who made the change.
+type Message struct {
+	ID             int64
+	ConversationID int
+	SenderID       int
+	Body           string
+	Event          string
+	TargetID       *int
+	CreatedAt      time.Time
+}
+
+// ConversationInvite invites a user to a group.
+type ConversationInvite struct {
+	ConversationID int
+	Name           string // of the group
+	UserID         int
+	InvitedBy      *int
+	CreatedAt      time.Time
+}
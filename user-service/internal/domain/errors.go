@@ -1,8 +1,30 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ErrRecordNotFound     = errors.New("record not found")
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrAccountSuspended   = errors.New("account is suspended")
+	ErrAccountDeactivated = errors.New("account is deactivated and scheduled for deletion")
+	ErrTweetServiceError  = errors.New("tweet-service request failed")
 )
+
+// ThrottleError is returned when the caller made too many failed attempts and
+// has to wait RetryAfter before trying again. Locked is set when the account
+// itself is locked rather than the caller being slowed down.
+type ThrottleError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *ThrottleError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account is temporarily locked, retry after %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
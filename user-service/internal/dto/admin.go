@@ -0,0 +1,20 @@
+package dto
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// DTO sent by other services to record a privileged action they performed
+type RecordAuditRequest struct {
+	ActorID    int                    `json:"actor_id"`
+	Action     string                 `json:"action"`
+	TargetType string                 `json:"target_type"`
+	TargetID   string                 `json:"target_id"`
+	Details    map[string]interface{} `json:"details"`
+	Service    string                 `json:"service"`
+	IP         string                 `json:"ip"`
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"encoding/json"
+	"time"
+)
+
+type CreateAppRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type CreateAPIKeyRequest struct {
+	Scopes    []string `json:"scopes"`
+	RateLimit int      `json:"rate_limit"` // requests per minute, defaults when zero
+}
+
+// DTO returned once when a key is created; the key can not be shown again
+type CreateAPIKeyResponse struct {
+	ID        int       `json:"id"`
+	Key       string    `json:"key"`
+	Prefix    string    `json:"prefix"`
+	Scopes    []string  `json:"scopes"`
+	RateLimit int       `json:"rate_limit"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// DTO returned once when a webhook is created; the secret its deliveries are
+// signed with can not be shown again
+type CreateWebhookResponse struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryPage is one page of the delivery log of a webhook, newest
+// first.
+type WebhookDeliveryPage struct {
+	Deliveries []*domain.WebhookDelivery `json:"deliveries"`
+	NextOffset *int                      `json:"next_offset,omitempty"`
+}
+
+// WebhookEventRequest reports an event another service wants delivered to
+// the webhooks of the user it concerns.
+type WebhookEventRequest struct {
+	Type   string          `json:"type"`
+	UserID int             `json:"user_id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// WebhookPayload is the body of every webhook delivery.
+type WebhookPayload struct {
+	Type       string      `json:"type"`
+	UserID     int         `json:"user_id"` // the owner of the app
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// WebhookFollower is the data of a follower.created webhook event.
+type WebhookFollower struct {
+	Follower *PublicProfile `json:"follower"`
+}
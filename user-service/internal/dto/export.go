@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"time"
+)
+
+// ExportDownloadPath is where export archives are downloaded, relative to the gateway.
+const ExportDownloadPath = "/users/exports/download"
+
+// ExportedTweet is a tweet as tweet-service hands it out for its author's
+// data export. Likes and dislikes are the totals the tweet received.
+type ExportedTweet struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Topic     string    `json:"topic"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	AppName   string    `json:"app_name,omitempty"`
+	Tags      []string  `json:"tags"`
+	Likes     int64     `json:"likes"`
+	Dislikes  int64     `json:"dislikes"`
+}
+
+// ExportedLike is a tweet the user liked, as tweet-service hands it out for
+// the user's data export.
+type ExportedLike struct {
+	TweetID int64     `json:"tweet_id"`
+	LikedAt time.Time `json:"liked_at"`
+}
+
+// DataExportStatus is what the owner sees of an export. The download link is
+// only set once the archive is ready, and stops working at DownloadExpiresAt.
+type DataExportStatus struct {
+	*domain.DataExport
+	DownloadURL       string     `json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
+}
@@ -0,0 +1,164 @@
+package dto
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"time"
+)
+
+type SendMessageRequest struct {
+	RecipientID int    `json:"recipient_id"`
+	Body        string `json:"body"`
+}
+
+type PostMessageRequest struct {
+	Body string `json:"body"`
+}
+
+type MessageView struct {
+	ID             int64     `json:"id"`
+	ConversationID int       `json:"conversation_id"`
+	SenderID       int       `json:"sender_id"`
+	Body           string    `json:"body"`
+	Event          string    `json:"event,omitempty"`     // set on system messages
+	TargetID       *int      `json:"target_id,omitempty"` // the user a system message is about
+	CreatedAt      time.Time `json:"created_at"`
+	ReadBy         []int     `json:"read_by"` // the other members who read it
+}
+
+// NewMessageView builds the view of a message, with its read receipts as
+// kept by the members of its conversation.
+func NewMessageView(m *domain.Message, members []*domain.ConversationMember) *MessageView {
+	view := &MessageView{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Body:           m.Body,
+		Event:          m.Event,
+		TargetID:       m.TargetID,
+		CreatedAt:      m.CreatedAt,
+		ReadBy:         []int{},
+	}
+	for _, member := range members {
+		if member.UserID != m.SenderID && member.LastReadMessageID >= m.ID {
+			view.ReadBy = append(view.ReadBy, member.UserID)
+		}
+	}
+	return view
+}
+
+type ConversationView struct {
+	ID                int                       `json:"id"`
+	IsGroup           bool                      `json:"is_group"`
+	Name              string                    `json:"name,omitempty"` // of a group
+	Role              string                    `json:"role"`           // the caller's
+	Members           []*ConversationMemberView `json:"members"`        // everyone but the caller
+	LastMessage       *MessageView              `json:"last_message,omitempty"`
+	UnreadCount       int                       `json:"unread_count"`
+	LastReadMessageID int64                     `json:"last_read_message_id"`
+	CreatedAt         time.Time                 `json:"created_at"`
+	LastMessageAt     *time.Time                `json:"last_message_at,omitempty"`
+}
+
+type ConversationMemberView struct {
+	*PublicProfile
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// NewConversationView builds the view of a conversation for userID, naming
+// the other members found in users.
+func NewConversationView(c *domain.Conversation, userID int, users map[int]*domain.User) *ConversationView {
+	view := &ConversationView{
+		ID:            c.ID,
+		IsGroup:       c.IsGroup,
+		Name:          c.Name,
+		Members:       []*ConversationMemberView{},
+		UnreadCount:   c.UnreadCount,
+		CreatedAt:     c.CreatedAt,
+		LastMessageAt: c.LastMessageAt,
+	}
+	for _, member := range c.Members {
+		if member.UserID == userID {
+			view.Role = member.Role
+			view.LastReadMessageID = member.LastReadMessageID
+		} else if u, ok := users[member.UserID]; ok {
+			view.Members = append(view.Members, &ConversationMemberView{
+				PublicProfile: NewPublicProfile(u),
+				Role:          member.Role,
+				JoinedAt:      member.JoinedAt,
+			})
+		}
+	}
+	if c.LastMessage != nil {
+		view.LastMessage = NewMessageView(c.LastMessage, c.Members)
+	}
+	return view
+}
+
+// ConversationPage is one page of a user's conversations.
+type ConversationPage struct {
+	Conversations []*ConversationView `json:"conversations"`
+	NextOffset    *int                `json:"next_offset,omitempty"`
+}
+
+// MessagePage is one page of the history of a conversation, newest first.
+type MessagePage struct {
+	Messages   []*MessageView `json:"messages"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type MessageSettings struct {
+	AllowNonFollowers bool `json:"allow_non_followers"` // users who do not follow the user may message them
+}
+
+type UpdateMessageSettingsRequest struct {
+	AllowNonFollowers *bool `json:"allow_non_followers"`
+}
+
+type CreateGroupRequest struct {
+	Name      string `json:"name"`
+	MemberIDs []int  `json:"member_ids"` // invited once the group is created
+}
+
+type RenameGroupRequest struct {
+	Name string `json:"name"`
+}
+
+type InviteRequest struct {
+	UserID int `json:"user_id"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type ConversationInviteView struct {
+	ConversationID int       `json:"conversation_id"`
+	Name           string    `json:"name"`
+	InvitedBy      *int      `json:"invited_by,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func NewConversationInviteView(invite *domain.ConversationInvite) *ConversationInviteView {
+	return &ConversationInviteView{
+		ConversationID: invite.ConversationID,
+		Name:           invite.Name,
+		InvitedBy:      invite.InvitedBy,
+		CreatedAt:      invite.CreatedAt,
+	}
+}
+
+// StreamedMessageDeleted is pushed to the members of a conversation when a
+// message is deleted.
+type StreamedMessageDeleted struct {
+	ConversationID int   `json:"conversation_id"`
+	MessageID      int64 `json:"message_id"`
+}
+
+// StreamedMessagesRead is pushed to the other members of a conversation when
+// a member reads it.
+type StreamedMessagesRead struct {
+	ConversationID    int   `json:"conversation_id"`
+	UserID            int   `json:"user_id"`
+	LastReadMessageID int64 `json:"last_read_message_id"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "time"
+
+type AddMutedWordRequest struct {
+	Phrase    string     `json:"phrase"`     // a keyword, #hashtag or phrase
+	Scope     string     `json:"scope"`      // "timeline" when empty, or "everywhere"
+	ExpiresAt *time.Time `json:"expires_at"` // never expires when empty
+}
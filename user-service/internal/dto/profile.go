@@ -0,0 +1,229 @@
+package dto
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"time"
+)
+
+// MediaPath is where uploaded profile images are served, relative to the gateway.
+const MediaPath = "/users/media/"
+
+// PublicProfile is what anyone may see about a user. It must never carry the
+// email address or other account details.
+type PublicProfile struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Bio       string    `json:"bio"`
+	Location  string    `json:"location"`
+	Website   string    `json:"website"`
+	AvatarURL string    `json:"avatar_url,omitempty"`
+	BannerURL string    `json:"banner_url,omitempty"`
+	Protected bool      `json:"protected"`
+	CreatedAt time.Time `json:"created_at"`
+
+	FollowersCount int `json:"followers_count"`
+	FollowingCount int `json:"following_count"`
+}
+
+// OwnProfile is the profile returned to its owner.
+type OwnProfile struct {
+	PublicProfile
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// UpdateProfileRequest changes the fields that are set. An empty string
+// clears an optional field.
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	Bio       *string `json:"bio"`
+	Location  *string `json:"location"`
+	Website   *string `json:"website"`
+}
+
+// SetProtectionRequest makes an account protected or public.
+type SetProtectionRequest struct {
+	Protected *bool `json:"protected"`
+}
+
+func NewPublicProfile(u *domain.User) *PublicProfile {
+	profile := &PublicProfile{
+		ID:        u.ID,
+		Username:  u.Username,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Bio:       u.Bio,
+		Location:  u.Location,
+		Website:   u.Website,
+		Protected: u.Protected,
+		CreatedAt: u.CreatedAt,
+
+		FollowersCount: u.FollowersCount,
+		FollowingCount: u.FollowingCount,
+	}
+	if u.AvatarKey != "" {
+		profile.AvatarURL = MediaPath + u.AvatarKey
+	}
+	if u.BannerKey != "" {
+		profile.BannerURL = MediaPath + u.BannerKey
+	}
+	return profile
+}
+
+func NewPublicProfiles(users []*domain.User) []*PublicProfile {
+	profiles := make([]*PublicProfile, 0, len(users))
+	for _, u := range users {
+		profiles = append(profiles, NewPublicProfile(u))
+	}
+	return profiles
+}
+
+// FollowListEntry is a user in a follower or following list.
+type FollowListEntry struct {
+	*PublicProfile
+	FollowedAt     time.Time `json:"followed_at"`
+	FollowedByUser bool      `json:"followed_by_you"` // the caller follows this user
+}
+
+// FollowList is one page of a follower or following list. NextCursor is
+// empty on the last page.
+type FollowList struct {
+	Users      []*FollowListEntry `json:"users"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func NewFollowList(entries []*domain.FollowEntry, nextCursor string) *FollowList {
+	users := make([]*FollowListEntry, 0, len(entries))
+	for _, e := range entries {
+		users = append(users, &FollowListEntry{
+			PublicProfile:  NewPublicProfile(e.User),
+			FollowedAt:     e.FollowedAt,
+			FollowedByUser: e.FollowedByUser,
+		})
+	}
+	return &FollowList{Users: users, NextCursor: nextCursor}
+}
+
+// PendingFollowRequest is a follow request as listed to either side of it.
+type PendingFollowRequest struct {
+	User        *PublicProfile `json:"user"`
+	RequestedAt time.Time      `json:"requested_at"`
+}
+
+func NewPendingFollowRequests(requests []*domain.FollowRequest) []*PendingFollowRequest {
+	pending := make([]*PendingFollowRequest, 0, len(requests))
+	for _, r := range requests {
+		pending = append(pending, &PendingFollowRequest{User: NewPublicProfile(r.User), RequestedAt: r.RequestedAt})
+	}
+	return pending
+}
+
+func NewOwnProfile(u *domain.User) *OwnProfile {
+	return &OwnProfile{
+		PublicProfile: *NewPublicProfile(u),
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+	}
+}
+
+// SuggestedUser is an account suggested to follow, with why it was suggested.
+type SuggestedUser struct {
+	*PublicProfile
+	MutualFollows int      `json:"mutual_follows"`
+	SharedTags    []string `json:"shared_tags,omitempty"`
+}
+
+func NewSuggestedUser(u *domain.User, s *domain.Suggestion) *SuggestedUser {
+	return &SuggestedUser{
+		PublicProfile: NewPublicProfile(u),
+		MutualFollows: s.MutualFollows,
+		SharedTags:    s.SharedTags,
+	}
+}
+
+// UserSearchResult is a user found by a search.
+type UserSearchResult struct {
+	*PublicProfile
+	FollowedByUser bool `json:"followed_by_you"` // the caller follows this user
+}
+
+// UserSearchResults is one page of search results. NextOffset is where the
+// next page starts, unset on the last page.
+type UserSearchResults struct {
+	Users      []*UserSearchResult `json:"users"`
+	NextOffset *int                `json:"next_offset,omitempty"`
+}
+
+func NewUserSearchResults(matches []*domain.UserMatch) []*UserSearchResult {
+	results := make([]*UserSearchResult, 0, len(matches))
+	for _, m := range matches {
+		results = append(results, &UserSearchResult{
+			PublicProfile:  NewPublicProfile(m.User),
+			FollowedByUser: m.FollowedByUser,
+		})
+	}
+	return results
+}
+
+// NotificationView is a notification as shown to its user.
+type NotificationView struct {
+	ID         int              `json:"id"`
+	Type       string           `json:"type"`
+	Message    string           `json:"message"`
+	TweetID    *int64           `json:"tweet_id,omitempty"`
+	Actors     []*PublicProfile `json:"actors"` // the most recent ones, newest first
+	ActorCount int              `json:"actor_count"`
+	Read       bool             `json:"read"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// NotificationPage is one page of a user's notifications.
+type NotificationPage struct {
+	Notifications []*NotificationView `json:"notifications"`
+	UnreadCount   int                 `json:"unread_count"`
+	NextOffset    *int                `json:"next_offset,omitempty"`
+}
+
+// NewNotificationView builds the view of a notification, naming the actors
+// found in actors.
+func NewNotificationView(n *domain.Notification, actors map[int]*domain.User) *NotificationView {
+	view := &NotificationView{
+		ID:         n.ID,
+		Type:       n.Type,
+		TweetID:    n.TweetID,
+		Actors:     []*PublicProfile{},
+		ActorCount: n.ActorCount,
+		Read:       n.ReadAt != nil,
+		CreatedAt:  n.CreatedAt,
+		UpdatedAt:  n.UpdatedAt,
+	}
+	for _, id := range n.ActorIDs {
+		if actor, ok := actors[id]; ok {
+			view.Actors = append(view.Actors, NewPublicProfile(actor))
+		}
+	}
+	return view
+}
+
+// StreamedNotification is pushed to the recipient's open clients when a
+// notification is recorded.
+type StreamedNotification struct {
+	Type        string `json:"type"`
+	ActorID     int    `json:"actor_id"`
+	TweetID     *int64 `json:"tweet_id,omitempty"`
+	UnreadCount int    `json:"unread_count"`
+}
+
+// NotificationEventRequest reports an event another service wants a user to
+// be told about. Mentions name the users by username instead of RecipientID.
+type NotificationEventRequest struct {
+	Type        string   `json:"type"`
+	ActorID     int      `json:"actor_id"`
+	RecipientID int      `json:"recipient_id"`
+	TweetID     *int64   `json:"tweet_id"`
+	Usernames   []string `json:"usernames"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+
 // DTO for user registration
 type RegisterUserRequest struct {
 	FirstName string `json:"firstName"`
@@ -33,7 +35,56 @@ type VerifyOTPRequest struct {
 	OTP   string `json:"otp"`
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type ChangeUsernameRequest struct {
+	Username string `json:"username"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// DTO describing the caller of an authenticated request
+type SessionResponse struct {
+	UserID        int      `json:"user_id"`
+	Username      string   `json:"username"`
+	EmailVerified bool     `json:"email_verified"`
+	AuthType      string   `json:"auth_type"` // "session" or "api_key"
+	AppName       string   `json:"app_name,omitempty"`
+	Scopes        []string `json:"scopes,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
+}
+
 type FollowRequest struct {
 	FollowerID int `json:"follower_id"`
 	FollowedID int `json:"followed_id"`
 }
+
+// SecurityEventPage is one page of the caller's security events, newest
+// first.
+type SecurityEventPage struct {
+	Events     []*domain.SecurityEvent `json:"events"`
+	NextOffset *int                    `json:"next_offset,omitempty"`
+}
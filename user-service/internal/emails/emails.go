@@ -0,0 +1,102 @@
+package emails
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/mailer"
+	"embed"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// Sender composes the account emails from templates and hands them to a mailer.
+type Sender struct {
+	mailer    mailer.Mailer
+	templates *mailer.Templates
+	appURL    string // base URL that links in emails point to
+	logger    *zap.SugaredLogger
+}
+
+func NewSender(m mailer.Mailer, appURL string, logger *zap.SugaredLogger) (*Sender, error) {
+	templates, err := mailer.ParseTemplates(templateFiles, "templates")
+	if err != nil {
+		return nil, err
+	}
+	return &Sender{
+		mailer:    m,
+		templates: templates,
+		appURL:    strings.TrimRight(appURL, "/"),
+		logger:    logger,
+	}, nil
+}
+
+func (s *Sender) SendOTP(to, username, code string, ttl time.Duration) error {
+	return s.send("otp", to, map[string]interface{}{
+		"Username": username,
+		"Code":     code,
+		"Minutes":  int(ttl.Minutes()),
+	})
+}
+
+func (s *Sender) SendPasswordReset(to, username, token string, ttl time.Duration) error {
+	return s.send("password_reset", to, map[string]interface{}{
+		"Username": username,
+		"Link":     s.link("/reset-password", token),
+		"Token":    token,
+		"Minutes":  int(ttl.Minutes()),
+	})
+}
+
+func (s *Sender) SendEmailVerification(to, username, token string, ttl time.Duration) error {
+	return s.send("verify_email", to, map[string]interface{}{
+		"Username": username,
+		"Link":     s.link("/verify-email", token),
+		"Token":    token,
+		"Hours":    int(ttl.Hours()),
+	})
+}
+
+func (s *Sender) SendPasswordChanged(to, username string) error {
+	return s.send("password_changed", to, map[string]interface{}{
+		"Username": username,
+	})
+}
+
+// SendNewDeviceLogin warns the user of a sign-in from a device, told apart by
+// its user agent, the account was not used from before.
+func (s *Sender) SendNewDeviceLogin(to, username, userAgent, ip string, at time.Time) error {
+	device := userAgent
+	if device == "" {
+		device = "unknown"
+	}
+	if ip == "" {
+		ip = "unknown"
+	}
+	return s.send("new_device_login", to, map[string]interface{}{
+		"Username": username,
+		"Device":   device,
+		"IP":       ip,
+		"Time":     at.UTC().Format("2 Jan 2006 15:04 MST"),
+	})
+}
+
+func (s *Sender) link(path, token string) string {
+	return s.appURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func (s *Sender) send(template, to string, data interface{}) error {
+	msg, err := s.templates.Render(template, to, data)
+	if err != nil {
+		s.logger.Errorw("failed to render email", "template", template, "error", err)
+		return err
+	}
+	if err = s.mailer.Send(msg); err != nil {
+		s.logger.Errorw("failed to send email", "template", template, "to", to, "error", err)
+		return err
+	}
+	return nil
+}
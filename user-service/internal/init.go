@@ -1,46 +1,231 @@
 package user
 
 import (
+	tweetClient "MussaShaukenov/twitter-clone-go/user-service/internal/clients/tweets"
 	ctrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller"
+	adminCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/admin"
+	appCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/apps"
+	deletionCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/deletions"
+	exportCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/exports"
 	followerCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/followers"
+	messageCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/messages"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/controller/middleware"
+	mutedWordCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/mutedwords"
+	notificationCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/notifications"
+	profileCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/profiles"
+	searchCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/search"
+	securityCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/security"
+	suggestionCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/suggestions"
 	userCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/users"
+	webhookCtrl "MussaShaukenov/twitter-clone-go/user-service/internal/controller/webhooks"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/emails"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/oidc"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/passwords"
+	appRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/apps"
+	attemptRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/attempts"
+	auditRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/audit"
+	deletionRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/deletions"
+	eventRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/events"
+	exportRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/exports"
 	followerRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/followers"
+	identityRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/identities"
+	messageRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/messages"
+	mutedWordRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/mutedwords"
+	notificationRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/notifications"
+	oidcStateRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/oidcstate"
 	otpRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/otp"
+	rateLimitRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/ratelimit"
+	resetRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/resets"
+	roleRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/roles"
+	searchRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/search"
+	securityRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/security"
+	sessionRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/sessions"
+	suggestionRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/suggestions"
 	userRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/users"
+	webhookRepo "MussaShaukenov/twitter-clone-go/user-service/internal/repository/webhooks"
+	adminUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/admin"
+	appUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/apps"
+	deletionUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/deletions"
+	exportUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/exports"
 	followerUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/followers"
+	messageUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/messages"
+	mutedWordUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/mutedwords"
+	notificationUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/notifications"
+	profileUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/profiles"
+	searchUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/search"
+	securityUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/security"
+	suggestionUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/suggestions"
 	userUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/users"
+	webhookUC "MussaShaukenov/twitter-clone-go/user-service/internal/usecase/webhooks"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/blob"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/mailer"
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
+	"context"
+	"fmt"
 	"net/http"
+	"time"
 )
 
 type Config struct {
 	Db     *pgxpool.Pool
 	Logger *zap.SugaredLogger
 	Redis  *redis.Client
+	Mailer mailer.Mailer
+	AppURL string
 	Router *chi.Mux
+
+	VerificationSecret string
+	PasswordPolicy     passwords.Policy
+	Argon2Params       utils.Argon2Params
+	OIDCProviders      []oidc.ProviderConfig
+	InternalToken      string   // shared with other services for internal endpoints
+	BootstrapAdmins    []string // usernames granted the admin role at startup
+	BlobDir            string   // directory for uploaded profile images
+	TweetServiceURL    string
 }
 
 func InitializeUserApp(config *Config) (http.Handler, error) {
+	// anyone could forge verification tokens and download links with a
+	// guessable secret
+	if err := utils.CheckSecret(config.VerificationSecret); err != nil {
+		return nil, fmt.Errorf("the email verification secret %w", err)
+	}
+	// the internal endpoints purge accounts and write audit records
+	if err := utils.CheckSecret(config.InternalToken); err != nil {
+		return nil, fmt.Errorf("the internal API token %w", err)
+	}
+
 	// initialize repositories
 	userRepository := userRepo.NewUsersRepo(config.Db, config.Logger)
 	followerRepository := followerRepo.NewFollowersRepo(config.Db, config.Logger)
 	otpRepository := otpRepo.NewOTPRepo(config.Redis, config.Logger)
+	attemptRepository := attemptRepo.NewAttemptRepo(config.Redis, config.Logger)
+	resetRepository := resetRepo.NewPasswordResetRepo(config.Redis, config.Logger)
+	identityRepository := identityRepo.NewIdentitiesRepo(config.Db, config.Logger)
+	oidcStateRepository := oidcStateRepo.NewOIDCStateRepo(config.Redis, config.Logger)
+	appRepository := appRepo.NewAppsRepo(config.Db, config.Logger)
+	rateLimitRepository := rateLimitRepo.NewRateLimitRepo(config.Redis, config.Logger)
+	roleRepository := roleRepo.NewRolesRepo(config.Db, config.Logger)
+	auditRepository := auditRepo.NewAuditRepo(config.Db, config.Logger)
+	deletionRepository := deletionRepo.NewDeletionsRepo(config.Db, config.Logger)
+	sessionRepository := sessionRepo.NewSessionHistoryRepo(config.Db, config.Logger)
+	securityRepository := securityRepo.NewSecurityEventRepo(config.Db, config.Logger)
+	exportRepository := exportRepo.NewExportsRepo(config.Db, config.Logger)
+	mutedWordRepository := mutedWordRepo.NewMutedWordsRepo(config.Db, config.Logger)
+	suggestionRepository := suggestionRepo.NewSuggestionsRepo(config.Redis, config.Logger)
+	searchRepository := searchRepo.NewSearchRepo(config.Db, config.Logger)
+	notificationRepository := notificationRepo.NewNotificationsRepo(config.Db, config.Logger)
+	eventRepository := eventRepo.NewEventsRepo(config.Redis, config.Logger)
+	messageRepository := messageRepo.NewMessagesRepo(config.Db, config.Logger)
+	webhookRepository := webhookRepo.NewWebhooksRepo(config.Db, config.Logger)
+
+	tweetServiceClient := tweetClient.NewTweetServiceClient(config.TweetServiceURL, config.InternalToken)
+
+	// initialize email sender
+	emailSender, err := emails.NewSender(config.Mailer, config.AppURL, config.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// initialize password validation
+	breachedPasswords, err := passwords.NewEmbeddedSource()
+	if err != nil {
+		return nil, err
+	}
+	passwordValidator := passwords.NewValidator(config.PasswordPolicy, passwords.NewBreachChecker(breachedPasswords))
+
+	// initialize storage for uploaded images and export archives
+	blobStore, err := blob.NewLocalStore(config.BlobDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// initialize external identity providers
+	providers := oidc.NewRegistry(config.OIDCProviders, nil)
 
 	// initialize use cases
-	userUseCase := userUC.NewUserUseCase(userRepository, otpRepository, config.Logger)
-	followerUseCase := followerUC.NewFollowerUseCase(userRepository, followerRepository, config.Logger)
+	hasher := utils.NewPasswordHasher(config.Argon2Params)
+	deletionUseCase := deletionUC.NewDeletionUseCase(
+		userRepository, followerRepository, otpRepository, deletionRepository, tweetServiceClient, hasher, config.Logger)
+	securityUseCase := securityUC.NewSecurityUseCase(securityRepository, userRepository, emailSender, config.Logger)
+	userUseCase := userUC.NewUserUseCase(
+		userRepository, otpRepository, attemptRepository, resetRepository, roleRepository,
+		identityRepository, oidcStateRepository, sessionRepository, providers, deletionUseCase, securityUseCase,
+		emailSender, passwordValidator, hasher, config.VerificationSecret, config.Logger)
+	notificationUseCase := notificationUC.NewNotificationUseCase(
+		notificationRepository, userRepository, followerRepository, eventRepository, config.Logger)
+	webhookUseCase := webhookUC.NewWebhookUseCase(
+		webhookRepository, appRepository, webhookUC.NewDeliveryClient(), config.Logger)
+	followerUseCase := followerUC.NewFollowerUseCase(
+		userRepository, followerRepository, mutedWordRepository, notificationUseCase, webhookUseCase, config.Logger)
+	messageUseCase := messageUC.NewMessageUseCase(
+		messageRepository, userRepository, followerRepository, eventRepository, config.Logger)
+	mutedWordUseCase := mutedWordUC.NewMutedWordUseCase(mutedWordRepository, config.Logger)
+	suggestionUseCase := suggestionUC.NewSuggestionUseCase(
+		userRepository, followerRepository, suggestionRepository, tweetServiceClient, config.Logger)
+	searchUseCase := searchUC.NewSearchUseCase(searchRepository, config.Logger)
+	appUseCase := appUC.NewAppUseCase(appRepository, userRepository, rateLimitRepository, config.Logger)
+	profileUseCase := profileUC.NewProfileUseCase(
+		userRepository, followerRepository, tweetServiceClient, blobStore, config.Logger)
+	// download links are signed with a key of their own, derived from the
+	// email verification secret
+	exportUseCase := exportUC.NewExportUseCase(
+		userRepository, followerRepository, sessionRepository, exportRepository, tweetServiceClient, blobStore,
+		utils.DeriveKey(config.VerificationSecret, "data-export-links"), config.Logger)
+	adminUseCase := adminUC.NewAdminUseCase(userRepository, roleRepository, otpRepository, auditRepository, config.Logger)
+
+	if err = adminUseCase.Bootstrap(config.BootstrapAdmins); err != nil {
+		return nil, err
+	}
+
+	// purge deleted accounts once their grace period is over
+	go deletionUseCase.Run(context.Background(), time.Minute)
+
+	// build requested data exports and remove expired ones
+	go exportUseCase.Run(context.Background(), time.Minute)
+
+	// precompute follow suggestions
+	go suggestionUseCase.Run(context.Background(), time.Hour)
+
+	// deliver webhook events and retry failed deliveries
+	go webhookUseCase.Run(context.Background(), 15*time.Second)
+
+	// initialize middleware
+	auth := middleware.NewAuth(userUseCase, appUseCase, config.Logger)
 
 	// initialize controller
 	followerController := followerCtrl.NewFollowerController(followerUseCase, config.Logger)
 	userController := userCtrl.NewUserController(userUseCase, config.Logger)
+	profileController := profileCtrl.NewProfileController(profileUseCase, config.Logger)
+	deletionController := deletionCtrl.NewDeletionController(deletionUseCase, config.Logger)
+	exportController := exportCtrl.NewExportController(exportUseCase, config.Logger)
+	mutedWordController := mutedWordCtrl.NewMutedWordController(mutedWordUseCase, config.Logger)
+	suggestionController := suggestionCtrl.NewSuggestionController(suggestionUseCase, config.Logger)
+	searchController := searchCtrl.NewSearchController(searchUseCase, config.Logger)
+	securityController := securityCtrl.NewSecurityController(securityUseCase, config.Logger)
+	notificationController := notificationCtrl.NewNotificationController(notificationUseCase, config.Logger)
+	messageController := messageCtrl.NewMessageController(messageUseCase, config.Logger)
+	appController := appCtrl.NewAppController(appUseCase, config.Logger)
+	webhookController := webhookCtrl.NewWebhookController(webhookUseCase, config.Logger)
+	adminController := adminCtrl.NewAdminController(adminUseCase, config.Logger)
 
 	// register routes
-	config.Router.Mount("/users", ctrl.RegisterUserRoutes(userController))
-	config.Router.Mount("/followers", ctrl.RegisterFollowerRoutes(followerController))
+	config.Router.Mount("/users", ctrl.RegisterUserRoutes(
+		userController, profileController, deletionController, exportController, mutedWordController, suggestionController,
+		searchController, securityController, auth))
+	config.Router.Mount("/followers", ctrl.RegisterFollowerRoutes(followerController, auth))
+	config.Router.Mount("/notifications", ctrl.RegisterNotificationRoutes(notificationController, auth))
+	config.Router.Mount("/messages", ctrl.RegisterMessageRoutes(messageController, auth))
+	config.Router.Mount("/apps", ctrl.RegisterAppRoutes(appController, webhookController, auth))
+	config.Router.Mount("/admin", ctrl.RegisterAdminRoutes(adminController, auth))
+	config.Router.Mount("/internal", ctrl.RegisterInternalRoutes(
+		userController, adminController, followerController, notificationController, webhookController, auth,
+		config.InternalToken))
 
 	return config.Router, nil
 }
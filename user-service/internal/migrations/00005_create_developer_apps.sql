@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS developer_apps
+(
+    id          SERIAL PRIMARY KEY,
+    owner_id    INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name        VARCHAR(64)  NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_developer_apps_owner_id ON developer_apps (owner_id);
+
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id         SERIAL PRIMARY KEY,
+    app_id     INT         NOT NULL REFERENCES developer_apps (id) ON DELETE CASCADE,
+    prefix     VARCHAR(16) NOT NULL,
+    key_hash   CHAR(64)    NOT NULL UNIQUE, -- sha256 of the key, the key itself is never stored
+    scopes     TEXT[]      NOT NULL,
+    rate_limit INT         NOT NULL CHECK (rate_limit > 0),
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_app_id ON api_keys (app_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS developer_apps;
+-- +goose StatementEnd
@@ -0,0 +1,72 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS roles
+(
+    id   SERIAL PRIMARY KEY,
+    name VARCHAR(64) UNIQUE NOT NULL
+);
+CREATE TABLE IF NOT EXISTS permissions
+(
+    id   SERIAL PRIMARY KEY,
+    name VARCHAR(64) UNIQUE NOT NULL
+);
+CREATE TABLE IF NOT EXISTS role_permissions
+(
+    role_id       INT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission_id INT NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+CREATE TABLE IF NOT EXISTS user_roles
+(
+    user_id    INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id    INT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    granted_by INT REFERENCES users (id) ON DELETE SET NULL,
+    granted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role_id)
+);
+
+INSERT INTO roles (name)
+VALUES ('admin'),
+       ('moderator');
+INSERT INTO permissions (name)
+VALUES ('users:list'),
+       ('users:suspend'),
+       ('tweets:delete_any'),
+       ('roles:manage'),
+       ('audit:read');
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+         JOIN permissions p ON r.name = 'admin'
+    OR (r.name = 'moderator' AND p.name IN ('users:list', 'users:suspend', 'tweets:delete_any'));
+
+ALTER TABLE users
+    ADD COLUMN suspended_at     TIMESTAMP,
+    ADD COLUMN suspended_reason VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id          BIGSERIAL PRIMARY KEY,
+    actor_id    INT REFERENCES users (id) ON DELETE SET NULL,
+    action      VARCHAR(64) NOT NULL,
+    target_type VARCHAR(32) NOT NULL,
+    target_id   VARCHAR(64) NOT NULL,
+    details     JSONB       NOT NULL DEFAULT '{}',
+    service     VARCHAR(32) NOT NULL,
+    ip          VARCHAR(64),
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log (actor_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_log;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS suspended_at,
+    DROP COLUMN IF EXISTS suspended_reason;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN bio        VARCHAR(160) NOT NULL DEFAULT '',
+    ADD COLUMN location   VARCHAR(30)  NOT NULL DEFAULT '',
+    ADD COLUMN website    VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN avatar_key VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN banner_key VARCHAR(255) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN bio,
+    DROP COLUMN location,
+    DROP COLUMN website,
+    DROP COLUMN avatar_key,
+    DROP COLUMN banner_key;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- username_skeleton folds case and look-alike characters (0 -> o, 1 and i -> l),
+-- matching usernames.Skeleton
+ALTER TABLE users
+    ADD COLUMN username_skeleton   VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN username_changed_at TIMESTAMP;
+UPDATE users
+SET username_skeleton = translate(lower(username), '01i', 'oll');
+CREATE INDEX IF NOT EXISTS users_username_skeleton_idx ON users (username_skeleton);
+
+CREATE TABLE IF NOT EXISTS username_history
+(
+    id                SERIAL PRIMARY KEY,
+    user_id           INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    username          VARCHAR(255) NOT NULL,
+    username_skeleton VARCHAR(255) NOT NULL,
+    changed_at        TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS username_history_skeleton_idx ON username_history (username_skeleton, changed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS username_history;
+ALTER TABLE users
+    DROP COLUMN username_skeleton,
+    DROP COLUMN username_changed_at;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The followers table was created with followee_id while the code always used
+-- followed_id
+DO
+$$
+    BEGIN
+        IF EXISTS (SELECT 1
+                   FROM information_schema.columns
+                   WHERE table_name = 'followers'
+                     AND column_name = 'followee_id') THEN
+            ALTER TABLE followers
+                RENAME COLUMN followee_id TO followed_id;
+        END IF;
+    END
+$$;
+CREATE INDEX IF NOT EXISTS followers_follower_id_idx ON followers (follower_id);
+CREATE INDEX IF NOT EXISTS followers_followed_id_idx ON followers (followed_id);
+
+ALTER TABLE users
+    ADD COLUMN deactivated_at TIMESTAMP;
+
+-- account_deletions tracks each deletion saga. It has no foreign key to users,
+-- because the row outlives the account it deletes.
+CREATE TABLE IF NOT EXISTS account_deletions
+(
+    id              SERIAL PRIMARY KEY,
+    user_id         INT         NOT NULL,
+    status          VARCHAR(16) NOT NULL,          -- pending, purging, completed, cancelled or failed
+    step            VARCHAR(32) NOT NULL DEFAULT '', -- last purge step that completed
+    attempts        INT         NOT NULL DEFAULT 0,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    requested_at    TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    purge_after     TIMESTAMP   NOT NULL,
+    next_attempt_at TIMESTAMP   NOT NULL,
+    completed_at    TIMESTAMP
+);
+-- At most one running saga per user
+CREATE UNIQUE INDEX IF NOT EXISTS account_deletions_active_idx
+    ON account_deletions (user_id) WHERE status IN ('pending', 'purging');
+CREATE INDEX IF NOT EXISTS account_deletions_due_idx
+    ON account_deletions (next_attempt_at) WHERE status IN ('pending', 'purging');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_deletions;
+ALTER TABLE users
+    DROP COLUMN deactivated_at;
+DROP INDEX IF EXISTS followers_follower_id_idx;
+DROP INDEX IF EXISTS followers_followed_id_idx;
+-- +goose StatementEnd
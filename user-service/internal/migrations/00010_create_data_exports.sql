@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS session_history
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    method     VARCHAR(64) NOT NULL,
+    ip         VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_session_history_user_id ON session_history (user_id, id);
+
+-- user_id has no foreign key: the archive of a deleted account still has to
+-- be found to remove it from storage once it expires.
+CREATE TABLE IF NOT EXISTS data_exports
+(
+    id              SERIAL PRIMARY KEY,
+    user_id         INT          NOT NULL,
+    status          VARCHAR(16)  NOT NULL,
+    attempts        INT          NOT NULL DEFAULT 0,
+    last_error      TEXT         NOT NULL DEFAULT '',
+    blob_key        VARCHAR(255) NOT NULL DEFAULT '',
+    size_bytes      BIGINT       NOT NULL DEFAULT 0,
+    requested_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    next_attempt_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at    TIMESTAMP,
+    expires_at      TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_exports_active_user
+    ON data_exports (user_id) WHERE status IN ('pending', 'building');
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports (user_id, id);
+CREATE INDEX IF NOT EXISTS idx_data_exports_due
+    ON data_exports (next_attempt_at) WHERE status IN ('pending', 'building');
+CREATE INDEX IF NOT EXISTS idx_data_exports_expires_at
+    ON data_exports (expires_at) WHERE status = 'ready';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS data_exports;
+DROP TABLE IF EXISTS session_history;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN protected BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS follow_requests
+(
+    requester_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    target_id    INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (requester_id, target_id)
+);
+CREATE INDEX IF NOT EXISTS idx_follow_requests_target_id ON follow_requests (target_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS follow_requests;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS protected;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A block cuts all ties between two users and hides their content from each other
+CREATE TABLE IF NOT EXISTS user_blocks
+(
+    blocker_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    blocked_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked_id ON user_blocks (blocked_id);
+
+-- A mute only hides the muted user's tweets from the muter's listings
+CREATE TABLE IF NOT EXISTS user_mutes
+(
+    muter_id   INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    muted_id   INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (muter_id, muted_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_mutes;
+DROP TABLE IF EXISTS user_blocks;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Keywords, hashtags and phrases a user does not want to read about
+CREATE TABLE IF NOT EXISTS muted_words
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    phrase     VARCHAR(100) NOT NULL,
+    scope      VARCHAR(16)  NOT NULL DEFAULT 'timeline',
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_muted_words_user_phrase ON muted_words (user_id, LOWER(phrase));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS muted_words;
+-- +goose StatementEnd
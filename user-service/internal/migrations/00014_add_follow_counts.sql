@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Duplicate follows would be counted twice; keep the oldest of each
+DELETE FROM followers f
+    USING followers older
+WHERE f.follower_id = older.follower_id
+  AND f.followed_id = older.followed_id
+  AND f.id > older.id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_followers_follower_followed ON followers (follower_id, followed_id);
+
+UPDATE followers SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+ALTER TABLE followers
+    ALTER COLUMN created_at SET NOT NULL;
+
+-- Follower lists are paged by follow date, newest first
+CREATE INDEX IF NOT EXISTS idx_followers_followed_created ON followers (followed_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_followers_follower_created ON followers (follower_id, created_at DESC, id DESC);
+
+ALTER TABLE users
+    ADD COLUMN followers_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN following_count INT NOT NULL DEFAULT 0;
+
+UPDATE users u
+SET followers_count = (SELECT COUNT(*) FROM followers f WHERE f.followed_id = u.id),
+    following_count = (SELECT COUNT(*) FROM followers f WHERE f.follower_id = u.id);
+
+-- The counts are kept by a trigger, so every way a follow is added or removed
+-- (follow, unfollow, approved requests, blocks, account deletion) updates them
+-- in the same transaction.
+CREATE OR REPLACE FUNCTION update_follow_counts() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE users SET followers_count = followers_count + 1 WHERE id = NEW.followed_id;
+        UPDATE users SET following_count = following_count + 1 WHERE id = NEW.follower_id;
+        RETURN NEW;
+    END IF;
+    UPDATE users SET followers_count = GREATEST(followers_count - 1, 0) WHERE id = OLD.followed_id;
+    UPDATE users SET following_count = GREATEST(following_count - 1, 0) WHERE id = OLD.follower_id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER followers_count_trigger
+    AFTER INSERT OR DELETE
+    ON followers
+    FOR EACH ROW
+EXECUTE FUNCTION update_follow_counts();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS followers_count_trigger ON followers;
+DROP FUNCTION IF EXISTS update_follow_counts();
+ALTER TABLE users
+    DROP COLUMN followers_count,
+    DROP COLUMN following_count;
+DROP INDEX IF EXISTS idx_followers_followed_created;
+DROP INDEX IF EXISTS idx_followers_follower_created;
+ALTER TABLE followers
+    ALTER COLUMN created_at DROP NOT NULL;
+DROP INDEX IF EXISTS idx_followers_follower_followed;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A notification gathers every actor of a similar event (the likes of one
+-- tweet, new followers) until the user reads it.
+CREATE TABLE IF NOT EXISTS notifications
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type       VARCHAR(16) NOT NULL,          -- follow, like, reply or mention
+    group_key  VARCHAR(64) NOT NULL,          -- events with the same key are grouped
+    tweet_id   BIGINT,
+    read_at    TIMESTAMP,
+    created_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- At most one unread notification per group
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_unread_group
+    ON notifications (user_id, type, group_key) WHERE read_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_user_updated ON notifications (user_id, updated_at DESC);
+
+CREATE TABLE IF NOT EXISTS notification_actors
+(
+    notification_id INT       NOT NULL REFERENCES notifications (id) ON DELETE CASCADE,
+    actor_id        INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (notification_id, actor_id)
+);
+
+-- Types a user turned off; every other type is on
+CREATE TABLE IF NOT EXISTS notification_preferences
+(
+    user_id INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    type    VARCHAR(16) NOT NULL,
+    enabled BOOLEAN     NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notification_actors;
+DROP TABLE IF EXISTS notifications;
+-- +goose StatementEnd
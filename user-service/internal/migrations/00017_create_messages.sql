@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A conversation between users. Direct conversations have a key made of the
+-- two user ids, so each pair of users has only one.
+CREATE TABLE IF NOT EXISTS conversations
+(
+    id              SERIAL PRIMARY KEY,
+    direct_key      VARCHAR(32) UNIQUE,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_message_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS conversation_members
+(
+    conversation_id      INT       NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    user_id              INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    last_read_message_id BIGINT    NOT NULL DEFAULT 0, -- read receipt: every message up to it was read
+    joined_at            TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_conversation_members_user ON conversation_members (user_id);
+
+CREATE TABLE IF NOT EXISTS messages
+(
+    id              BIGSERIAL PRIMARY KEY,
+    conversation_id INT       NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    sender_id       INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    body            TEXT      NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- History is paged by id, newest first
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages (conversation_id, id DESC);
+
+-- Users who only take messages from their followers; everyone else takes
+-- messages from anyone
+CREATE TABLE IF NOT EXISTS message_settings
+(
+    user_id             INT     PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    allow_non_followers BOOLEAN NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS message_settings;
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS conversation_members;
+DROP TABLE IF EXISTS conversations;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE conversations
+    ADD COLUMN is_group BOOLEAN     NOT NULL DEFAULT FALSE,
+    ADD COLUMN name     VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE conversation_members
+    ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'member'; -- admin or member
+
+-- System messages tell the members about a change to the group; event names
+-- it, target_id is the user it concerns and body carries the new value.
+ALTER TABLE messages
+    ADD COLUMN event     VARCHAR(32),
+    ADD COLUMN target_id INT REFERENCES users (id) ON DELETE SET NULL;
+
+-- Users invited to a group, until they accept or decline
+CREATE TABLE IF NOT EXISTS conversation_invites
+(
+    conversation_id INT       NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    user_id         INT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    invited_by      INT       REFERENCES users (id) ON DELETE SET NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_conversation_invites_user ON conversation_invites (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS conversation_invites;
+ALTER TABLE messages
+    DROP COLUMN event,
+    DROP COLUMN target_id;
+ALTER TABLE conversation_members
+    DROP COLUMN role;
+ALTER TABLE conversations
+    DROP COLUMN is_group,
+    DROP COLUMN name;
+-- +goose StatementEnd
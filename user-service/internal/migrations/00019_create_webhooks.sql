@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id         SERIAL PRIMARY KEY,
+    app_id     INT           NOT NULL REFERENCES developer_apps (id) ON DELETE CASCADE,
+    url        VARCHAR(2048) NOT NULL,
+    secret     VARCHAR(80)   NOT NULL, -- kept as is, deliveries are signed with it
+    events     TEXT[]        NOT NULL,
+    created_at TIMESTAMP     NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_app_id ON webhooks (app_id);
+
+-- One row per event sent to a webhook. Pending deliveries are retried until
+-- they succeed or run out of attempts and become dead.
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id              BIGSERIAL PRIMARY KEY,
+    webhook_id      INT         NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event           VARCHAR(32) NOT NULL,
+    payload         JSONB       NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending', -- pending, delivered or dead
+    attempts        INT         NOT NULL DEFAULT 0,
+    last_status     INT,                                    -- HTTP status of the last attempt
+    last_error      TEXT        NOT NULL DEFAULT '',
+    redelivery_of   BIGINT      REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    next_attempt_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    delivered_at    TIMESTAMP,
+    created_at      TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+-- +goose StatementEnd
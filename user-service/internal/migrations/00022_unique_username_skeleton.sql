@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Two usernames with the same skeleton can not coexist, even when both are
+-- taken at once and pass the availability check
+DROP INDEX IF EXISTS users_username_skeleton_idx;
+CREATE UNIQUE INDEX users_username_skeleton_idx ON users (username_skeleton);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_username_skeleton_idx;
+CREATE INDEX IF NOT EXISTS users_username_skeleton_idx ON users (username_skeleton);
+-- +goose StatementEnd
@@ -0,0 +1,245 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockIdP is a minimal OpenID provider: it issues a code for a pending
+// authorization request and signs ID tokens with its own RSA key.
+type mockIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	keyID  string
+
+	mu      sync.Mutex
+	pending map[string]url.Values // code -> authorization request
+	claims  map[string]interface{}
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{t: t, key: key, keyID: "test-key", pending: map[string]url.Values{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": idp.keyID,
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// authorize simulates the user signing in and returns the issued code.
+func (idp *mockIdP) authorize(authURL string) string {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	code := "code-" + parsed.Query().Get("state")
+	idp.mu.Lock()
+	idp.pending[code] = parsed.Query()
+	idp.mu.Unlock()
+	return code
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	clientID, secret, _ := r.BasicAuth()
+
+	idp.mu.Lock()
+	request, ok := idp.pending[r.PostForm.Get("code")]
+	delete(idp.pending, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	switch {
+	case !ok, clientID != "twitter-clone", secret != "secret":
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	case CodeChallenge(r.PostForm.Get("code_verifier")) != request.Get("code_challenge"):
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	claims := map[string]interface{}{
+		"iss":            idp.server.URL,
+		"sub":            "user-42",
+		"aud":            clientID,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          request.Get("nonce"),
+		"email":          "alice@example.com",
+		"email_verified": true,
+	}
+	for name, value := range idp.claims {
+		claims[name] = value
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(claims)})
+}
+
+func (idp *mockIdP) sign(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": idp.keyID, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (idp *mockIdP) provider() *Provider {
+	return NewProvider(ProviderConfig{
+		Name:         "mock",
+		Issuer:       idp.server.URL,
+		ClientID:     "twitter-clone",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8000/users/oidc/mock/callback",
+	}, idp.server.Client())
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := idp.authorize(authURL)
+
+	claims, err := provider.Exchange(ctx, code, "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-42" || claims.Email != "alice@example.com" || !claims.EmailVerified {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := idp.authorize(authURL)
+
+	if _, err = provider.Exchange(ctx, code, "another-verifier", "nonce-1"); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("expected ErrExchangeFailed, got %v", err)
+	}
+}
+
+func TestVerifyIDTokenRejectsBadClaims(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   idp.server.URL,
+			"sub":   "user-42",
+			"aud":   "twitter-clone",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": "nonce-1",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(map[string]interface{})
+	}{
+		{"wrong issuer", func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }},
+		{"wrong audience", func(c map[string]interface{}) { c["aud"] = "someone-else" }},
+		{"expired", func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"wrong nonce", func(c map[string]interface{}) { c["nonce"] = "replayed" }},
+		{"missing subject", func(c map[string]interface{}) { delete(c, "sub") }},
+	}
+
+	if _, err := provider.VerifyIDToken(ctx, idp.sign(valid()), "nonce-1"); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.change(claims)
+			if _, err := provider.VerifyIDToken(ctx, idp.sign(claims), "nonce-1"); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+
+	t.Run("tampered signature", func(t *testing.T) {
+		token := idp.sign(valid())
+		tampered := token[:len(token)-4] + "AAAA"
+		if _, err := provider.VerifyIDToken(ctx, tampered, "nonce-1"); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+}
+
+func TestLoadProviders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "providers.json")
+	content := `[{"name":"mock","issuer":"https://idp.example.com","client_id":"id","redirect_url":"http://localhost/cb"}]`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	configs, err := LoadProviders(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].Name != "mock" {
+		t.Errorf("unexpected configs: %+v", configs)
+	}
+
+	if err = os.WriteFile(path, []byte(`[{"name":"broken"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadProviders(path); err == nil {
+		t.Error("expected an error for an incomplete provider")
+	}
+}
@@ -0,0 +1,81 @@
+package passwords
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"embed"
+	"encoding/hex"
+	"strings"
+)
+
+//go:embed data/breached_sha1.txt
+var breachedData embed.FS
+
+// RangeSource returns the hash suffixes known for a 5 character SHA-1 prefix,
+// the same k-anonymity scheme the Pwned Passwords range API uses. Only the
+// prefix of a password hash ever leaves the caller.
+type RangeSource interface {
+	Range(prefix string) ([]string, error)
+}
+
+type embeddedSource struct {
+	ranges map[string][]string
+}
+
+// NewEmbeddedSource loads the breached password hashes bundled with the service.
+func NewEmbeddedSource() (*embeddedSource, error) {
+	data, err := breachedData.ReadFile("data/breached_sha1.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := map[string][]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefix, suffix, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		ranges[prefix] = append(ranges[prefix], suffix)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &embeddedSource{ranges: ranges}, nil
+}
+
+func (s *embeddedSource) Range(prefix string) ([]string, error) {
+	return s.ranges[prefix], nil
+}
+
+type BreachChecker struct {
+	source RangeSource
+}
+
+func NewBreachChecker(source RangeSource) *BreachChecker {
+	return &BreachChecker{
+		source: source,
+	}
+}
+
+// IsBreached reports whether password is in the breached password list.
+func (c *BreachChecker) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := c.source.Range(hash[:5])
+	if err != nil {
+		return false, err
+	}
+	for _, suffix := range suffixes {
+		if suffix == hash[5:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,41 @@
+package passwords
+
+import "errors"
+
+// Validator applies the password policy and the breached password check.
+type Validator struct {
+	policy Policy
+	breach *BreachChecker
+}
+
+func NewValidator(policy Policy, breach *BreachChecker) *Validator {
+	return &Validator{
+		policy: policy,
+		breach: breach,
+	}
+}
+
+func (v *Validator) Validate(password, username, email string) error {
+	if err := v.policy.Validate(password, username, email); err != nil {
+		return err
+	}
+
+	breached, err := v.breach.IsBreached(password)
+	if err != nil {
+		return err
+	}
+	if breached {
+		return ErrBreached
+	}
+	return nil
+}
+
+// IsRejection reports whether err means the password itself was not accepted.
+func IsRejection(err error) bool {
+	for _, rejection := range []error{ErrTooShort, ErrTooLong, ErrTooWeak, ErrMatchesAccount, ErrBreached} {
+		if errors.Is(err, rejection) {
+			return true
+		}
+	}
+	return false
+}
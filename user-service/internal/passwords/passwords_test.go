@@ -0,0 +1,96 @@
+package passwords
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	tc := []struct {
+		name     string
+		password string
+		err      error
+	}{
+		{
+			name:     "strong password",
+			password: "correct-Horse-battery-42",
+			err:      nil,
+		},
+		{
+			name:     "too short",
+			password: "Ab1!",
+			err:      ErrTooShort,
+		},
+		{
+			name:     "repeated characters",
+			password: "aaaaaaaaaaaaaaaa",
+			err:      ErrTooWeak,
+		},
+		{
+			name:     "contains username",
+			password: "xX-Gopher2024-Xx",
+			err:      ErrMatchesAccount,
+		},
+		{
+			name:     "is the email",
+			password: "gopher@example.com",
+			err:      ErrMatchesAccount,
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DefaultPolicy.Validate(tt.password, "gopher", "gopher@example.com")
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestBreachChecker(t *testing.T) {
+	source, err := NewEmbeddedSource()
+	if err != nil {
+		t.Fatalf("error loading breached passwords: %v", err)
+	}
+	checker := NewBreachChecker(source)
+
+	tc := []struct {
+		password string
+		breached bool
+	}{
+		{password: "password123", breached: true},
+		{password: "Qwerty123!", breached: true},
+		{password: "correct-Horse-battery-42", breached: false},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.password, func(t *testing.T) {
+			breached, err := checker.IsBreached(tt.password)
+			if err != nil {
+				t.Fatalf("error checking password: %v", err)
+			}
+			if breached != tt.breached {
+				t.Errorf("expected breached to be %v, got %v", tt.breached, breached)
+			}
+		})
+	}
+}
+
+func TestValidatorRejectsBreached(t *testing.T) {
+	source, err := NewEmbeddedSource()
+	if err != nil {
+		t.Fatalf("error loading breached passwords: %v", err)
+	}
+	validator := NewValidator(DefaultPolicy, NewBreachChecker(source))
+
+	// long and varied enough for the policy, so only the breach check stops them
+	for _, password := range []string{"1qaz!QAZ2wsx", "Pa$$w0rd123", "Summer2024!"} {
+		if err = DefaultPolicy.Validate(password, "gopher", "gopher@example.com"); err != nil {
+			t.Fatalf("%s does not pass the policy: %v", password, err)
+		}
+		if err = validator.Validate(password, "gopher", "gopher@example.com"); !errors.Is(err, ErrBreached) {
+			t.Errorf("%s: expected %v, got %v", password, ErrBreached, err)
+		}
+	}
+}
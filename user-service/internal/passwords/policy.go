@@ -0,0 +1,108 @@
+package passwords
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrTooShort       = errors.New("password is too short")
+	ErrTooLong        = errors.New("password is too long")
+	ErrTooWeak        = errors.New("password is too easy to guess")
+	ErrMatchesAccount = errors.New("password must not contain your username or email")
+	ErrBreached       = errors.New("password has appeared in a data breach, choose a different one")
+)
+
+// Policy describes which passwords are acceptable.
+type Policy struct {
+	MinLength      int
+	MaxLength      int
+	MinEntropyBits float64
+}
+
+var DefaultPolicy = Policy{
+	MinLength:      10,
+	MaxLength:      128,
+	MinEntropyBits: 45,
+}
+
+// Validate checks password against the policy. username and email are the
+// account's own identifiers, which must not be used as or within the password.
+func (p Policy) Validate(password, username, email string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrTooShort, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("%w: use at most %d characters", ErrTooLong, p.MaxLength)
+	}
+
+	lowered := strings.ToLower(password)
+	localPart, _, _ := strings.Cut(strings.ToLower(email), "@")
+	for _, identifier := range []string{strings.ToLower(username), strings.ToLower(email), localPart} {
+		if len(identifier) >= 3 && strings.Contains(lowered, identifier) {
+			return ErrMatchesAccount
+		}
+	}
+
+	if EstimateEntropy(password) < p.MinEntropyBits {
+		return ErrTooWeak
+	}
+	return nil
+}
+
+// EstimateEntropy gives a rough number of bits of entropy of password based on
+// the character classes it uses. Repeated characters count half, so padding a
+// short password with the same character does not make it pass.
+func EstimateEntropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	seen := map[rune]bool{}
+	var effectiveLength float64
+
+	for _, r := range password {
+		switch {
+		case r < unicode.MaxASCII && unicode.IsLower(r):
+			lower = true
+		case r < unicode.MaxASCII && unicode.IsUpper(r):
+			upper = true
+		case r < unicode.MaxASCII && unicode.IsDigit(r):
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+
+		if seen[r] {
+			effectiveLength += 0.5
+		} else {
+			effectiveLength++
+			seen[r] = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if other {
+		pool += 100
+	}
+	if pool == 0 {
+		return 0
+	}
+	return effectiveLength * math.Log2(float64(pool))
+}
@@ -0,0 +1,171 @@
+package apps
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+var ErrAppNameTaken = errors.New("an app with this name already exists")
+
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewAppsRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *repository) InsertApp(in *domain.DeveloperApp) error {
+	query := `
+		INSERT INTO developer_apps (owner_id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+
+	err := repo.db.QueryRow(context.Background(), query, in.OwnerID, in.Name, in.Description).
+		Scan(&in.ID, &in.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrAppNameTaken
+		}
+		repo.logger.Errorw("Failed to insert developer app", "ownerID", in.OwnerID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (repo *repository) GetApp(id int) (*domain.DeveloperApp, error) {
+	query := `SELECT id, owner_id, name, description, created_at FROM developer_apps WHERE id = $1`
+
+	var app domain.DeveloperApp
+	err := repo.db.QueryRow(context.Background(), query, id).
+		Scan(&app.ID, &app.OwnerID, &app.Name, &app.Description, &app.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrRecordNotFound
+		}
+		repo.logger.Errorw("Failed to get developer app", "appID", id, "error", err)
+		return nil, err
+	}
+	return &app, nil
+}
+
+func (repo *repository) ListApps(ownerID int) ([]*domain.DeveloperApp, error) {
+	query := `
+		SELECT id, owner_id, name, description, created_at
+		FROM developer_apps
+		WHERE owner_id = $1
+		ORDER BY id`
+
+	rows, err := repo.db.Query(context.Background(), query, ownerID)
+	if err != nil {
+		repo.logger.Errorw("Failed to list developer apps", "ownerID", ownerID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	apps := []*domain.DeveloperApp{}
+	for rows.Next() {
+		var app domain.DeveloperApp
+		if err = rows.Scan(&app.ID, &app.OwnerID, &app.Name, &app.Description, &app.CreatedAt); err != nil {
+			repo.logger.Errorw("Failed to scan developer app", "error", err)
+			return nil, err
+		}
+		apps = append(apps, &app)
+	}
+	return apps, rows.Err()
+}
+
+func (repo *repository) InsertKey(in *domain.APIKey) error {
+	query := `
+		INSERT INTO api_keys (app_id, prefix, key_hash, scopes, rate_limit)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	args := []interface{}{in.AppID, in.Prefix, in.Hash, in.Scopes, in.RateLimit}
+	err := repo.db.QueryRow(context.Background(), query, args...).Scan(&in.ID, &in.CreatedAt)
+	if err != nil {
+		repo.logger.Errorw("Failed to insert api key", "appID", in.AppID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (repo *repository) ListKeys(appID int) ([]*domain.APIKey, error) {
+	query := `
+		SELECT id, app_id, prefix, scopes, rate_limit, revoked_at, created_at
+		FROM api_keys
+		WHERE app_id = $1
+		ORDER BY id`
+
+	rows, err := repo.db.Query(context.Background(), query, appID)
+	if err != nil {
+		repo.logger.Errorw("Failed to list api keys", "appID", appID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+	for rows.Next() {
+		var key domain.APIKey
+		err = rows.Scan(&key.ID, &key.AppID, &key.Prefix, &key.Scopes, &key.RateLimit, &key.RevokedAt, &key.CreatedAt)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan api key", "error", err)
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+	return keys, rows.Err()
+}
+
+// GetKeyByHash returns an API key together with the app it belongs to.
+func (repo *repository) GetKeyByHash(hash string) (*domain.APIKey, *domain.DeveloperApp, error) {
+	query := `
+		SELECT k.id, k.app_id, k.prefix, k.scopes, k.rate_limit, k.revoked_at, k.created_at,
+		       a.id, a.owner_id, a.name, a.description, a.created_at
+		FROM api_keys k
+		JOIN developer_apps a ON a.id = k.app_id
+		WHERE k.key_hash = $1`
+
+	var key domain.APIKey
+	var app domain.DeveloperApp
+	err := repo.db.QueryRow(context.Background(), query, hash).Scan(
+		&key.ID, &key.AppID, &key.Prefix, &key.Scopes, &key.RateLimit, &key.RevokedAt, &key.CreatedAt,
+		&app.ID, &app.OwnerID, &app.Name, &app.Description, &app.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, domain.ErrRecordNotFound
+		}
+		repo.logger.Errorw("Failed to get api key", "error", err)
+		return nil, nil, err
+	}
+	return &key, &app, nil
+}
+
+// RevokeKey marks a key of the app as revoked. Revoking a key twice is not an error.
+func (repo *repository) RevokeKey(appID, keyID int) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1 AND app_id = $2`
+
+	result, err := repo.db.Exec(context.Background(), query, keyID, appID)
+	if err != nil {
+		repo.logger.Errorw("Failed to revoke api key", "keyID", keyID, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package attempts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"time"
+)
+
+type repository struct {
+	redis  *redis.Client
+	logger *zap.SugaredLogger
+}
+
+func NewAttemptRepo(redis *redis.Client, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+// RegisterFailure bumps the failure counter for key and returns the new count.
+// The counter expires window after the first failure was recorded.
+func (repo *repository) RegisterFailure(key string, window time.Duration) (int64, error) {
+	ctx := context.Background()
+	redisKey := fmt.Sprintf("auth_failures:%s", key)
+
+	count, err := repo.redis.Incr(ctx, redisKey).Result()
+	if err != nil {
+		repo.logger.Errorw("failed to register auth failure", "key", key, "error", err)
+		return 0, err
+	}
+	if count == 1 {
+		if err = repo.redis.Expire(ctx, redisKey, window).Err(); err != nil {
+			repo.logger.Errorw("failed to set auth failure window", "key", key, "error", err)
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+func (repo *repository) ResetFailures(key string) error {
+	redisKey := fmt.Sprintf("auth_failures:%s", key)
+	err := repo.redis.Del(context.Background(), redisKey).Err()
+	if err != nil {
+		repo.logger.Errorw("failed to reset auth failures", "key", key, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (repo *repository) Block(key string, ttl time.Duration) error {
+	redisKey := fmt.Sprintf("auth_block:%s", key)
+	err := repo.redis.Set(context.Background(), redisKey, 1, ttl).Err()
+	if err != nil {
+		repo.logger.Errorw("failed to block", "key", key, "error", err)
+		return err
+	}
+	return nil
+}
+
+// BlockedFor returns how long key stays blocked, or zero if it is not blocked.
+func (repo *repository) BlockedFor(key string) (time.Duration, error) {
+	redisKey := fmt.Sprintf("auth_block:%s", key)
+	ttl, err := repo.redis.PTTL(context.Background(), redisKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		repo.logger.Errorw("failed to check block", "key", key, "error", err)
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
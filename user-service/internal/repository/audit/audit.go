@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewAuditRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *repository) Insert(in *domain.AuditRecord) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_type, target_id, details, service, ip)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		RETURNING id, created_at`
+
+	details := in.Details
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	args := []interface{}{in.ActorID, in.Action, in.TargetType, in.TargetID, details, in.Service, in.IP}
+	err := repo.db.QueryRow(context.Background(), query, args...).Scan(&in.ID, &in.CreatedAt)
+	if err != nil {
+		repo.logger.Errorw("Failed to insert audit record", "action", in.Action, "error", err)
+		return err
+	}
+	return nil
+}
+
+// List returns up to limit records older than beforeID, newest first. A
+// beforeID of zero starts from the newest record.
+func (repo *repository) List(limit int, beforeID int64) ([]*domain.AuditRecord, error) {
+	query := `
+		SELECT id, actor_id, action, target_type, target_id, details, service, COALESCE(ip, ''), created_at
+		FROM audit_log
+		WHERE $2 = 0 OR id < $2
+		ORDER BY id DESC
+		LIMIT $1`
+
+	rows, err := repo.db.Query(context.Background(), query, limit, beforeID)
+	if err != nil {
+		repo.logger.Errorw("Failed to list audit records", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []*domain.AuditRecord{}
+	for rows.Next() {
+		var record domain.AuditRecord
+		err = rows.Scan(&record.ID, &record.ActorID, &record.Action, &record.TargetType, &record.TargetID,
+			&record.Details, &record.Service, &record.IP, &record.CreatedAt)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan audit record", "error", err)
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
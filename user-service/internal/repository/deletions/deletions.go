@@ -0,0 +1,171 @@
+package deletions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+const deletionColumns = `id, user_id, status, step, attempts, last_error, requested_at, purge_after, next_attempt_at, completed_at`
+
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewDeletionsRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Start deactivates the user and records a pending deletion in one
+// transaction. If a deletion is already running for the user, that one is
+// returned instead.
+func (repo *repository) Start(userID int, purgeAfter time.Time) (*domain.AccountDeletion, error) {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `UPDATE users SET deactivated_at = COALESCE(deactivated_at, NOW()) WHERE id = $1`, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to deactivate user", "userID", userID, "error", err)
+		return nil, err
+	}
+	if result.RowsAffected() == 0 {
+		return nil, domain.ErrRecordNotFound
+	}
+
+	insert := `
+		INSERT INTO account_deletions (user_id, status, purge_after, next_attempt_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (user_id) WHERE status IN ('pending', 'purging') DO NOTHING`
+	if _, err = tx.Exec(ctx, insert, userID, domain.DeletionPending, purgeAfter); err != nil {
+		repo.logger.Errorw("Failed to record account deletion", "userID", userID, "error", err)
+		return nil, err
+	}
+
+	deletion, err := scanDeletion(tx.QueryRow(ctx, `
+		SELECT `+deletionColumns+` FROM account_deletions
+		WHERE user_id = $1 AND status IN ('pending', 'purging')`, userID))
+	if err != nil {
+		return nil, err
+	}
+	return deletion, tx.Commit(ctx)
+}
+
+// GetActive returns the pending or purging deletion of the user.
+func (repo *repository) GetActive(userID int) (*domain.AccountDeletion, error) {
+	query := `
+		SELECT ` + deletionColumns + ` FROM account_deletions
+		WHERE user_id = $1 AND status IN ('pending', 'purging')`
+	return scanDeletion(repo.db.QueryRow(context.Background(), query, userID))
+}
+
+// Cancel stops the pending deletion of the user, if any, and reactivates the
+// user. Once the purge has started the account can not be reactivated and
+// ErrRecordNotFound is returned.
+func (repo *repository) Cancel(userID int, reason string) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the pending saga keeps runners from claiming it meanwhile
+	_, err = tx.Exec(ctx, `
+		UPDATE account_deletions
+		SET status = $2, last_error = $3, completed_at = NOW()
+		WHERE user_id = $1 AND status = $4`, userID, domain.DeletionCancelled, reason, domain.DeletionPending)
+	if err != nil {
+		repo.logger.Errorw("Failed to cancel account deletion", "userID", userID, "error", err)
+		return err
+	}
+
+	result, err := tx.Exec(ctx, `
+		UPDATE users SET deactivated_at = NULL
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM account_deletions WHERE user_id = $1 AND status = $2)`,
+		userID, domain.DeletionPurging)
+	if err != nil {
+		repo.logger.Errorw("Failed to reactivate user", "userID", userID, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return tx.Commit(ctx)
+}
+
+// ClaimDue picks one deletion whose purge is due and leases it until
+// leaseUntil, so concurrent runners never work on the same saga. A lease that
+// runs out without the saga being updated makes it due again.
+func (repo *repository) ClaimDue(leaseUntil time.Time) (*domain.AccountDeletion, error) {
+	query := `
+		UPDATE account_deletions
+		SET status = $1, next_attempt_at = $2
+		WHERE id = (SELECT id FROM account_deletions
+		            WHERE status IN ('pending', 'purging') AND next_attempt_at <= NOW()
+		            ORDER BY next_attempt_at
+		            LIMIT 1 FOR UPDATE SKIP LOCKED)
+		RETURNING ` + deletionColumns
+	return scanDeletion(repo.db.QueryRow(context.Background(), query, domain.DeletionPurging, leaseUntil))
+}
+
+// CompleteStep records that a purge step finished.
+func (repo *repository) CompleteStep(id int, step string) error {
+	_, err := repo.db.Exec(context.Background(), `UPDATE account_deletions SET step = $2 WHERE id = $1`, id, step)
+	if err != nil {
+		repo.logger.Errorw("Failed to record purge step", "deletionID", id, "step", step, "error", err)
+	}
+	return err
+}
+
+// Fail records a failed purge attempt. The saga is retried at retryAt, or
+// marked failed if giveUp is set.
+func (repo *repository) Fail(id int, reason string, retryAt time.Time, giveUp bool) error {
+	status := domain.DeletionPurging
+	if giveUp {
+		status = domain.DeletionFailed
+	}
+	query := `
+		UPDATE account_deletions
+		SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4
+		WHERE id = $1`
+	_, err := repo.db.Exec(context.Background(), query, id, status, reason, retryAt)
+	if err != nil {
+		repo.logger.Errorw("Failed to record purge failure", "deletionID", id, "error", err)
+	}
+	return err
+}
+
+func (repo *repository) Complete(id int) error {
+	query := `UPDATE account_deletions SET status = $2, last_error = '', completed_at = NOW() WHERE id = $1`
+	_, err := repo.db.Exec(context.Background(), query, id, domain.DeletionCompleted)
+	if err != nil {
+		repo.logger.Errorw("Failed to complete account deletion", "deletionID", id, "error", err)
+	}
+	return err
+}
+
+func scanDeletion(row pgx.Row) (*domain.AccountDeletion, error) {
+	var d domain.AccountDeletion
+	err := row.Scan(&d.ID, &d.UserID, &d.Status, &d.Step, &d.Attempts, &d.LastError,
+		&d.RequestedAt, &d.PurgeAfter, &d.NextAttemptAt, &d.CompletedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Events for a user are appended to the user's Redis stream, which keeps the
+// recent ones for clients that resume, and published on the channel
+// tweet-service listens to, which streams them to the user.
+const (
+	channel    = "events"
+	userMaxLen = 500
+)
+
+// event is the message tweet-service expects on the channel.
+type event struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	UserID int             `json:"user_id"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type repository struct {
+	redis  *redis.Client
+	logger *zap.SugaredLogger
+}
+
+func NewEventsRepo(redis *redis.Client, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+// PublishToUser streams an event of the type to the user's open clients.
+func (repo *repository) PublishToUser(userID int, eventType string, data interface{}) error {
+	ctx := context.Background()
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	id, err := repo.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: fmt.Sprintf("events:user:%d", userID),
+		MaxLen: userMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"type": eventType, "data": string(payload)},
+	}).Result()
+	if err != nil {
+		repo.logger.Errorw("failed to store event", "userID", userID, "type", eventType, "error", err)
+		return err
+	}
+
+	message, err := json.Marshal(event{ID: id, Type: eventType, UserID: userID, Data: payload})
+	if err != nil {
+		return err
+	}
+	if err = repo.redis.Publish(ctx, channel, message).Err(); err != nil {
+		repo.logger.Errorw("failed to publish event", "userID", userID, "type", eventType, "error", err)
+		return err
+	}
+	return nil
+}
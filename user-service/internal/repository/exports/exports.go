@@ -0,0 +1,150 @@
+package exports
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+const exportColumns = `id, user_id, status, attempts, last_error, blob_key, size_bytes, requested_at, next_attempt_at, completed_at, expires_at`
+
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewExportsRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create records a pending export for the user. If one is already pending or
+// being built, that one is returned instead.
+func (repo *repository) Create(userID int) (*domain.DataExport, error) {
+	ctx := context.Background()
+	insert := `
+		INSERT INTO data_exports (user_id, status)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) WHERE status IN ('pending', 'building') DO NOTHING`
+	if _, err := repo.db.Exec(ctx, insert, userID, domain.ExportPending); err != nil {
+		repo.logger.Errorw("Failed to create data export", "userID", userID, "error", err)
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + exportColumns + ` FROM data_exports
+		WHERE user_id = $1 AND status IN ('pending', 'building')`
+	return scanExport(repo.db.QueryRow(ctx, query, userID))
+}
+
+func (repo *repository) Get(id int) (*domain.DataExport, error) {
+	query := `SELECT ` + exportColumns + ` FROM data_exports WHERE id = $1`
+	return scanExport(repo.db.QueryRow(context.Background(), query, id))
+}
+
+// GetLatest returns the user's most recently requested export.
+func (repo *repository) GetLatest(userID int) (*domain.DataExport, error) {
+	query := `SELECT ` + exportColumns + ` FROM data_exports WHERE user_id = $1 ORDER BY id DESC LIMIT 1`
+	return scanExport(repo.db.QueryRow(context.Background(), query, userID))
+}
+
+// ClaimDue picks one export that is due to be built and leases it until
+// leaseUntil, so concurrent builders never work on the same export.
+func (repo *repository) ClaimDue(leaseUntil time.Time) (*domain.DataExport, error) {
+	query := `
+		UPDATE data_exports
+		SET status = $1, next_attempt_at = $2
+		WHERE id = (SELECT id FROM data_exports
+		            WHERE status IN ('pending', 'building') AND next_attempt_at <= NOW()
+		            ORDER BY next_attempt_at
+		            LIMIT 1 FOR UPDATE SKIP LOCKED)
+		RETURNING ` + exportColumns
+	return scanExport(repo.db.QueryRow(context.Background(), query, domain.ExportBuilding, leaseUntil))
+}
+
+// Complete marks the export ready to download until expiresAt.
+func (repo *repository) Complete(id int, blobKey string, size int64, expiresAt time.Time) error {
+	query := `
+		UPDATE data_exports
+		SET status = $2, blob_key = $3, size_bytes = $4, last_error = '', completed_at = NOW(), expires_at = $5
+		WHERE id = $1`
+	_, err := repo.db.Exec(context.Background(), query, id, domain.ExportReady, blobKey, size, expiresAt)
+	if err != nil {
+		repo.logger.Errorw("Failed to complete data export", "exportID", id, "error", err)
+	}
+	return err
+}
+
+// Fail records a failed build. The export is retried at retryAt, or marked
+// failed if giveUp is set.
+func (repo *repository) Fail(id int, reason string, retryAt time.Time, giveUp bool) error {
+	status := domain.ExportBuilding
+	if giveUp {
+		status = domain.ExportFailed
+	}
+	query := `
+		UPDATE data_exports
+		SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4
+		WHERE id = $1`
+	_, err := repo.db.Exec(context.Background(), query, id, status, reason, retryAt)
+	if err != nil {
+		repo.logger.Errorw("Failed to record data export failure", "exportID", id, "error", err)
+	}
+	return err
+}
+
+// ListExpired returns up to limit ready exports whose archive has expired.
+func (repo *repository) ListExpired(limit int) ([]*domain.DataExport, error) {
+	query := `
+		SELECT ` + exportColumns + ` FROM data_exports
+		WHERE status = 'ready' AND expires_at <= NOW()
+		ORDER BY expires_at
+		LIMIT $1`
+
+	rows, err := repo.db.Query(context.Background(), query, limit)
+	if err != nil {
+		repo.logger.Errorw("Failed to list expired data exports", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	exports := []*domain.DataExport{}
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+	return exports, rows.Err()
+}
+
+// MarkExpired records that the archive of the export was removed.
+func (repo *repository) MarkExpired(id int) error {
+	query := `UPDATE data_exports SET status = $2, blob_key = '' WHERE id = $1`
+	_, err := repo.db.Exec(context.Background(), query, id, domain.ExportExpired)
+	if err != nil {
+		repo.logger.Errorw("Failed to expire data export", "exportID", id, "error", err)
+	}
+	return err
+}
+
+func scanExport(row pgx.Row) (*domain.DataExport, error) {
+	var e domain.DataExport
+	err := row.Scan(&e.ID, &e.UserID, &e.Status, &e.Attempts, &e.LastError, &e.BlobKey, &e.SizeBytes,
+		&e.RequestedAt, &e.NextAttemptAt, &e.CompletedAt, &e.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &e, nil
+}
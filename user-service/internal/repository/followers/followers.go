@@ -3,6 +3,8 @@ package followers
 import (
 	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
 	"context"
+	"time"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
@@ -57,10 +59,11 @@ func (repo *repository) IsFollowing(followerID, followedID int) (bool, error) {
 func (repo *repository) GetFollowers(userID int) ([]*domain.User, error) {
 	var users []*domain.User
 	query := `
-		SELECT u.id, u.first_name, u.last_name, u.email, u.username
+		SELECT u.id, u.first_name, u.last_name, u.username, u.bio, u.location, u.website,
+		       u.avatar_key, u.banner_key, u.created_at, u.protected
 		FROM users u
 		JOIN followers f ON u.id = f.follower_id
-		WHERE f.followed_id = $1`
+		WHERE f.followed_id = $1 AND u.suspended_at IS NULL AND u.deactivated_at IS NULL`
 
 	rows, err := repo.db.Query(context.Background(), query, userID)
 	if err != nil {
@@ -71,7 +74,8 @@ func (repo *repository) GetFollowers(userID int) ([]*domain.User, error) {
 
 	for rows.Next() {
 		var user domain.User
-		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Username)
+		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username,
+			&user.Bio, &user.Location, &user.Website, &user.AvatarKey, &user.BannerKey, &user.CreatedAt, &user.Protected)
 		if err != nil {
 			repo.logger.Errorw("Failed to scan row", "error", err)
 			return nil, err
@@ -84,10 +88,11 @@ func (repo *repository) GetFollowers(userID int) ([]*domain.User, error) {
 func (repo *repository) GetFollowing(userID int) ([]*domain.User, error) {
 	var users []*domain.User
 	query := `
-		SELECT u.id, u.first_name, u.last_name, u.email, u.username
+		SELECT u.id, u.first_name, u.last_name, u.username, u.bio, u.location, u.website,
+		       u.avatar_key, u.banner_key, u.created_at, u.protected
 		FROM users u
 		JOIN followers f ON u.id = f.followed_id
-		WHERE f.follower_id = $1`
+		WHERE f.follower_id = $1 AND u.suspended_at IS NULL AND u.deactivated_at IS NULL`
 
 	rows, err := repo.db.Query(context.Background(), query, userID)
 	if err != nil {
@@ -98,7 +103,8 @@ func (repo *repository) GetFollowing(userID int) ([]*domain.User, error) {
 
 	for rows.Next() {
 		var user domain.User
-		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Username)
+		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username,
+			&user.Bio, &user.Location, &user.Website, &user.AvatarKey, &user.BannerKey, &user.CreatedAt, &user.Protected)
 		if err != nil {
 			repo.logger.Errorw("Failed to scan row", "error", err)
 			return nil, err
@@ -107,3 +113,254 @@ func (repo *repository) GetFollowing(userID int) ([]*domain.User, error) {
 	}
 	return users, nil
 }
+
+// ListFollowers returns up to limit followers of the user, most recent follow
+// first, starting after the cursor. Each entry tells whether viewerID follows
+// the follower; a viewerID of 0 follows nobody.
+func (repo *repository) ListFollowers(userID, viewerID int, after *domain.FollowCursor, limit int) ([]*domain.FollowEntry, error) {
+	query := `
+		SELECT u.id, u.first_name, u.last_name, u.username, u.bio, u.location, u.website,
+		       u.avatar_key, u.banner_key, u.created_at, u.protected, u.followers_count, u.following_count,
+		       f.id, f.created_at, v.id IS NOT NULL
+		FROM followers f
+		JOIN users u ON u.id = f.follower_id
+		LEFT JOIN followers v ON v.follower_id = $2 AND v.followed_id = f.follower_id
+		WHERE f.followed_id = $1 AND u.suspended_at IS NULL AND u.deactivated_at IS NULL
+		  AND ($3::timestamp IS NULL OR (f.created_at, f.id) < ($3, $4))
+		ORDER BY f.created_at DESC, f.id DESC
+		LIMIT $5`
+	return repo.listFollowEntries(query, userID, viewerID, after, limit)
+}
+
+// ListFollowing returns up to limit users the user follows, most recent follow
+// first, starting after the cursor. Each entry tells whether viewerID follows
+// the user as well.
+func (repo *repository) ListFollowing(userID, viewerID int, after *domain.FollowCursor, limit int) ([]*domain.FollowEntry, error) {
+	query := `
+		SELECT u.id, u.first_name, u.last_name, u.username, u.bio, u.location, u.website,
+		       u.avatar_key, u.banner_key, u.created_at, u.protected, u.followers_count, u.following_count,
+		       f.id, f.created_at, v.id IS NOT NULL
+		FROM followers f
+		JOIN users u ON u.id = f.followed_id
+		LEFT JOIN followers v ON v.follower_id = $2 AND v.followed_id = f.followed_id
+		WHERE f.follower_id = $1 AND u.suspended_at IS NULL AND u.deactivated_at IS NULL
+		  AND ($3::timestamp IS NULL OR (f.created_at, f.id) < ($3, $4))
+		ORDER BY f.created_at DESC, f.id DESC
+		LIMIT $5`
+	return repo.listFollowEntries(query, userID, viewerID, after, limit)
+}
+
+func (repo *repository) listFollowEntries(query string, userID, viewerID int, after *domain.FollowCursor, limit int) ([]*domain.FollowEntry, error) {
+	var afterTime *time.Time
+	afterID := 0
+	if after != nil {
+		afterTime, afterID = &after.FollowedAt, after.EdgeID
+	}
+
+	rows, err := repo.db.Query(context.Background(), query, userID, viewerID, afterTime, afterID, limit)
+	if err != nil {
+		repo.logger.Errorw("Failed to list follows", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*domain.FollowEntry{}
+	for rows.Next() {
+		var user domain.User
+		var entry domain.FollowEntry
+		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Bio, &user.Location,
+			&user.Website, &user.AvatarKey, &user.BannerKey, &user.CreatedAt, &user.Protected,
+			&user.FollowersCount, &user.FollowingCount, &entry.EdgeID, &entry.FollowedAt, &entry.FollowedByUser)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan row", "error", err)
+			return nil, err
+		}
+		entry.User = &user
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+// DeleteUserEdges removes every follow, follow request, block and mute from or
+// to the user.
+func (repo *repository) DeleteUserEdges(userID int) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, `DELETE FROM followers WHERE follower_id = $1 OR followed_id = $1`, userID); err != nil {
+		repo.logger.Errorw("Failed to delete follower edges", "userID", userID, "error", err)
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM follow_requests WHERE requester_id = $1 OR target_id = $1`, userID); err != nil {
+		repo.logger.Errorw("Failed to delete follow requests", "userID", userID, "error", err)
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM user_blocks WHERE blocker_id = $1 OR blocked_id = $1`, userID); err != nil {
+		repo.logger.Errorw("Failed to delete blocks", "userID", userID, "error", err)
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM user_mutes WHERE muter_id = $1 OR muted_id = $1`, userID); err != nil {
+		repo.logger.Errorw("Failed to delete mutes", "userID", userID, "error", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListProtectedFollowing returns the protected users the user follows, whose
+// tweets the user may see.
+func (repo *repository) ListProtectedFollowing(userID int) ([]int, error) {
+	query := `
+		SELECT f.followed_id
+		FROM followers f
+		JOIN users u ON u.id = f.followed_id
+		WHERE f.follower_id = $1 AND u.protected`
+	return repo.listIDs(query, userID)
+}
+
+// RequestFollow records a request to follow a protected user. Asking again
+// keeps the original request.
+func (repo *repository) RequestFollow(requesterID, targetID int) error {
+	query := `
+		INSERT INTO follow_requests (requester_id, target_id) VALUES ($1, $2)
+		ON CONFLICT (requester_id, target_id) DO NOTHING`
+	_, err := repo.db.Exec(context.Background(), query, requesterID, targetID)
+	if err != nil {
+		repo.logger.Errorw("Failed to request follow", "requesterID", requesterID, "targetID", targetID, "error", err)
+	}
+	return err
+}
+
+func (repo *repository) HasFollowRequest(requesterID, targetID int) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM follow_requests WHERE requester_id = $1 AND target_id = $2)`
+	var exists bool
+	err := repo.db.QueryRow(context.Background(), query, requesterID, targetID).Scan(&exists)
+	if err != nil {
+		repo.logger.Errorw("Failed to check follow request", "requesterID", requesterID, "targetID", targetID, "error", err)
+		return false, err
+	}
+	return exists, nil
+}
+
+// ListIncomingRequests returns the pending requests to follow the user,
+// oldest first.
+func (repo *repository) ListIncomingRequests(targetID int) ([]*domain.FollowRequest, error) {
+	query := `
+		SELECT u.id, u.first_name, u.last_name, u.username, u.bio, u.location, u.website,
+		       u.avatar_key, u.banner_key, u.created_at, u.protected, r.created_at
+		FROM follow_requests r
+		JOIN users u ON u.id = r.requester_id
+		WHERE r.target_id = $1 AND u.suspended_at IS NULL AND u.deactivated_at IS NULL
+		ORDER BY r.created_at`
+	return repo.listRequests(query, targetID)
+}
+
+// ListOutgoingRequests returns the user's pending requests to follow others,
+// oldest first.
+func (repo *repository) ListOutgoingRequests(requesterID int) ([]*domain.FollowRequest, error) {
+	query := `
+		SELECT u.id, u.first_name, u.last_name, u.username, u.bio, u.location, u.website,
+		       u.avatar_key, u.banner_key, u.created_at, u.protected, r.created_at
+		FROM follow_requests r
+		JOIN users u ON u.id = r.target_id
+		WHERE r.requester_id = $1 AND u.suspended_at IS NULL AND u.deactivated_at IS NULL
+		ORDER BY r.created_at`
+	return repo.listRequests(query, requesterID)
+}
+
+func (repo *repository) listRequests(query string, userID int) ([]*domain.FollowRequest, error) {
+	rows, err := repo.db.Query(context.Background(), query, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to list follow requests", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*domain.FollowRequest{}
+	for rows.Next() {
+		var user domain.User
+		var request domain.FollowRequest
+		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Bio, &user.Location,
+			&user.Website, &user.AvatarKey, &user.BannerKey, &user.CreatedAt, &user.Protected, &request.RequestedAt)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan follow request", "error", err)
+			return nil, err
+		}
+		request.User = &user
+		requests = append(requests, &request)
+	}
+	return requests, rows.Err()
+}
+
+// ApproveFollowRequest turns a pending request into a follow. It returns
+// ErrRecordNotFound if there is no such request.
+func (repo *repository) ApproveFollowRequest(requesterID, targetID int) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2`, requesterID, targetID)
+	if err != nil {
+		repo.logger.Errorw("Failed to approve follow request", "requesterID", requesterID, "targetID", targetID, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+
+	insert := `
+		INSERT INTO followers (follower_id, followed_id)
+		SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM followers WHERE follower_id = $1 AND followed_id = $2)`
+	if _, err = tx.Exec(ctx, insert, requesterID, targetID); err != nil {
+		repo.logger.Errorw("Failed to approve follow request", "requesterID", requesterID, "targetID", targetID, "error", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ApproveAllFollowRequests turns every pending request to follow the user
+// into a follow.
+func (repo *repository) ApproveAllFollowRequests(targetID int) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	insert := `
+		INSERT INTO followers (follower_id, followed_id)
+		SELECT r.requester_id, r.target_id FROM follow_requests r
+		WHERE r.target_id = $1
+		  AND NOT EXISTS (SELECT 1 FROM followers f WHERE f.follower_id = r.requester_id AND f.followed_id = r.target_id)`
+	if _, err = tx.Exec(ctx, insert, targetID); err != nil {
+		repo.logger.Errorw("Failed to approve follow requests", "targetID", targetID, "error", err)
+		return err
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM follow_requests WHERE target_id = $1`, targetID); err != nil {
+		repo.logger.Errorw("Failed to approve follow requests", "targetID", targetID, "error", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteFollowRequest rejects or cancels a pending request. It returns
+// ErrRecordNotFound if there is no such request.
+func (repo *repository) DeleteFollowRequest(requesterID, targetID int) error {
+	query := `DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2`
+	result, err := repo.db.Exec(context.Background(), query, requesterID, targetID)
+	if err != nil {
+		repo.logger.Errorw("Failed to delete follow request", "requesterID", requesterID, "targetID", targetID, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package followers
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+)
+
+// Block makes the blocker block the user and removes every follow and follow
+// request between the two. Blocking again keeps the original block.
+func (repo *repository) Block(blockerID, blockedID int) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := []string{
+		`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2)
+		 ON CONFLICT (blocker_id, blocked_id) DO NOTHING`,
+		`DELETE FROM followers
+		 WHERE (follower_id = $1 AND followed_id = $2) OR (follower_id = $2 AND followed_id = $1)`,
+		`DELETE FROM follow_requests
+		 WHERE (requester_id = $1 AND target_id = $2) OR (requester_id = $2 AND target_id = $1)`,
+	}
+	for _, query := range queries {
+		if _, err = tx.Exec(ctx, query, blockerID, blockedID); err != nil {
+			repo.logger.Errorw("Failed to block", "blockerID", blockerID, "blockedID", blockedID, "error", err)
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Unblock lifts a block. It returns ErrRecordNotFound if there is no such block.
+func (repo *repository) Unblock(blockerID, blockedID int) error {
+	return repo.deleteRelation(`DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2`, blockerID, blockedID)
+}
+
+// IsBlocked reports whether either user blocked the other.
+func (repo *repository) IsBlocked(userID, otherID int) (bool, error) {
+	query := `
+		SELECT EXISTS(SELECT 1 FROM user_blocks
+		              WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1))`
+	var exists bool
+	err := repo.db.QueryRow(context.Background(), query, userID, otherID).Scan(&exists)
+	if err != nil {
+		repo.logger.Errorw("Failed to check block", "userID", userID, "otherID", otherID, "error", err)
+		return false, err
+	}
+	return exists, nil
+}
+
+// ListBlocked returns the users the user blocked, most recent first.
+func (repo *repository) ListBlocked(userID int) ([]*domain.User, error) {
+	query := `
+		SELECT u.id, u.first_name, u.last_name, u.email, u.username, u.bio, u.location, u.website,
+		       u.avatar_key, u.banner_key, u.created_at, u.protected
+		FROM users u
+		JOIN user_blocks b ON u.id = b.blocked_id
+		WHERE b.blocker_id = $1
+		ORDER BY b.created_at DESC`
+	return repo.listUsers(query, userID)
+}
+
+// Mute hides the muted user's tweets from the muter's listings. Muting again
+// keeps the original mute.
+func (repo *repository) Mute(muterID, mutedID int) error {
+	query := `
+		INSERT INTO user_mutes (muter_id, muted_id) VALUES ($1, $2)
+		ON CONFLICT (muter_id, muted_id) DO NOTHING`
+	_, err := repo.db.Exec(context.Background(), query, muterID, mutedID)
+	if err != nil {
+		repo.logger.Errorw("Failed to mute", "muterID", muterID, "mutedID", mutedID, "error", err)
+	}
+	return err
+}
+
+// Unmute lifts a mute. It returns ErrRecordNotFound if there is no such mute.
+func (repo *repository) Unmute(muterID, mutedID int) error {
+	return repo.deleteRelation(`DELETE FROM user_mutes WHERE muter_id = $1 AND muted_id = $2`, muterID, mutedID)
+}
+
+// ListMuted returns the users the user muted, most recent first.
+func (repo *repository) ListMuted(userID int) ([]*domain.User, error) {
+	query := `
+		SELECT u.id, u.first_name, u.last_name, u.email, u.username, u.bio, u.location, u.website,
+		       u.avatar_key, u.banner_key, u.created_at, u.protected
+		FROM users u
+		JOIN user_mutes m ON u.id = m.muted_id
+		WHERE m.muter_id = $1
+		ORDER BY m.created_at DESC`
+	return repo.listUsers(query, userID)
+}
+
+// GetRelations returns the users whose tweets are shown to or hidden from the user.
+func (repo *repository) GetRelations(userID int) (*domain.Relations, error) {
+	var relations domain.Relations
+	var err error
+
+	relations.ProtectedFollowing, err = repo.ListProtectedFollowing(userID)
+	if err != nil {
+		return nil, err
+	}
+	relations.Blocked, err = repo.listIDs(`
+		SELECT blocked_id FROM user_blocks WHERE blocker_id = $1
+		UNION
+		SELECT blocker_id FROM user_blocks WHERE blocked_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	relations.Muted, err = repo.listIDs(`SELECT muted_id FROM user_mutes WHERE muter_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &relations, nil
+}
+
+func (repo *repository) deleteRelation(query string, userID, otherID int) error {
+	result, err := repo.db.Exec(context.Background(), query, userID, otherID)
+	if err != nil {
+		repo.logger.Errorw("Failed to delete relation", "userID", userID, "otherID", otherID, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (repo *repository) listUsers(query string, userID int) ([]*domain.User, error) {
+	rows, err := repo.db.Query(context.Background(), query, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to list users", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*domain.User{}
+	for rows.Next() {
+		var user domain.User
+		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Username,
+			&user.Bio, &user.Location, &user.Website, &user.AvatarKey, &user.BannerKey, &user.CreatedAt, &user.Protected)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan row", "error", err)
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+func (repo *repository) listIDs(query string, userID int) ([]int, error) {
+	rows, err := repo.db.Query(context.Background(), query, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to list user ids", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListFriendsOfFriends returns the users followed by the users the user
+// follows, with how many of them follow each one. Users the user follows or
+// is blocked with are left out, as are suspended and deactivated ones.
+func (repo *repository) ListFriendsOfFriends(userID, limit int) (map[int]int, error) {
+	query := `
+		SELECT f2.followed_id, COUNT(*) AS mutual
+		FROM followers f1
+		JOIN followers f2 ON f2.follower_id = f1.followed_id
+		JOIN users u ON u.id = f2.followed_id
+		WHERE f1.follower_id = $1
+		  AND f2.followed_id <> $1
+		  AND u.suspended_at IS NULL AND u.deactivated_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM followers f WHERE f.follower_id = $1 AND f.followed_id = f2.followed_id)
+		  AND NOT EXISTS (SELECT 1 FROM user_blocks b
+		                  WHERE (b.blocker_id = $1 AND b.blocked_id = f2.followed_id)
+		                     OR (b.blocker_id = f2.followed_id AND b.blocked_id = $1))
+		GROUP BY f2.followed_id
+		ORDER BY mutual DESC, f2.followed_id
+		LIMIT $2`
+
+	rows, err := repo.db.Query(context.Background(), query, userID, limit)
+	if err != nil {
+		repo.logger.Errorw("Failed to list friends of friends", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	mutual := make(map[int]int)
+	for rows.Next() {
+		var id, count int
+		if err = rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		mutual[id] = count
+	}
+	return mutual, rows.Err()
+}
+
+// ListUnsuggestable returns the users never to suggest the user to follow:
+// those the user follows and those on either side of a block with the user.
+func (repo *repository) ListUnsuggestable(userID int) ([]int, error) {
+	return repo.listIDs(`
+		SELECT followed_id FROM followers WHERE follower_id = $1
+		UNION
+		SELECT blocked_id FROM user_blocks WHERE blocker_id = $1
+		UNION
+		SELECT blocker_id FROM user_blocks WHERE blocked_id = $1`, userID)
+}
@@ -0,0 +1,53 @@
+package identities
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewIdentitiesRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetUserID returns the user an external identity is linked to.
+func (repo *repository) GetUserID(provider, subject string) (int, error) {
+	query := `SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2`
+
+	var userID int
+	err := repo.db.QueryRow(context.Background(), query, provider, subject).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrRecordNotFound
+		}
+		repo.logger.Errorw("Failed to get identity", "provider", provider, "error", err)
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (repo *repository) Link(in *domain.Identity) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	err := repo.db.QueryRow(context.Background(), query, in.UserID, in.Provider, in.Subject, in.Email).
+		Scan(&in.ID, &in.CreatedAt)
+	if err != nil {
+		repo.logger.Errorw("Failed to link identity", "userID", in.UserID, "provider", in.Provider, "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package messages
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateGroup starts a group named name with its creator as the only member,
+// an admin, and returns its id.
+func (repo *repository) CreateGroup(creatorID int, name string) (int, error) {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	err = tx.QueryRow(ctx, `INSERT INTO conversations (is_group, name) VALUES (TRUE, $1) RETURNING id`, name).Scan(&id)
+	if err != nil {
+		repo.logger.Errorw("Failed to create group", "creatorID", creatorID, "error", err)
+		return 0, err
+	}
+	query := `INSERT INTO conversation_members (conversation_id, user_id, role) VALUES ($1, $2, $3)`
+	if _, err = tx.Exec(ctx, query, id, creatorID, domain.ConversationRoleAdmin); err != nil {
+		repo.logger.Errorw("Failed to add group creator", "conversationID", id, "error", err)
+		return 0, err
+	}
+	return id, tx.Commit(ctx)
+}
+
+func (repo *repository) Rename(conversationID int, name string) error {
+	_, err := repo.db.Exec(context.Background(), `UPDATE conversations SET name = $2 WHERE id = $1`, conversationID, name)
+	if err != nil {
+		repo.logger.Errorw("Failed to rename group", "conversationID", conversationID, "error", err)
+	}
+	return err
+}
+
+// CountSeats returns how many members and pending invites the group has.
+func (repo *repository) CountSeats(conversationID int) (int, error) {
+	query := `
+		SELECT (SELECT COUNT(*) FROM conversation_members WHERE conversation_id = $1)
+		     + (SELECT COUNT(*) FROM conversation_invites WHERE conversation_id = $1)`
+	var count int
+	if err := repo.db.QueryRow(context.Background(), query, conversationID).Scan(&count); err != nil {
+		repo.logger.Errorw("Failed to count group seats", "conversationID", conversationID, "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// Invite invites the user to the group. Inviting again keeps the first invite.
+func (repo *repository) Invite(conversationID, userID, invitedBy int) error {
+	query := `
+		INSERT INTO conversation_invites (conversation_id, user_id, invited_by) VALUES ($1, $2, $3)
+		ON CONFLICT (conversation_id, user_id) DO NOTHING`
+	if _, err := repo.db.Exec(context.Background(), query, conversationID, userID, invitedBy); err != nil {
+		repo.logger.Errorw("Failed to invite to group", "conversationID", conversationID, "userID", userID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// ListInvites returns the pending invites of the user, newest first.
+func (repo *repository) ListInvites(userID int) ([]*domain.ConversationInvite, error) {
+	query := `
+		SELECT i.conversation_id, c.name, i.invited_by, i.created_at
+		FROM conversation_invites i
+		JOIN conversations c ON c.id = i.conversation_id
+		WHERE i.user_id = $1
+		ORDER BY i.created_at DESC`
+	rows, err := repo.db.Query(context.Background(), query, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to list group invites", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []*domain.ConversationInvite{}
+	for rows.Next() {
+		invite := domain.ConversationInvite{UserID: userID}
+		if err = rows.Scan(&invite.ConversationID, &invite.Name, &invite.InvitedBy, &invite.CreatedAt); err != nil {
+			repo.logger.Errorw("Failed to scan group invite", "error", err)
+			return nil, err
+		}
+		invites = append(invites, &invite)
+	}
+	return invites, rows.Err()
+}
+
+// HasInvite reports whether the user has a pending invite to the group.
+func (repo *repository) HasInvite(conversationID, userID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM conversation_invites WHERE conversation_id = $1 AND user_id = $2)`
+	var invited bool
+	if err := repo.db.QueryRow(context.Background(), query, conversationID, userID).Scan(&invited); err != nil {
+		return false, err
+	}
+	return invited, nil
+}
+
+// AcceptInvite makes the invited user a member of the group. It returns
+// ErrRecordNotFound if the user has no invite.
+func (repo *repository) AcceptInvite(conversationID, userID int) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = deleteInvite(ctx, tx, conversationID, userID); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO conversation_members (conversation_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (conversation_id, user_id) DO NOTHING`
+	if _, err = tx.Exec(ctx, query, conversationID, userID, domain.ConversationRoleMember); err != nil {
+		repo.logger.Errorw("Failed to add group member", "conversationID", conversationID, "userID", userID, "error", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DeclineInvite drops the invite of the user, or returns ErrRecordNotFound.
+func (repo *repository) DeclineInvite(conversationID, userID int) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = deleteInvite(ctx, tx, conversationID, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func deleteInvite(ctx context.Context, tx pgx.Tx, conversationID, userID int) error {
+	result, err := tx.Exec(ctx, `DELETE FROM conversation_invites WHERE conversation_id = $1 AND user_id = $2`,
+		conversationID, userID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RemoveMember takes the user out of the group. A group left without members
+// is deleted with its messages and invites.
+func (repo *repository) RemoveMember(conversationID, userID int) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `DELETE FROM conversation_members WHERE conversation_id = $1 AND user_id = $2`,
+		conversationID, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to remove group member", "conversationID", conversationID, "userID", userID, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM conversations c
+		WHERE c.id = $1 AND c.is_group
+		  AND NOT EXISTS (SELECT 1 FROM conversation_members m WHERE m.conversation_id = c.id)`
+	if _, err = tx.Exec(ctx, query, conversationID); err != nil {
+		repo.logger.Errorw("Failed to delete empty group", "conversationID", conversationID, "error", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SetRole gives a member of the group the role, or returns ErrRecordNotFound.
+func (repo *repository) SetRole(conversationID, userID int, role string) error {
+	result, err := repo.db.Exec(context.Background(),
+		`UPDATE conversation_members SET role = $3 WHERE conversation_id = $1 AND user_id = $2`,
+		conversationID, userID, role)
+	if err != nil {
+		repo.logger.Errorw("Failed to set group role", "conversationID", conversationID, "userID", userID, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package messages
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+// messageColumns are the columns scanned by scanMessage.
+const messageColumns = `id, sender_id, body, COALESCE(event, ''), target_id, created_at`
+
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewMessagesRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetOrCreateDirect returns the id of the direct conversation between the two
+// users, starting it if they never talked.
+func (repo *repository) GetOrCreateDirect(userID, otherID int) (int, error) {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	key := directKey(userID, otherID)
+	var id int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO conversations (direct_key) VALUES ($1) ON CONFLICT (direct_key) DO NOTHING RETURNING id`,
+		key).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = tx.QueryRow(ctx, `SELECT id FROM conversations WHERE direct_key = $1`, key).Scan(&id)
+	}
+	if err != nil {
+		repo.logger.Errorw("Failed to get direct conversation", "userID", userID, "otherID", otherID, "error", err)
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO conversation_members (conversation_id, user_id) VALUES ($1, $2), ($1, $3)
+		ON CONFLICT (conversation_id, user_id) DO NOTHING`
+	if _, err = tx.Exec(ctx, query, id, userID, otherID); err != nil {
+		repo.logger.Errorw("Failed to add conversation members", "conversationID", id, "error", err)
+		return 0, err
+	}
+	return id, tx.Commit(ctx)
+}
+
+// GetConversation returns a conversation of the user with its members. It
+// returns ErrRecordNotFound if the user is not a member.
+func (repo *repository) GetConversation(userID, conversationID int) (*domain.Conversation, error) {
+	query := `
+		SELECT c.id, c.is_group, c.name, c.created_at, c.last_message_at
+		FROM conversations c
+		JOIN conversation_members me ON me.conversation_id = c.id AND me.user_id = $2
+		WHERE c.id = $1`
+	var c domain.Conversation
+	err := repo.db.QueryRow(context.Background(), query, conversationID, userID).Scan(&c.ID, &c.IsGroup, &c.Name, &c.CreatedAt, &c.LastMessageAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRecordNotFound
+	} else if err != nil {
+		repo.logger.Errorw("Failed to get conversation", "conversationID", conversationID, "error", err)
+		return nil, err
+	}
+
+	conversations := []*domain.Conversation{&c}
+	if err = repo.loadMembers(conversations); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListConversations returns up to limit conversations of the user, the most
+// recently active first, with their last message and unread count.
+// Conversations nobody wrote in yet are left out.
+func (repo *repository) ListConversations(userID, limit, offset int) ([]*domain.Conversation, error) {
+	query := `
+		SELECT c.id, c.is_group, c.name, c.created_at, c.last_message_at,
+		       (SELECT COUNT(*) FROM messages m
+		        WHERE m.conversation_id = c.id AND m.id > me.last_read_message_id AND m.sender_id <> me.user_id),
+		       last.id, last.sender_id, last.body, last.event, last.target_id, last.created_at
+		FROM conversation_members me
+		JOIN conversations c ON c.id = me.conversation_id
+		LEFT JOIN LATERAL (
+		    SELECT id, sender_id, body, COALESCE(event, '') AS event, target_id, created_at FROM messages
+		    WHERE conversation_id = c.id
+		    ORDER BY id DESC
+		    LIMIT 1
+		) last ON TRUE
+		WHERE me.user_id = $1 AND c.last_message_at IS NOT NULL
+		ORDER BY c.last_message_at DESC, c.id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := repo.db.Query(context.Background(), query, userID, limit, offset)
+	if err != nil {
+		repo.logger.Errorw("Failed to list conversations", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []*domain.Conversation{}
+	for rows.Next() {
+		var c domain.Conversation
+		var lastID *int64
+		var lastSenderID *int
+		var lastBody, lastEvent *string
+		var lastTargetID *int
+		var lastCreatedAt *time.Time
+		err = rows.Scan(&c.ID, &c.IsGroup, &c.Name, &c.CreatedAt, &c.LastMessageAt, &c.UnreadCount,
+			&lastID, &lastSenderID, &lastBody, &lastEvent, &lastTargetID, &lastCreatedAt)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan conversation", "error", err)
+			return nil, err
+		}
+		if lastID != nil {
+			c.LastMessage = &domain.Message{
+				ID:             *lastID,
+				ConversationID: c.ID,
+				SenderID:       *lastSenderID,
+				Body:           *lastBody,
+				Event:          *lastEvent,
+				TargetID:       lastTargetID,
+				CreatedAt:      *lastCreatedAt,
+			}
+		}
+		conversations = append(conversations, &c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = repo.loadMembers(conversations); err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// loadMembers fills in the members of the conversations.
+func (repo *repository) loadMembers(conversations []*domain.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+	byID := make(map[int]*domain.Conversation, len(conversations))
+	ids := make([]int, 0, len(conversations))
+	for _, c := range conversations {
+		byID[c.ID] = c
+		ids = append(ids, c.ID)
+	}
+
+	query := `
+		SELECT conversation_id, user_id, role, last_read_message_id, joined_at FROM conversation_members
+		WHERE conversation_id = ANY($1)
+		ORDER BY joined_at, user_id`
+	rows, err := repo.db.Query(context.Background(), query, ids)
+	if err != nil {
+		repo.logger.Errorw("Failed to list conversation members", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var conversationID int
+		var member domain.ConversationMember
+		if err = rows.Scan(&conversationID, &member.UserID, &member.Role, &member.LastReadMessageID, &member.JoinedAt); err != nil {
+			repo.logger.Errorw("Failed to scan conversation member", "error", err)
+			return err
+		}
+		byID[conversationID].Members = append(byID[conversationID].Members, &member)
+	}
+	return rows.Err()
+}
+
+// Insert stores a message, which gets its id and time, and marks the
+// conversation read up to it for the sender.
+func (repo *repository) Insert(message *domain.Message) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO messages (conversation_id, sender_id, body, event, target_id) VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING id, created_at`
+	err = tx.QueryRow(ctx, query, message.ConversationID, message.SenderID, message.Body, message.Event, message.TargetID).
+		Scan(&message.ID, &message.CreatedAt)
+	if err != nil {
+		repo.logger.Errorw("Failed to insert message", "conversationID", message.ConversationID, "error", err)
+		return err
+	}
+
+	query = `UPDATE conversations SET last_message_at = $2 WHERE id = $1`
+	if _, err = tx.Exec(ctx, query, message.ConversationID, message.CreatedAt); err != nil {
+		return err
+	}
+	query = `UPDATE conversation_members SET last_read_message_id = $3 WHERE conversation_id = $1 AND user_id = $2`
+	if _, err = tx.Exec(ctx, query, message.ConversationID, message.SenderID, message.ID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListMessages returns up to limit messages of the conversation sent before
+// the message beforeID, or the latest when it is 0, newest first.
+func (repo *repository) ListMessages(conversationID int, beforeID int64, limit int) ([]*domain.Message, error) {
+	query := `
+		SELECT ` + messageColumns + ` FROM messages
+		WHERE conversation_id = $1 AND ($2::BIGINT = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3`
+	rows, err := repo.db.Query(context.Background(), query, conversationID, beforeID, limit)
+	if err != nil {
+		repo.logger.Errorw("Failed to list messages", "conversationID", conversationID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*domain.Message{}
+	for rows.Next() {
+		m := domain.Message{ConversationID: conversationID}
+		if err = scanMessage(rows, &m); err != nil {
+			repo.logger.Errorw("Failed to scan message", "error", err)
+			return nil, err
+		}
+		messages = append(messages, &m)
+	}
+	return messages, rows.Err()
+}
+
+// GetMessage returns a message of the conversation, or ErrRecordNotFound.
+func (repo *repository) GetMessage(conversationID int, id int64) (*domain.Message, error) {
+	query := `SELECT ` + messageColumns + ` FROM messages WHERE id = $1 AND conversation_id = $2`
+	m := domain.Message{ConversationID: conversationID}
+	err := scanMessage(repo.db.QueryRow(context.Background(), query, id, conversationID), &m)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRecordNotFound
+	} else if err != nil {
+		repo.logger.Errorw("Failed to get message", "id", id, "error", err)
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (repo *repository) DeleteMessage(conversationID int, id int64) error {
+	query := `DELETE FROM messages WHERE id = $1 AND conversation_id = $2`
+	result, err := repo.db.Exec(context.Background(), query, id, conversationID)
+	if err != nil {
+		repo.logger.Errorw("Failed to delete message", "id", id, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkRead marks every message of the conversation read by the user and
+// returns the id of the last one. It returns ErrRecordNotFound if the user
+// is not a member.
+func (repo *repository) MarkRead(conversationID, userID int) (int64, error) {
+	query := `
+		UPDATE conversation_members
+		SET last_read_message_id = GREATEST(last_read_message_id,
+		    (SELECT COALESCE(MAX(id), 0) FROM messages WHERE conversation_id = $1))
+		WHERE conversation_id = $1 AND user_id = $2
+		RETURNING last_read_message_id`
+	var lastRead int64
+	err := repo.db.QueryRow(context.Background(), query, conversationID, userID).Scan(&lastRead)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, domain.ErrRecordNotFound
+	} else if err != nil {
+		repo.logger.Errorw("Failed to mark conversation read", "conversationID", conversationID, "userID", userID, "error", err)
+		return 0, err
+	}
+	return lastRead, nil
+}
+
+// HasSent reports whether the user sent a message in the conversation.
+func (repo *repository) HasSent(conversationID, userID int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM messages WHERE conversation_id = $1 AND sender_id = $2)`
+	var sent bool
+	if err := repo.db.QueryRow(context.Background(), query, conversationID, userID).Scan(&sent); err != nil {
+		repo.logger.Errorw("Failed to check sent messages", "conversationID", conversationID, "userID", userID, "error", err)
+		return false, err
+	}
+	return sent, nil
+}
+
+// AllowsNonFollowers reports whether users who do not follow the user may
+// message them. Users who never changed it do.
+func (repo *repository) AllowsNonFollowers(userID int) (bool, error) {
+	var allow bool
+	err := repo.db.QueryRow(context.Background(),
+		`SELECT allow_non_followers FROM message_settings WHERE user_id = $1`, userID).Scan(&allow)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true, nil
+	} else if err != nil {
+		repo.logger.Errorw("Failed to get message settings", "userID", userID, "error", err)
+		return false, err
+	}
+	return allow, nil
+}
+
+func (repo *repository) SetAllowNonFollowers(userID int, allow bool) error {
+	query := `
+		INSERT INTO message_settings (user_id, allow_non_followers) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET allow_non_followers = EXCLUDED.allow_non_followers`
+	if _, err := repo.db.Exec(context.Background(), query, userID, allow); err != nil {
+		repo.logger.Errorw("Failed to set message settings", "userID", userID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// directKey names the direct conversation of two users, whatever their order.
+func directKey(userID, otherID int) string {
+	if otherID < userID {
+		userID, otherID = otherID, userID
+	}
+	return fmt.Sprintf("%d:%d", userID, otherID)
+}
+
+func scanMessage(row pgx.Row, m *domain.Message) error {
+	return row.Scan(&m.ID, &m.SenderID, &m.Body, &m.Event, &m.TargetID, &m.CreatedAt)
+}
@@ -0,0 +1,112 @@
+package mutedwords
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+var ErrMutedWordExists = errors.New("this word is already muted")
+
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewMutedWordsRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Insert adds a muted word. An expired entry with the same phrase is replaced.
+func (repo *repository) Insert(in *domain.MutedWord) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cleanup := `
+		DELETE FROM muted_words
+		WHERE user_id = $1 AND LOWER(phrase) = LOWER($2) AND expires_at <= NOW()`
+	if _, err = tx.Exec(ctx, cleanup, in.UserID, in.Phrase); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO muted_words (user_id, phrase, scope, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	err = tx.QueryRow(ctx, query, in.UserID, in.Phrase, in.Scope, in.ExpiresAt).Scan(&in.ID, &in.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrMutedWordExists
+		}
+		repo.logger.Errorw("Failed to insert muted word", "userID", in.UserID, "error", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListActive returns the muted words of the user that have not expired,
+// oldest first.
+func (repo *repository) ListActive(userID int) ([]*domain.MutedWord, error) {
+	query := `
+		SELECT id, user_id, phrase, scope, expires_at, created_at
+		FROM muted_words
+		WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY id`
+
+	rows, err := repo.db.Query(context.Background(), query, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to list muted words", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	words := []*domain.MutedWord{}
+	for rows.Next() {
+		var word domain.MutedWord
+		err = rows.Scan(&word.ID, &word.UserID, &word.Phrase, &word.Scope, &word.ExpiresAt, &word.CreatedAt)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan muted word", "error", err)
+			return nil, err
+		}
+		words = append(words, &word)
+	}
+	return words, rows.Err()
+}
+
+// CountActive returns how many muted words of the user have not expired.
+func (repo *repository) CountActive(userID int) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM muted_words
+		WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())`
+	var count int
+	if err := repo.db.QueryRow(context.Background(), query, userID).Scan(&count); err != nil {
+		repo.logger.Errorw("Failed to count muted words", "userID", userID, "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// Delete removes a muted word of the user. It returns ErrRecordNotFound if
+// the user has no such entry.
+func (repo *repository) Delete(userID, id int) error {
+	result, err := repo.db.Exec(context.Background(), `DELETE FROM muted_words WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to delete muted word", "userID", userID, "id", id, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package notifications
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// shownActors is how many of the most recent actors a notification names.
+const shownActors = 3
+
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewNotificationsRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// visibleActors selects the actors of notification n the user may see:
+// active users that the user neither blocked, was blocked by, nor muted.
+const visibleActors = `
+	SELECT a.actor_id, a.created_at
+	FROM notification_actors a
+	JOIN users u ON u.id = a.actor_id
+	WHERE a.notification_id = n.id
+	  AND u.suspended_at IS NULL AND u.deactivated_at IS NULL
+	  AND NOT EXISTS (SELECT 1 FROM user_blocks b
+	                  WHERE (b.blocker_id = n.user_id AND b.blocked_id = a.actor_id)
+	                     OR (b.blocker_id = a.actor_id AND b.blocked_id = n.user_id))
+	  AND NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.muter_id = n.user_id AND m.muted_id = a.actor_id)`
+
+// Record adds the actor of the event to the unread notification of its group,
+// creating the notification if there is none.
+func (repo *repository) Record(event *domain.NotificationEvent, groupKey string) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO notifications (user_id, type, group_key, tweet_id) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, type, group_key) WHERE read_at IS NULL
+		DO UPDATE SET updated_at = CURRENT_TIMESTAMP
+		RETURNING id`
+	var id int
+	err = tx.QueryRow(ctx, query, event.RecipientID, event.Type, groupKey, event.TweetID).Scan(&id)
+	if err != nil {
+		repo.logger.Errorw("Failed to record notification", "userID", event.RecipientID, "type", event.Type, "error", err)
+		return err
+	}
+
+	// an actor acting again moves to the front
+	query = `
+		INSERT INTO notification_actors (notification_id, actor_id) VALUES ($1, $2)
+		ON CONFLICT (notification_id, actor_id) DO UPDATE SET created_at = CURRENT_TIMESTAMP`
+	if _, err = tx.Exec(ctx, query, id, event.ActorID); err != nil {
+		repo.logger.Errorw("Failed to record notification actor", "notificationID", id, "error", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// List returns up to limit notifications of the user, most recently updated
+// first. Notifications without a visible actor are left out.
+func (repo *repository) List(userID, limit, offset int) ([]*domain.Notification, error) {
+	query := `
+		SELECT n.id, n.type, n.tweet_id, n.read_at, n.created_at, n.updated_at, v.actor_count, v.actor_ids
+		FROM notifications n
+		CROSS JOIN LATERAL (
+		    SELECT COUNT(*) AS actor_count,
+		           (ARRAY_AGG(a.actor_id ORDER BY a.created_at DESC))[1:$4] AS actor_ids
+		    FROM (` + visibleActors + `) a
+		) v
+		WHERE n.user_id = $1 AND v.actor_count > 0
+		ORDER BY n.updated_at DESC, n.id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := repo.db.Query(context.Background(), query, userID, limit, offset, shownActors)
+	if err != nil {
+		repo.logger.Errorw("Failed to list notifications", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*domain.Notification{}
+	for rows.Next() {
+		n := domain.Notification{UserID: userID}
+		err = rows.Scan(&n.ID, &n.Type, &n.TweetID, &n.ReadAt, &n.CreatedAt, &n.UpdatedAt, &n.ActorCount, &n.ActorIDs)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan notification", "error", err)
+			return nil, err
+		}
+		notifications = append(notifications, &n)
+	}
+	return notifications, rows.Err()
+}
+
+// CountUnread returns how many unread notifications of the user List would
+// show.
+func (repo *repository) CountUnread(userID int) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM notifications n
+		WHERE n.user_id = $1 AND n.read_at IS NULL AND EXISTS (` + visibleActors + `)`
+	var count int
+	if err := repo.db.QueryRow(context.Background(), query, userID).Scan(&count); err != nil {
+		repo.logger.Errorw("Failed to count unread notifications", "userID", userID, "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// MarkRead marks a notification of the user read. It returns
+// ErrRecordNotFound if the user has no such notification.
+func (repo *repository) MarkRead(userID, id int) error {
+	query := `UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP) WHERE id = $1 AND user_id = $2`
+	result, err := repo.db.Exec(context.Background(), query, id, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to mark notification read", "userID", userID, "id", id, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks every notification of the user read.
+func (repo *repository) MarkAllRead(userID int) error {
+	query := `UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND read_at IS NULL`
+	if _, err := repo.db.Exec(context.Background(), query, userID); err != nil {
+		repo.logger.Errorw("Failed to mark notifications read", "userID", userID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Preferences returns the notification types the user turned on or off.
+// Types missing from the map were never changed.
+func (repo *repository) Preferences(userID int) (map[string]bool, error) {
+	rows, err := repo.db.Query(context.Background(),
+		`SELECT type, enabled FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to get notification preferences", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences := map[string]bool{}
+	for rows.Next() {
+		var notificationType string
+		var enabled bool
+		if err = rows.Scan(&notificationType, &enabled); err != nil {
+			return nil, err
+		}
+		preferences[notificationType] = enabled
+	}
+	return preferences, rows.Err()
+}
+
+// SetPreferences turns the given notification types on or off.
+func (repo *repository) SetPreferences(userID int, preferences map[string]bool) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO notification_preferences (user_id, type, enabled) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled`
+	for notificationType, enabled := range preferences {
+		if _, err = tx.Exec(ctx, query, userID, notificationType, enabled); err != nil {
+			repo.logger.Errorw("Failed to set notification preference", "userID", userID, "type", notificationType, "error", err)
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
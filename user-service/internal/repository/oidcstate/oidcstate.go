@@ -0,0 +1,57 @@
+package oidcstate
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"time"
+)
+
+var ErrLoginStateNotFound = errors.New("login state not found or expired")
+
+type repository struct {
+	redis  *redis.Client
+	logger *zap.SugaredLogger
+}
+
+func NewOIDCStateRepo(redis *redis.Client, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+func (repo *repository) StoreLoginState(state string, login domain.OIDCLogin, ttl time.Duration) error {
+	value, err := json.Marshal(login)
+	if err != nil {
+		return err
+	}
+	err = repo.redis.Set(context.Background(), fmt.Sprintf("oidc_state:%s", state), value, ttl).Err()
+	if err != nil {
+		repo.logger.Errorw("failed to store oidc login state", "provider", login.Provider, "error", err)
+		return err
+	}
+	return nil
+}
+
+// ConsumeLoginState returns the login started with state and deletes it, so a
+// callback can not be replayed.
+func (repo *repository) ConsumeLoginState(state string) (*domain.OIDCLogin, error) {
+	value, err := repo.redis.GetDel(context.Background(), fmt.Sprintf("oidc_state:%s", state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrLoginStateNotFound
+	} else if err != nil {
+		repo.logger.Errorw("failed to consume oidc login state", "error", err)
+		return nil, err
+	}
+
+	var login domain.OIDCLogin
+	if err = json.Unmarshal(value, &login); err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
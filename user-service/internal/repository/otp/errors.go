@@ -7,4 +7,5 @@ var (
 	ErrFailedToRetrieveOTP   = errors.New("failed to retrieve OTP")
 	ErrOTPNotFound           = errors.New("OTP not found or expired")
 	ErrFailedToStoreOTP      = errors.New("failed to store OTP")
+	ErrSessionNotFound       = errors.New("session not found or expired")
 )
@@ -6,6 +6,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,10 +22,54 @@ func NewOTPRepo(redis *redis.Client, logger *zap.SugaredLogger) *repository {
 	}
 }
 
+// A session stores "<user id>:<session version>". Revoking the sessions of a
+// user bumps the version, which also ends sessions that were never tracked in
+// the user's set; sessions from before versions were stored have none and
+// count as version 0.
+func sessionVersionKey(userID int) string { return fmt.Sprintf("session_version:%d", userID) }
+
+func formatSession(userID int, version int64) string {
+	return strconv.Itoa(userID) + ":" + strconv.FormatInt(version, 10)
+}
+
+func parseSession(value string) (int, int64, error) {
+	id, version, found := strings.Cut(value, ":")
+	userID, err := strconv.Atoi(id)
+	if err != nil || !found {
+		return userID, 0, err
+	}
+	parsed, err := strconv.ParseInt(version, 10, 64)
+	return userID, parsed, err
+}
+
+// sessionVersion returns the current session version of the user.
+func (repo *repository) sessionVersion(ctx context.Context, userID int) (int64, error) {
+	version, err := repo.redis.Get(ctx, sessionVersionKey(userID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
 func (repo *repository) CreateSession(userID int, token string, ttl time.Duration) error {
+	ctx := context.Background()
 	key := fmt.Sprintf("session:%s", token)
-	value := strconv.Itoa(userID)
-	err := repo.redis.Set(context.Background(), key, value, ttl).Err()
+	userKey := fmt.Sprintf("user_sessions:%d", userID)
+
+	version, err := repo.sessionVersion(ctx, userID)
+	if err != nil {
+		repo.logger.Errorw("failed to get session version", "userID", userID, "error", err)
+		return ErrFailedToCreateSession
+	}
+	value := formatSession(userID, version)
+
+	// Track the user's tokens so that all of them can be revoked at once
+	_, err = repo.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, value, ttl)
+		pipe.SAdd(ctx, userKey, token)
+		pipe.Expire(ctx, userKey, ttl)
+		return nil
+	})
 	if err != nil {
 		repo.logger.Errorw("failed to create session", "error", err)
 		return ErrFailedToCreateSession
@@ -32,20 +77,80 @@ func (repo *repository) CreateSession(userID int, token string, ttl time.Duratio
 	return nil
 }
 
-func (repo *repository) DeleteSession(token string) error {
+func (repo *repository) GetSession(token string) (int, error) {
+	ctx := context.Background()
 	key := fmt.Sprintf("session:%s", token)
-	err := repo.redis.Del(context.Background(), key).Err()
+	value, err := repo.redis.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return 0, ErrSessionNotFound
+	} else if err != nil {
+		repo.logger.Errorw("failed to get session", "error", err)
+		return 0, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	userID, version, err := parseSession(value)
 	if err != nil {
+		return 0, err
+	}
+	current, err := repo.sessionVersion(ctx, userID)
+	if err != nil {
+		repo.logger.Errorw("failed to get session version", "userID", userID, "error", err)
+		return 0, fmt.Errorf("failed to get session: %w", err)
+	}
+	if version < current {
+		// revoked along with the other sessions of the user
+		repo.redis.Del(ctx, key)
+		return 0, ErrSessionNotFound
+	}
+	return userID, nil
+}
+
+func (repo *repository) DeleteSession(token string) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("session:%s", token)
+
+	value, err := repo.redis.GetDel(ctx, key).Result()
+	if err != nil && err != redis.Nil {
 		repo.logger.Errorw("failed to delete session", "error", err)
 		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	if userID, _, err := parseSession(value); value != "" && err == nil {
+		repo.redis.SRem(ctx, fmt.Sprintf("user_sessions:%d", userID), token)
+	}
+	return nil
+}
+
+// DeleteUserSessions revokes every session of the user, those missing from
+// the user's set included.
+func (repo *repository) DeleteUserSessions(userID int) error {
+	ctx := context.Background()
+	userKey := fmt.Sprintf("user_sessions:%d", userID)
+
+	if err := repo.redis.Incr(ctx, sessionVersionKey(userID)).Err(); err != nil {
+		repo.logger.Errorw("failed to bump session version", "userID", userID, "error", err)
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	tokens, err := repo.redis.SMembers(ctx, userKey).Result()
+	if err != nil {
+		repo.logger.Errorw("failed to list user sessions", "userID", userID, "error", err)
+		return fmt.Errorf("failed to list user sessions: %w", err)
+	}
 
+	keys := []string{userKey}
+	for _, token := range tokens {
+		keys = append(keys, fmt.Sprintf("session:%s", token))
+	}
+	if err = repo.redis.Del(ctx, keys...).Err(); err != nil {
+		repo.logger.Errorw("failed to delete user sessions", "userID", userID, "error", err)
+		return fmt.Errorf("failed to delete user sessions: %w", err)
 	}
 	return nil
 }
 
-func (repo *repository) StoreOTP(email, code string) error {
+func (repo *repository) StoreOTP(email, code string, ttl time.Duration) error {
 	key := fmt.Sprintf("otp:%s", email)
-	err := repo.redis.Set(context.Background(), key, code, 5*time.Minute).Err()
+	err := repo.redis.Set(context.Background(), key, code, ttl).Err()
 	if err != nil {
 		repo.logger.Errorw("failed to store otp", "error", err)
 		return ErrFailedToStoreOTP
@@ -64,3 +169,13 @@ func (repo *repository) GetStoreOTP(email string) (string, error) {
 	}
 	return otp, nil
 }
+
+func (repo *repository) DeleteOTP(email string) error {
+	key := fmt.Sprintf("otp:%s", email)
+	err := repo.redis.Del(context.Background(), key).Err()
+	if err != nil {
+		repo.logger.Errorw("failed to delete otp", "error", err)
+		return fmt.Errorf("failed to delete otp: %w", err)
+	}
+	return nil
+}
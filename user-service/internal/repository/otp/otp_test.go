@@ -0,0 +1,22 @@
+package otp
+
+import "testing"
+
+func TestParseSession(t *testing.T) {
+	for value, want := range map[string]struct {
+		userID  int
+		version int64
+	}{
+		formatSession(7, 3): {7, 3},
+		formatSession(7, 0): {7, 0},
+		"7":                 {7, 0}, // stored before sessions had versions
+	} {
+		userID, version, err := parseSession(value)
+		if err != nil || userID != want.userID || version != want.version {
+			t.Errorf("parseSession(%q) = %d, %d, %v", value, userID, version, err)
+		}
+	}
+	if _, _, err := parseSession("seven:1"); err == nil {
+		t.Error("expected an error for a malformed session")
+	}
+}
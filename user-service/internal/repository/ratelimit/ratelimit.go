@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"time"
+)
+
+type repository struct {
+	redis  *redis.Client
+	logger *zap.SugaredLogger
+}
+
+func NewRateLimitRepo(redis *redis.Client, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+// Allow counts a request against key in a fixed window. It returns how long
+// the caller has to wait when more than limit requests were made in the
+// current window, and zero otherwise.
+func (repo *repository) Allow(key string, limit int64, window time.Duration) (time.Duration, error) {
+	ctx := context.Background()
+	redisKey := fmt.Sprintf("rate_limit:%s", key)
+
+	var count *redis.IntCmd
+	var ttl *redis.DurationCmd
+	_, err := repo.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, redisKey)
+		pipe.ExpireNX(ctx, redisKey, window)
+		ttl = pipe.PTTL(ctx, redisKey)
+		return nil
+	})
+	if err != nil {
+		repo.logger.Errorw("failed to count request", "key", key, "error", err)
+		return 0, err
+	}
+
+	if count.Val() <= limit {
+		return 0, nil
+	}
+	if ttl.Val() <= 0 {
+		return window, nil
+	}
+	return ttl.Val(), nil
+}
@@ -14,6 +14,18 @@ type UserRepo interface {
 	GetByEmail(email string) (*domain.User, error)
 	IsFirstLogin(userId int) (bool, error)
 	List() ([]*domain.User, error)
+	ListActiveIDs(afterID, limit int) ([]int, error)
+	ListActiveByIDs(ids []int) ([]*domain.User, error)
+	UpdatePassword(id int, hashedPassword string) error
+	MarkEmailVerified(id int, email string) error
+	SetSuspended(id int, suspended bool, reason string) error
+	SetProtected(id int, protected bool) error
+	UpdateProfile(in *domain.User) error
+	UpdateAvatar(id int, key string) error
+	UpdateBanner(id int, key string) error
+	UsernameInUse(skeleton string, exceptUserID int, since time.Time) (bool, error)
+	ChangeUsername(id int, username string) error
+	GetUserIDByPreviousUsername(username string, since time.Time) (int, error)
 }
 
 type FollowerRepo interface {
@@ -22,11 +34,183 @@ type FollowerRepo interface {
 	IsFollowing(followerID, followedID int) (bool, error)
 	GetFollowers(userID int) ([]*domain.User, error)
 	GetFollowing(userID int) ([]*domain.User, error)
+	ListFollowers(userID, viewerID int, after *domain.FollowCursor, limit int) ([]*domain.FollowEntry, error)
+	ListFollowing(userID, viewerID int, after *domain.FollowCursor, limit int) ([]*domain.FollowEntry, error)
+	DeleteUserEdges(userID int) error
+	RequestFollow(requesterID, targetID int) error
+	HasFollowRequest(requesterID, targetID int) (bool, error)
+	ListIncomingRequests(targetID int) ([]*domain.FollowRequest, error)
+	ListOutgoingRequests(requesterID int) ([]*domain.FollowRequest, error)
+	ApproveFollowRequest(requesterID, targetID int) error
+	ApproveAllFollowRequests(targetID int) error
+	DeleteFollowRequest(requesterID, targetID int) error
+	Block(blockerID, blockedID int) error
+	Unblock(blockerID, blockedID int) error
+	IsBlocked(userID, otherID int) (bool, error)
+	ListBlocked(userID int) ([]*domain.User, error)
+	Mute(muterID, mutedID int) error
+	Unmute(muterID, mutedID int) error
+	ListMuted(userID int) ([]*domain.User, error)
+	GetRelations(userID int) (*domain.Relations, error)
+	ListFriendsOfFriends(userID, limit int) (map[int]int, error)
+	ListUnsuggestable(userID int) ([]int, error)
+}
+
+type MutedWordRepo interface {
+	Insert(in *domain.MutedWord) error
+	ListActive(userID int) ([]*domain.MutedWord, error)
+	CountActive(userID int) (int, error)
+	Delete(userID, id int) error
+}
+
+type NotificationRepo interface {
+	Record(event *domain.NotificationEvent, groupKey string) error
+	List(userID, limit, offset int) ([]*domain.Notification, error)
+	CountUnread(userID int) (int, error)
+	MarkRead(userID, id int) error
+	MarkAllRead(userID int) error
+	Preferences(userID int) (map[string]bool, error)
+	SetPreferences(userID int, preferences map[string]bool) error
+}
+
+type MessageRepo interface {
+	GetOrCreateDirect(userID, otherID int) (int, error)
+	GetConversation(userID, conversationID int) (*domain.Conversation, error)
+	ListConversations(userID, limit, offset int) ([]*domain.Conversation, error)
+	Insert(message *domain.Message) error
+	ListMessages(conversationID int, beforeID int64, limit int) ([]*domain.Message, error)
+	GetMessage(conversationID int, id int64) (*domain.Message, error)
+	DeleteMessage(conversationID int, id int64) error
+	MarkRead(conversationID, userID int) (int64, error)
+	HasSent(conversationID, userID int) (bool, error)
+	AllowsNonFollowers(userID int) (bool, error)
+	SetAllowNonFollowers(userID int, allow bool) error
+	CreateGroup(creatorID int, name string) (int, error)
+	Rename(conversationID int, name string) error
+	CountSeats(conversationID int) (int, error)
+	Invite(conversationID, userID, invitedBy int) error
+	ListInvites(userID int) ([]*domain.ConversationInvite, error)
+	HasInvite(conversationID, userID int) (bool, error)
+	AcceptInvite(conversationID, userID int) error
+	DeclineInvite(conversationID, userID int) error
+	RemoveMember(conversationID, userID int) error
+	SetRole(conversationID, userID int, role string) error
+}
+
+type EventRepo interface {
+	PublishToUser(userID int, eventType string, data interface{}) error
+}
+
+type SearchRepo interface {
+	Search(search *domain.UserSearch) ([]*domain.UserMatch, error)
+}
+
+type SuggestionRepo interface {
+	Store(userID int, suggestions []*domain.Suggestion, ttl time.Duration) error
+	Get(userID int) ([]*domain.Suggestion, error)
 }
 
 type OTPRepo interface {
 	CreateSession(userID int, token string, ttl time.Duration) error
+	GetSession(token string) (int, error)
 	DeleteSession(token string) error
-	StoreOTP(email, code string) error
+	DeleteUserSessions(userID int) error
+	StoreOTP(email, code string, ttl time.Duration) error
 	GetStoreOTP(email string) (string, error)
+	DeleteOTP(email string) error
+}
+
+type PasswordResetRepo interface {
+	StoreResetToken(userID int, tokenHash string, ttl time.Duration) error
+	GetResetToken(tokenHash string) (int, error)
+	ConsumeResetToken(tokenHash string) (int, error)
+}
+
+type AttemptRepo interface {
+	RegisterFailure(key string, window time.Duration) (int64, error)
+	ResetFailures(key string) error
+	Block(key string, ttl time.Duration) error
+	BlockedFor(key string) (time.Duration, error)
+}
+
+type IdentityRepo interface {
+	GetUserID(provider, subject string) (int, error)
+	Link(in *domain.Identity) error
+}
+
+type OIDCStateRepo interface {
+	StoreLoginState(state string, login domain.OIDCLogin, ttl time.Duration) error
+	ConsumeLoginState(state string) (*domain.OIDCLogin, error)
+}
+
+type AppRepo interface {
+	InsertApp(in *domain.DeveloperApp) error
+	GetApp(id int) (*domain.DeveloperApp, error)
+	ListApps(ownerID int) ([]*domain.DeveloperApp, error)
+	InsertKey(in *domain.APIKey) error
+	ListKeys(appID int) ([]*domain.APIKey, error)
+	GetKeyByHash(hash string) (*domain.APIKey, *domain.DeveloperApp, error)
+	RevokeKey(appID, keyID int) error
+}
+
+type WebhookRepo interface {
+	InsertWebhook(webhook *domain.Webhook) error
+	ListWebhooks(appID int) ([]*domain.Webhook, error)
+	GetWebhook(appID, id int) (*domain.Webhook, error)
+	DeleteWebhook(appID, id int) error
+	Enqueue(userID int, event string, payload []byte) (int64, error)
+	ClaimDue(leaseUntil time.Time) (*domain.WebhookDelivery, *domain.Webhook, error)
+	Delivered(id int64, status int) error
+	Fail(id int64, status *int, reason string, retryAt time.Time, giveUp bool) error
+	ListDeliveries(webhookID int, status string, limit, offset int) ([]*domain.WebhookDelivery, error)
+	Redeliver(webhookID int, id int64) (*domain.WebhookDelivery, error)
+}
+
+type RateLimitRepo interface {
+	Allow(key string, limit int64, window time.Duration) (time.Duration, error)
+}
+
+type RoleRepo interface {
+	GetUserRoles(userID int) ([]string, []string, error)
+	ListRolesByUser() (map[int][]string, error)
+	AssignRole(userID int, role string, grantedBy *int) error
+	RevokeRole(userID int, role string) error
+}
+
+type AuditRepo interface {
+	Insert(in *domain.AuditRecord) error
+	List(limit int, beforeID int64) ([]*domain.AuditRecord, error)
+}
+
+type DeletionRepo interface {
+	Start(userID int, purgeAfter time.Time) (*domain.AccountDeletion, error)
+	GetActive(userID int) (*domain.AccountDeletion, error)
+	Cancel(userID int, reason string) error
+	ClaimDue(leaseUntil time.Time) (*domain.AccountDeletion, error)
+	CompleteStep(id int, step string) error
+	Fail(id int, reason string, retryAt time.Time, giveUp bool) error
+	Complete(id int) error
+}
+
+type SessionHistoryRepo interface {
+	Record(userID int, method, ip string) error
+	List(userID int) ([]*domain.SessionRecord, error)
+}
+
+type SecurityEventRepo interface {
+	Insert(event *domain.SecurityEvent) error
+	CountLogins(userID int, userAgent string) (int, int, error)
+	List(userID, limit, offset int) ([]*domain.SecurityEvent, error)
+	Prune(userID int, before time.Time, keep int) error
+}
+
+type ExportRepo interface {
+	Create(userID int) (*domain.DataExport, error)
+	Get(id int) (*domain.DataExport, error)
+	GetLatest(userID int) (*domain.DataExport, error)
+	ClaimDue(leaseUntil time.Time) (*domain.DataExport, error)
+	Complete(id int, blobKey string, size int64, expiresAt time.Time) error
+	Fail(id int, reason string, retryAt time.Time, giveUp bool) error
+	ListExpired(limit int) ([]*domain.DataExport, error)
+	MarkExpired(id int) error
 }
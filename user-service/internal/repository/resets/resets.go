@@ -0,0 +1,87 @@
+package resets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+var ErrResetTokenNotFound = errors.New("reset token not found or expired")
+
+type repository struct {
+	redis  *redis.Client
+	logger *zap.SugaredLogger
+}
+
+func NewPasswordResetRepo(redis *redis.Client, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+// StoreResetToken saves the hash of a reset token for userID and invalidates
+// any token issued to the user before.
+func (repo *repository) StoreResetToken(userID int, tokenHash string, ttl time.Duration) error {
+	ctx := context.Background()
+	userKey := fmt.Sprintf("password_reset_user:%d", userID)
+
+	previous, err := repo.redis.Get(ctx, userKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		repo.logger.Errorw("failed to look up previous reset token", "userID", userID, "error", err)
+		return err
+	}
+
+	_, err = repo.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previous != "" {
+			pipe.Del(ctx, fmt.Sprintf("password_reset:%s", previous))
+		}
+		pipe.Set(ctx, fmt.Sprintf("password_reset:%s", tokenHash), userID, ttl)
+		pipe.Set(ctx, userKey, tokenHash, ttl)
+		return nil
+	})
+	if err != nil {
+		repo.logger.Errorw("failed to store reset token", "userID", userID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetResetToken returns the user a reset token was issued to without using it up.
+func (repo *repository) GetResetToken(tokenHash string) (int, error) {
+	value, err := repo.redis.Get(context.Background(), fmt.Sprintf("password_reset:%s", tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrResetTokenNotFound
+	} else if err != nil {
+		repo.logger.Errorw("failed to get reset token", "error", err)
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// ConsumeResetToken returns the user a reset token was issued to and deletes
+// it, so every token can be used once.
+func (repo *repository) ConsumeResetToken(tokenHash string) (int, error) {
+	ctx := context.Background()
+
+	value, err := repo.redis.GetDel(ctx, fmt.Sprintf("password_reset:%s", tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrResetTokenNotFound
+	} else if err != nil {
+		repo.logger.Errorw("failed to consume reset token", "error", err)
+		return 0, err
+	}
+
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if err = repo.redis.Del(ctx, fmt.Sprintf("password_reset_user:%d", userID)).Err(); err != nil {
+		repo.logger.Errorw("failed to clear reset token of user", "userID", userID, "error", err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,127 @@
+package roles
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+var ErrUnknownRole = errors.New("unknown role")
+
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewRolesRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetUserRoles returns the roles of a user and the permissions they grant.
+func (repo *repository) GetUserRoles(userID int) ([]string, []string, error) {
+	query := `
+		SELECT r.name, COALESCE(array_agg(p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = $1
+		GROUP BY r.name
+		ORDER BY r.name`
+
+	rows, err := repo.db.Query(context.Background(), query, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to get user roles", "userID", userID, "error", err)
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var roles, permissions []string
+	seen := map[string]bool{}
+	for rows.Next() {
+		var role string
+		var rolePermissions []string
+		if err = rows.Scan(&role, &rolePermissions); err != nil {
+			repo.logger.Errorw("Failed to scan user role", "error", err)
+			return nil, nil, err
+		}
+		roles = append(roles, role)
+		for _, permission := range rolePermissions {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return roles, permissions, rows.Err()
+}
+
+// ListRolesByUser returns the roles of every user that has any.
+func (repo *repository) ListRolesByUser() (map[int][]string, error) {
+	query := `
+		SELECT ur.user_id, r.name
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		ORDER BY ur.user_id, r.name`
+
+	rows, err := repo.db.Query(context.Background(), query)
+	if err != nil {
+		repo.logger.Errorw("Failed to list user roles", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := map[int][]string{}
+	for rows.Next() {
+		var userID int
+		var role string
+		if err = rows.Scan(&userID, &role); err != nil {
+			repo.logger.Errorw("Failed to scan user role", "error", err)
+			return nil, err
+		}
+		roles[userID] = append(roles[userID], role)
+	}
+	return roles, rows.Err()
+}
+
+// AssignRole grants a role to a user. Granting a role twice is not an error.
+func (repo *repository) AssignRole(userID int, role string, grantedBy *int) error {
+	query := `
+		INSERT INTO user_roles (user_id, role_id, granted_by)
+		SELECT $1, id, $3 FROM roles WHERE name = $2
+		ON CONFLICT (user_id, role_id) DO NOTHING`
+
+	result, err := repo.db.Exec(context.Background(), query, userID, role, grantedBy)
+	if err != nil {
+		repo.logger.Errorw("Failed to assign role", "userID", userID, "role", role, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		var exists bool
+		err = repo.db.QueryRow(context.Background(), `SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)`, role).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrUnknownRole
+		}
+	}
+	return nil
+}
+
+func (repo *repository) RevokeRole(userID int, role string) error {
+	query := `
+		DELETE FROM user_roles
+		WHERE user_id = $1 AND role_id = (SELECT id FROM roles WHERE name = $2)`
+
+	_, err := repo.db.Exec(context.Background(), query, userID, role)
+	if err != nil {
+		repo.logger.Errorw("Failed to revoke role", "userID", userID, "role", role, "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// followedBoost lifts users the searcher follows above better matches of
+// strangers; an exact username always stays on top.
+const followedBoost = 0.3
+
+// repository searches users with the pg_trgm indexes on their names.
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewSearchRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Fuzzy matches: similar by trigrams, or containing the text.
+const fuzzyMatch = `
+	(u.username % $1 OR u.first_name % $1 OR u.last_name % $1
+	 OR (u.first_name || ' ' || u.last_name) % $1
+	 OR u.username ILIKE $3 OR u.first_name ILIKE $3 OR u.last_name ILIKE $3)`
+
+// Prefix matches: the username or a name starts with the text.
+const prefixMatch = `
+	(u.username ILIKE $3 OR u.first_name ILIKE $3 OR u.last_name ILIKE $3)`
+
+// Search returns the active users matching the search, best match first.
+// Users blocking the searcher or blocked by them are never found.
+func (repo *repository) Search(search *domain.UserSearch) ([]*domain.UserMatch, error) {
+	match, pattern := fuzzyMatch, "%"+escapeLike(search.Text)+"%"
+	if search.Prefix {
+		match, pattern = prefixMatch, escapeLike(search.Text)+"%"
+	}
+
+	query := `
+		SELECT u.id, u.first_name, u.last_name, u.username, u.bio, u.location, u.website,
+		       u.avatar_key, u.banner_key, u.created_at, u.protected, u.followers_count, u.following_count,
+		       f.id IS NOT NULL,
+		       GREATEST(similarity(u.username, $1), similarity(u.first_name, $1), similarity(u.last_name, $1),
+		                similarity(u.first_name || ' ' || u.last_name, $1))
+		           + CASE WHEN LOWER(u.username) = LOWER($1) THEN 1 ELSE 0 END
+		           + CASE WHEN u.username ILIKE $3 THEN 0.2 ELSE 0 END
+		           + CASE WHEN f.id IS NOT NULL THEN $4::float8 ELSE 0 END AS score
+		FROM users u
+		LEFT JOIN followers f ON f.follower_id = $2 AND f.followed_id = u.id
+		WHERE ` + match + `
+		  AND u.suspended_at IS NULL AND u.deactivated_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM user_blocks b
+		                  WHERE (b.blocker_id = $2 AND b.blocked_id = u.id)
+		                     OR (b.blocker_id = u.id AND b.blocked_id = $2))
+		ORDER BY score DESC, u.followers_count DESC, u.id
+		LIMIT $5 OFFSET $6`
+
+	rows, err := repo.db.Query(context.Background(), query,
+		search.Text, search.ViewerID, pattern, followedBoost, search.Limit, search.Offset)
+	if err != nil {
+		repo.logger.Errorw("Failed to search users", "text", search.Text, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []*domain.UserMatch{}
+	for rows.Next() {
+		var user domain.User
+		var m domain.UserMatch
+		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Bio, &user.Location,
+			&user.Website, &user.AvatarKey, &user.BannerKey, &user.CreatedAt, &user.Protected,
+			&user.FollowersCount, &user.FollowingCount, &m.FollowedByUser, &m.Score)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan row", "error", err)
+			return nil, err
+		}
+		m.User = &user
+		matches = append(matches, &m)
+	}
+	return matches, rows.Err()
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes the text match itself literally in a LIKE pattern.
+func escapeLike(text string) string {
+	return likeEscaper.Replace(text)
+}
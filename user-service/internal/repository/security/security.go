@@ -0,0 +1,101 @@
+package security
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+// repository keeps the security events of accounts.
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewSecurityEventRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *repository) Insert(event *domain.SecurityEvent) error {
+	query := `
+		INSERT INTO security_events (user_id, type, ip, user_agent)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING id, created_at`
+
+	err := repo.db.QueryRow(context.Background(), query, event.UserID, event.Type, event.IP, event.UserAgent).
+		Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		repo.logger.Errorw("Failed to insert security event", "userID", event.UserID, "type", event.Type, "error", err)
+	}
+	return err
+}
+
+// CountLogins returns how many times the user signed in, in all and with the
+// user agent.
+func (repo *repository) CountLogins(userID int, userAgent string) (int, int, error) {
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE user_agent = $3)
+		FROM security_events
+		WHERE user_id = $1 AND type = $2`
+
+	var total, withAgent int
+	err := repo.db.QueryRow(context.Background(), query, userID, domain.SecurityLogin, userAgent).Scan(&total, &withAgent)
+	if err != nil {
+		repo.logger.Errorw("Failed to count logins", "userID", userID, "error", err)
+		return 0, 0, err
+	}
+	return total, withAgent, nil
+}
+
+// List returns a page of the user's security events, newest first.
+func (repo *repository) List(userID, limit, offset int) ([]*domain.SecurityEvent, error) {
+	query := `
+		SELECT id, user_id, type, COALESCE(ip, ''), user_agent, created_at
+		FROM security_events
+		WHERE user_id = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := repo.db.Query(context.Background(), query, userID, limit, offset)
+	if err != nil {
+		repo.logger.Errorw("Failed to list security events", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*domain.SecurityEvent{}
+	for rows.Next() {
+		var event domain.SecurityEvent
+		err = rows.Scan(&event.ID, &event.UserID, &event.Type, &event.IP, &event.UserAgent, &event.CreatedAt)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan security event", "error", err)
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+// Prune deletes the user's security events from before the time, and all but
+// the newest keep of the rest.
+func (repo *repository) Prune(userID int, before time.Time, keep int) error {
+	query := `
+		DELETE FROM security_events
+		WHERE user_id = $1
+		  AND (created_at < $2 OR id <= (
+			SELECT id FROM security_events
+			WHERE user_id = $1
+			ORDER BY id DESC
+			OFFSET $3 LIMIT 1))`
+
+	if _, err := repo.db.Exec(context.Background(), query, userID, before, keep); err != nil {
+		repo.logger.Errorw("Failed to prune security events", "userID", userID, "error", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package sessions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// repository keeps the history of sign-ins. The live sessions are in Redis.
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewSessionHistoryRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *repository) Record(userID int, method, ip string) error {
+	query := `INSERT INTO session_history (user_id, method, ip) VALUES ($1, $2, NULLIF($3, ''))`
+	_, err := repo.db.Exec(context.Background(), query, userID, method, ip)
+	if err != nil {
+		repo.logger.Errorw("Failed to record session", "userID", userID, "error", err)
+	}
+	return err
+}
+
+// List returns the whole session history of the user, newest first.
+func (repo *repository) List(userID int) ([]*domain.SessionRecord, error) {
+	query := `
+		SELECT id, user_id, method, COALESCE(ip, ''), created_at
+		FROM session_history
+		WHERE user_id = $1
+		ORDER BY id DESC`
+
+	rows, err := repo.db.Query(context.Background(), query, userID)
+	if err != nil {
+		repo.logger.Errorw("Failed to list session history", "userID", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []*domain.SessionRecord{}
+	for rows.Next() {
+		var record domain.SessionRecord
+		if err = rows.Scan(&record.ID, &record.UserID, &record.Method, &record.IP, &record.CreatedAt); err != nil {
+			repo.logger.Errorw("Failed to scan session record", "error", err)
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
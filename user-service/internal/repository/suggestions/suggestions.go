@@ -0,0 +1,56 @@
+package suggestions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"time"
+)
+
+// repository keeps the precomputed follow suggestions of each user in Redis.
+type repository struct {
+	redis  *redis.Client
+	logger *zap.SugaredLogger
+}
+
+func NewSuggestionsRepo(redis *redis.Client, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		redis:  redis,
+		logger: logger,
+	}
+}
+
+func (repo *repository) Store(userID int, suggestions []*domain.Suggestion, ttl time.Duration) error {
+	value, err := json.Marshal(suggestions)
+	if err != nil {
+		return err
+	}
+	err = repo.redis.Set(context.Background(), fmt.Sprintf("suggestions:%d", userID), value, ttl).Err()
+	if err != nil {
+		repo.logger.Errorw("failed to store follow suggestions", "userID", userID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Get returns the stored suggestions of the user, or ErrRecordNotFound if
+// there are none yet or they expired.
+func (repo *repository) Get(userID int) ([]*domain.Suggestion, error) {
+	value, err := repo.redis.Get(context.Background(), fmt.Sprintf("suggestions:%d", userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrRecordNotFound
+	} else if err != nil {
+		repo.logger.Errorw("failed to get follow suggestions", "userID", userID, "error", err)
+		return nil, err
+	}
+
+	var suggestions []*domain.Suggestion
+	if err = json.Unmarshal(value, &suggestions); err != nil {
+		return nil, err
+	}
+	return suggestions, nil
+}
@@ -2,12 +2,15 @@ package users
 
 import (
 	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usernames"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
+	"time"
 )
 
 type repository struct {
@@ -25,22 +28,37 @@ func NewUsersRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
 func (repo *repository) Insert(in *domain.User) error {
 	repo.logger.Infow("Inserting user into repository", "user", in)
 	query := `
-			INSERT INTO users (first_name, last_name, email, username, password, age) 
-			VALUES ($1, $2, $3, $4, $5, $6)
+			INSERT INTO users (first_name, last_name, email, username, password, age, username_skeleton) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
 			RETURNING id, created_at`
 
-	args := []interface{}{in.FirstName, in.LastName, in.Email, in.Username, in.Password, in.Age}
+	args := []interface{}{in.FirstName, in.LastName, in.Email, in.Username, in.Password, in.Age, usernames.Skeleton(in.Username)}
 	err := repo.db.QueryRow(context.Background(), query, args...).Scan(&in.ID, &in.CreatedAt)
 	if err != nil {
+		if usernameConflict(err) {
+			return usernames.ErrTaken
+		}
 		repo.logger.Errorw("Failed to insert user", "error", err)
 		return err
 	}
 	return nil
 }
 
+// usernameConflict reports whether err is a violation of the uniqueness of
+// usernames or of their skeletons, which the availability check can race.
+func usernameConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return false
+	}
+	return pgErr.ConstraintName == "users_username_key" || pgErr.ConstraintName == "users_username_skeleton_idx"
+}
+
 func (repo *repository) GetByID(id int) (*domain.User, error) {
 	query := `
-		SELECT id, first_name, last_name, email, username 
+		SELECT id, first_name, last_name, email, username, email_verified, suspended_at, COALESCE(suspended_reason, ''),
+		       bio, location, website, avatar_key, banner_key, created_at, username_changed_at, deactivated_at, protected,
+		       followers_count, following_count
 		FROM users 
 		WHERE id = $1`
 
@@ -51,6 +69,20 @@ func (repo *repository) GetByID(id int) (*domain.User, error) {
 		&user.LastName,
 		&user.Email,
 		&user.Username,
+		&user.EmailVerified,
+		&user.SuspendedAt,
+		&user.SuspendedReason,
+		&user.Bio,
+		&user.Location,
+		&user.Website,
+		&user.AvatarKey,
+		&user.BannerKey,
+		&user.CreatedAt,
+		&user.UsernameChangedAt,
+		&user.DeactivatedAt,
+		&user.Protected,
+		&user.FollowersCount,
+		&user.FollowingCount,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -100,7 +132,9 @@ func (repo *repository) Delete(id int) error {
 
 func (repo *repository) GetByUsername(username string) (*domain.User, error) {
 	query := `
-		SELECT id, first_name, last_name, email, username, password 
+		SELECT id, first_name, last_name, email, username, password, email_verified, suspended_at, COALESCE(suspended_reason, ''),
+		       bio, location, website, avatar_key, banner_key, created_at, deactivated_at, protected,
+		       followers_count, following_count
 		FROM users 
 		WHERE username = $1`
 
@@ -112,6 +146,19 @@ func (repo *repository) GetByUsername(username string) (*domain.User, error) {
 		&user.Email,
 		&user.Username,
 		&user.Password,
+		&user.EmailVerified,
+		&user.SuspendedAt,
+		&user.SuspendedReason,
+		&user.Bio,
+		&user.Location,
+		&user.Website,
+		&user.AvatarKey,
+		&user.BannerKey,
+		&user.CreatedAt,
+		&user.DeactivatedAt,
+		&user.Protected,
+		&user.FollowersCount,
+		&user.FollowingCount,
 	)
 
 	if err != nil {
@@ -127,9 +174,15 @@ func (repo *repository) GetByUsername(username string) (*domain.User, error) {
 
 func (repo *repository) GetByEmail(email string) (*domain.User, error) {
 	var user domain.User
-	query := `SELECT id, first_name, last_name, email, username, password FROM users WHERE email = $1`
+	query := `
+		SELECT id, first_name, last_name, email, username, password, email_verified, suspended_at, COALESCE(suspended_reason, ''),
+		       deactivated_at
+		FROM users WHERE lower(email) = lower($1)
+		ORDER BY id
+		LIMIT 1`
 	err := repo.db.QueryRow(context.Background(), query, email).Scan(
-		&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Username, &user.Password)
+		&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Username, &user.Password, &user.EmailVerified,
+		&user.SuspendedAt, &user.SuspendedReason, &user.DeactivatedAt)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -163,7 +216,11 @@ func (repo *repository) IsFirstLogin(userId int) (bool, error) {
 
 func (repo *repository) List() ([]*domain.User, error) {
 	var users []*domain.User
-	query := `SELECT id, first_name, last_name, email, username FROM users`
+	query := `
+		SELECT id, first_name, last_name, email, username, suspended_at, COALESCE(suspended_reason, ''),
+		       bio, location, website, avatar_key, banner_key, created_at, deactivated_at, protected
+		FROM users
+		ORDER BY id`
 	rows, err := repo.db.Query(context.Background(), query)
 	if err != nil {
 		repo.logger.Errorw("Failed to list users", "error", err)
@@ -173,7 +230,10 @@ func (repo *repository) List() ([]*domain.User, error) {
 
 	for rows.Next() {
 		var user domain.User
-		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Username)
+		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Username,
+			&user.SuspendedAt, &user.SuspendedReason,
+			&user.Bio, &user.Location, &user.Website, &user.AvatarKey, &user.BannerKey, &user.CreatedAt,
+			&user.DeactivatedAt, &user.Protected)
 		if err != nil {
 			repo.logger.Errorw("Failed to scan user", "error", err)
 			return nil, err
@@ -182,3 +242,237 @@ func (repo *repository) List() ([]*domain.User, error) {
 	}
 	return users, nil
 }
+
+// ListActiveByIDs returns the users with the given ids who are neither
+// suspended nor deactivated, in no particular order.
+func (repo *repository) ListActiveByIDs(ids []int) ([]*domain.User, error) {
+	query := `
+		SELECT id, first_name, last_name, username, bio, location, website, avatar_key, banner_key, created_at,
+		       protected, followers_count, following_count
+		FROM users
+		WHERE id = ANY($1) AND suspended_at IS NULL AND deactivated_at IS NULL`
+	rows, err := repo.db.Query(context.Background(), query, ids)
+	if err != nil {
+		repo.logger.Errorw("Failed to list users by id", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*domain.User{}
+	for rows.Next() {
+		var user domain.User
+		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Bio, &user.Location,
+			&user.Website, &user.AvatarKey, &user.BannerKey, &user.CreatedAt, &user.Protected,
+			&user.FollowersCount, &user.FollowingCount)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan user", "error", err)
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+// ListActiveIDs returns the ids of users who are neither suspended nor
+// deactivated, in id order, starting after afterID.
+func (repo *repository) ListActiveIDs(afterID, limit int) ([]int, error) {
+	query := `
+		SELECT id FROM users
+		WHERE id > $1 AND suspended_at IS NULL AND deactivated_at IS NULL
+		ORDER BY id
+		LIMIT $2`
+	rows, err := repo.db.Query(context.Background(), query, afterID, limit)
+	if err != nil {
+		repo.logger.Errorw("Failed to list active user ids", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (repo *repository) UpdatePassword(id int, hashedPassword string) error {
+	query := `UPDATE users SET password = $1, updated_at = NOW() WHERE id = $2`
+	result, err := repo.db.Exec(context.Background(), query, hashedPassword, id)
+	if err != nil {
+		repo.logger.Errorw("Failed to update password", "userID", id, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		repo.logger.Errorw("Failed to update password", "userID", id, "error", domain.ErrRecordNotFound)
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (repo *repository) MarkEmailVerified(id int, email string) error {
+	query := `
+		UPDATE users
+		SET email_verified = TRUE, email_verified_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND email = $2`
+	result, err := repo.db.Exec(context.Background(), query, id, email)
+	if err != nil {
+		repo.logger.Errorw("Failed to mark email verified", "userID", id, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		repo.logger.Errorw("Failed to mark email verified", "userID", id, "error", domain.ErrRecordNotFound)
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetSuspended suspends the user with reason, or lifts the suspension.
+func (repo *repository) SetSuspended(id int, suspended bool, reason string) error {
+	query := `
+		UPDATE users
+		SET suspended_at = CASE WHEN $2 THEN NOW() END,
+		    suspended_reason = CASE WHEN $2 THEN $3 END,
+		    updated_at = NOW()
+		WHERE id = $1`
+	result, err := repo.db.Exec(context.Background(), query, id, suspended, reason)
+	if err != nil {
+		repo.logger.Errorw("Failed to update suspension", "userID", id, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetProtected makes the user's account protected or public.
+func (repo *repository) SetProtected(id int, protected bool) error {
+	query := `UPDATE users SET protected = $2, updated_at = NOW() WHERE id = $1`
+	result, err := repo.db.Exec(context.Background(), query, id, protected)
+	if err != nil {
+		repo.logger.Errorw("Failed to update protection", "userID", id, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateProfile stores the editable profile fields of the user.
+func (repo *repository) UpdateProfile(in *domain.User) error {
+	query := `
+		UPDATE users
+		SET first_name = $1, last_name = $2, bio = $3, location = $4, website = $5, updated_at = NOW()
+		WHERE id = $6`
+	args := []interface{}{in.FirstName, in.LastName, in.Bio, in.Location, in.Website, in.ID}
+	result, err := repo.db.Exec(context.Background(), query, args...)
+	if err != nil {
+		repo.logger.Errorw("Failed to update profile", "userID", in.ID, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateAvatar points the user's avatar to the blob with key, or removes it if key is empty.
+func (repo *repository) UpdateAvatar(id int, key string) error {
+	return repo.updateImage(id, "avatar_key", key)
+}
+
+// UpdateBanner points the user's banner to the blob with key, or removes it if key is empty.
+func (repo *repository) UpdateBanner(id int, key string) error {
+	return repo.updateImage(id, "banner_key", key)
+}
+
+func (repo *repository) updateImage(id int, column, key string) error {
+	query := fmt.Sprintf(`UPDATE users SET %s = $1, updated_at = NOW() WHERE id = $2`, column)
+	result, err := repo.db.Exec(context.Background(), query, key, id)
+	if err != nil {
+		repo.logger.Errorw("Failed to update profile image", "userID", id, "column", column, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UsernameInUse reports whether another account holds a username with the given
+// skeleton, or gave one up after since.
+func (repo *repository) UsernameInUse(skeleton string, exceptUserID int, since time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM users WHERE username_skeleton = $1 AND id <> $2)
+		    OR EXISTS (SELECT 1 FROM username_history
+		               WHERE username_skeleton = $1 AND user_id <> $2 AND changed_at > $3)`
+
+	var inUse bool
+	err := repo.db.QueryRow(context.Background(), query, skeleton, exceptUserID, since).Scan(&inUse)
+	if err != nil {
+		repo.logger.Errorw("Failed to check username", "error", err)
+		return false, err
+	}
+	return inUse, nil
+}
+
+// ChangeUsername renames the user and keeps the previous username in the history.
+func (repo *repository) ChangeUsername(id int, username string) error {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	history := `
+		INSERT INTO username_history (user_id, username, username_skeleton)
+		SELECT id, username, username_skeleton FROM users WHERE id = $1`
+	result, err := tx.Exec(ctx, history, id)
+	if err != nil {
+		repo.logger.Errorw("Failed to record username history", "userID", id, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+
+	update := `
+		UPDATE users
+		SET username = $1, username_skeleton = $2, username_changed_at = NOW(), updated_at = NOW()
+		WHERE id = $3`
+	_, err = tx.Exec(ctx, update, username, usernames.Skeleton(username), id)
+	if err != nil {
+		if usernameConflict(err) {
+			return usernames.ErrTaken
+		}
+		repo.logger.Errorw("Failed to change username", "userID", id, "error", err)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetUserIDByPreviousUsername finds the account that gave up username after since.
+func (repo *repository) GetUserIDByPreviousUsername(username string, since time.Time) (int, error) {
+	query := `
+		SELECT user_id FROM username_history
+		WHERE username_skeleton = $1 AND lower(username) = lower($2) AND changed_at > $3
+		ORDER BY changed_at DESC
+		LIMIT 1`
+
+	var userID int
+	err := repo.db.QueryRow(context.Background(), query, usernames.Skeleton(username), username, since).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrRecordNotFound
+		}
+		repo.logger.Errorw("Failed to look up previous username", "error", err)
+		return 0, err
+	}
+	return userID, nil
+}
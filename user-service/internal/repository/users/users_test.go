@@ -0,0 +1,27 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestUsernameConflict(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&pgconn.PgError{Code: "23505", ConstraintName: "users_username_skeleton_idx"}, true},
+		{fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505", ConstraintName: "users_username_key"}), true},
+		{&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"}, false},
+		{&pgconn.PgError{Code: "23503", ConstraintName: "users_username_skeleton_idx"}, false},
+		{errors.New("connection reset"), false},
+	}
+	for _, tt := range tests {
+		if got := usernameConflict(tt.err); got != tt.want {
+			t.Errorf("usernameConflict(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
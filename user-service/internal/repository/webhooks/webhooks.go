@@ -0,0 +1,236 @@
+package webhooks
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	webhookColumns  = `id, app_id, url, secret, events, created_at`
+	deliveryColumns = `id, webhook_id, event, payload, status, attempts, last_status, last_error, redelivery_of,
+		next_attempt_at, last_attempt_at, delivered_at, created_at`
+)
+
+type repository struct {
+	db     *pgxpool.Pool
+	logger *zap.SugaredLogger
+}
+
+func NewWebhooksRepo(db *pgxpool.Pool, logger *zap.SugaredLogger) *repository {
+	return &repository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (repo *repository) InsertWebhook(webhook *domain.Webhook) error {
+	query := `
+		INSERT INTO webhooks (app_id, url, secret, events) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	err := repo.db.QueryRow(context.Background(), query, webhook.AppID, webhook.URL, webhook.Secret, webhook.Events).
+		Scan(&webhook.ID, &webhook.CreatedAt)
+	if err != nil {
+		repo.logger.Errorw("Failed to insert webhook", "appID", webhook.AppID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (repo *repository) ListWebhooks(appID int) ([]*domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE app_id = $1 ORDER BY id`
+	rows, err := repo.db.Query(context.Background(), query, appID)
+	if err != nil {
+		repo.logger.Errorw("Failed to list webhooks", "appID", appID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*domain.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan webhook", "error", err)
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// GetWebhook returns a webhook of the app, or ErrRecordNotFound.
+func (repo *repository) GetWebhook(appID, id int) (*domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND app_id = $2`
+	webhook, err := scanWebhook(repo.db.QueryRow(context.Background(), query, id, appID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRecordNotFound
+	} else if err != nil {
+		repo.logger.Errorw("Failed to get webhook", "webhookID", id, "error", err)
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook of the app with its deliveries, or returns
+// ErrRecordNotFound.
+func (repo *repository) DeleteWebhook(appID, id int) error {
+	result, err := repo.db.Exec(context.Background(), `DELETE FROM webhooks WHERE id = $1 AND app_id = $2`, id, appID)
+	if err != nil {
+		repo.logger.Errorw("Failed to delete webhook", "webhookID", id, "error", err)
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Enqueue schedules a delivery of the payload to every webhook subscribed to
+// the event among the apps of the user, and returns how many it scheduled.
+func (repo *repository) Enqueue(userID int, event string, payload []byte) (int64, error) {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT w.id, $2, $3
+		FROM webhooks w
+		JOIN developer_apps a ON a.id = w.app_id
+		WHERE a.owner_id = $1 AND $2 = ANY (w.events)`
+	result, err := repo.db.Exec(context.Background(), query, userID, event, payload)
+	if err != nil {
+		repo.logger.Errorw("Failed to enqueue webhook deliveries", "userID", userID, "event", event, "error", err)
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// ClaimDue picks one pending delivery that is due, with its webhook, and
+// leases it until leaseUntil so concurrent runners never send it twice. A
+// lease that runs out without the delivery being updated makes it due again.
+func (repo *repository) ClaimDue(leaseUntil time.Time) (*domain.WebhookDelivery, *domain.Webhook, error) {
+	ctx := context.Background()
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $2
+		WHERE id = (SELECT id FROM webhook_deliveries
+		            WHERE status = $1 AND next_attempt_at <= NOW()
+		            ORDER BY next_attempt_at
+		            LIMIT 1 FOR UPDATE SKIP LOCKED)
+		RETURNING ` + deliveryColumns
+	delivery, err := scanDelivery(tx.QueryRow(ctx, query, domain.DeliveryPending, leaseUntil))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, domain.ErrRecordNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	webhook, err := scanWebhook(tx.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, delivery.WebhookID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return delivery, webhook, tx.Commit(ctx)
+}
+
+// Delivered records that the endpoint accepted the delivery.
+func (repo *repository) Delivered(id int64, status int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, last_status = $3, last_error = '',
+		    last_attempt_at = NOW(), delivered_at = NOW()
+		WHERE id = $1`
+	_, err := repo.db.Exec(context.Background(), query, id, domain.DeliveryDelivered, status)
+	if err != nil {
+		repo.logger.Errorw("Failed to record webhook delivery", "deliveryID", id, "error", err)
+	}
+	return err
+}
+
+// Fail records a failed attempt, with the HTTP status if the endpoint
+// answered. The delivery is retried at retryAt, or marked dead if giveUp is
+// set.
+func (repo *repository) Fail(id int64, status *int, reason string, retryAt time.Time, giveUp bool) error {
+	state := domain.DeliveryPending
+	if giveUp {
+		state = domain.DeliveryDead
+	}
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, last_status = $3, last_error = $4,
+		    last_attempt_at = NOW(), next_attempt_at = $5
+		WHERE id = $1`
+	_, err := repo.db.Exec(context.Background(), query, id, state, status, reason, retryAt)
+	if err != nil {
+		repo.logger.Errorw("Failed to record webhook delivery failure", "deliveryID", id, "error", err)
+	}
+	return err
+}
+
+// ListDeliveries returns up to limit deliveries of the webhook, newest
+// first. status picks one status, or every one when empty.
+func (repo *repository) ListDeliveries(webhookID int, status string, limit, offset int) ([]*domain.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4`
+	rows, err := repo.db.Query(context.Background(), query, webhookID, status, limit, offset)
+	if err != nil {
+		repo.logger.Errorw("Failed to list webhook deliveries", "webhookID", webhookID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*domain.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			repo.logger.Errorw("Failed to scan webhook delivery", "error", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// Redeliver schedules the payload of a delivery of the webhook to be sent
+// again, as a new delivery, or returns ErrRecordNotFound.
+func (repo *repository) Redeliver(webhookID int, id int64) (*domain.WebhookDelivery, error) {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, redelivery_of)
+		SELECT webhook_id, event, payload, id FROM webhook_deliveries
+		WHERE id = $1 AND webhook_id = $2
+		RETURNING ` + deliveryColumns
+	delivery, err := scanDelivery(repo.db.QueryRow(context.Background(), query, id, webhookID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRecordNotFound
+	} else if err != nil {
+		repo.logger.Errorw("Failed to redeliver webhook delivery", "deliveryID", id, "error", err)
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func scanWebhook(row pgx.Row) (*domain.Webhook, error) {
+	var w domain.Webhook
+	if err := row.Scan(&w.ID, &w.AppID, &w.URL, &w.Secret, &w.Events, &w.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func scanDelivery(row pgx.Row) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	err := row.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.LastStatus, &d.LastError,
+		&d.RedeliveryOf, &d.NextAttemptAt, &d.LastAttemptAt, &d.DeliveredAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
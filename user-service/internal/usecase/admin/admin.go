@@ -0,0 +1,266 @@
+package admin
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"errors"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+)
+
+// Audited actions taken in user-service.
+const (
+	ActionSuspendUser   = "user.suspend"
+	ActionUnsuspendUser = "user.unsuspend"
+	ActionAssignRole    = "role.assign"
+	ActionRevokeRole    = "role.revoke"
+	ActionListUsers     = "user.list"
+)
+
+const serviceName = "user-service"
+
+type useCase struct {
+	userRepo  repository.UserRepo
+	roleRepo  repository.RoleRepo
+	otpRepo   repository.OTPRepo
+	auditRepo repository.AuditRepo
+	logger    *zap.SugaredLogger
+}
+
+func NewAdminUseCase(
+	userRepo repository.UserRepo,
+	roleRepo repository.RoleRepo,
+	otpRepo repository.OTPRepo,
+	auditRepo repository.AuditRepo,
+	logger *zap.SugaredLogger,
+) *useCase {
+	return &useCase{
+		userRepo:  userRepo,
+		roleRepo:  roleRepo,
+		otpRepo:   otpRepo,
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+// ListUsers returns every user with their roles and suspension state. Seeing
+// everyone's account is privileged too, so it is audited.
+func (uc *useCase) ListUsers(actor *domain.User, ip string) ([]*domain.User, error) {
+	if err := uc.record(actor, ActionListUsers, "user", "*", nil, ip); err != nil {
+		return nil, err
+	}
+	users, err := uc.userRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	roles, err := uc.roleRepo.ListRolesByUser()
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		user.Roles = roles[user.ID]
+	}
+	return users, nil
+}
+
+// Suspend blocks a user from signing in and signs out all their sessions.
+//
+// Like the other privileged actions, it is audited before it is taken, so
+// that an action that went through always has its record.
+func (uc *useCase) Suspend(actor *domain.User, userID int, input dto.SuspendUserRequest, ip string) error {
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return ErrReasonRequired
+	}
+	if _, err := uc.targetUser(actor, userID); err != nil {
+		return err
+	}
+	if err := uc.audit(actor, ActionSuspendUser, userID, map[string]interface{}{"reason": reason}, ip); err != nil {
+		return err
+	}
+
+	if err := uc.userRepo.SetSuspended(userID, true, reason); err != nil {
+		return err
+	}
+	if err := uc.otpRepo.DeleteUserSessions(userID); err != nil {
+		uc.logger.Errorw("Failed to revoke sessions of suspended user", "userID", userID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (uc *useCase) Unsuspend(actor *domain.User, userID int, ip string) error {
+	if _, err := uc.targetUser(actor, userID); err != nil {
+		return err
+	}
+	if err := uc.audit(actor, ActionUnsuspendUser, userID, nil, ip); err != nil {
+		return err
+	}
+	return uc.userRepo.SetSuspended(userID, false, "")
+}
+
+func (uc *useCase) AssignRole(actor *domain.User, userID int, input dto.AssignRoleRequest, ip string) error {
+	if _, err := uc.getUser(userID); err != nil {
+		return err
+	}
+	if err := uc.audit(actor, ActionAssignRole, userID, map[string]interface{}{"role": input.Role}, ip); err != nil {
+		return err
+	}
+	return uc.roleRepo.AssignRole(userID, input.Role, &actor.ID)
+}
+
+func (uc *useCase) RevokeRole(actor *domain.User, userID int, role, ip string) error {
+	if _, err := uc.getUser(userID); err != nil {
+		return err
+	}
+	if role == domain.RoleAdmin {
+		if err := uc.ensureAnotherAdmin(userID); err != nil {
+			return err
+		}
+	}
+	if err := uc.audit(actor, ActionRevokeRole, userID, map[string]interface{}{"role": role}, ip); err != nil {
+		return err
+	}
+	return uc.roleRepo.RevokeRole(userID, role)
+}
+
+// Bootstrap grants the admin role to the given usernames. It runs at startup
+// so a fresh deployment has someone who can hand out roles.
+func (uc *useCase) Bootstrap(usernames []string) error {
+	for _, username := range usernames {
+		user, err := uc.userRepo.GetByUsername(username)
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			uc.logger.Warnw("Bootstrap admin does not exist yet", "username", username)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		roles, _, err := uc.roleRepo.GetUserRoles(user.ID)
+		if err != nil {
+			return err
+		}
+		if hasRole(roles, domain.RoleAdmin) {
+			continue
+		}
+		if err = uc.roleRepo.AssignRole(user.ID, domain.RoleAdmin, nil); err != nil {
+			return err
+		}
+		err = uc.auditRepo.Insert(&domain.AuditRecord{
+			Action:     ActionAssignRole,
+			TargetType: "user",
+			TargetID:   strconv.Itoa(user.ID),
+			Details:    map[string]interface{}{"role": domain.RoleAdmin, "bootstrap": true},
+			Service:    serviceName,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordAudit stores an audit record reported by another service.
+func (uc *useCase) RecordAudit(input dto.RecordAuditRequest) (*domain.AuditRecord, error) {
+	if input.Action == "" || input.TargetType == "" || input.TargetID == "" || input.Service == "" {
+		return nil, ErrInvalidAuditRecord
+	}
+
+	record := &domain.AuditRecord{
+		Action:     input.Action,
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+		Details:    input.Details,
+		Service:    input.Service,
+		IP:         input.IP,
+	}
+	if input.ActorID != 0 {
+		record.ActorID = &input.ActorID
+	}
+	if err := uc.auditRepo.Insert(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (uc *useCase) ListAudit(limit int, beforeID int64) ([]*domain.AuditRecord, error) {
+	if limit < 1 || limit > 500 {
+		return nil, ErrInvalidAuditPageSize
+	}
+	return uc.auditRepo.List(limit, beforeID)
+}
+
+// targetUser returns the user an action is taken on. Nobody can act on their
+// own account, and only admins can act on other admins.
+func (uc *useCase) targetUser(actor *domain.User, userID int) (*domain.User, error) {
+	if actor.ID == userID {
+		return nil, ErrCannotTargetSelf
+	}
+	user, err := uc.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, _, err := uc.roleRepo.GetUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	if hasRole(roles, domain.RoleAdmin) && !actor.HasPermission(domain.PermissionManageRoles) {
+		return nil, ErrInsufficientRights
+	}
+	return user, nil
+}
+
+func (uc *useCase) getUser(userID int) (*domain.User, error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (uc *useCase) ensureAnotherAdmin(userID int) error {
+	roles, err := uc.roleRepo.ListRolesByUser()
+	if err != nil {
+		return err
+	}
+	for id, userRoles := range roles {
+		if id != userID && hasRole(userRoles, domain.RoleAdmin) {
+			return nil
+		}
+	}
+	return ErrLastAdmin
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (uc *useCase) audit(actor *domain.User, action string, userID int, details map[string]interface{}, ip string) error {
+	return uc.record(actor, action, "user", strconv.Itoa(userID), details, ip)
+}
+
+func (uc *useCase) record(actor *domain.User, action, targetType, targetID string, details map[string]interface{}, ip string) error {
+	record := &domain.AuditRecord{
+		ActorID:    &actor.ID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+		Service:    serviceName,
+		IP:         ip,
+	}
+	if err := uc.auditRepo.Insert(record); err != nil {
+		uc.logger.Errorw("Failed to write audit record", "action", action, "targetID", targetID, "error", err)
+		return err
+	}
+	uc.logger.Infow("Privileged action", "action", action, "actorID", actor.ID, "targetID", targetID)
+	return nil
+}
@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+var errAuditDown = errors.New("audit log unavailable")
+
+type fakeUserRepo struct {
+	repository.UserRepo
+	suspended map[int]bool
+}
+
+func (r *fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	return &domain.User{ID: id}, nil
+}
+
+func (r *fakeUserRepo) List() ([]*domain.User, error) {
+	return []*domain.User{{ID: 1}, {ID: 2}}, nil
+}
+
+func (r *fakeUserRepo) SetSuspended(id int, suspended bool, reason string) error {
+	r.suspended[id] = suspended
+	return nil
+}
+
+type fakeRoleRepo struct {
+	repository.RoleRepo
+	roles map[int][]string
+}
+
+func (r *fakeRoleRepo) GetUserRoles(userID int) ([]string, []string, error) {
+	return r.roles[userID], nil, nil
+}
+
+func (r *fakeRoleRepo) ListRolesByUser() (map[int][]string, error) {
+	return r.roles, nil
+}
+
+func (r *fakeRoleRepo) AssignRole(userID int, role string, grantedBy *int) error {
+	r.roles[userID] = append(r.roles[userID], role)
+	return nil
+}
+
+func (r *fakeRoleRepo) RevokeRole(userID int, role string) error {
+	var kept []string
+	for _, r := range r.roles[userID] {
+		if r != role {
+			kept = append(kept, r)
+		}
+	}
+	r.roles[userID] = kept
+	return nil
+}
+
+type fakeOTPRepo struct {
+	repository.OTPRepo
+}
+
+func (fakeOTPRepo) DeleteUserSessions(userID int) error { return nil }
+
+type fakeAuditRepo struct {
+	repository.AuditRepo
+	down    bool
+	actions []string
+}
+
+func (r *fakeAuditRepo) Insert(in *domain.AuditRecord) error {
+	if r.down {
+		return errAuditDown
+	}
+	r.actions = append(r.actions, in.Action)
+	return nil
+}
+
+func TestPrivilegedActionsNeedTheirAuditRecord(t *testing.T) {
+	users := &fakeUserRepo{suspended: map[int]bool{}}
+	roles := &fakeRoleRepo{roles: map[int][]string{1: {domain.RoleAdmin}, 2: {domain.RoleAdmin}}}
+	audit := &fakeAuditRepo{}
+	uc := NewAdminUseCase(users, roles, fakeOTPRepo{}, audit, zap.NewNop().Sugar())
+	actor := &domain.User{ID: 1, Permissions: []string{domain.PermissionManageRoles}}
+
+	actions := map[string]func() error{
+		ActionListUsers: func() error {
+			_, err := uc.ListUsers(actor, "203.0.113.7")
+			return err
+		},
+		ActionSuspendUser: func() error {
+			return uc.Suspend(actor, 3, dto.SuspendUserRequest{Reason: "spam"}, "203.0.113.7")
+		},
+		ActionUnsuspendUser: func() error { return uc.Unsuspend(actor, 4, "203.0.113.7") },
+		ActionAssignRole: func() error {
+			return uc.AssignRole(actor, 3, dto.AssignRoleRequest{Role: "moderator"}, "203.0.113.7")
+		},
+		ActionRevokeRole: func() error { return uc.RevokeRole(actor, 2, domain.RoleAdmin, "203.0.113.7") },
+	}
+
+	// nothing is done that could not be recorded
+	audit.down = true
+	for action, run := range actions {
+		if err := run(); !errors.Is(err, errAuditDown) {
+			t.Errorf("%s: got %v, want the audit error", action, err)
+		}
+	}
+	if len(users.suspended) != 0 || len(roles.roles[3]) != 0 || len(roles.roles[2]) != 1 {
+		t.Fatalf("acted without an audit record: suspended %v, roles %v", users.suspended, roles.roles)
+	}
+
+	audit.down = false
+	for action, run := range actions {
+		if err := run(); err != nil {
+			t.Fatalf("%s: %v", action, err)
+		}
+	}
+	if len(audit.actions) != len(actions) {
+		t.Errorf("recorded %v, want one record per action", audit.actions)
+	}
+	if !users.suspended[3] || len(roles.roles[3]) != 1 || len(roles.roles[2]) != 0 {
+		t.Errorf("actions not taken: suspended %v, roles %v", users.suspended, roles.roles)
+	}
+}
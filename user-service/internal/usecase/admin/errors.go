@@ -0,0 +1,13 @@
+package admin
+
+import "errors"
+
+var (
+	ErrUserNotFound         = errors.New("user not found")
+	ErrCannotTargetSelf     = errors.New("admins can not take this action on their own account")
+	ErrInsufficientRights   = errors.New("only admins can take this action on an admin")
+	ErrReasonRequired       = errors.New("a reason is required")
+	ErrInvalidAuditRecord   = errors.New("audit record needs an action, target and service")
+	ErrLastAdmin            = errors.New("the last admin can not lose the admin role")
+	ErrInvalidAuditPageSize = errors.New("limit must be between 1 and 500")
+)
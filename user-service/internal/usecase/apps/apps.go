@@ -0,0 +1,205 @@
+package apps
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	DefaultRateLimit = 60
+	MaxRateLimit     = 600
+	rateLimitWindow  = time.Minute
+)
+
+type useCase struct {
+	appRepo       repository.AppRepo
+	userRepo      repository.UserRepo
+	rateLimitRepo repository.RateLimitRepo
+	logger        *zap.SugaredLogger
+}
+
+func NewAppUseCase(
+	appRepo repository.AppRepo,
+	userRepo repository.UserRepo,
+	rateLimitRepo repository.RateLimitRepo,
+	logger *zap.SugaredLogger,
+) *useCase {
+	return &useCase{
+		appRepo:       appRepo,
+		userRepo:      userRepo,
+		rateLimitRepo: rateLimitRepo,
+		logger:        logger,
+	}
+}
+
+func (uc *useCase) CreateApp(ownerID int, input dto.CreateAppRequest) (*domain.DeveloperApp, error) {
+	name := strings.TrimSpace(input.Name)
+	if length := utf8.RuneCountInString(name); length < 3 || length > 64 {
+		return nil, ErrInvalidAppName
+	}
+
+	app := &domain.DeveloperApp{
+		OwnerID:     ownerID,
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+	}
+	if err := uc.appRepo.InsertApp(app); err != nil {
+		return nil, err
+	}
+	uc.logger.Infow("Registered developer app", "appID", app.ID, "ownerID", ownerID)
+	return app, nil
+}
+
+func (uc *useCase) ListApps(ownerID int) ([]*domain.DeveloperApp, error) {
+	return uc.appRepo.ListApps(ownerID)
+}
+
+// CreateKey issues a new API key for an app of the owner. The key is returned
+// only here; afterwards just its hash is known.
+func (uc *useCase) CreateKey(ownerID, appID int, input dto.CreateAPIKeyRequest) (string, *domain.APIKey, error) {
+	if _, err := uc.ownedApp(ownerID, appID); err != nil {
+		return "", nil, err
+	}
+
+	scopes, err := validateScopes(input.Scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	rateLimit := input.RateLimit
+	if rateLimit == 0 {
+		rateLimit = DefaultRateLimit
+	}
+	if rateLimit < 0 || rateLimit > MaxRateLimit {
+		return "", nil, ErrInvalidRateLimit
+	}
+
+	prefix, err := utils.GenerateToken(4)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := utils.GenerateToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	rawKey := domain.APIKeyPrefix + prefix + "_" + secret
+
+	key := &domain.APIKey{
+		AppID:     appID,
+		Prefix:    domain.APIKeyPrefix + prefix,
+		Hash:      utils.HashToken(rawKey),
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+	}
+	if err = uc.appRepo.InsertKey(key); err != nil {
+		return "", nil, err
+	}
+	uc.logger.Infow("Issued api key", "appID", appID, "keyID", key.ID, "scopes", scopes)
+	return rawKey, key, nil
+}
+
+func (uc *useCase) ListKeys(ownerID, appID int) ([]*domain.APIKey, error) {
+	if _, err := uc.ownedApp(ownerID, appID); err != nil {
+		return nil, err
+	}
+	return uc.appRepo.ListKeys(appID)
+}
+
+func (uc *useCase) RevokeKey(ownerID, appID, keyID int) error {
+	if _, err := uc.ownedApp(ownerID, appID); err != nil {
+		return err
+	}
+
+	err := uc.appRepo.RevokeKey(appID, keyID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrKeyNotFound
+	} else if err != nil {
+		return err
+	}
+	uc.logger.Infow("Revoked api key", "appID", appID, "keyID", keyID)
+	return nil
+}
+
+// ResolveAPIKey returns what an API key grants and counts the call against
+// the key's rate limit.
+func (uc *useCase) ResolveAPIKey(rawKey string) (*domain.APIKeyGrant, error) {
+	key, app, err := uc.appRepo.GetKeyByHash(utils.HashToken(rawKey))
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		uc.logger.Warnw("Revoked api key used", "keyID", key.ID)
+		return nil, ErrInvalidAPIKey
+	}
+
+	retryAfter, err := uc.rateLimitRepo.Allow(fmt.Sprintf("api_key:%d", key.ID), int64(key.RateLimit), rateLimitWindow)
+	if err != nil {
+		return nil, err
+	}
+	if retryAfter > 0 {
+		return nil, &domain.ThrottleError{RetryAfter: retryAfter}
+	}
+
+	user, err := uc.userRepo.GetByID(app.OwnerID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+	if err = user.CheckActive(); err != nil {
+		return nil, err
+	}
+
+	return &domain.APIKeyGrant{Key: key, App: app, User: user}, nil
+}
+
+// ownedApp returns the app if it belongs to the owner. Other users' apps are
+// reported as missing.
+func (uc *useCase) ownedApp(ownerID, appID int) (*domain.DeveloperApp, error) {
+	app, err := uc.appRepo.GetApp(appID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, ErrAppNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if app.OwnerID != ownerID {
+		return nil, ErrAppNotFound
+	}
+	return app, nil
+}
+
+// validateScopes rejects unknown scopes and drops duplicates.
+func validateScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrNoScopes
+	}
+
+	var result []string
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		known := false
+		for _, candidate := range domain.KnownScopes {
+			if scope == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package apps
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type fakeAppRepo struct {
+	apps map[int]*domain.DeveloperApp
+	keys map[int]*domain.APIKey
+}
+
+func (r *fakeAppRepo) InsertApp(in *domain.DeveloperApp) error {
+	in.ID = len(r.apps) + 1
+	r.apps[in.ID] = in
+	return nil
+}
+
+func (r *fakeAppRepo) GetApp(id int) (*domain.DeveloperApp, error) {
+	if app, ok := r.apps[id]; ok {
+		return app, nil
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *fakeAppRepo) ListApps(ownerID int) ([]*domain.DeveloperApp, error) { return nil, nil }
+
+func (r *fakeAppRepo) InsertKey(in *domain.APIKey) error {
+	in.ID = len(r.keys) + 1
+	r.keys[in.ID] = in
+	return nil
+}
+
+func (r *fakeAppRepo) ListKeys(appID int) ([]*domain.APIKey, error) { return nil, nil }
+
+func (r *fakeAppRepo) GetKeyByHash(hash string) (*domain.APIKey, *domain.DeveloperApp, error) {
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			return key, r.apps[key.AppID], nil
+		}
+	}
+	return nil, nil, domain.ErrRecordNotFound
+}
+
+func (r *fakeAppRepo) RevokeKey(appID, keyID int) error {
+	key, ok := r.keys[keyID]
+	if !ok || key.AppID != appID {
+		return domain.ErrRecordNotFound
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	return nil
+}
+
+// fakeUserRepo only implements the lookup the use case needs.
+type fakeUserRepo struct {
+	repository.UserRepo
+	user domain.User
+}
+
+func (r *fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	if id != r.user.ID {
+		return nil, domain.ErrRecordNotFound
+	}
+	return &r.user, nil
+}
+
+type fakeRateLimitRepo struct{ counts map[string]int64 }
+
+func (r *fakeRateLimitRepo) Allow(key string, limit int64, window time.Duration) (time.Duration, error) {
+	r.counts[key]++
+	if r.counts[key] > limit {
+		return window, nil
+	}
+	return 0, nil
+}
+
+func newTestUseCase() *useCase {
+	return &useCase{
+		appRepo:       &fakeAppRepo{apps: map[int]*domain.DeveloperApp{}, keys: map[int]*domain.APIKey{}},
+		userRepo:      &fakeUserRepo{user: domain.User{ID: 7, Username: "bot_owner"}},
+		rateLimitRepo: &fakeRateLimitRepo{counts: map[string]int64{}},
+		logger:        zap.NewNop().Sugar(),
+	}
+}
+
+func TestIssueAndResolveKey(t *testing.T) {
+	uc := newTestUseCase()
+
+	app, err := uc.CreateApp(7, dto.CreateAppRequest{Name: "weather-bot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawKey, key, err := uc.CreateKey(7, app.ID, dto.CreateAPIKeyRequest{
+		Scopes:    []string{domain.ScopeTweetsWrite, domain.ScopeTweetsWrite},
+		RateLimit: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(rawKey, key.Prefix+"_") || key.Hash == rawKey {
+		t.Errorf("unexpected key %q with prefix %q", rawKey, key.Prefix)
+	}
+	if len(key.Scopes) != 1 {
+		t.Errorf("duplicate scopes were kept: %v", key.Scopes)
+	}
+
+	grant, err := uc.ResolveAPIKey(rawKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grant.User.ID != 7 || grant.App.Name != "weather-bot" || !grant.Key.HasScope(domain.ScopeTweetsWrite) {
+		t.Errorf("unexpected grant: %+v", grant)
+	}
+
+	// The second call is still within the limit of 2, the third is not
+	if _, err = uc.ResolveAPIKey(rawKey); err != nil {
+		t.Fatal(err)
+	}
+	var throttleErr *domain.ThrottleError
+	if _, err = uc.ResolveAPIKey(rawKey); !errors.As(err, &throttleErr) {
+		t.Errorf("expected a ThrottleError, got %v", err)
+	}
+
+	if err = uc.RevokeKey(7, app.ID, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = uc.ResolveAPIKey(rawKey); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey for a revoked key, got %v", err)
+	}
+}
+
+func TestKeysOfOtherUsersAppsAreHidden(t *testing.T) {
+	uc := newTestUseCase()
+
+	app, err := uc.CreateApp(7, dto.CreateAppRequest{Name: "weather-bot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = uc.CreateKey(8, app.ID, dto.CreateAPIKeyRequest{Scopes: []string{domain.ScopeTweetsRead}})
+	if !errors.Is(err, ErrAppNotFound) {
+		t.Errorf("expected ErrAppNotFound, got %v", err)
+	}
+}
+
+func TestCreateKeyValidatesScopes(t *testing.T) {
+	uc := newTestUseCase()
+
+	app, err := uc.CreateApp(7, dto.CreateAppRequest{Name: "weather-bot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = uc.CreateKey(7, app.ID, dto.CreateAPIKeyRequest{}); !errors.Is(err, ErrNoScopes) {
+		t.Errorf("expected ErrNoScopes, got %v", err)
+	}
+	_, _, err = uc.CreateKey(7, app.ID, dto.CreateAPIKeyRequest{Scopes: []string{"users:delete"}})
+	if !errors.Is(err, ErrUnknownScope) {
+		t.Errorf("expected ErrUnknownScope, got %v", err)
+	}
+}
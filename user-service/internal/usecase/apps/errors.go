@@ -0,0 +1,13 @@
+package apps
+
+import "errors"
+
+var (
+	ErrAppNotFound      = errors.New("app not found")
+	ErrKeyNotFound      = errors.New("api key not found")
+	ErrInvalidAppName   = errors.New("app name must be 3 to 64 characters")
+	ErrNoScopes         = errors.New("at least one scope is required")
+	ErrUnknownScope     = errors.New("unknown scope")
+	ErrInvalidRateLimit = errors.New("invalid rate limit")
+	ErrInvalidAPIKey    = errors.New("invalid or revoked api key")
+)
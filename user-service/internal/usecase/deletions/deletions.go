@@ -0,0 +1,271 @@
+package deletions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	// GracePeriod is how long a deactivated account waits before it is purged.
+	// Signing in during that time cancels the deletion.
+	GracePeriod = 30 * 24 * time.Hour
+
+	purgeLease       = 10 * time.Minute
+	maxPurgeAttempts = 10
+	maxRetryDelay    = time.Hour
+)
+
+// TweetService is the part of tweet-service the deletion saga drives. All
+// calls are idempotent.
+type TweetService interface {
+	DeactivateAuthor(userID int) error
+	ReactivateAuthor(userID int) error
+	PurgeAuthor(userID int) error
+}
+
+// purgeStep is one step of the purge. Steps run in order, and each has to be
+// safe to repeat, since a failed purge is retried from the step that failed.
+type purgeStep struct {
+	name string
+	run  func(userID int) error
+}
+
+type useCase struct {
+	userRepo     repository.UserRepo
+	followerRepo repository.FollowerRepo
+	otpRepo      repository.OTPRepo
+	deletionRepo repository.DeletionRepo
+	tweets       TweetService
+	hasher       *utils.PasswordHasher
+	logger       *zap.SugaredLogger
+}
+
+func NewDeletionUseCase(
+	userRepo repository.UserRepo,
+	followerRepo repository.FollowerRepo,
+	otpRepo repository.OTPRepo,
+	deletionRepo repository.DeletionRepo,
+	tweets TweetService,
+	hasher *utils.PasswordHasher,
+	logger *zap.SugaredLogger,
+) *useCase {
+	return &useCase{
+		userRepo:     userRepo,
+		followerRepo: followerRepo,
+		otpRepo:      otpRepo,
+		deletionRepo: deletionRepo,
+		tweets:       tweets,
+		hasher:       hasher,
+		logger:       logger,
+	}
+}
+
+// RequestDeletion deactivates the account in both services and schedules the
+// purge after the grace period. Asking again while a deletion is running
+// returns that deletion.
+func (uc *useCase) RequestDeletion(userID int, input dto.DeleteAccountRequest) (*domain.AccountDeletion, error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	// Only the username lookup loads the password hash
+	withPassword, err := uc.userRepo.GetByUsername(user.Username)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := uc.hasher.Verify(input.Password, withPassword.Password)
+	if err != nil {
+		uc.logger.Errorw("Failed to verify password", "userID", userID, "error", err)
+	}
+	if !matches {
+		return nil, ErrWrongPassword
+	}
+
+	deletion, err := uc.deletionRepo.GetActive(userID)
+	if err == nil {
+		return deletion, nil
+	} else if !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	deletion, err = uc.deletionRepo.Start(userID, time.Now().Add(GracePeriod))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = uc.tweets.DeactivateAuthor(userID); err != nil {
+		uc.logger.Errorw("Failed to deactivate author in tweet-service, rolling back", "userID", userID, "error", err)
+		if cancelErr := uc.deletionRepo.Cancel(userID, "rolled back: "+err.Error()); cancelErr != nil {
+			uc.logger.Errorw("Failed to roll back account deactivation", "userID", userID, "error", cancelErr)
+		}
+		return nil, ErrDeletionUnavailable
+	}
+
+	// Sessions are only dropped once nothing can roll the deactivation back
+	if err = uc.otpRepo.DeleteUserSessions(userID); err != nil {
+		uc.logger.Warnw("Failed to delete sessions of deactivated user", "userID", userID, "error", err)
+	}
+
+	uc.logger.Infow("Account deactivated for deletion", "userID", userID, "purgeAfter", deletion.PurgeAfter)
+	return deletion, nil
+}
+
+// Reactivate cancels the pending deletion of the user. It undoes the steps of
+// RequestDeletion in reverse order, and redoes them if reactivation fails
+// halfway.
+func (uc *useCase) Reactivate(userID int) error {
+	deletion, err := uc.deletionRepo.GetActive(userID)
+	if err == nil && deletion.Status == domain.DeletionPurging {
+		return ErrDeletionInProgress
+	} else if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return err
+	}
+
+	if err = uc.tweets.ReactivateAuthor(userID); err != nil {
+		uc.logger.Errorw("Failed to reactivate author in tweet-service", "userID", userID, "error", err)
+		return ErrDeletionUnavailable
+	}
+
+	if err = uc.deletionRepo.Cancel(userID, "cancelled by signing in"); err != nil {
+		if compensateErr := uc.tweets.DeactivateAuthor(userID); compensateErr != nil {
+			uc.logger.Errorw("Failed to hide tweets again after failed reactivation", "userID", userID, "error", compensateErr)
+		}
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return ErrDeletionInProgress
+		}
+		return err
+	}
+
+	uc.logger.Infow("Account reactivated, deletion cancelled", "userID", userID)
+	return nil
+}
+
+// Run purges due accounts every interval until ctx is done.
+func (uc *useCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		uc.PurgeDue()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDue works through the deletions that are due and returns how many of
+// them completed.
+func (uc *useCase) PurgeDue() int {
+	completed := 0
+	for {
+		deletion, err := uc.deletionRepo.ClaimDue(time.Now().Add(purgeLease))
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return completed
+		}
+		if err != nil {
+			uc.logger.Errorw("Failed to claim due account deletion", "error", err)
+			return completed
+		}
+		if uc.purge(deletion) {
+			completed++
+		}
+	}
+}
+
+// purge runs the purge steps after the last one that completed. It reports
+// whether the saga completed.
+func (uc *useCase) purge(deletion *domain.AccountDeletion) bool {
+	steps := uc.purgeSteps()
+
+	next := 0
+	for i, step := range steps {
+		if step.name == deletion.Step {
+			next = i + 1
+		}
+	}
+
+	for _, step := range steps[next:] {
+		err := step.run(deletion.UserID)
+		if err == nil {
+			err = uc.deletionRepo.CompleteStep(deletion.ID, step.name)
+		}
+		if err != nil {
+			uc.fail(deletion, step.name, err)
+			return false
+		}
+		deletion.Step = step.name
+	}
+
+	if err := uc.deletionRepo.Complete(deletion.ID); err != nil {
+		// Every step is done; the next attempt only has to mark it completed
+		uc.fail(deletion, "complete", err)
+		return false
+	}
+	uc.logger.Infow("Account deleted", "userID", deletion.UserID, "deletionID", deletion.ID)
+	return true
+}
+
+func (uc *useCase) purgeSteps() []purgeStep {
+	return []purgeStep{
+		{name: "tweets", run: uc.tweets.PurgeAuthor},
+		{name: "follows", run: uc.followerRepo.DeleteUserEdges},
+		{name: "sessions", run: uc.purgeSessions},
+		{name: "account", run: uc.deleteAccount},
+	}
+}
+
+func (uc *useCase) purgeSessions(userID int) error {
+	user, err := uc.userRepo.GetByID(userID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err = uc.otpRepo.DeleteUserSessions(userID); err != nil {
+		return err
+	}
+	return uc.otpRepo.DeleteOTP(user.Email)
+}
+
+// deleteAccount removes the user row. Identities, roles, apps and the
+// username history go with it through their foreign keys.
+func (uc *useCase) deleteAccount(userID int) error {
+	err := uc.userRepo.Delete(userID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+// fail schedules a retry with exponential backoff, or gives up after
+// maxPurgeAttempts. A saga that gave up needs an operator.
+func (uc *useCase) fail(deletion *domain.AccountDeletion, step string, err error) {
+	attempts := deletion.Attempts + 1
+	giveUp := attempts >= maxPurgeAttempts
+
+	delay := utils.RetryDelay(attempts, maxRetryDelay)
+
+	reason := fmt.Sprintf("%s: %v", step, err)
+	if failErr := uc.deletionRepo.Fail(deletion.ID, reason, time.Now().Add(delay), giveUp); failErr != nil {
+		uc.logger.Errorw("Failed to record purge failure", "deletionID", deletion.ID, "error", failErr)
+	}
+
+	if giveUp {
+		uc.logger.Errorw("Account deletion gave up, needs manual attention",
+			"userID", deletion.UserID, "deletionID", deletion.ID, "step", step, "error", err)
+		return
+	}
+	uc.logger.Warnw("Account deletion step failed, will retry",
+		"userID", deletion.UserID, "deletionID", deletion.ID, "step", step, "retryIn", delay, "error", err)
+}
@@ -0,0 +1,306 @@
+package deletions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fakeStore keeps the user and the deletion saga the way the database would.
+type fakeStore struct {
+	user     *domain.User
+	deletion *domain.AccountDeletion
+	edges    int
+	sessions int
+}
+
+type fakeUserRepo struct {
+	repository.UserRepo
+	store *fakeStore
+}
+
+func (r *fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	if r.store.user == nil || r.store.user.ID != id {
+		return nil, domain.ErrRecordNotFound
+	}
+	return r.store.user, nil
+}
+
+func (r *fakeUserRepo) GetByUsername(username string) (*domain.User, error) {
+	if r.store.user == nil || r.store.user.Username != username {
+		return nil, domain.ErrRecordNotFound
+	}
+	return r.store.user, nil
+}
+
+func (r *fakeUserRepo) Delete(id int) error {
+	if r.store.user == nil {
+		return domain.ErrRecordNotFound
+	}
+	r.store.user = nil
+	return nil
+}
+
+type fakeFollowerRepo struct {
+	repository.FollowerRepo
+	store *fakeStore
+}
+
+func (r *fakeFollowerRepo) DeleteUserEdges(userID int) error {
+	r.store.edges = 0
+	return nil
+}
+
+type fakeOTPRepo struct {
+	repository.OTPRepo
+	store *fakeStore
+}
+
+func (r *fakeOTPRepo) DeleteUserSessions(userID int) error {
+	r.store.sessions = 0
+	return nil
+}
+
+func (r *fakeOTPRepo) DeleteOTP(email string) error { return nil }
+
+type fakeDeletionRepo struct{ store *fakeStore }
+
+func (r *fakeDeletionRepo) Start(userID int, purgeAfter time.Time) (*domain.AccountDeletion, error) {
+	now := time.Now()
+	r.store.user.DeactivatedAt = &now
+	r.store.deletion = &domain.AccountDeletion{ID: 1, UserID: userID, Status: domain.DeletionPending,
+		PurgeAfter: purgeAfter, NextAttemptAt: purgeAfter}
+	return r.store.deletion, nil
+}
+
+func (r *fakeDeletionRepo) GetActive(userID int) (*domain.AccountDeletion, error) {
+	d := r.store.deletion
+	if d == nil || (d.Status != domain.DeletionPending && d.Status != domain.DeletionPurging) {
+		return nil, domain.ErrRecordNotFound
+	}
+	return d, nil
+}
+
+func (r *fakeDeletionRepo) Cancel(userID int, reason string) error {
+	d := r.store.deletion
+	if d != nil && d.Status == domain.DeletionPurging {
+		return domain.ErrRecordNotFound
+	}
+	if d != nil && d.Status == domain.DeletionPending {
+		d.Status, d.LastError = domain.DeletionCancelled, reason
+	}
+	r.store.user.DeactivatedAt = nil
+	return nil
+}
+
+func (r *fakeDeletionRepo) ClaimDue(leaseUntil time.Time) (*domain.AccountDeletion, error) {
+	d := r.store.deletion
+	if d == nil || (d.Status != domain.DeletionPending && d.Status != domain.DeletionPurging) ||
+		d.NextAttemptAt.After(time.Now()) {
+		return nil, domain.ErrRecordNotFound
+	}
+	d.Status, d.NextAttemptAt = domain.DeletionPurging, leaseUntil
+	claimed := *d
+	return &claimed, nil
+}
+
+func (r *fakeDeletionRepo) CompleteStep(id int, step string) error {
+	r.store.deletion.Step = step
+	return nil
+}
+
+func (r *fakeDeletionRepo) Fail(id int, reason string, retryAt time.Time, giveUp bool) error {
+	d := r.store.deletion
+	d.Attempts++
+	d.LastError, d.NextAttemptAt = reason, retryAt
+	if giveUp {
+		d.Status = domain.DeletionFailed
+	}
+	return nil
+}
+
+func (r *fakeDeletionRepo) Complete(id int) error {
+	r.store.deletion.Status = domain.DeletionCompleted
+	return nil
+}
+
+type fakeTweetService struct {
+	hidden     bool
+	purges     int
+	failNext   error
+	failPurges int
+}
+
+func (s *fakeTweetService) DeactivateAuthor(userID int) error {
+	if err := s.failNext; err != nil {
+		s.failNext = nil
+		return err
+	}
+	s.hidden = true
+	return nil
+}
+
+func (s *fakeTweetService) ReactivateAuthor(userID int) error {
+	s.hidden = false
+	return nil
+}
+
+func (s *fakeTweetService) PurgeAuthor(userID int) error {
+	if s.failPurges > 0 {
+		s.failPurges--
+		return domain.ErrTweetServiceError
+	}
+	s.purges++
+	return nil
+}
+
+var testParams = utils.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newTestUseCase(t *testing.T) (*useCase, *fakeStore, *fakeTweetService) {
+	hasher := utils.NewPasswordHasher(testParams)
+	hashed, err := hasher.Hash("correct-Horse-battery-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &fakeStore{
+		user:     &domain.User{ID: 7, Username: "alice", Email: "alice@example.com", Password: hashed},
+		edges:    3,
+		sessions: 2,
+	}
+	tweets := &fakeTweetService{}
+	uc := NewDeletionUseCase(&fakeUserRepo{store: store}, &fakeFollowerRepo{store: store}, &fakeOTPRepo{store: store},
+		&fakeDeletionRepo{store: store}, tweets, hasher, zap.NewNop().Sugar())
+	return uc, store, tweets
+}
+
+// makeDue moves the purge of the running saga to now.
+func makeDue(store *fakeStore) {
+	store.deletion.NextAttemptAt = time.Now().Add(-time.Second)
+}
+
+func TestRequestDeletionRollsBackWhenTweetServiceFails(t *testing.T) {
+	uc, store, tweets := newTestUseCase(t)
+	tweets.failNext = domain.ErrTweetServiceError
+
+	_, err := uc.RequestDeletion(7, dto.DeleteAccountRequest{Password: "correct-Horse-battery-42"})
+	if !errors.Is(err, ErrDeletionUnavailable) {
+		t.Fatalf("expected ErrDeletionUnavailable, got %v", err)
+	}
+	if store.user.IsDeactivated() || store.deletion.Status != domain.DeletionCancelled {
+		t.Errorf("deactivation must be rolled back, got user %+v and deletion %+v", store.user, store.deletion)
+	}
+	if store.sessions != 2 {
+		t.Error("sessions must survive a rolled back deletion")
+	}
+}
+
+func TestRequestDeletionRequiresPassword(t *testing.T) {
+	uc, store, _ := newTestUseCase(t)
+
+	_, err := uc.RequestDeletion(7, dto.DeleteAccountRequest{Password: "wrong"})
+	if !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("expected ErrWrongPassword, got %v", err)
+	}
+	if store.deletion != nil {
+		t.Error("no deletion must start with a wrong password")
+	}
+}
+
+func TestDeletionWaitsForGracePeriodAndCanBeCancelled(t *testing.T) {
+	uc, store, tweets := newTestUseCase(t)
+
+	deletion, err := uc.RequestDeletion(7, dto.DeleteAccountRequest{Password: "correct-Horse-battery-42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !store.user.IsDeactivated() || !tweets.hidden || store.sessions != 0 {
+		t.Fatalf("expected a deactivated user with hidden tweets and no sessions, got %+v", store)
+	}
+	if deletion.PurgeAfter.Before(time.Now().Add(GracePeriod - time.Minute)) {
+		t.Errorf("purge must wait for the grace period, scheduled at %s", deletion.PurgeAfter)
+	}
+
+	// Asking again returns the running deletion
+	again, err := uc.RequestDeletion(7, dto.DeleteAccountRequest{Password: "correct-Horse-battery-42"})
+	if err != nil || again.ID != deletion.ID {
+		t.Fatalf("expected the same deletion, got %+v %v", again, err)
+	}
+
+	if n := uc.PurgeDue(); n != 0 || store.user == nil {
+		t.Fatal("nothing may be purged during the grace period")
+	}
+
+	if err = uc.Reactivate(7); err != nil {
+		t.Fatal(err)
+	}
+	if store.user.IsDeactivated() || tweets.hidden || store.deletion.Status != domain.DeletionCancelled {
+		t.Errorf("expected the deletion to be cancelled, got %+v", store.deletion)
+	}
+}
+
+func TestPurgeRetriesFromFailedStep(t *testing.T) {
+	uc, store, tweets := newTestUseCase(t)
+
+	if _, err := uc.RequestDeletion(7, dto.DeleteAccountRequest{Password: "correct-Horse-battery-42"}); err != nil {
+		t.Fatal(err)
+	}
+	makeDue(store)
+	tweets.failPurges = 1
+
+	if n := uc.PurgeDue(); n != 0 {
+		t.Fatalf("expected the purge to fail, %d completed", n)
+	}
+	if store.deletion.Status != domain.DeletionPurging || store.deletion.Attempts != 1 || store.user == nil {
+		t.Fatalf("expected a purge to retry, got %+v", store.deletion)
+	}
+	if !store.deletion.NextAttemptAt.After(time.Now()) {
+		t.Error("the retry must be delayed")
+	}
+	if err := uc.Reactivate(7); !errors.Is(err, ErrDeletionInProgress) {
+		t.Errorf("a started purge can not be cancelled, got %v", err)
+	}
+	if !tweets.hidden {
+		t.Error("a failed reactivation must hide the tweets again")
+	}
+
+	makeDue(store)
+	if n := uc.PurgeDue(); n != 1 {
+		t.Fatalf("expected the retry to complete, got %d", n)
+	}
+	if store.deletion.Status != domain.DeletionCompleted || store.user != nil || store.edges != 0 {
+		t.Errorf("expected all data gone, got %+v", store)
+	}
+
+	// A completed saga is never run again
+	if n := uc.PurgeDue(); n != 0 || tweets.purges != 1 {
+		t.Errorf("expected a single purge, got %d purges", tweets.purges)
+	}
+}
+
+func TestPurgeResumesAfterLastCompletedStep(t *testing.T) {
+	uc, store, tweets := newTestUseCase(t)
+
+	if _, err := uc.RequestDeletion(7, dto.DeleteAccountRequest{Password: "correct-Horse-battery-42"}); err != nil {
+		t.Fatal(err)
+	}
+	// A runner died after the first two steps
+	store.deletion.Status, store.deletion.Step = domain.DeletionPurging, "follows"
+	makeDue(store)
+
+	if n := uc.PurgeDue(); n != 1 {
+		t.Fatalf("expected the purge to complete, got %d", n)
+	}
+	if tweets.purges != 0 {
+		t.Error("completed steps must not run again")
+	}
+	if store.user != nil {
+		t.Error("expected the account to be deleted")
+	}
+}
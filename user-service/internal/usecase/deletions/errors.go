@@ -0,0 +1,9 @@
+package deletions
+
+import "errors"
+
+var (
+	ErrWrongPassword       = errors.New("password is incorrect")
+	ErrDeletionInProgress  = errors.New("account deletion is already in progress and can not be cancelled")
+	ErrDeletionUnavailable = errors.New("account deletion is temporarily unavailable, try again later")
+)
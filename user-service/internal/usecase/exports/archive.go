@@ -0,0 +1,167 @@
+package exports
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/blob"
+	"archive/zip"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"path"
+	"sort"
+	"time"
+)
+
+//go:embed templates/index.html
+var indexHTML string
+
+var indexTemplate = template.Must(template.New("index").Funcs(template.FuncMap{
+	"formatTime": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04 UTC")
+	},
+}).Parse(indexHTML))
+
+// archive is the content of a data export.
+type archive struct {
+	GeneratedAt time.Time
+	Profile     *dto.OwnProfile
+	Tweets      []*dto.ExportedTweet
+	Likes       []*dto.ExportedLike
+	Tags        []tagUsage
+	Followers   []*dto.PublicProfile
+	Following   []*dto.PublicProfile
+	Sessions    []*domain.SessionRecord
+
+	avatarKey string
+	bannerKey string
+}
+
+// tagUsage is a tag the user put on their tweets and how often.
+type tagUsage struct {
+	Name   string `json:"name"`
+	Tweets int    `json:"tweets"`
+}
+
+func newArchive(
+	user *domain.User,
+	tweets []*dto.ExportedTweet,
+	likes []*dto.ExportedLike,
+	followers, following []*domain.User,
+	sessions []*domain.SessionRecord,
+) *archive {
+	if tweets == nil {
+		tweets = []*dto.ExportedTweet{}
+	}
+	if likes == nil {
+		likes = []*dto.ExportedLike{}
+	}
+	if sessions == nil {
+		sessions = []*domain.SessionRecord{}
+	}
+	return &archive{
+		GeneratedAt: time.Now(),
+		Profile:     dto.NewOwnProfile(user),
+		Tweets:      tweets,
+		Likes:       likes,
+		Tags:        countTags(tweets),
+		Followers:   dto.NewPublicProfiles(followers),
+		Following:   dto.NewPublicProfiles(following),
+		Sessions:    sessions,
+		avatarKey:   user.AvatarKey,
+		bannerKey:   user.BannerKey,
+	}
+}
+
+// countTags lists the tags used on the tweets, most used first.
+func countTags(tweets []*dto.ExportedTweet) []tagUsage {
+	counts := map[string]int{}
+	for _, tweet := range tweets {
+		for _, tag := range tweet.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]tagUsage, 0, len(counts))
+	for name, n := range counts {
+		tags = append(tags, tagUsage{Name: name, Tweets: n})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Tweets != tags[j].Tweets {
+			return tags[i].Tweets > tags[j].Tweets
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags
+}
+
+// writeArchive writes the archive as a zip file: machine readable JSON files
+// under data/, the profile images under media/ and an index.html that shows
+// everything in a browser.
+func writeArchive(w io.Writer, a *archive, blobs blob.Store) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"data/profile.json", a.Profile},
+		{"data/tweets.json", a.Tweets},
+		{"data/likes.json", a.Likes},
+		{"data/tags.json", a.Tags},
+		{"data/followers.json", a.Followers},
+		{"data/following.json", a.Following},
+		{"data/sessions.json", a.Sessions},
+	}
+	for _, file := range files {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(file.content); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range []string{a.avatarKey, a.bannerKey} {
+		if key == "" {
+			continue
+		}
+		if err := copyBlob(zw, blobs, key); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("index.html")
+	if err != nil {
+		return err
+	}
+	if err = indexTemplate.Execute(f, a); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// copyBlob adds a stored profile image to the archive. An image that is gone
+// from the store is left out.
+func copyBlob(zw *zip.Writer, blobs blob.Store, key string) error {
+	src, err := blobs.Open(key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := zw.Create("media/" + path.Base(key))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
@@ -0,0 +1,9 @@
+package exports
+
+import "errors"
+
+var (
+	ErrExportNotFound      = errors.New("data export not found")
+	ErrInvalidDownloadLink = errors.New("invalid or expired download link")
+	ErrExportExpired       = errors.New("data export has expired, request a new one")
+)
@@ -0,0 +1,325 @@
+package exports
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/blob"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Retention is how long a finished archive can be downloaded.
+	Retention = 7 * 24 * time.Hour
+	// RequestCooldown is how long a finished archive is handed out again
+	// instead of building a new one.
+	RequestCooldown = 24 * time.Hour
+
+	downloadLinkTTL  = 15 * time.Minute
+	buildLease       = 10 * time.Minute
+	maxBuildAttempts = 5
+	maxRetryDelay    = time.Hour
+	expireBatchSize  = 100
+)
+
+// TweetService is the part of tweet-service the export reads from.
+type TweetService interface {
+	ExportTweets(userID int) ([]*dto.ExportedTweet, error)
+	ExportLikes(userID int) ([]*dto.ExportedLike, error)
+}
+
+type useCase struct {
+	userRepo      repository.UserRepo
+	followerRepo  repository.FollowerRepo
+	sessionRepo   repository.SessionHistoryRepo
+	exportRepo    repository.ExportRepo
+	tweets        TweetService
+	blobs         blob.Store
+	signingSecret []byte
+	logger        *zap.SugaredLogger
+
+	wake chan struct{} // tells Run a new export is waiting
+}
+
+func NewExportUseCase(
+	userRepo repository.UserRepo,
+	followerRepo repository.FollowerRepo,
+	sessionRepo repository.SessionHistoryRepo,
+	exportRepo repository.ExportRepo,
+	tweets TweetService,
+	blobs blob.Store,
+	signingSecret string,
+	logger *zap.SugaredLogger,
+) *useCase {
+	return &useCase{
+		userRepo:      userRepo,
+		followerRepo:  followerRepo,
+		sessionRepo:   sessionRepo,
+		exportRepo:    exportRepo,
+		tweets:        tweets,
+		blobs:         blobs,
+		signingSecret: []byte(signingSecret),
+		logger:        logger,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// RequestExport schedules an archive of the user's data. An export that is
+// still being built, or was finished within the cooldown, is returned
+// instead of starting another one.
+func (uc *useCase) RequestExport(userID int) (*dto.DataExportStatus, error) {
+	latest, err := uc.exportRepo.GetLatest(userID)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, err
+	}
+	if latest != nil && latest.Status == domain.ExportReady && time.Since(latest.RequestedAt) < RequestCooldown {
+		return uc.status(latest), nil
+	}
+
+	export, err := uc.exportRepo.Create(userID)
+	if err != nil {
+		uc.logger.Errorw("Failed to request data export", "userID", userID, "error", err)
+		return nil, err
+	}
+	uc.logger.Infow("Requested data export", "userID", userID, "exportID", export.ID)
+
+	select {
+	case uc.wake <- struct{}{}:
+	default:
+	}
+	return uc.status(export), nil
+}
+
+// GetExport returns the state of one of the user's exports.
+func (uc *useCase) GetExport(userID, exportID int) (*dto.DataExportStatus, error) {
+	export, err := uc.exportRepo.Get(exportID)
+	if errors.Is(err, domain.ErrRecordNotFound) || (err == nil && export.UserID != userID) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return uc.status(export), nil
+}
+
+// status adds a fresh download link to a ready export. The link is signed,
+// so it works without a session, and expires after downloadLinkTTL.
+func (uc *useCase) status(export *domain.DataExport) *dto.DataExportStatus {
+	status := &dto.DataExportStatus{DataExport: export}
+	if export.Status != domain.ExportReady {
+		return status
+	}
+
+	expires := time.Now().Add(downloadLinkTTL)
+	if export.ExpiresAt != nil && export.ExpiresAt.Before(expires) {
+		expires = *export.ExpiresAt
+	}
+	token := utils.SignPayload(uc.signingSecret, fmt.Sprintf("export:%d:%d", export.ID, expires.Unix()))
+	status.DownloadURL = dto.ExportDownloadPath + "?token=" + url.QueryEscape(token)
+	status.DownloadExpiresAt = &expires
+	return status
+}
+
+// OpenDownload checks a download link and opens the archive it points to.
+func (uc *useCase) OpenDownload(token string) (*os.File, *domain.DataExport, error) {
+	payload, err := utils.VerifySignedPayload(uc.signingSecret, token)
+	if err != nil {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+	exportID, expires, err := parseDownloadPayload(payload)
+	if err != nil || time.Now().Unix() > expires {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+
+	export, err := uc.exportRepo.Get(exportID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, nil, ErrInvalidDownloadLink
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.Status != domain.ExportReady || (export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt)) {
+		return nil, nil, ErrExportExpired
+	}
+
+	file, err := uc.blobs.Open(export.BlobKey)
+	if errors.Is(err, blob.ErrNotFound) {
+		uc.logger.Errorw("Archive of ready data export is missing", "exportID", export.ID)
+		return nil, nil, ErrExportExpired
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, export, nil
+}
+
+func parseDownloadPayload(payload string) (int, int64, error) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 || parts[0] != "export" {
+		return 0, 0, ErrInvalidDownloadLink
+	}
+	exportID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return exportID, expires, nil
+}
+
+// Run builds requested exports and removes expired archives until ctx is
+// cancelled. It checks every interval, and right away when an export is
+// requested.
+func (uc *useCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		uc.BuildDue()
+		uc.ExpireOld()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-uc.wake:
+		}
+	}
+}
+
+// BuildDue builds every export that is due and returns how many finished.
+func (uc *useCase) BuildDue() int {
+	built := 0
+	for {
+		export, err := uc.exportRepo.ClaimDue(time.Now().Add(buildLease))
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return built
+		}
+		if err != nil {
+			uc.logger.Errorw("Failed to claim due data export", "error", err)
+			return built
+		}
+		if uc.build(export) {
+			built++
+		}
+	}
+}
+
+func (uc *useCase) build(export *domain.DataExport) bool {
+	archive, err := uc.collect(export.UserID)
+	if err != nil {
+		uc.fail(export, err)
+		return false
+	}
+
+	var buf bytes.Buffer
+	if err = writeArchive(&buf, archive, uc.blobs); err != nil {
+		uc.fail(export, err)
+		return false
+	}
+	size := int64(buf.Len())
+
+	token, err := utils.GenerateToken(12)
+	if err != nil {
+		uc.fail(export, err)
+		return false
+	}
+	key := fmt.Sprintf("exports/%d-%s.zip", export.UserID, token)
+	if err = uc.blobs.Put(key, &buf); err != nil {
+		uc.fail(export, err)
+		return false
+	}
+
+	if err = uc.exportRepo.Complete(export.ID, key, size, time.Now().Add(Retention)); err != nil {
+		// The lease runs out and the export is built again
+		if delErr := uc.blobs.Delete(key); delErr != nil {
+			uc.logger.Errorw("Failed to delete unused export archive", "key", key, "error", delErr)
+		}
+		return false
+	}
+
+	uc.logger.Infow("Built data export", "userID", export.UserID, "exportID", export.ID, "size", size)
+	return true
+}
+
+// collect gathers everything the archive holds about the user.
+func (uc *useCase) collect(userID int) (*archive, error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("profile: %w", err)
+	}
+	tweets, err := uc.tweets.ExportTweets(userID)
+	if err != nil {
+		return nil, fmt.Errorf("tweets: %w", err)
+	}
+	likes, err := uc.tweets.ExportLikes(userID)
+	if err != nil {
+		return nil, fmt.Errorf("likes: %w", err)
+	}
+	followers, err := uc.followerRepo.GetFollowers(userID)
+	if err != nil {
+		return nil, fmt.Errorf("followers: %w", err)
+	}
+	following, err := uc.followerRepo.GetFollowing(userID)
+	if err != nil {
+		return nil, fmt.Errorf("following: %w", err)
+	}
+	sessions, err := uc.sessionRepo.List(userID)
+	if err != nil {
+		return nil, fmt.Errorf("sessions: %w", err)
+	}
+	return newArchive(user, tweets, likes, followers, following, sessions), nil
+}
+
+func (uc *useCase) fail(export *domain.DataExport, err error) {
+	attempts := export.Attempts + 1
+	giveUp := attempts >= maxBuildAttempts
+
+	delay := utils.RetryDelay(attempts, maxRetryDelay)
+
+	if failErr := uc.exportRepo.Fail(export.ID, err.Error(), time.Now().Add(delay), giveUp); failErr != nil {
+		uc.logger.Errorw("Failed to record data export failure", "exportID", export.ID, "error", failErr)
+	}
+
+	if giveUp {
+		uc.logger.Errorw("Data export gave up", "userID", export.UserID, "exportID", export.ID, "error", err)
+		return
+	}
+	uc.logger.Warnw("Data export failed, will retry",
+		"userID", export.UserID, "exportID", export.ID, "retryIn", delay, "error", err)
+}
+
+// ExpireOld removes the archives of expired exports and returns how many
+// were removed.
+func (uc *useCase) ExpireOld() int {
+	exports, err := uc.exportRepo.ListExpired(expireBatchSize)
+	if err != nil {
+		uc.logger.Errorw("Failed to list expired data exports", "error", err)
+		return 0
+	}
+
+	expired := 0
+	for _, export := range exports {
+		if err = uc.blobs.Delete(export.BlobKey); err != nil {
+			uc.logger.Errorw("Failed to delete export archive", "exportID", export.ID, "error", err)
+			continue
+		}
+		if err = uc.exportRepo.MarkExpired(export.ID); err != nil {
+			continue
+		}
+		expired++
+	}
+	return expired
+}
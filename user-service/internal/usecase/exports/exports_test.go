@@ -0,0 +1,308 @@
+package exports
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/blob"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type fakeUserRepo struct {
+	repository.UserRepo
+	user *domain.User
+}
+
+func (r *fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	if r.user.ID != id {
+		return nil, domain.ErrRecordNotFound
+	}
+	return r.user, nil
+}
+
+type fakeFollowerRepo struct {
+	repository.FollowerRepo
+}
+
+func (r *fakeFollowerRepo) GetFollowers(userID int) ([]*domain.User, error) {
+	return []*domain.User{{ID: 2, Username: "follower", Email: "follower@example.com"}}, nil
+}
+
+func (r *fakeFollowerRepo) GetFollowing(userID int) ([]*domain.User, error) {
+	return nil, nil
+}
+
+type fakeSessionRepo struct {
+	repository.SessionHistoryRepo
+}
+
+func (r *fakeSessionRepo) List(userID int) ([]*domain.SessionRecord, error) {
+	return []*domain.SessionRecord{{ID: 1, UserID: userID, Method: domain.SessionMethodPassword, IP: "10.0.0.1"}}, nil
+}
+
+type fakeExportRepo struct {
+	exports []*domain.DataExport
+}
+
+func (r *fakeExportRepo) Create(userID int) (*domain.DataExport, error) {
+	for _, e := range r.exports {
+		if e.UserID == userID && (e.Status == domain.ExportPending || e.Status == domain.ExportBuilding) {
+			return e, nil
+		}
+	}
+	e := &domain.DataExport{ID: len(r.exports) + 1, UserID: userID, Status: domain.ExportPending, RequestedAt: time.Now()}
+	r.exports = append(r.exports, e)
+	return e, nil
+}
+
+func (r *fakeExportRepo) Get(id int) (*domain.DataExport, error) {
+	if id < 1 || id > len(r.exports) {
+		return nil, domain.ErrRecordNotFound
+	}
+	return r.exports[id-1], nil
+}
+
+func (r *fakeExportRepo) GetLatest(userID int) (*domain.DataExport, error) {
+	for i := len(r.exports) - 1; i >= 0; i-- {
+		if r.exports[i].UserID == userID {
+			return r.exports[i], nil
+		}
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *fakeExportRepo) ClaimDue(leaseUntil time.Time) (*domain.DataExport, error) {
+	for _, e := range r.exports {
+		if e.Status == domain.ExportPending || (e.Status == domain.ExportBuilding && !e.NextAttemptAt.After(time.Now())) {
+			e.Status = domain.ExportBuilding
+			e.NextAttemptAt = leaseUntil
+			return e, nil
+		}
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *fakeExportRepo) Complete(id int, blobKey string, size int64, expiresAt time.Time) error {
+	now := time.Now()
+	e := r.exports[id-1]
+	e.Status, e.BlobKey, e.SizeBytes, e.CompletedAt, e.ExpiresAt = domain.ExportReady, blobKey, size, &now, &expiresAt
+	return nil
+}
+
+func (r *fakeExportRepo) Fail(id int, reason string, retryAt time.Time, giveUp bool) error {
+	e := r.exports[id-1]
+	e.Attempts++
+	e.LastError, e.NextAttemptAt = reason, retryAt
+	if giveUp {
+		e.Status = domain.ExportFailed
+	}
+	return nil
+}
+
+func (r *fakeExportRepo) ListExpired(limit int) ([]*domain.DataExport, error) {
+	var expired []*domain.DataExport
+	for _, e := range r.exports {
+		if e.Status == domain.ExportReady && e.ExpiresAt.Before(time.Now()) {
+			expired = append(expired, e)
+		}
+	}
+	return expired, nil
+}
+
+func (r *fakeExportRepo) MarkExpired(id int) error {
+	r.exports[id-1].Status, r.exports[id-1].BlobKey = domain.ExportExpired, ""
+	return nil
+}
+
+type fakeTweetService struct{ err error }
+
+func (s *fakeTweetService) ExportTweets(userID int) ([]*dto.ExportedTweet, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []*dto.ExportedTweet{
+		{ID: 1, Title: "hello", Content: "<script>alert(1)</script>", Tags: []string{"go", "web"}, Likes: 3},
+		{ID: 2, Title: "again", Content: "more", Tags: []string{"go"}},
+	}, nil
+}
+
+func (s *fakeTweetService) ExportLikes(userID int) ([]*dto.ExportedLike, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []*dto.ExportedLike{{TweetID: 7, LikedAt: time.Date(2026, time.May, 4, 10, 0, 0, 0, time.UTC)}}, nil
+}
+
+func newTestUseCase(t *testing.T, tweets *fakeTweetService) (*useCase, *fakeExportRepo, blob.Store) {
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &domain.User{ID: 1, Username: "alice", Email: "alice@example.com"}
+	exports := &fakeExportRepo{}
+	uc := NewExportUseCase(&fakeUserRepo{user: user}, &fakeFollowerRepo{}, &fakeSessionRepo{}, exports,
+		tweets, blobs, "secret", zap.NewNop().Sugar())
+	return uc, exports, blobs
+}
+
+func tokenFrom(t *testing.T, status *dto.DataExportStatus) string {
+	link, err := url.Parse(status.DownloadURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
+
+func TestBuildAndDownload(t *testing.T) {
+	uc, _, _ := newTestUseCase(t, &fakeTweetService{})
+
+	requested, err := uc.RequestExport(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requested.Status != domain.ExportPending || requested.DownloadURL != "" {
+		t.Fatalf("new export should be pending without a link, got %+v", requested)
+	}
+	if built := uc.BuildDue(); built != 1 {
+		t.Fatalf("expected 1 export built, got %d", built)
+	}
+
+	status, err := uc.GetExport(1, requested.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != domain.ExportReady || status.DownloadURL == "" {
+		t.Fatalf("built export should be ready with a link, got %+v", status)
+	}
+
+	file, export, err := uc.OpenDownload(tokenFrom(t, status))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	info, _ := file.Stat()
+	if info.Size() != export.SizeBytes {
+		t.Fatalf("archive is %d bytes, recorded %d", info.Size(), export.SizeBytes)
+	}
+
+	zr, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	for _, name := range []string{"index.html", "data/profile.json", "data/tweets.json", "data/likes.json",
+		"data/tags.json", "data/followers.json", "data/following.json", "data/sessions.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+	if strings.Contains(files["index.html"], "<script>") {
+		t.Error("tweet content must be escaped in index.html")
+	}
+	if strings.Contains(files["data/followers.json"], "follower@example.com") {
+		t.Error("followers must not carry their email")
+	}
+	if !strings.Contains(files["data/tags.json"], `"name": "go",
+    "tweets": 2`) {
+		t.Errorf("tags should be counted, got %s", files["data/tags.json"])
+	}
+	if !strings.Contains(files["data/likes.json"], `"tweet_id": 7`) {
+		t.Errorf("the user's likes should be exported, got %s", files["data/likes.json"])
+	}
+	if files["data/following.json"] != "[]\n" {
+		t.Errorf("empty list should be [], got %q", files["data/following.json"])
+	}
+}
+
+func TestRequestExportReusesRecentArchive(t *testing.T) {
+	uc, exports, _ := newTestUseCase(t, &fakeTweetService{})
+
+	first, _ := uc.RequestExport(1)
+	again, _ := uc.RequestExport(1)
+	if again.ID != first.ID {
+		t.Fatal("a pending export should be returned while it is built")
+	}
+
+	uc.BuildDue()
+	ready, _ := uc.RequestExport(1)
+	if ready.ID != first.ID || ready.Status != domain.ExportReady {
+		t.Fatalf("a recent archive should be handed out again, got %+v", ready)
+	}
+
+	exports.exports[0].RequestedAt = time.Now().Add(-RequestCooldown - time.Minute)
+	fresh, _ := uc.RequestExport(1)
+	if fresh.ID == first.ID {
+		t.Fatal("a new export should start after the cooldown")
+	}
+}
+
+func TestGetExportOfOtherUser(t *testing.T) {
+	uc, _, _ := newTestUseCase(t, &fakeTweetService{})
+	export, _ := uc.RequestExport(1)
+
+	if _, err := uc.GetExport(2, export.ID); !errors.Is(err, ErrExportNotFound) {
+		t.Fatalf("expected ErrExportNotFound, got %v", err)
+	}
+}
+
+func TestDownloadLinkRejected(t *testing.T) {
+	uc, exports, _ := newTestUseCase(t, &fakeTweetService{})
+	requested, _ := uc.RequestExport(1)
+	uc.BuildDue()
+	status, _ := uc.GetExport(1, requested.ID)
+	token := tokenFrom(t, status)
+
+	if _, _, err := uc.OpenDownload(token + "x"); !errors.Is(err, ErrInvalidDownloadLink) {
+		t.Errorf("tampered link: expected ErrInvalidDownloadLink, got %v", err)
+	}
+
+	stale := utils.SignPayload(uc.signingSecret, fmt.Sprintf("export:%d:%d", requested.ID, time.Now().Add(-time.Second).Unix()))
+	if _, _, err := uc.OpenDownload(stale); !errors.Is(err, ErrInvalidDownloadLink) {
+		t.Errorf("stale link: expected ErrInvalidDownloadLink, got %v", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	exports.exports[0].ExpiresAt = &past
+	if expired := uc.ExpireOld(); expired != 1 {
+		t.Fatalf("expected 1 archive removed, got %d", expired)
+	}
+	if _, _, err := uc.OpenDownload(token); !errors.Is(err, ErrExportExpired) {
+		t.Errorf("expired archive: expected ErrExportExpired, got %v", err)
+	}
+}
+
+func TestBuildRetriesWhenTweetServiceFails(t *testing.T) {
+	tweets := &fakeTweetService{err: domain.ErrTweetServiceError}
+	uc, exports, _ := newTestUseCase(t, tweets)
+	uc.RequestExport(1)
+
+	if built := uc.BuildDue(); built != 0 {
+		t.Fatalf("expected nothing built, got %d", built)
+	}
+	export := exports.exports[0]
+	if export.Status != domain.ExportBuilding || export.Attempts != 1 || !export.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("failed build should be retried later, got %+v", export)
+	}
+
+	tweets.err = nil
+	export.NextAttemptAt = time.Now()
+	if built := uc.BuildDue(); built != 1 || export.Status != domain.ExportReady {
+		t.Fatalf("retry should build the export, got %+v", export)
+	}
+}
@@ -0,0 +1,42 @@
+package followers
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// encodeCursor turns the last entry of a page into the opaque cursor of the
+// next page.
+func encodeCursor(last *domain.FollowEntry) string {
+	raw := fmt.Sprintf("%d:%d", last.FollowedAt.UnixNano(), last.EdgeID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor reads a cursor made by encodeCursor. An empty cursor starts at
+// the first page.
+func decodeCursor(cursor string) (*domain.FollowCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	edgeID, err := strconv.Atoi(id)
+	if err != nil || edgeID < 1 {
+		return nil, ErrInvalidCursor
+	}
+	return &domain.FollowCursor{FollowedAt: time.Unix(0, unixNano).UTC(), EdgeID: edgeID}, nil
+}
@@ -0,0 +1,34 @@
+package followers
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	followedAt := time.Date(2024, 3, 1, 12, 30, 15, 123456000, time.UTC)
+	cursor := encodeCursor(&domain.FollowEntry{EdgeID: 42, FollowedAt: followedAt})
+
+	got, err := decodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	if got.EdgeID != 42 || !got.FollowedAt.Equal(followedAt) {
+		t.Fatalf("decodeCursor = %+v, want edge 42 at %v", got, followedAt)
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	for _, cursor := range []string{"not base64!", "bm9jb2xvbg", "MTIzOmFiYw", "MTIzOjA"} {
+		if _, err := decodeCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+
+	got, err := decodeCursor("")
+	if err != nil || got != nil {
+		t.Fatalf("decodeCursor(\"\") = %v, %v, want the first page", got, err)
+	}
+}
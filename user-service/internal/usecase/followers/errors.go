@@ -12,4 +12,11 @@ var (
 	ErrFolloweeNotFound                = errors.New("followee not found")
 	ErrFailedToCheckIfAlreadyFollowing = errors.New("failed to check if already following")
 	ErrorAlreadyFollowing              = errors.New("already following")
+	ErrFollowRequestPending            = errors.New("follow request already sent")
+	ErrFollowRequestNotFound           = errors.New("follow request not found")
+	ErrBlocked                         = errors.New("one of the users blocked the other")
+	ErrNotBlocked                      = errors.New("user is not blocked")
+	ErrNotMuted                        = errors.New("user is not muted")
+	ErrInvalidCursor                   = errors.New("invalid cursor")
+	ErrInvalidPageSize                 = errors.New("limit must be between 1 and 100")
 )
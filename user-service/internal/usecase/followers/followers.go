@@ -2,57 +2,150 @@ package followers
 
 import (
 	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
 	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
 	"errors"
 	"go.uber.org/zap"
 )
 
+// Page sizes of follower and following lists.
+const (
+	DefaultFollowPageSize = 20
+	MaxFollowPageSize     = 100
+)
+
+// Notifier tells users what others did to them.
+type Notifier interface {
+	Notify(event *domain.NotificationEvent) error
+}
+
+// Webhooks delivers events to the webhooks of the apps of the user they
+// concern.
+type Webhooks interface {
+	Dispatch(userID int, event string, data interface{}) error
+}
+
 type useCase struct {
-	userRepo     repository.UserRepo
-	followerRepo repository.FollowerRepo
-	logger       *zap.SugaredLogger
+	userRepo      repository.UserRepo
+	followerRepo  repository.FollowerRepo
+	mutedWordRepo repository.MutedWordRepo
+	notifier      Notifier
+	webhooks      Webhooks
+	logger        *zap.SugaredLogger
 }
 
-func NewFollowerUseCase(userRepo repository.UserRepo, followerRepo repository.FollowerRepo, logger *zap.SugaredLogger) *useCase {
+func NewFollowerUseCase(
+	userRepo repository.UserRepo,
+	followerRepo repository.FollowerRepo,
+	mutedWordRepo repository.MutedWordRepo,
+	notifier Notifier,
+	webhooks Webhooks,
+	logger *zap.SugaredLogger,
+) *useCase {
 	return &useCase{
-		userRepo:     userRepo,
-		followerRepo: followerRepo,
-		logger:       logger,
+		userRepo:      userRepo,
+		followerRepo:  followerRepo,
+		mutedWordRepo: mutedWordRepo,
+		notifier:      notifier,
+		webhooks:      webhooks,
+		logger:        logger,
 	}
 }
 
-func (uc *useCase) Follow(followerID, followeeID int) error {
+// Follow makes the follower follow the followee. Following a protected user
+// only sends a follow request, which the followee has to approve; requested
+// reports whether that happened.
+func (uc *useCase) Follow(followerID, followeeID int) (requested bool, err error) {
 	// validate ids
-	err := validateIds(followerID, followeeID)
+	err = validateIds(followerID, followeeID)
 	if err != nil {
 		uc.logger.Warnw("validation failed: validateIds", "followerID", followerID, "followeeID:", followeeID, "error", err)
-		return err
+		return false, err
 	}
 	// check if ids exists
 	err = uc.checkIfFollowerAndFolloweeExist(followerID, followeeID)
 	if err != nil {
 		uc.logger.Warnw("validation failed: checkIfFollowerAndFolloweeExist",
 			"followerID", followerID, "followeeID:", followeeID, "error", err)
-		return err
+		return false, err
 	}
 
 	// check if already following
 	isFollowing, err := uc.followerRepo.IsFollowing(followerID, followeeID)
 	if err != nil {
 		uc.logger.Errorw("failed to check following status", "followerID", followerID, "followeeID", followeeID, "error", err)
-		return ErrFailedToCheckIfAlreadyFollowing
+		return false, ErrFailedToCheckIfAlreadyFollowing
 	}
 	if isFollowing {
-		return ErrorAlreadyFollowing
+		return false, ErrorAlreadyFollowing
+	}
+
+	// no follows between users who blocked each other, in either direction
+	blocked, err := uc.followerRepo.IsBlocked(followerID, followeeID)
+	if err != nil {
+		uc.logger.Errorw("failed to check block", "followerID", followerID, "followeeID", followeeID, "error", err)
+		return false, err
+	}
+	if blocked {
+		return false, ErrBlocked
+	}
+
+	followee, err := uc.userRepo.GetByID(followeeID)
+	if err != nil {
+		return false, err
+	}
+	if followee.Protected {
+		return true, uc.requestFollow(followerID, followeeID)
 	}
 
 	// follow
 	err = uc.followerRepo.Follow(followerID, followeeID)
 	if err != nil {
 		uc.logger.Errorw("Failed to follow", "followerID", followerID, "followeeID", followeeID, "error", err)
+		return false, err
+	}
+
+	uc.notifyFollow(followerID, followeeID)
+	uc.dispatchFollow(followerID, followeeID)
+
+	return false, nil
+}
+
+// notifyFollow tells the followee about a new follower. The follow stands
+// even if they cannot be told.
+func (uc *useCase) notifyFollow(followerID, followeeID int) {
+	event := &domain.NotificationEvent{Type: domain.NotificationFollow, RecipientID: followeeID, ActorID: followerID}
+	if err := uc.notifier.Notify(event); err != nil {
+		uc.logger.Warnw("Failed to notify of follow", "followerID", followerID, "followeeID", followeeID, "error", err)
+	}
+}
+
+// dispatchFollow tells the webhooks of the followee's apps about a new
+// follower. The follow stands even if they cannot be told.
+func (uc *useCase) dispatchFollow(followerID, followeeID int) {
+	follower, err := uc.userRepo.GetByID(followerID)
+	if err == nil {
+		err = uc.webhooks.Dispatch(followeeID, domain.WebhookFollowerCreated,
+			&dto.WebhookFollower{Follower: dto.NewPublicProfile(follower)})
+	}
+	if err != nil {
+		uc.logger.Warnw("Failed to dispatch follow to webhooks", "followerID", followerID, "followeeID", followeeID, "error", err)
+	}
+}
+
+func (uc *useCase) requestFollow(followerID, followeeID int) error {
+	pending, err := uc.followerRepo.HasFollowRequest(followerID, followeeID)
+	if err != nil {
 		return err
 	}
+	if pending {
+		return ErrFollowRequestPending
+	}
 
+	if err = uc.followerRepo.RequestFollow(followerID, followeeID); err != nil {
+		return err
+	}
+	uc.logger.Infow("Sent follow request", "followerID", followerID, "followeeID", followeeID)
 	return nil
 }
 
@@ -90,61 +183,54 @@ func (uc *useCase) Unfollow(followerID, followeeID int) error {
 	return nil
 }
 
-func (uc *useCase) GetFollowers(userID int) ([]*domain.User, error) {
-	// validate id
-	err := validateID(userID)
-	if err != nil {
-		uc.logger.Warnw("validation failed: validateID", "userID", userID, "error", err)
-		return nil, err
-	}
-	// check if id exists
-	_, err = uc.userRepo.GetByID(userID)
-	if err != nil {
-		uc.logger.Warnw("user not found in GetFollowers", "userID", userID, "error", err)
-		switch {
-		case errors.Is(err, ErrUserNotFound):
-			return nil, ErrUserNotFound
-		default:
-			return nil, err
-		}
-	}
-
-	followers, err := uc.followerRepo.GetFollowers(userID)
-	if err != nil {
-		uc.logger.Errorw("Failed to retrieve followers", "userID", userID, "error", err)
-		return nil, err
-	}
+// GetFollowers returns one page of the user's followers, most recent first,
+// and the cursor of the next page. viewerID is the caller, or 0 when
+// anonymous.
+func (uc *useCase) GetFollowers(userID, viewerID int, cursor string, limit int) ([]*domain.FollowEntry, string, error) {
+	return uc.listFollows(userID, viewerID, cursor, limit, uc.followerRepo.ListFollowers)
+}
 
-	return followers, nil
+// GetFollowing returns one page of the users the user follows, most recent
+// first, and the cursor of the next page.
+func (uc *useCase) GetFollowing(userID, viewerID int, cursor string, limit int) ([]*domain.FollowEntry, string, error) {
+	return uc.listFollows(userID, viewerID, cursor, limit, uc.followerRepo.ListFollowing)
 }
 
-func (uc *useCase) GetFollowing(userID int) ([]*domain.User, error) {
-	// validate id
-	err := validateID(userID)
+type listFollowsFunc func(userID, viewerID int, after *domain.FollowCursor, limit int) ([]*domain.FollowEntry, error)
+
+func (uc *useCase) listFollows(userID, viewerID int, cursor string, limit int, list listFollowsFunc) ([]*domain.FollowEntry, string, error) {
+	if err := validateID(userID); err != nil {
+		uc.logger.Warnw("validation failed: validateID", "userID", userID, "error", err)
+		return nil, "", err
+	}
+	if limit < 1 || limit > MaxFollowPageSize {
+		return nil, "", ErrInvalidPageSize
+	}
+	after, err := decodeCursor(cursor)
 	if err != nil {
-		uc.logger.Warnw("Validation failed in GetFollowing", "userID", userID, "error", err)
-		return nil, err
+		return nil, "", err
 	}
 
 	// check if id exists
-	_, err = uc.userRepo.GetByID(userID)
-	if err != nil {
-		uc.logger.Warnw("User not found in GetFollowing", "userID", userID, "error", err)
-		switch {
-		case errors.Is(err, ErrUserNotFound):
-			return nil, ErrUserNotFound
-		default:
-			return nil, err
+	if _, err = uc.userRepo.GetByID(userID); err != nil {
+		uc.logger.Warnw("user not found when listing follows", "userID", userID, "error", err)
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, "", ErrUserNotFound
 		}
+		return nil, "", err
 	}
 
-	following, err := uc.followerRepo.GetFollowing(userID)
+	// one extra entry tells whether there is a next page
+	entries, err := list(userID, viewerID, after, limit+1)
 	if err != nil {
-		uc.logger.Errorw("Failed to retrieve following", "userID", userID, "error", err)
-		return nil, err
+		uc.logger.Errorw("Failed to list follows", "userID", userID, "error", err)
+		return nil, "", err
 	}
-
-	return following, nil
+	if len(entries) <= limit {
+		return entries, "", nil
+	}
+	entries = entries[:limit]
+	return entries, encodeCursor(entries[limit-1]), nil
 }
 
 func (uc *useCase) IsFollowing(followerID, followeeID int) (bool, error) {
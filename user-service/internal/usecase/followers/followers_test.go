@@ -0,0 +1,110 @@
+package followers
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// fakeUserRepo holds users 1 to 3, of whom 3 is protected.
+type fakeUserRepo struct {
+	repository.UserRepo
+}
+
+func (fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	if id < 1 || id > 3 {
+		return nil, domain.ErrRecordNotFound
+	}
+	return &domain.User{ID: id, Protected: id == 3}, nil
+}
+
+type follow struct{ follower, followee int }
+
+type fakeFollowerRepo struct {
+	repository.FollowerRepo
+	follows  map[follow]bool
+	requests map[follow]bool
+}
+
+func (r *fakeFollowerRepo) IsFollowing(followerID, followedID int) (bool, error) {
+	return r.follows[follow{followerID, followedID}], nil
+}
+
+func (r *fakeFollowerRepo) IsBlocked(userID, otherID int) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeFollowerRepo) Follow(followerID, followedID int) error {
+	r.follows[follow{followerID, followedID}] = true
+	return nil
+}
+
+func (r *fakeFollowerRepo) HasFollowRequest(requesterID, targetID int) (bool, error) {
+	return r.requests[follow{requesterID, targetID}], nil
+}
+
+func (r *fakeFollowerRepo) RequestFollow(requesterID, targetID int) error {
+	r.requests[follow{requesterID, targetID}] = true
+	return nil
+}
+
+func (r *fakeFollowerRepo) ApproveFollowRequest(requesterID, targetID int) error {
+	if !r.requests[follow{requesterID, targetID}] {
+		return domain.ErrRecordNotFound
+	}
+	delete(r.requests, follow{requesterID, targetID})
+	return r.Follow(requesterID, targetID)
+}
+
+type recorder struct {
+	notified   []follow
+	dispatched []follow
+}
+
+func (r *recorder) Notify(event *domain.NotificationEvent) error {
+	if event.Type == domain.NotificationFollow {
+		r.notified = append(r.notified, follow{event.ActorID, event.RecipientID})
+	}
+	return nil
+}
+
+func (r *recorder) Dispatch(userID int, event string, data interface{}) error {
+	r.dispatched = append(r.dispatched, follow{0, userID})
+	return nil
+}
+
+func newTestUseCase() (*useCase, *recorder) {
+	rec := &recorder{}
+	repo := &fakeFollowerRepo{follows: map[follow]bool{}, requests: map[follow]bool{}}
+	return NewFollowerUseCase(fakeUserRepo{}, repo, nil, rec, rec, zap.NewNop().Sugar()), rec
+}
+
+func TestFollowNotifiesFollowee(t *testing.T) {
+	uc, rec := newTestUseCase()
+
+	if requested, err := uc.Follow(1, 2); err != nil || requested {
+		t.Fatalf("Follow = %v, %v", requested, err)
+	}
+	if len(rec.notified) != 1 || rec.notified[0] != (follow{1, 2}) || len(rec.dispatched) != 1 {
+		t.Errorf("notified %v, dispatched %v, want the follow of 2 by 1", rec.notified, rec.dispatched)
+	}
+}
+
+func TestApprovedRequestNotifiesFollowee(t *testing.T) {
+	uc, rec := newTestUseCase()
+
+	if requested, err := uc.Follow(1, 3); err != nil || !requested {
+		t.Fatalf("Follow = %v, %v, want a request", requested, err)
+	}
+	if len(rec.notified) != 0 {
+		t.Fatalf("notified %v before the request was approved", rec.notified)
+	}
+	if err := uc.ApproveRequest(3, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.notified) != 1 || rec.notified[0] != (follow{1, 3}) || len(rec.dispatched) != 1 {
+		t.Errorf("notified %v, dispatched %v, want the follow of 3 by 1", rec.notified, rec.dispatched)
+	}
+}
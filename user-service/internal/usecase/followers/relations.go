@@ -0,0 +1,73 @@
+package followers
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"errors"
+)
+
+// Block makes the user block the target, which also ends any follow or follow
+// request between them.
+func (uc *useCase) Block(userID, targetID int) error {
+	if err := uc.checkTarget(userID, targetID); err != nil {
+		return err
+	}
+	if err := uc.followerRepo.Block(userID, targetID); err != nil {
+		return err
+	}
+	uc.logger.Infow("Blocked user", "userID", userID, "targetID", targetID)
+	return nil
+}
+
+func (uc *useCase) Unblock(userID, targetID int) error {
+	err := uc.followerRepo.Unblock(userID, targetID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrNotBlocked
+	}
+	return err
+}
+
+func (uc *useCase) ListBlocked(userID int) ([]*domain.User, error) {
+	return uc.followerRepo.ListBlocked(userID)
+}
+
+// Mute hides the target's tweets from the user's listings. Unlike a block, the
+// target is not told and follows are kept.
+func (uc *useCase) Mute(userID, targetID int) error {
+	if err := uc.checkTarget(userID, targetID); err != nil {
+		return err
+	}
+	return uc.followerRepo.Mute(userID, targetID)
+}
+
+func (uc *useCase) Unmute(userID, targetID int) error {
+	err := uc.followerRepo.Unmute(userID, targetID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrNotMuted
+	}
+	return err
+}
+
+func (uc *useCase) ListMuted(userID int) ([]*domain.User, error) {
+	return uc.followerRepo.ListMuted(userID)
+}
+
+// Relations returns the users and words whose tweets are shown to or hidden
+// from the user, for tweet-service to filter what the user reads.
+func (uc *useCase) Relations(userID int) (*domain.Relations, error) {
+	relations, err := uc.followerRepo.GetRelations(userID)
+	if err != nil {
+		return nil, err
+	}
+	relations.MutedWords, err = uc.mutedWordRepo.ListActive(userID)
+	if err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+func (uc *useCase) checkTarget(userID, targetID int) error {
+	if err := validateIds(userID, targetID); err != nil {
+		return err
+	}
+	return uc.checkIfFolloweeExists(targetID)
+}
@@ -0,0 +1,51 @@
+package followers
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"errors"
+)
+
+// IncomingRequests returns the pending requests to follow the user.
+func (uc *useCase) IncomingRequests(userID int) ([]*domain.FollowRequest, error) {
+	return uc.followerRepo.ListIncomingRequests(userID)
+}
+
+// OutgoingRequests returns the user's pending requests to follow others.
+func (uc *useCase) OutgoingRequests(userID int) ([]*domain.FollowRequest, error) {
+	return uc.followerRepo.ListOutgoingRequests(userID)
+}
+
+// ApproveRequest lets the requester follow the user. The follow starts now, so
+// it is notified and dispatched like the follow of a public account.
+func (uc *useCase) ApproveRequest(userID, requesterID int) error {
+	err := uc.followerRepo.ApproveFollowRequest(requesterID, userID)
+	if err != nil {
+		return requestNotFound(err)
+	}
+	uc.logger.Infow("Approved follow request", "userID", userID, "requesterID", requesterID)
+	uc.notifyFollow(requesterID, userID)
+	uc.dispatchFollow(requesterID, userID)
+	return nil
+}
+
+// RejectRequest turns down a request to follow the user.
+func (uc *useCase) RejectRequest(userID, requesterID int) error {
+	err := uc.followerRepo.DeleteFollowRequest(requesterID, userID)
+	if err != nil {
+		return requestNotFound(err)
+	}
+	uc.logger.Infow("Rejected follow request", "userID", userID, "requesterID", requesterID)
+	return nil
+}
+
+// CancelRequest withdraws the user's request to follow the target.
+func (uc *useCase) CancelRequest(userID, targetID int) error {
+	return requestNotFound(uc.followerRepo.DeleteFollowRequest(userID, targetID))
+}
+
+func requestNotFound(err error) error {
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrFollowRequestNotFound
+	}
+	return err
+}
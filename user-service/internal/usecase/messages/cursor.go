@@ -0,0 +1,29 @@
+package messages
+
+import (
+	"encoding/base64"
+	"strconv"
+)
+
+// encodeCursor turns the id of the oldest message of a page into the opaque
+// cursor of the next page.
+func encodeCursor(lastID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(lastID, 10)))
+}
+
+// decodeCursor reads a cursor made by encodeCursor. An empty cursor starts at
+// the latest message, which is returned as 0.
+func decodeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id < 1 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,30 @@
+package messages
+
+import "errors"
+
+var (
+	ErrInvalidRecipient     = errors.New("invalid recipient")
+	ErrRecipientNotFound    = errors.New("recipient not found")
+	ErrEmptyMessage         = errors.New("message cannot be empty")
+	ErrMessageTooLong       = errors.New("message is too long")
+	ErrBlocked              = errors.New("one of the users blocked the other")
+	ErrRecipientRestricted  = errors.New("the recipient only takes messages from their followers")
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrMessageNotFound      = errors.New("message not found")
+	ErrNotSender            = errors.New("only the sender can delete a message")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrInvalidPageSize      = errors.New("limit must be between 1 and 100")
+	ErrInvalidOffset        = errors.New("invalid offset")
+	ErrNoSettings           = errors.New("no setting to update")
+	ErrSystemMessage        = errors.New("system messages cannot be deleted")
+	ErrInvalidName          = errors.New("group name must be between 1 and 64 characters")
+	ErrGroupFull            = errors.New("group is full")
+	ErrNotGroup             = errors.New("conversation is not a group")
+	ErrNotAdmin             = errors.New("only admins can manage the group")
+	ErrNotMember            = errors.New("user is not a member of the group")
+	ErrAlreadyMember        = errors.New("user is already a member of the group")
+	ErrAlreadyInvited       = errors.New("user is already invited to the group")
+	ErrInviteNotFound       = errors.New("invite not found")
+	ErrInvalidRole          = errors.New("role must be admin or member")
+	ErrLastAdmin            = errors.New("the group needs another admin first")
+)
@@ -0,0 +1,327 @@
+package messages
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxGroupMembers caps the members of a group, pending invites included.
+	MaxGroupMembers = 50
+	// MaxGroupNameLength is the longest group name, in characters.
+	MaxGroupNameLength = 64
+)
+
+// CreateGroup starts a group with the creator as its admin and invites the
+// members, who join once they accept.
+func (uc *useCase) CreateGroup(creatorID int, input *dto.CreateGroupRequest) (*dto.ConversationView, error) {
+	name, err := validateName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{creatorID: true}
+	var memberIDs []int
+	for _, id := range input.MemberIDs {
+		if id < 1 {
+			return nil, ErrInvalidRecipient
+		}
+		if !seen[id] {
+			seen[id] = true
+			memberIDs = append(memberIDs, id)
+		}
+	}
+	if len(memberIDs)+1 > MaxGroupMembers {
+		return nil, ErrGroupFull
+	}
+	// the group does not exist yet, so no member could have written in it
+	for _, id := range memberIDs {
+		if err = uc.checkMayMessage(creatorID, id, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	conversationID, err := uc.messageRepo.CreateGroup(creatorID, name)
+	if err != nil {
+		return nil, err
+	}
+	conversation, err := uc.conversation(creatorID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	uc.systemMessage(conversation, creatorID, domain.GroupCreated, nil, name)
+	for _, id := range memberIDs {
+		if err = uc.invite(conversation, creatorID, id); err != nil {
+			return nil, err
+		}
+	}
+	return uc.GetConversation(creatorID, conversationID)
+}
+
+// GetConversation returns a conversation of the user with its members.
+func (uc *useCase) GetConversation(userID, conversationID int) (*dto.ConversationView, error) {
+	conversation, err := uc.conversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	conversations := []*domain.Conversation{conversation}
+	users, err := uc.members(conversations)
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewConversationView(conversation, userID, users), nil
+}
+
+// Rename renames a group the user is an admin of.
+func (uc *useCase) Rename(userID, conversationID int, name string) (*dto.ConversationView, error) {
+	name, err := validateName(name)
+	if err != nil {
+		return nil, err
+	}
+	conversation, err := uc.requireAdmin(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if name != conversation.Name {
+		if err = uc.messageRepo.Rename(conversationID, name); err != nil {
+			return nil, err
+		}
+		uc.systemMessage(conversation, userID, domain.GroupRenamed, nil, name)
+	}
+	return uc.GetConversation(userID, conversationID)
+}
+
+// Invite invites a user to a group the inviter is an admin of.
+func (uc *useCase) Invite(userID, conversationID, inviteeID int) error {
+	conversation, err := uc.requireAdmin(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if inviteeID < 1 || inviteeID == userID {
+		return ErrInvalidRecipient
+	}
+	if conversation.Member(inviteeID) != nil {
+		return ErrAlreadyMember
+	}
+	invited, err := uc.messageRepo.HasInvite(conversationID, inviteeID)
+	if err != nil {
+		return err
+	}
+	if invited {
+		return ErrAlreadyInvited
+	}
+	seats, err := uc.messageRepo.CountSeats(conversationID)
+	if err != nil {
+		return err
+	}
+	if seats >= MaxGroupMembers {
+		return ErrGroupFull
+	}
+	if err = uc.checkMayMessage(userID, inviteeID, conversationID); err != nil {
+		return err
+	}
+	return uc.invite(conversation, userID, inviteeID)
+}
+
+// invite stores the invite and streams it to the invitee.
+func (uc *useCase) invite(conversation *domain.Conversation, inviterID, inviteeID int) error {
+	if err := uc.messageRepo.Invite(conversation.ID, inviteeID, inviterID); err != nil {
+		return err
+	}
+	view := &dto.ConversationInviteView{ConversationID: conversation.ID, Name: conversation.Name, InvitedBy: &inviterID}
+	if err := uc.eventRepo.PublishToUser(inviteeID, domain.EventInvite, view); err != nil {
+		uc.logger.Warnw("Failed to stream group invite",
+			"conversationID", conversation.ID, "userID", inviteeID, "error", err)
+	}
+	return nil
+}
+
+// Invites returns the user's pending group invites, newest first.
+func (uc *useCase) Invites(userID int) ([]*dto.ConversationInviteView, error) {
+	invites, err := uc.messageRepo.ListInvites(userID)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]*dto.ConversationInviteView, 0, len(invites))
+	for _, invite := range invites {
+		views = append(views, dto.NewConversationInviteView(invite))
+	}
+	return views, nil
+}
+
+// AcceptInvite makes the user a member of the group they were invited to.
+// They see its whole history.
+func (uc *useCase) AcceptInvite(userID, conversationID int) (*dto.ConversationView, error) {
+	err := uc.messageRepo.AcceptInvite(conversationID, userID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, ErrInviteNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	conversation, err := uc.conversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	uc.systemMessage(conversation, userID, domain.GroupMemberJoined, &userID, "")
+	return uc.GetConversation(userID, conversationID)
+}
+
+func (uc *useCase) DeclineInvite(userID, conversationID int) error {
+	err := uc.messageRepo.DeclineInvite(conversationID, userID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrInviteNotFound
+	}
+	return err
+}
+
+// Leave takes the user out of a group. When the last admin leaves, the
+// member who joined first becomes one; the last member leaving deletes it.
+func (uc *useCase) Leave(userID, conversationID int) error {
+	conversation, err := uc.conversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if !conversation.IsGroup {
+		return ErrNotGroup
+	}
+
+	if successor := successor(conversation, userID); successor != nil {
+		if err = uc.messageRepo.SetRole(conversationID, successor.UserID, domain.ConversationRoleAdmin); err != nil {
+			return err
+		}
+		uc.systemMessage(conversation, userID, domain.GroupRoleChanged, &successor.UserID, domain.ConversationRoleAdmin)
+	}
+
+	if err = uc.messageRepo.RemoveMember(conversationID, userID); errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrConversationNotFound
+	} else if err != nil {
+		return err
+	}
+	if len(conversation.Members) > 1 {
+		uc.systemMessage(conversation, userID, domain.GroupMemberLeft, &userID, "")
+	}
+	return nil
+}
+
+// successor returns the member to make an admin when the user leaves the
+// group, or nil when the group keeps an admin or has nobody else.
+func successor(conversation *domain.Conversation, userID int) *domain.ConversationMember {
+	if conversation.Member(userID).Role != domain.ConversationRoleAdmin || hasOtherAdmin(conversation, userID) {
+		return nil
+	}
+	// members are in the order they joined
+	for _, member := range conversation.Members {
+		if member.UserID != userID {
+			return member
+		}
+	}
+	return nil
+}
+
+// hasOtherAdmin reports whether the group has an admin besides the user.
+func hasOtherAdmin(conversation *domain.Conversation, userID int) bool {
+	for _, member := range conversation.Members {
+		if member.UserID != userID && member.Role == domain.ConversationRoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveMember takes a member out of a group the user is an admin of.
+// Removing themselves is leaving.
+func (uc *useCase) RemoveMember(userID, conversationID, memberID int) error {
+	if memberID == userID {
+		return uc.Leave(userID, conversationID)
+	}
+	conversation, err := uc.requireAdmin(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if conversation.Member(memberID) == nil {
+		return ErrNotMember
+	}
+
+	if err = uc.messageRepo.RemoveMember(conversationID, memberID); errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrNotMember
+	} else if err != nil {
+		return err
+	}
+	// the removed member is still among the members streamed to, so they
+	// learn they were removed
+	uc.systemMessage(conversation, userID, domain.GroupMemberRemoved, &memberID, "")
+	return nil
+}
+
+// SetRole gives a member of a group the user is an admin of a role. Admins
+// may step down as long as another admin is left.
+func (uc *useCase) SetRole(userID, conversationID, memberID int, role string) error {
+	if role != domain.ConversationRoleAdmin && role != domain.ConversationRoleMember {
+		return ErrInvalidRole
+	}
+	conversation, err := uc.requireAdmin(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	member := conversation.Member(memberID)
+	if member == nil {
+		return ErrNotMember
+	}
+	if member.Role == role {
+		return nil
+	}
+	if memberID == userID && !hasOtherAdmin(conversation, userID) {
+		return ErrLastAdmin
+	}
+
+	if err = uc.messageRepo.SetRole(conversationID, memberID, role); errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrNotMember
+	} else if err != nil {
+		return err
+	}
+	uc.systemMessage(conversation, userID, domain.GroupRoleChanged, &memberID, role)
+	return nil
+}
+
+// requireAdmin returns a group the user is an admin of.
+func (uc *useCase) requireAdmin(userID, conversationID int) (*domain.Conversation, error) {
+	conversation, err := uc.conversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conversation.IsGroup {
+		return nil, ErrNotGroup
+	}
+	if conversation.Member(userID).Role != domain.ConversationRoleAdmin {
+		return nil, ErrNotAdmin
+	}
+	return conversation, nil
+}
+
+// systemMessage tells the members of a group about a change the actor made
+// to it. The change stands even if the message cannot be stored.
+func (uc *useCase) systemMessage(conversation *domain.Conversation, actorID int, event string, targetID *int, body string) {
+	message := &domain.Message{
+		ConversationID: conversation.ID,
+		SenderID:       actorID,
+		Body:           body,
+		Event:          event,
+		TargetID:       targetID,
+	}
+	if err := uc.messageRepo.Insert(message); err != nil {
+		uc.logger.Errorw("Failed to store system message", "conversationID", conversation.ID, "event", event, "error", err)
+		return
+	}
+	uc.publish(conversation, domain.EventMessage, dto.NewMessageView(message, conversation.Members))
+}
+
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxGroupNameLength {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
@@ -0,0 +1,113 @@
+package messages
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type fakeGroupRepo struct {
+	fakeMessageRepo
+	group   *domain.Conversation
+	roles   map[int]string
+	removed []int
+}
+
+func (r *fakeGroupRepo) GetConversation(userID, conversationID int) (*domain.Conversation, error) {
+	if r.group.Member(userID) == nil {
+		return nil, domain.ErrRecordNotFound
+	}
+	return r.group, nil
+}
+
+func (r *fakeGroupRepo) SetRole(conversationID, userID int, role string) error {
+	r.roles[userID] = role
+	return nil
+}
+
+func (r *fakeGroupRepo) RemoveMember(conversationID, userID int) error {
+	r.removed = append(r.removed, userID)
+	return nil
+}
+
+func newGroup(roles ...string) *domain.Conversation {
+	group := &domain.Conversation{ID: 7, IsGroup: true, Name: "team"}
+	for i, role := range roles {
+		group.Members = append(group.Members, &domain.ConversationMember{UserID: i + 1, Role: role})
+	}
+	return group
+}
+
+func TestLeave(t *testing.T) {
+	admin, member := domain.ConversationRoleAdmin, domain.ConversationRoleMember
+	tests := []struct {
+		name     string
+		group    *domain.Conversation
+		promoted int
+		events   []string
+	}{
+		{"last admin", newGroup(admin, member, member), 2, []string{domain.GroupRoleChanged, domain.GroupMemberLeft}},
+		{"another admin left", newGroup(admin, member, admin), 0, []string{domain.GroupMemberLeft}},
+		{"member", newGroup(member, admin), 0, []string{domain.GroupMemberLeft}},
+		{"last member", newGroup(admin), 0, nil},
+	}
+	for _, tt := range tests {
+		messages := &fakeGroupRepo{group: tt.group, roles: map[int]string{}}
+		uc := NewMessageUseCase(messages, &fakeUserRepo{}, &fakeFollowerRepo{}, &fakeEventRepo{}, zap.NewNop().Sugar())
+
+		if err := uc.Leave(1, 7); err != nil {
+			t.Errorf("%s: got error %v", tt.name, err)
+			continue
+		}
+		if len(messages.removed) != 1 || messages.removed[0] != 1 {
+			t.Errorf("%s: removed %v, want the user", tt.name, messages.removed)
+		}
+		if tt.promoted != 0 && messages.roles[tt.promoted] != admin {
+			t.Errorf("%s: user %d was not made an admin", tt.name, tt.promoted)
+		}
+		if tt.promoted == 0 && len(messages.roles) != 0 {
+			t.Errorf("%s: roles changed to %v", tt.name, messages.roles)
+		}
+		var events []string
+		for _, m := range messages.inserted {
+			events = append(events, m.Event)
+		}
+		if len(events) != len(tt.events) {
+			t.Errorf("%s: system messages %v, want %v", tt.name, events, tt.events)
+			continue
+		}
+		for i := range events {
+			if events[i] != tt.events[i] {
+				t.Errorf("%s: system messages %v, want %v", tt.name, events, tt.events)
+				break
+			}
+		}
+	}
+}
+
+func TestGroupAdminChecks(t *testing.T) {
+	admin, member := domain.ConversationRoleAdmin, domain.ConversationRoleMember
+	messages := &fakeGroupRepo{group: newGroup(admin, member), roles: map[int]string{}}
+	uc := NewMessageUseCase(messages, &fakeUserRepo{}, &fakeFollowerRepo{}, &fakeEventRepo{}, zap.NewNop().Sugar())
+
+	if err := uc.Invite(2, 7, 3); !errors.Is(err, ErrNotAdmin) {
+		t.Errorf("member invites: got %v, want ErrNotAdmin", err)
+	}
+	if err := uc.RemoveMember(2, 7, 1); !errors.Is(err, ErrNotAdmin) {
+		t.Errorf("member removes: got %v, want ErrNotAdmin", err)
+	}
+	if err := uc.SetRole(1, 7, 1, member); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("last admin steps down: got %v, want ErrLastAdmin", err)
+	}
+	if err := uc.SetRole(1, 7, 3, admin); !errors.Is(err, ErrNotMember) {
+		t.Errorf("outsider promoted: got %v, want ErrNotMember", err)
+	}
+	if _, err := uc.Messages(3, 7, "", 10); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("outsider reads: got %v, want ErrConversationNotFound", err)
+	}
+	if len(messages.roles) != 0 || len(messages.removed) != 0 {
+		t.Errorf("group changed: roles %v, removed %v", messages.roles, messages.removed)
+	}
+}
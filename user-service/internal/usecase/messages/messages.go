@@ -0,0 +1,319 @@
+package messages
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	// MaxMessageLength is the longest message, in characters.
+	MaxMessageLength = 1000
+)
+
+type useCase struct {
+	messageRepo  repository.MessageRepo
+	userRepo     repository.UserRepo
+	followerRepo repository.FollowerRepo
+	eventRepo    repository.EventRepo
+	logger       *zap.SugaredLogger
+}
+
+func NewMessageUseCase(
+	messageRepo repository.MessageRepo,
+	userRepo repository.UserRepo,
+	followerRepo repository.FollowerRepo,
+	eventRepo repository.EventRepo,
+	logger *zap.SugaredLogger,
+) *useCase {
+	return &useCase{
+		messageRepo:  messageRepo,
+		userRepo:     userRepo,
+		followerRepo: followerRepo,
+		eventRepo:    eventRepo,
+		logger:       logger,
+	}
+}
+
+// Send sends a message to the recipient in their direct conversation with
+// the sender, starting it if they never talked.
+func (uc *useCase) Send(senderID, recipientID int, body string) (*dto.MessageView, error) {
+	if recipientID < 1 || recipientID == senderID {
+		return nil, ErrInvalidRecipient
+	}
+	body, err := validateBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	// a conversation without messages is not listed, so starting it before
+	// checking whether the sender may write is harmless
+	conversationID, err := uc.messageRepo.GetOrCreateDirect(senderID, recipientID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.Post(senderID, conversationID, body)
+}
+
+// Post sends a message in a conversation of the sender.
+func (uc *useCase) Post(senderID, conversationID int, body string) (*dto.MessageView, error) {
+	body, err := validateBody(body)
+	if err != nil {
+		return nil, err
+	}
+	conversation, err := uc.conversation(senderID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	// members of a group accepted to hear from everyone in it
+	for _, member := range conversation.Members {
+		if conversation.IsGroup || member.UserID == senderID {
+			continue
+		}
+		if err = uc.checkMayMessage(senderID, member.UserID, conversationID); err != nil {
+			return nil, err
+		}
+	}
+
+	message := &domain.Message{ConversationID: conversationID, SenderID: senderID, Body: body}
+	if err = uc.messageRepo.Insert(message); err != nil {
+		uc.logger.Errorw("Failed to send message", "senderID", senderID, "conversationID", conversationID, "error", err)
+		return nil, err
+	}
+
+	view := dto.NewMessageView(message, conversation.Members)
+	uc.publish(conversation, domain.EventMessage, view)
+	return view, nil
+}
+
+// checkMayMessage tells whether the sender may write to the recipient: never
+// across a block, and, when the recipient only takes messages from their
+// followers, if the sender follows them or they wrote in the conversation.
+func (uc *useCase) checkMayMessage(senderID, recipientID, conversationID int) error {
+	recipient, err := uc.userRepo.GetByID(recipientID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrRecipientNotFound
+	} else if err != nil {
+		return err
+	}
+	if recipient.CheckActive() != nil {
+		return ErrRecipientNotFound
+	}
+
+	blocked, err := uc.followerRepo.IsBlocked(recipientID, senderID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+
+	allow, err := uc.messageRepo.AllowsNonFollowers(recipientID)
+	if err != nil || allow {
+		return err
+	}
+	following, err := uc.followerRepo.IsFollowing(senderID, recipientID)
+	if err != nil || following {
+		return err
+	}
+	replied, err := uc.messageRepo.HasSent(conversationID, recipientID)
+	if err != nil || replied {
+		return err
+	}
+	return ErrRecipientRestricted
+}
+
+// Conversations returns one page of the user's conversations, the most
+// recently active first.
+func (uc *useCase) Conversations(userID, limit, offset int) (*dto.ConversationPage, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, ErrInvalidPageSize
+	}
+	if offset < 0 {
+		return nil, ErrInvalidOffset
+	}
+
+	// one extra conversation tells whether there is a next page
+	conversations, err := uc.messageRepo.ListConversations(userID, limit+1, offset)
+	if err != nil {
+		return nil, err
+	}
+	page := &dto.ConversationPage{}
+	if len(conversations) > limit {
+		conversations = conversations[:limit]
+		next := offset + limit
+		page.NextOffset = &next
+	}
+
+	users, err := uc.members(conversations)
+	if err != nil {
+		return nil, err
+	}
+	page.Conversations = make([]*dto.ConversationView, 0, len(conversations))
+	for _, c := range conversations {
+		page.Conversations = append(page.Conversations, dto.NewConversationView(c, userID, users))
+	}
+	return page, nil
+}
+
+// members loads the users taking part in the conversations.
+func (uc *useCase) members(conversations []*domain.Conversation) (map[int]*domain.User, error) {
+	var ids []int
+	for _, c := range conversations {
+		for _, member := range c.Members {
+			ids = append(ids, member.UserID)
+		}
+	}
+	if len(ids) == 0 {
+		return map[int]*domain.User{}, nil
+	}
+	users, err := uc.userRepo.ListActiveByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]*domain.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	return byID, nil
+}
+
+// Messages returns one page of the history of a conversation of the user,
+// newest first, and the cursor of the next page.
+func (uc *useCase) Messages(userID, conversationID int, cursor string, limit int) (*dto.MessagePage, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, ErrInvalidPageSize
+	}
+	beforeID, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	conversation, err := uc.conversation(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// one extra message tells whether there is a next page
+	messages, err := uc.messageRepo.ListMessages(conversationID, beforeID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &dto.MessagePage{}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		page.NextCursor = encodeCursor(messages[limit-1].ID)
+	}
+	page.Messages = make([]*dto.MessageView, 0, len(messages))
+	for _, m := range messages {
+		page.Messages = append(page.Messages, dto.NewMessageView(m, conversation.Members))
+	}
+	return page, nil
+}
+
+// DeleteMessage deletes a message the user sent, for every member.
+func (uc *useCase) DeleteMessage(userID, conversationID int, messageID int64) error {
+	conversation, err := uc.conversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	message, err := uc.messageRepo.GetMessage(conversationID, messageID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrMessageNotFound
+	} else if err != nil {
+		return err
+	}
+	if message.Event != "" {
+		return ErrSystemMessage
+	}
+	if message.SenderID != userID {
+		return ErrNotSender
+	}
+
+	err = uc.messageRepo.DeleteMessage(conversationID, messageID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrMessageNotFound
+	} else if err != nil {
+		return err
+	}
+	uc.publish(conversation, domain.EventMessageDeleted,
+		&dto.StreamedMessageDeleted{ConversationID: conversationID, MessageID: messageID})
+	return nil
+}
+
+// MarkRead marks every message of a conversation of the user read, which the
+// other members see as a read receipt.
+func (uc *useCase) MarkRead(userID, conversationID int) error {
+	conversation, err := uc.conversation(userID, conversationID)
+	if err != nil {
+		return err
+	}
+	lastRead, err := uc.messageRepo.MarkRead(conversationID, userID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrConversationNotFound
+	} else if err != nil {
+		return err
+	}
+	uc.publish(conversation, domain.EventMessagesRead, &dto.StreamedMessagesRead{
+		ConversationID:    conversationID,
+		UserID:            userID,
+		LastReadMessageID: lastRead,
+	})
+	return nil
+}
+
+func (uc *useCase) Settings(userID int) (*dto.MessageSettings, error) {
+	allow, err := uc.messageRepo.AllowsNonFollowers(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MessageSettings{AllowNonFollowers: allow}, nil
+}
+
+func (uc *useCase) UpdateSettings(userID int, input *dto.UpdateMessageSettingsRequest) (*dto.MessageSettings, error) {
+	if input.AllowNonFollowers == nil {
+		return nil, ErrNoSettings
+	}
+	if err := uc.messageRepo.SetAllowNonFollowers(userID, *input.AllowNonFollowers); err != nil {
+		return nil, err
+	}
+	return uc.Settings(userID)
+}
+
+// conversation returns a conversation of the user.
+func (uc *useCase) conversation(userID, conversationID int) (*domain.Conversation, error) {
+	conversation, err := uc.messageRepo.GetConversation(userID, conversationID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, ErrConversationNotFound
+	}
+	return conversation, err
+}
+
+// publish streams an event to every member of the conversation, the sender's
+// other clients included. What happened stands even if it cannot be streamed.
+func (uc *useCase) publish(conversation *domain.Conversation, eventType string, data interface{}) {
+	for _, member := range conversation.Members {
+		if err := uc.eventRepo.PublishToUser(member.UserID, eventType, data); err != nil {
+			uc.logger.Warnw("Failed to stream message event",
+				"conversationID", conversation.ID, "userID", member.UserID, "type", eventType, "error", err)
+		}
+	}
+}
+
+func validateBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(body) > MaxMessageLength {
+		return "", ErrMessageTooLong
+	}
+	return body, nil
+}
@@ -0,0 +1,139 @@
+package messages
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type fakeMessageRepo struct {
+	repository.MessageRepo
+	allowNonFollowers bool
+	recipientReplied  bool
+	inserted          []*domain.Message
+}
+
+func (r *fakeMessageRepo) GetOrCreateDirect(userID, otherID int) (int, error) {
+	return 1, nil
+}
+
+func (r *fakeMessageRepo) GetConversation(userID, conversationID int) (*domain.Conversation, error) {
+	return &domain.Conversation{ID: conversationID, Members: []*domain.ConversationMember{
+		{UserID: 1}, {UserID: 2, LastReadMessageID: 10},
+	}}, nil
+}
+
+func (r *fakeMessageRepo) AllowsNonFollowers(userID int) (bool, error) {
+	return r.allowNonFollowers, nil
+}
+
+func (r *fakeMessageRepo) HasSent(conversationID, userID int) (bool, error) {
+	return r.recipientReplied, nil
+}
+
+func (r *fakeMessageRepo) Insert(message *domain.Message) error {
+	message.ID = int64(len(r.inserted) + 11)
+	r.inserted = append(r.inserted, message)
+	return nil
+}
+
+type fakeUserRepo struct {
+	repository.UserRepo
+}
+
+func (r *fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	return &domain.User{ID: id}, nil
+}
+
+type fakeFollowerRepo struct {
+	repository.FollowerRepo
+	following bool
+	blocked   bool
+}
+
+func (r *fakeFollowerRepo) IsFollowing(followerID, followedID int) (bool, error) {
+	return r.following, nil
+}
+
+func (r *fakeFollowerRepo) IsBlocked(userID, otherID int) (bool, error) {
+	return r.blocked, nil
+}
+
+type fakeEventRepo struct {
+	repository.EventRepo
+	published []int
+}
+
+func (r *fakeEventRepo) PublishToUser(userID int, eventType string, data interface{}) error {
+	r.published = append(r.published, userID)
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name              string
+		allowNonFollowers bool
+		following         bool
+		recipientReplied  bool
+		blocked           bool
+		want              error
+	}{
+		{"open to everyone", true, false, false, false, nil},
+		{"follower", false, true, false, false, nil},
+		{"recipient wrote first", false, false, true, false, nil},
+		{"not a follower", false, false, false, false, ErrRecipientRestricted},
+		{"blocked", true, true, false, true, ErrBlocked},
+	}
+	for _, tt := range tests {
+		messages := &fakeMessageRepo{allowNonFollowers: tt.allowNonFollowers, recipientReplied: tt.recipientReplied}
+		events := &fakeEventRepo{}
+		followers := &fakeFollowerRepo{following: tt.following, blocked: tt.blocked}
+		uc := NewMessageUseCase(messages, &fakeUserRepo{}, followers, events, zap.NewNop().Sugar())
+
+		view, err := uc.Send(1, 2, "  hello  ")
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		if tt.want != nil {
+			if len(messages.inserted) != 0 {
+				t.Errorf("%s: message was stored", tt.name)
+			}
+			continue
+		}
+		if view.Body != "hello" || len(view.ReadBy) != 0 {
+			t.Errorf("%s: got %+v", tt.name, view)
+		}
+		if len(events.published) != 2 {
+			t.Errorf("%s: streamed to %v, want both members", tt.name, events.published)
+		}
+	}
+}
+
+func TestValidateBody(t *testing.T) {
+	if _, err := validateBody("   "); err != ErrEmptyMessage {
+		t.Errorf("blank message: got %v", err)
+	}
+	if _, err := validateBody(strings.Repeat("é", MaxMessageLength)); err != nil {
+		t.Errorf("longest message: got %v", err)
+	}
+	if _, err := validateBody(strings.Repeat("a", MaxMessageLength+1)); err != ErrMessageTooLong {
+		t.Errorf("long message: got %v", err)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	id, err := decodeCursor(encodeCursor(42))
+	if err != nil || id != 42 {
+		t.Fatalf("round trip: got %d, %v", id, err)
+	}
+	for _, cursor := range []string{"!!", encodeCursor(0), "YWJj"} {
+		if _, err = decodeCursor(cursor); err != ErrInvalidCursor {
+			t.Errorf("decodeCursor(%q) = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package mutedwords
+
+import "errors"
+
+var (
+	ErrInvalidPhrase     = errors.New("muted phrase must be 1 to 100 characters")
+	ErrUnknownScope      = errors.New("scope must be timeline or everywhere")
+	ErrExpiryInPast      = errors.New("expiry must be in the future")
+	ErrTooManyMutedWords = errors.New("too many muted words")
+	ErrMutedWordNotFound = errors.New("muted word not found")
+)
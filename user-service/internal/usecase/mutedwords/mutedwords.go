@@ -0,0 +1,85 @@
+package mutedwords
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"errors"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxMutedWords   = 200
+	maxPhraseLength = 100
+)
+
+type useCase struct {
+	mutedWordRepo repository.MutedWordRepo
+	logger        *zap.SugaredLogger
+	now           func() time.Time
+}
+
+func NewMutedWordUseCase(mutedWordRepo repository.MutedWordRepo, logger *zap.SugaredLogger) *useCase {
+	return &useCase{
+		mutedWordRepo: mutedWordRepo,
+		logger:        logger,
+		now:           time.Now,
+	}
+}
+
+// Add mutes a keyword, hashtag or phrase for the user. Runs of whitespace in
+// the phrase are collapsed; matching ignores case.
+func (uc *useCase) Add(userID int, input dto.AddMutedWordRequest) (*domain.MutedWord, error) {
+	phrase := strings.Join(strings.Fields(input.Phrase), " ")
+	if length := utf8.RuneCountInString(phrase); length == 0 || length > maxPhraseLength ||
+		strings.Trim(phrase, "# ") == "" {
+		return nil, ErrInvalidPhrase
+	}
+
+	scope := input.Scope
+	if scope == "" {
+		scope = domain.MuteScopeTimeline
+	}
+	if scope != domain.MuteScopeTimeline && scope != domain.MuteScopeEverywhere {
+		return nil, ErrUnknownScope
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(uc.now()) {
+		return nil, ErrExpiryInPast
+	}
+
+	count, err := uc.mutedWordRepo.CountActive(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxMutedWords {
+		return nil, ErrTooManyMutedWords
+	}
+
+	word := &domain.MutedWord{
+		UserID:    userID,
+		Phrase:    phrase,
+		Scope:     scope,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if err = uc.mutedWordRepo.Insert(word); err != nil {
+		return nil, err
+	}
+	uc.logger.Infow("Muted word", "userID", userID, "mutedWordID", word.ID)
+	return word, nil
+}
+
+// List returns the muted words of the user that have not expired.
+func (uc *useCase) List(userID int) ([]*domain.MutedWord, error) {
+	return uc.mutedWordRepo.ListActive(userID)
+}
+
+func (uc *useCase) Remove(userID, id int) error {
+	err := uc.mutedWordRepo.Delete(userID, id)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrMutedWordNotFound
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package mutedwords
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type fakeMutedWordRepo struct {
+	words []*domain.MutedWord
+}
+
+func (r *fakeMutedWordRepo) Insert(in *domain.MutedWord) error {
+	in.ID = len(r.words) + 1
+	r.words = append(r.words, in)
+	return nil
+}
+
+func (r *fakeMutedWordRepo) ListActive(userID int) ([]*domain.MutedWord, error) { return r.words, nil }
+
+func (r *fakeMutedWordRepo) CountActive(userID int) (int, error) { return len(r.words), nil }
+
+func (r *fakeMutedWordRepo) Delete(userID, id int) error { return domain.ErrRecordNotFound }
+
+func TestAddMutedWord(t *testing.T) {
+	uc := NewMutedWordUseCase(&fakeMutedWordRepo{}, zap.NewNop().Sugar())
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		input   dto.AddMutedWordRequest
+		wantErr error
+	}{
+		{"empty phrase", dto.AddMutedWordRequest{Phrase: "   "}, ErrInvalidPhrase},
+		{"bare hash", dto.AddMutedWordRequest{Phrase: "#"}, ErrInvalidPhrase},
+		{"too long", dto.AddMutedWordRequest{Phrase: strings.Repeat("a", 101)}, ErrInvalidPhrase},
+		{"unknown scope", dto.AddMutedWordRequest{Phrase: "spoiler", Scope: "nowhere"}, ErrUnknownScope},
+		{"expired", dto.AddMutedWordRequest{Phrase: "spoiler", ExpiresAt: &past}, ErrExpiryInPast},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := uc.Add(1, tt.input); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	word, err := uc.Add(1, dto.AddMutedWordRequest{Phrase: "  hot \t take "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if word.Phrase != "hot take" || word.Scope != domain.MuteScopeTimeline {
+		t.Fatalf("got phrase %q in scope %q", word.Phrase, word.Scope)
+	}
+}
+
+func TestMutedWordLimit(t *testing.T) {
+	repo := &fakeMutedWordRepo{}
+	for i := 0; i < MaxMutedWords; i++ {
+		repo.words = append(repo.words, &domain.MutedWord{})
+	}
+	uc := NewMutedWordUseCase(repo, zap.NewNop().Sugar())
+
+	if _, err := uc.Add(1, dto.AddMutedWordRequest{Phrase: "one more"}); !errors.Is(err, ErrTooManyMutedWords) {
+		t.Fatalf("got error %v, want %v", err, ErrTooManyMutedWords)
+	}
+}
@@ -0,0 +1,11 @@
+package notifications
+
+import "errors"
+
+var (
+	ErrUnknownType          = errors.New("unknown notification type")
+	ErrInvalidEvent         = errors.New("invalid notification event")
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrInvalidPageSize      = errors.New("limit must be between 1 and 100")
+	ErrInvalidOffset        = errors.New("invalid offset")
+)
@@ -0,0 +1,33 @@
+package notifications
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"fmt"
+)
+
+// actions completes the message of each notification type.
+var actions = map[string]string{
+	domain.NotificationFollow:  "followed you",
+	domain.NotificationLike:    "liked your tweet",
+	domain.NotificationReply:   "replied to your tweet",
+	domain.NotificationMention: "mentioned you in a tweet",
+}
+
+// message sums up a notification: "alice liked your tweet", "alice and bob
+// liked your tweet" or "alice and 5 others liked your tweet". actors are the
+// ones named, out of count in all.
+func message(notificationType string, actors []*dto.PublicProfile, count int) string {
+	action := actions[notificationType]
+	switch {
+	case len(actors) == 0:
+		return fmt.Sprintf("%d people %s", count, action)
+	case count == 1:
+		return fmt.Sprintf("%s %s", actors[0].Username, action)
+	case count == 2 && len(actors) == 2:
+		return fmt.Sprintf("%s and %s %s", actors[0].Username, actors[1].Username, action)
+	case count == 2:
+		return fmt.Sprintf("%s and 1 other %s", actors[0].Username, action)
+	}
+	return fmt.Sprintf("%s and %d others %s", actors[0].Username, count-1, action)
+}
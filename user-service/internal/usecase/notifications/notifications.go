@@ -0,0 +1,257 @@
+package notifications
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	// maxMentions caps the users one tweet can notify by mentioning them.
+	maxMentions = 10
+)
+
+type useCase struct {
+	notificationRepo repository.NotificationRepo
+	userRepo         repository.UserRepo
+	followerRepo     repository.FollowerRepo
+	eventRepo        repository.EventRepo
+	logger           *zap.SugaredLogger
+}
+
+func NewNotificationUseCase(
+	notificationRepo repository.NotificationRepo,
+	userRepo repository.UserRepo,
+	followerRepo repository.FollowerRepo,
+	eventRepo repository.EventRepo,
+	logger *zap.SugaredLogger,
+) *useCase {
+	return &useCase{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		followerRepo:     followerRepo,
+		eventRepo:        eventRepo,
+		logger:           logger,
+	}
+}
+
+// Notify tells the recipient about the event, unless the recipient turned its
+// type off, is the actor, or one of them blocked the other. Similar unread
+// events are grouped into one notification.
+func (uc *useCase) Notify(event *domain.NotificationEvent) error {
+	if !slices.Contains(domain.NotificationTypes, event.Type) {
+		return ErrUnknownType
+	}
+	if event.RecipientID < 1 || event.ActorID < 1 || (event.Type != domain.NotificationFollow && event.TweetID == nil) {
+		return ErrInvalidEvent
+	}
+	if event.RecipientID == event.ActorID {
+		return nil
+	}
+
+	recipient, err := uc.userRepo.GetByID(event.RecipientID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if recipient.CheckActive() != nil {
+		return nil
+	}
+
+	preferences, err := uc.notificationRepo.Preferences(event.RecipientID)
+	if err != nil {
+		return err
+	}
+	if enabled, ok := preferences[event.Type]; ok && !enabled {
+		return nil
+	}
+
+	blocked, err := uc.followerRepo.IsBlocked(event.RecipientID, event.ActorID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return nil
+	}
+
+	if err = uc.notificationRepo.Record(event, groupKey(event)); err != nil {
+		uc.logger.Errorw("Failed to record notification",
+			"type", event.Type, "recipientID", event.RecipientID, "actorID", event.ActorID, "error", err)
+		return err
+	}
+	uc.stream(event)
+	return nil
+}
+
+// stream pushes the notification to the recipient's open clients. The
+// notification stands even if it cannot be streamed.
+func (uc *useCase) stream(event *domain.NotificationEvent) {
+	unread, err := uc.notificationRepo.CountUnread(event.RecipientID)
+	if err != nil {
+		uc.logger.Warnw("Failed to count unread notifications", "userID", event.RecipientID, "error", err)
+		return
+	}
+	streamed := &dto.StreamedNotification{
+		Type:        event.Type,
+		ActorID:     event.ActorID,
+		TweetID:     event.TweetID,
+		UnreadCount: unread,
+	}
+	if err = uc.eventRepo.PublishToUser(event.RecipientID, domain.EventNotification, streamed); err != nil {
+		uc.logger.Warnw("Failed to stream notification", "userID", event.RecipientID, "type", event.Type, "error", err)
+	}
+}
+
+// NotifyMentions tells the users mentioned in a tweet by username. Unknown
+// usernames are ignored.
+func (uc *useCase) NotifyMentions(actorID int, tweetID int64, usernames []string) error {
+	seen := map[int]bool{}
+	for _, username := range usernames {
+		if len(seen) == maxMentions {
+			break
+		}
+		user, err := uc.userRepo.GetByUsername(username)
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if seen[user.ID] {
+			continue
+		}
+		seen[user.ID] = true
+
+		event := &domain.NotificationEvent{
+			Type:        domain.NotificationMention,
+			RecipientID: user.ID,
+			ActorID:     actorID,
+			TweetID:     &tweetID,
+		}
+		if err = uc.Notify(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupKey is what events grouped into one notification share: every new
+// follower, or everyone acting on the same tweet.
+func groupKey(event *domain.NotificationEvent) string {
+	if event.TweetID == nil {
+		return event.Type
+	}
+	return fmt.Sprintf("tweet:%d", *event.TweetID)
+}
+
+// List returns one page of the user's notifications, most recent first, with
+// the count of unread ones.
+func (uc *useCase) List(userID, limit, offset int) (*dto.NotificationPage, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, ErrInvalidPageSize
+	}
+	if offset < 0 {
+		return nil, ErrInvalidOffset
+	}
+
+	// one extra notification tells whether there is a next page
+	notifications, err := uc.notificationRepo.List(userID, limit+1, offset)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := uc.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &dto.NotificationPage{UnreadCount: unread}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		next := offset + limit
+		page.NextOffset = &next
+	}
+
+	actors, err := uc.actors(notifications)
+	if err != nil {
+		return nil, err
+	}
+	page.Notifications = make([]*dto.NotificationView, 0, len(notifications))
+	for _, n := range notifications {
+		view := dto.NewNotificationView(n, actors)
+		view.Message = message(n.Type, view.Actors, n.ActorCount)
+		page.Notifications = append(page.Notifications, view)
+	}
+	return page, nil
+}
+
+// actors loads the users named by the notifications.
+func (uc *useCase) actors(notifications []*domain.Notification) (map[int]*domain.User, error) {
+	var ids []int
+	for _, n := range notifications {
+		ids = append(ids, n.ActorIDs...)
+	}
+	if len(ids) == 0 {
+		return map[int]*domain.User{}, nil
+	}
+	users, err := uc.userRepo.ListActiveByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	actors := make(map[int]*domain.User, len(users))
+	for _, u := range users {
+		actors[u.ID] = u
+	}
+	return actors, nil
+}
+
+func (uc *useCase) UnreadCount(userID int) (int, error) {
+	return uc.notificationRepo.CountUnread(userID)
+}
+
+func (uc *useCase) MarkRead(userID, id int) error {
+	err := uc.notificationRepo.MarkRead(userID, id)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrNotificationNotFound
+	}
+	return err
+}
+
+func (uc *useCase) MarkAllRead(userID int) error {
+	return uc.notificationRepo.MarkAllRead(userID)
+}
+
+// Preferences tells for every notification type whether the user gets it.
+func (uc *useCase) Preferences(userID int) (map[string]bool, error) {
+	stored, err := uc.notificationRepo.Preferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	preferences := make(map[string]bool, len(domain.NotificationTypes))
+	for _, t := range domain.NotificationTypes {
+		enabled, ok := stored[t]
+		preferences[t] = enabled || !ok
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences turns the given notification types on or off and returns
+// the preferences of every type.
+func (uc *useCase) UpdatePreferences(userID int, changes map[string]bool) (map[string]bool, error) {
+	for t := range changes {
+		if !slices.Contains(domain.NotificationTypes, t) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownType, t)
+		}
+	}
+	if err := uc.notificationRepo.SetPreferences(userID, changes); err != nil {
+		return nil, err
+	}
+	return uc.Preferences(userID)
+}
@@ -0,0 +1,112 @@
+package notifications
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type fakeNotificationRepo struct {
+	repository.NotificationRepo
+	preferences map[string]bool
+	recorded    []string // group keys
+}
+
+func (r *fakeNotificationRepo) Preferences(userID int) (map[string]bool, error) {
+	return r.preferences, nil
+}
+
+func (r *fakeNotificationRepo) Record(event *domain.NotificationEvent, groupKey string) error {
+	r.recorded = append(r.recorded, groupKey)
+	return nil
+}
+
+func (r *fakeNotificationRepo) CountUnread(userID int) (int, error) {
+	return len(r.recorded), nil
+}
+
+type fakeEventRepo struct {
+	repository.EventRepo
+	published []int // recipients
+}
+
+func (r *fakeEventRepo) PublishToUser(userID int, eventType string, data interface{}) error {
+	r.published = append(r.published, userID)
+	return nil
+}
+
+type fakeUserRepo struct {
+	repository.UserRepo
+}
+
+func (r *fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	return &domain.User{ID: id}, nil
+}
+
+type fakeFollowerRepo struct {
+	repository.FollowerRepo
+	blocked bool
+}
+
+func (r *fakeFollowerRepo) IsBlocked(userID, otherID int) (bool, error) {
+	return r.blocked, nil
+}
+
+func TestNotify(t *testing.T) {
+	tweetID := int64(9)
+	like := &domain.NotificationEvent{Type: domain.NotificationLike, RecipientID: 1, ActorID: 2, TweetID: &tweetID}
+	follow := &domain.NotificationEvent{Type: domain.NotificationFollow, RecipientID: 1, ActorID: 3}
+	ownLike := &domain.NotificationEvent{Type: domain.NotificationLike, RecipientID: 2, ActorID: 2, TweetID: &tweetID}
+
+	tests := []struct {
+		name        string
+		event       *domain.NotificationEvent
+		preferences map[string]bool
+		blocked     bool
+		want        []string
+	}{
+		{"like", like, nil, false, []string{"tweet:9"}},
+		{"follow", follow, map[string]bool{domain.NotificationLike: false}, false, []string{"follow"}},
+		{"type turned off", like, map[string]bool{domain.NotificationLike: false}, false, nil},
+		{"blocked", follow, nil, true, nil},
+		{"own tweet", ownLike, nil, false, nil},
+	}
+	for _, tt := range tests {
+		repo := &fakeNotificationRepo{preferences: tt.preferences}
+		events := &fakeEventRepo{}
+		uc := NewNotificationUseCase(
+			repo, &fakeUserRepo{}, &fakeFollowerRepo{blocked: tt.blocked}, events, zap.NewNop().Sugar())
+		if err := uc.Notify(tt.event); err != nil {
+			t.Fatalf("%s: Notify: %v", tt.name, err)
+		}
+		if len(repo.recorded) != len(tt.want) || (len(tt.want) == 1 && repo.recorded[0] != tt.want[0]) {
+			t.Errorf("%s: recorded %v, want %v", tt.name, repo.recorded, tt.want)
+		}
+		if len(events.published) != len(tt.want) {
+			t.Errorf("%s: streamed to %v, want %d events", tt.name, events.published, len(tt.want))
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	alice, bob := &dto.PublicProfile{Username: "alice"}, &dto.PublicProfile{Username: "bob"}
+
+	tests := []struct {
+		actors []*dto.PublicProfile
+		count  int
+		want   string
+	}{
+		{[]*dto.PublicProfile{alice}, 1, "alice liked your tweet"},
+		{[]*dto.PublicProfile{alice, bob}, 2, "alice and bob liked your tweet"},
+		{[]*dto.PublicProfile{alice}, 2, "alice and 1 other liked your tweet"},
+		{[]*dto.PublicProfile{alice, bob}, 6, "alice and 5 others liked your tweet"},
+	}
+	for _, tt := range tests {
+		if got := message(domain.NotificationLike, tt.actors, tt.count); got != tt.want {
+			t.Errorf("message(%d actors, %d) = %q, want %q", len(tt.actors), tt.count, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package profiles
+
+import "errors"
+
+var (
+	ErrProfileNotFound  = errors.New("profile not found")
+	ErrMediaNotFound    = errors.New("media not found")
+	ErrInvalidName      = errors.New("first and last name must be 1 to 50 characters")
+	ErrBioTooLong       = errors.New("bio must be at most 160 characters")
+	ErrLocationTooLong  = errors.New("location must be at most 30 characters")
+	ErrInvalidWebsite   = errors.New("website must be an http or https URL of at most 100 characters")
+	ErrUnsupportedImage = errors.New("image must be a PNG, JPEG or GIF")
+	ErrImageTooLarge    = errors.New("image file is too large")
+	ErrImageDimensions  = errors.New("image dimensions are too large")
+
+	ErrProtectionRequired    = errors.New("protected must be true or false")
+	ErrProtectionUnavailable = errors.New("could not change account protection, try again later")
+)
+
+// IsValidationError reports whether err was caused by invalid input.
+func IsValidationError(err error) bool {
+	for _, target := range []error{
+		ErrInvalidName, ErrBioTooLong, ErrLocationTooLong, ErrInvalidWebsite,
+		ErrUnsupportedImage, ErrImageTooLarge, ErrImageDimensions, ErrProtectionRequired,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
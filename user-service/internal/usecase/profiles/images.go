@@ -0,0 +1,152 @@
+package profiles
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/blob"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path"
+)
+
+const (
+	MaxAvatarSize = 2 << 20
+	MaxBannerSize = 5 << 20
+
+	maxImageDimension = 4096
+)
+
+// imageKind describes where an uploaded profile image is stored.
+type imageKind struct {
+	dir     string
+	maxSize int64
+}
+
+var (
+	avatarImage = imageKind{dir: "avatars", maxSize: MaxAvatarSize}
+	bannerImage = imageKind{dir: "banners", maxSize: MaxBannerSize}
+)
+
+// imageTypes maps the accepted content types to the extension of the stored blob.
+var imageTypes = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpg",
+	"image/gif":  "gif",
+}
+
+func (uc *useCase) UploadAvatar(userID int, r io.Reader) (*dto.OwnProfile, error) {
+	return uc.uploadImage(userID, avatarImage, r)
+}
+
+func (uc *useCase) UploadBanner(userID int, r io.Reader) (*dto.OwnProfile, error) {
+	return uc.uploadImage(userID, bannerImage, r)
+}
+
+// uploadImage stores the image under a fresh key, points the profile to it
+// and removes the image it replaces. Keys are never reused, so served images
+// can be cached forever.
+func (uc *useCase) uploadImage(userID int, kind imageKind, r io.Reader) (*dto.OwnProfile, error) {
+	content, ext, err := readImage(r, kind.maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	suffix, err := utils.GenerateToken(12)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s/%d-%s.%s", kind.dir, userID, suffix, ext)
+	if err = uc.blobs.Put(key, bytes.NewReader(content)); err != nil {
+		uc.logger.Errorw("Failed to store profile image", "userID", userID, "error", err)
+		return nil, err
+	}
+
+	previous := user.AvatarKey
+	if kind == bannerImage {
+		previous = user.BannerKey
+		user.BannerKey = key
+		err = uc.userRepo.UpdateBanner(userID, key)
+	} else {
+		user.AvatarKey = key
+		err = uc.userRepo.UpdateAvatar(userID, key)
+	}
+	if err != nil {
+		_ = uc.blobs.Delete(key)
+		return nil, err
+	}
+
+	if previous != "" {
+		if err = uc.blobs.Delete(previous); err != nil {
+			uc.logger.Warnw("Failed to delete replaced profile image", "key", previous, "error", err)
+		}
+	}
+	uc.logger.Infow("Updated profile image", "userID", userID, "key", key)
+	return dto.NewOwnProfile(user), nil
+}
+
+// readImage reads at most maxSize bytes and checks that they are an image of
+// an accepted type and size. The declared content type of the upload is
+// ignored; only the content decides.
+func readImage(r io.Reader, maxSize int64) ([]byte, string, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(content)) > maxSize {
+		return nil, "", ErrImageTooLarge
+	}
+
+	ext, ok := imageTypes[http.DetectContentType(content)]
+	if !ok {
+		return nil, "", ErrUnsupportedImage
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", ErrUnsupportedImage
+	}
+	if config.Width > maxImageDimension || config.Height > maxImageDimension {
+		return nil, "", ErrImageDimensions
+	}
+	return content, ext, nil
+}
+
+// OpenMedia opens a stored profile image and returns its content type. The
+// blob store also holds private files, so only the image directories are
+// served.
+func (uc *useCase) OpenMedia(key string) (*os.File, string, error) {
+	if dir := path.Dir(key); dir != avatarImage.dir && dir != bannerImage.dir {
+		return nil, "", ErrMediaNotFound
+	}
+
+	contentType := ""
+	for ct, ext := range imageTypes {
+		if path.Ext(key) == "."+ext {
+			contentType = ct
+		}
+	}
+	if contentType == "" || !blob.ValidKey(key) {
+		return nil, "", ErrMediaNotFound
+	}
+
+	file, err := uc.blobs.Open(key)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, "", ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return file, contentType, nil
+}
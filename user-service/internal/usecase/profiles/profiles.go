@@ -0,0 +1,169 @@
+package profiles
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usernames"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/blob"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	maxNameLength     = 50
+	maxBioLength      = 160
+	maxLocationLength = 30
+	maxWebsiteLength  = 100
+)
+
+type useCase struct {
+	userRepo     repository.UserRepo
+	followerRepo repository.FollowerRepo
+	tweets       TweetService
+	blobs        blob.Store
+	logger       *zap.SugaredLogger
+}
+
+func NewProfileUseCase(
+	userRepo repository.UserRepo,
+	followerRepo repository.FollowerRepo,
+	tweets TweetService,
+	blobs blob.Store,
+	logger *zap.SugaredLogger,
+) *useCase {
+	return &useCase{
+		userRepo:     userRepo,
+		followerRepo: followerRepo,
+		tweets:       tweets,
+		blobs:        blobs,
+		logger:       logger,
+	}
+}
+
+// GetProfile returns the public profile of the user. Suspended and
+// deactivated users have no public profile.
+func (uc *useCase) GetProfile(id int) (*dto.PublicProfile, error) {
+	user, err := uc.userRepo.GetByID(id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if user.CheckActive() != nil {
+		return nil, ErrProfileNotFound
+	}
+	return dto.NewPublicProfile(user), nil
+}
+
+// GetProfileByUsername returns the profile of the account holding username.
+// A username given up within the grace period still resolves to the account
+// that gave it up; the profile then carries the current username.
+func (uc *useCase) GetProfileByUsername(username string) (*dto.PublicProfile, error) {
+	user, err := uc.userRepo.GetByUsername(username)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		user, err = uc.byPreviousUsername(username)
+	}
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if user.CheckActive() != nil {
+		return nil, ErrProfileNotFound
+	}
+	return dto.NewPublicProfile(user), nil
+}
+
+func (uc *useCase) byPreviousUsername(username string) (*domain.User, error) {
+	userID, err := uc.userRepo.GetUserIDByPreviousUsername(username, time.Now().Add(-usernames.GracePeriod))
+	if err != nil {
+		return nil, err
+	}
+	return uc.userRepo.GetByID(userID)
+}
+
+func (uc *useCase) GetOwnProfile(userID int) (*dto.OwnProfile, error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return dto.NewOwnProfile(user), nil
+}
+
+func (uc *useCase) UpdateProfile(userID int, input dto.UpdateProfileRequest) (*dto.OwnProfile, error) {
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	if err = applyProfileUpdate(user, input); err != nil {
+		return nil, err
+	}
+	if err = uc.userRepo.UpdateProfile(user); err != nil {
+		return nil, err
+	}
+	uc.logger.Infow("Updated profile", "userID", userID)
+	return dto.NewOwnProfile(user), nil
+}
+
+// applyProfileUpdate validates the fields set in input and copies them to user.
+func applyProfileUpdate(user *domain.User, input dto.UpdateProfileRequest) error {
+	if input.FirstName != nil {
+		name := strings.TrimSpace(*input.FirstName)
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+			return ErrInvalidName
+		}
+		user.FirstName = name
+	}
+	if input.LastName != nil {
+		name := strings.TrimSpace(*input.LastName)
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+			return ErrInvalidName
+		}
+		user.LastName = name
+	}
+	if input.Bio != nil {
+		bio := strings.TrimSpace(*input.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return ErrBioTooLong
+		}
+		user.Bio = bio
+	}
+	if input.Location != nil {
+		location := strings.TrimSpace(*input.Location)
+		if utf8.RuneCountInString(location) > maxLocationLength {
+			return ErrLocationTooLong
+		}
+		user.Location = location
+	}
+	if input.Website != nil {
+		website := strings.TrimSpace(*input.Website)
+		if website != "" && !validWebsite(website) {
+			return ErrInvalidWebsite
+		}
+		user.Website = website
+	}
+	return nil
+}
+
+// validWebsite accepts absolute http(s) URLs only, so a profile link can never
+// be a javascript: or data: URL.
+func validWebsite(website string) bool {
+	if len(website) > maxWebsiteLength {
+		return false
+	}
+	u, err := url.Parse(website)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrProfileNotFound
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package profiles
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestApplyProfileUpdate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input dto.UpdateProfileRequest
+		err   error
+	}{
+		{"valid", dto.UpdateProfileRequest{Bio: strPtr("hello"), Website: strPtr("https://example.com/me")}, nil},
+		{"clear website", dto.UpdateProfileRequest{Website: strPtr("")}, nil},
+		{"bio too long", dto.UpdateProfileRequest{Bio: strPtr(strings.Repeat("é", 161))}, ErrBioTooLong},
+		{"location too long", dto.UpdateProfileRequest{Location: strPtr(strings.Repeat("a", 31))}, ErrLocationTooLong},
+		{"blank name", dto.UpdateProfileRequest{FirstName: strPtr("  ")}, ErrInvalidName},
+		{"javascript website", dto.UpdateProfileRequest{Website: strPtr("javascript:alert(1)")}, ErrInvalidWebsite},
+		{"relative website", dto.UpdateProfileRequest{Website: strPtr("example.com")}, ErrInvalidWebsite},
+		{"website with credentials", dto.UpdateProfileRequest{Website: strPtr("https://user:pw@example.com")}, ErrInvalidWebsite},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &domain.User{FirstName: "Ann", Website: "https://old.example.com"}
+			err := applyProfileUpdate(user, tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	user := &domain.User{FirstName: "Ann", Bio: "old bio"}
+	if err := applyProfileUpdate(user, dto.UpdateProfileRequest{Location: strPtr(" Almaty ")}); err != nil {
+		t.Fatal(err)
+	}
+	if user.Location != "Almaty" || user.Bio != "old bio" || user.FirstName != "Ann" {
+		t.Errorf("unset fields must be kept and set fields trimmed, got %+v", user)
+	}
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadImage(t *testing.T) {
+	content, ext, err := readImage(bytes.NewReader(encodePNG(t, 10, 10)), MaxAvatarSize)
+	if err != nil || ext != "png" || len(content) == 0 {
+		t.Fatalf("expected a png, got %q %v", ext, err)
+	}
+
+	if _, _, err = readImage(strings.NewReader("<svg onload=alert(1)>"), MaxAvatarSize); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("expected ErrUnsupportedImage, got %v", err)
+	}
+	if _, _, err = readImage(bytes.NewReader(encodePNG(t, 10, 10)), 16); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("expected ErrImageTooLarge, got %v", err)
+	}
+	if _, _, err = readImage(bytes.NewReader(encodePNG(t, maxImageDimension+1, 1)), MaxAvatarSize); !errors.Is(err, ErrImageDimensions) {
+		t.Errorf("expected ErrImageDimensions, got %v", err)
+	}
+}
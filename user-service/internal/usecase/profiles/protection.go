@@ -0,0 +1,78 @@
+package profiles
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+)
+
+// TweetService is the part of tweet-service that hides the tweets of
+// protected users from everyone but their approved followers.
+type TweetService interface {
+	ProtectAuthor(userID int) error
+	UnprotectAuthor(userID int) error
+}
+
+// SetProtection makes the account protected or public in both services.
+// Tweet-service is told first when protecting and last when unprotecting, and
+// the local change is undone if it fails, so the tweets are never visible
+// while the account is protected.
+func (uc *useCase) SetProtection(userID int, input dto.SetProtectionRequest) (*dto.OwnProfile, error) {
+	if input.Protected == nil {
+		return nil, ErrProtectionRequired
+	}
+	protected := *input.Protected
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if user.Protected == protected {
+		return dto.NewOwnProfile(user), nil
+	}
+
+	if protected {
+		err = uc.protect(userID)
+	} else {
+		err = uc.unprotect(userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user.Protected = protected
+	uc.logger.Infow("Changed account protection", "userID", userID, "protected", protected)
+	return dto.NewOwnProfile(user), nil
+}
+
+func (uc *useCase) protect(userID int) error {
+	if err := uc.tweets.ProtectAuthor(userID); err != nil {
+		uc.logger.Errorw("Failed to protect tweets", "userID", userID, "error", err)
+		return ErrProtectionUnavailable
+	}
+	if err := uc.userRepo.SetProtected(userID, true); err != nil {
+		if undoErr := uc.tweets.UnprotectAuthor(userID); undoErr != nil {
+			uc.logger.Errorw("Failed to unprotect tweets after failed protection", "userID", userID, "error", undoErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// unprotect makes the account public and lets everyone who asked follow it.
+func (uc *useCase) unprotect(userID int) error {
+	if err := uc.userRepo.SetProtected(userID, false); err != nil {
+		return err
+	}
+	if err := uc.tweets.UnprotectAuthor(userID); err != nil {
+		uc.logger.Errorw("Failed to unprotect tweets", "userID", userID, "error", err)
+		if undoErr := uc.userRepo.SetProtected(userID, true); undoErr != nil {
+			uc.logger.Errorw("Failed to restore protection", "userID", userID, "error", undoErr)
+		}
+		return ErrProtectionUnavailable
+	}
+
+	// The requests can still be approved one by one if this fails
+	if err := uc.followerRepo.ApproveAllFollowRequests(userID); err != nil {
+		uc.logger.Errorw("Failed to approve pending follow requests", "userID", userID, "error", err)
+	}
+	return nil
+}
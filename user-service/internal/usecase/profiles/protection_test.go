@@ -0,0 +1,105 @@
+package profiles
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type fakeUserRepo struct {
+	repository.UserRepo
+	user *domain.User
+}
+
+func (r *fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	copied := *r.user
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) SetProtected(id int, protected bool) error {
+	r.user.Protected = protected
+	return nil
+}
+
+type fakeFollowerRepo struct {
+	repository.FollowerRepo
+	approvedAll bool
+}
+
+func (r *fakeFollowerRepo) ApproveAllFollowRequests(targetID int) error {
+	r.approvedAll = true
+	return nil
+}
+
+type fakeTweetService struct {
+	protected bool
+	err       error
+}
+
+func (s *fakeTweetService) ProtectAuthor(userID int) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.protected = true
+	return nil
+}
+
+func (s *fakeTweetService) UnprotectAuthor(userID int) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.protected = false
+	return nil
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestSetProtection(t *testing.T) {
+	users := &fakeUserRepo{user: &domain.User{ID: 1}}
+	followers := &fakeFollowerRepo{}
+	tweets := &fakeTweetService{}
+	uc := NewProfileUseCase(users, followers, tweets, nil, zap.NewNop().Sugar())
+
+	if _, err := uc.SetProtection(1, dto.SetProtectionRequest{}); !errors.Is(err, ErrProtectionRequired) {
+		t.Fatalf("expected ErrProtectionRequired, got %v", err)
+	}
+
+	profile, err := uc.SetProtection(1, dto.SetProtectionRequest{Protected: boolPtr(true)})
+	if err != nil || !profile.Protected || !users.user.Protected || !tweets.protected {
+		t.Fatalf("account and tweets should be protected, got %v %+v", err, users.user)
+	}
+
+	// Tweets stay hidden if tweet-service can not be told to show them
+	tweets.err = domain.ErrTweetServiceError
+	if _, err = uc.SetProtection(1, dto.SetProtectionRequest{Protected: boolPtr(false)}); !errors.Is(err, ErrProtectionUnavailable) {
+		t.Fatalf("expected ErrProtectionUnavailable, got %v", err)
+	}
+	if !users.user.Protected || !tweets.protected {
+		t.Fatal("failed unprotect must leave the account protected")
+	}
+
+	tweets.err = nil
+	if _, err = uc.SetProtection(1, dto.SetProtectionRequest{Protected: boolPtr(false)}); err != nil {
+		t.Fatal(err)
+	}
+	if users.user.Protected || tweets.protected || !followers.approvedAll {
+		t.Fatal("unprotecting should make the account public and approve pending requests")
+	}
+}
+
+func TestSetProtectionFailsBeforeLocalChange(t *testing.T) {
+	users := &fakeUserRepo{user: &domain.User{ID: 1}}
+	tweets := &fakeTweetService{err: domain.ErrTweetServiceError}
+	uc := NewProfileUseCase(users, &fakeFollowerRepo{}, tweets, nil, zap.NewNop().Sugar())
+
+	if _, err := uc.SetProtection(1, dto.SetProtectionRequest{Protected: boolPtr(true)}); !errors.Is(err, ErrProtectionUnavailable) {
+		t.Fatalf("expected ErrProtectionUnavailable, got %v", err)
+	}
+	if users.user.Protected {
+		t.Fatal("account must not be marked protected while its tweets are visible")
+	}
+}
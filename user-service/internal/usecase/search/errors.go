@@ -0,0 +1,10 @@
+package search
+
+import "errors"
+
+var (
+	ErrQueryTooShort   = errors.New("search query is too short")
+	ErrQueryTooLong    = errors.New("search query is too long")
+	ErrInvalidPageSize = errors.New("invalid limit")
+	ErrInvalidOffset   = errors.New("invalid offset")
+)
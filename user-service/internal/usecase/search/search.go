@@ -0,0 +1,91 @@
+package search
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	MaxQueryLength = 50
+
+	// A single letter matches too much to rank, except as the start of a
+	// name being typed.
+	minSearchLength       = 2
+	minAutocompleteLength = 1
+
+	DefaultPageSize         = 20
+	MaxPageSize             = 50
+	DefaultAutocompleteSize = 8
+	MaxAutocompleteSize     = 20
+)
+
+type useCase struct {
+	searchRepo repository.SearchRepo
+	logger     *zap.SugaredLogger
+}
+
+func NewSearchUseCase(searchRepo repository.SearchRepo, logger *zap.SugaredLogger) *useCase {
+	return &useCase{
+		searchRepo: searchRepo,
+		logger:     logger,
+	}
+}
+
+// SearchUsers finds users by username or name, best match first, one page at
+// a time. viewerID is the caller, or 0 when anonymous; users the caller
+// follows rank higher. In autocomplete mode the text is the start of a name
+// being typed, such as an @mention.
+func (uc *useCase) SearchUsers(viewerID int, text string, autocomplete bool, limit, offset int) (*dto.UserSearchResults, error) {
+	text = normalizeQuery(text)
+	minLength, maxLimit := minSearchLength, MaxPageSize
+	if autocomplete {
+		minLength, maxLimit = minAutocompleteLength, MaxAutocompleteSize
+	}
+
+	switch length := utf8.RuneCountInString(text); {
+	case length < minLength:
+		return nil, ErrQueryTooShort
+	case length > MaxQueryLength:
+		return nil, ErrQueryTooLong
+	}
+	if limit < 1 || limit > maxLimit {
+		return nil, ErrInvalidPageSize
+	}
+	if offset < 0 {
+		return nil, ErrInvalidOffset
+	}
+
+	// one extra match tells whether there is a next page
+	matches, err := uc.searchRepo.Search(&domain.UserSearch{
+		Text:     text,
+		ViewerID: viewerID,
+		Prefix:   autocomplete,
+		Limit:    limit + 1,
+		Offset:   offset,
+	})
+	if err != nil {
+		uc.logger.Errorw("Failed to search users", "text", text, "error", err)
+		return nil, err
+	}
+
+	results := &dto.UserSearchResults{}
+	if len(matches) > limit {
+		matches = matches[:limit]
+		next := offset + limit
+		results.NextOffset = &next
+	}
+	results.Users = dto.NewUserSearchResults(matches)
+	return results, nil
+}
+
+// normalizeQuery trims the text, drops the @ of a mention and collapses
+// whitespace.
+func normalizeQuery(text string) string {
+	text = strings.TrimPrefix(strings.TrimSpace(text), "@")
+	return strings.Join(strings.Fields(text), " ")
+}
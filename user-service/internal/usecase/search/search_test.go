@@ -0,0 +1,75 @@
+package search
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type fakeSearchRepo struct {
+	matches []*domain.UserMatch
+	last    *domain.UserSearch
+}
+
+func (r *fakeSearchRepo) Search(search *domain.UserSearch) ([]*domain.UserMatch, error) {
+	r.last = search
+	end := min(search.Offset+search.Limit, len(r.matches))
+	if search.Offset >= end {
+		return nil, nil
+	}
+	return r.matches[search.Offset:end], nil
+}
+
+func TestSearchUsersPages(t *testing.T) {
+	repo := &fakeSearchRepo{}
+	for id := 1; id <= 5; id++ {
+		repo.matches = append(repo.matches, &domain.UserMatch{User: &domain.User{ID: id}})
+	}
+	uc := NewSearchUseCase(repo, zap.NewNop().Sugar())
+
+	page, err := uc.SearchUsers(7, "  @ali  ", true, 3, 0)
+	if err != nil {
+		t.Fatalf("SearchUsers: %v", err)
+	}
+	if repo.last.Text != "ali" || !repo.last.Prefix || repo.last.ViewerID != 7 {
+		t.Fatalf("searched %+v, want prefix search for ali by user 7", repo.last)
+	}
+	if len(page.Users) != 3 || page.NextOffset == nil || *page.NextOffset != 3 {
+		t.Fatalf("first page has %d users and next offset %v, want 3 and 3", len(page.Users), page.NextOffset)
+	}
+
+	page, err = uc.SearchUsers(7, "ali", true, 3, 3)
+	if err != nil {
+		t.Fatalf("SearchUsers: %v", err)
+	}
+	if len(page.Users) != 2 || page.NextOffset != nil {
+		t.Fatalf("last page has %d users and next offset %v, want 2 and none", len(page.Users), page.NextOffset)
+	}
+}
+
+func TestSearchUsersValidates(t *testing.T) {
+	uc := NewSearchUseCase(&fakeSearchRepo{}, zap.NewNop().Sugar())
+
+	tests := []struct {
+		text         string
+		autocomplete bool
+		limit        int
+		want         error
+	}{
+		{"a", false, 10, ErrQueryTooShort},
+		{"@", true, 10, ErrQueryTooShort},
+		{"a", true, 10, nil},
+		{"alice", false, MaxPageSize + 1, ErrInvalidPageSize},
+		{"alice", true, MaxAutocompleteSize + 1, ErrInvalidPageSize},
+		{strings.Repeat("a", MaxQueryLength+1), false, 10, ErrQueryTooLong},
+	}
+	for _, tt := range tests {
+		if _, err := uc.SearchUsers(0, tt.text, tt.autocomplete, tt.limit, 0); !errors.Is(err, tt.want) {
+			t.Errorf("SearchUsers(%q, autocomplete=%v, limit=%d) error = %v, want %v",
+				tt.text, tt.autocomplete, tt.limit, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package security
+
+import "errors"
+
+var (
+	ErrInvalidPageSize = errors.New("limit must be between 1 and 100")
+	ErrInvalidOffset   = errors.New("invalid offset")
+)
@@ -0,0 +1,130 @@
+package security
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	// MaxUserAgentLength is how much of a user agent is kept.
+	MaxUserAgentLength = 512
+
+	// Retention is how long security events are kept, and MaxEventsPerUser
+	// how many of them at most. Failed sign-ins can be recorded for anyone's
+	// account, so without the cap one could grow without bound.
+	Retention        = 90 * 24 * time.Hour
+	MaxEventsPerUser = 1000
+)
+
+// Alerter tells users about sign-ins from new devices.
+type Alerter interface {
+	SendNewDeviceLogin(to, username, userAgent, ip string, at time.Time) error
+}
+
+type useCase struct {
+	eventRepo repository.SecurityEventRepo
+	userRepo  repository.UserRepo
+	alerts    Alerter
+	logger    *zap.SugaredLogger
+}
+
+func NewSecurityUseCase(
+	eventRepo repository.SecurityEventRepo,
+	userRepo repository.UserRepo,
+	alerts Alerter,
+	logger *zap.SugaredLogger,
+) *useCase {
+	return &useCase{
+		eventRepo: eventRepo,
+		userRepo:  userRepo,
+		alerts:    alerts,
+		logger:    logger,
+	}
+}
+
+// Record stores a security event of the user with the client it came from.
+// A sign-in from a user agent the account was never signed in with is a new
+// device, and the user is mailed about it; the first sign-in of an account is
+// not. Events past the retention or the cap are dropped as new ones come in,
+// so a device last used before the retention counts as new again.
+func (uc *useCase) Record(userID int, eventType, ip, userAgent string) error {
+	userAgent = truncate(userAgent, MaxUserAgentLength)
+
+	newDevice := false
+	if eventType == domain.SecurityLogin {
+		total, withAgent, err := uc.eventRepo.CountLogins(userID, userAgent)
+		if err != nil {
+			uc.logger.Errorw("Failed to look up known devices", "userID", userID, "error", err)
+		} else {
+			newDevice = total > 0 && withAgent == 0
+		}
+	}
+
+	event := &domain.SecurityEvent{UserID: userID, Type: eventType, IP: ip, UserAgent: userAgent}
+	if err := uc.eventRepo.Insert(event); err != nil {
+		return err
+	}
+	if err := uc.eventRepo.Prune(userID, time.Now().Add(-Retention), MaxEventsPerUser); err != nil {
+		uc.logger.Errorw("Failed to prune security events", "userID", userID, "error", err)
+	}
+	if newDevice {
+		uc.alertNewDevice(event)
+	}
+	return nil
+}
+
+// alertNewDevice mails the user about a sign-in from a new device. The
+// sign-in stands even if the mail cannot be sent. The IP in the mail is the
+// one utils.ClientIP reports, which only believes forwarding headers from the
+// trusted proxies, so clients cannot put an address of their choice in it.
+func (uc *useCase) alertNewDevice(event *domain.SecurityEvent) {
+	user, err := uc.userRepo.GetByID(event.UserID)
+	if err != nil {
+		uc.logger.Errorw("Failed to fetch user for new device alert", "userID", event.UserID, "error", err)
+		return
+	}
+	uc.logger.Infow("Sign-in from a new device", "userID", user.ID, "ip", event.IP)
+
+	err = uc.alerts.SendNewDeviceLogin(user.Email, user.Username, event.UserAgent, event.IP, event.CreatedAt)
+	if err != nil {
+		uc.logger.Errorw("Failed to send new device alert", "userID", user.ID, "error", err)
+	}
+}
+
+// Events returns one page of the user's security events, newest first.
+func (uc *useCase) Events(userID, limit, offset int) (*dto.SecurityEventPage, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, ErrInvalidPageSize
+	}
+	if offset < 0 {
+		return nil, ErrInvalidOffset
+	}
+
+	// one extra event tells whether there is a next page
+	events, err := uc.eventRepo.List(userID, limit+1, offset)
+	if err != nil {
+		return nil, err
+	}
+	page := &dto.SecurityEventPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		next := offset + limit
+		page.NextOffset = &next
+	}
+	return page, nil
+}
+
+// truncate cuts s to at most n characters.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,179 @@
+package security
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type fakeEventRepo struct {
+	repository.SecurityEventRepo
+	events []*domain.SecurityEvent
+}
+
+func (r *fakeEventRepo) Insert(event *domain.SecurityEvent) error {
+	event.ID = int64(len(r.events) + 1)
+	event.CreatedAt = time.Now()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *fakeEventRepo) CountLogins(userID int, userAgent string) (int, int, error) {
+	total, withAgent := 0, 0
+	for _, event := range r.events {
+		if event.UserID == userID && event.Type == domain.SecurityLogin {
+			total++
+			if event.UserAgent == userAgent {
+				withAgent++
+			}
+		}
+	}
+	return total, withAgent, nil
+}
+
+func (r *fakeEventRepo) List(userID, limit, offset int) ([]*domain.SecurityEvent, error) {
+	var events []*domain.SecurityEvent
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if r.events[i].UserID == userID {
+			events = append(events, r.events[i])
+		}
+	}
+	if offset >= len(events) {
+		return nil, nil
+	}
+	return events[offset:min(offset+limit, len(events))], nil
+}
+
+func (r *fakeEventRepo) Prune(userID int, before time.Time, keep int) error {
+	recent := 0
+	for _, event := range r.events {
+		if event.UserID == userID && !event.CreatedAt.Before(before) {
+			recent++
+		}
+	}
+	kept := r.events[:0]
+	for _, event := range r.events {
+		if event.UserID == userID && event.CreatedAt.Before(before) {
+			continue
+		}
+		if event.UserID == userID && recent > keep {
+			recent--
+			continue
+		}
+		kept = append(kept, event)
+	}
+	r.events = kept
+	return nil
+}
+
+type fakeUserRepo struct {
+	repository.UserRepo
+}
+
+func (fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	return &domain.User{ID: id, Username: "alice", Email: "alice@example.com"}, nil
+}
+
+type fakeAlerter struct {
+	devices []string
+}
+
+func (a *fakeAlerter) SendNewDeviceLogin(to, username, userAgent, ip string, at time.Time) error {
+	a.devices = append(a.devices, userAgent)
+	return nil
+}
+
+func TestRecordAlertsOnNewDevice(t *testing.T) {
+	repo := &fakeEventRepo{}
+	alerts := &fakeAlerter{}
+	uc := NewSecurityUseCase(repo, fakeUserRepo{}, alerts, zap.NewNop().Sugar())
+
+	for _, step := range []struct {
+		eventType, userAgent string
+	}{
+		{domain.SecurityLogin, "laptop"}, // the first sign-in is not a new device
+		{domain.SecurityLogin, "laptop"},
+		{domain.SecurityLoginFailed, "phone"},
+		{domain.SecurityLogin, "phone"},
+		{domain.SecurityLogout, "tablet"},
+		{domain.SecurityLogin, "phone"},
+	} {
+		if err := uc.Record(1, step.eventType, "203.0.113.7", step.userAgent); err != nil {
+			t.Fatalf("Record(%s, %s): %v", step.eventType, step.userAgent, err)
+		}
+	}
+	if len(alerts.devices) != 1 || alerts.devices[0] != "phone" {
+		t.Errorf("alerted for %v, want only the phone", alerts.devices)
+	}
+
+	if err := uc.Record(1, domain.SecurityLogin, "", strings.Repeat("x", MaxUserAgentLength+10)); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(repo.events[len(repo.events)-1].UserAgent); got != MaxUserAgentLength {
+		t.Errorf("kept %d characters of the user agent", got)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	repo := &fakeEventRepo{}
+	uc := NewSecurityUseCase(repo, fakeUserRepo{}, &fakeAlerter{}, zap.NewNop().Sugar())
+	for i := 0; i < 3; i++ {
+		_ = uc.Record(1, domain.SecurityOTPIssued, "", "")
+	}
+	_ = uc.Record(2, domain.SecurityOTPIssued, "", "")
+
+	page, err := uc.Events(1, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Events) != 2 || page.Events[0].ID != 3 || page.NextOffset == nil || *page.NextOffset != 2 {
+		t.Errorf("first page: %d events, next offset %v", len(page.Events), page.NextOffset)
+	}
+	page, err = uc.Events(1, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Events) != 1 || page.NextOffset != nil {
+		t.Errorf("last page: %d events, next offset %v", len(page.Events), page.NextOffset)
+	}
+
+	if _, err = uc.Events(1, MaxPageSize+1, 0); err != ErrInvalidPageSize {
+		t.Errorf("got %v, want ErrInvalidPageSize", err)
+	}
+	if _, err = uc.Events(1, 10, -1); err != ErrInvalidOffset {
+		t.Errorf("got %v, want ErrInvalidOffset", err)
+	}
+}
+
+func TestRecordPrunesEvents(t *testing.T) {
+	repo := &fakeEventRepo{events: []*domain.SecurityEvent{
+		{ID: 1, UserID: 1, Type: domain.SecurityLogin, CreatedAt: time.Now().Add(-Retention - time.Hour)},
+		{ID: 2, UserID: 2, Type: domain.SecurityLogin, CreatedAt: time.Now().Add(-Retention - time.Hour)},
+	}}
+	uc := NewSecurityUseCase(repo, fakeUserRepo{}, &fakeAlerter{}, zap.NewNop().Sugar())
+
+	for i := 0; i < MaxEventsPerUser+5; i++ {
+		if err := uc.Record(1, domain.SecurityLoginFailed, "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mine := 0
+	for _, event := range repo.events {
+		if event.UserID == 1 {
+			mine++
+			if event.ID == 1 {
+				t.Error("kept an event past the retention")
+			}
+		}
+	}
+	if mine != MaxEventsPerUser {
+		t.Errorf("kept %d events, want %d", mine, MaxEventsPerUser)
+	}
+	if repo.events[0].ID != 2 {
+		t.Error("pruned the events of another user")
+	}
+}
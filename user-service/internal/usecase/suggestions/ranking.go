@@ -0,0 +1,115 @@
+package suggestions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Weights of the signals a candidate is ranked by.
+const (
+	mutualFollowWeight = 3.0
+	sharedTagWeight    = 2.0
+	activeWeekWeight   = 1.0 // tweeted in the last week
+	activeWindowWeight = 0.5 // tweeted within the activity window
+)
+
+// activityIndex is the recent activity of all authors, with the authors of
+// each tag, so candidates sharing tags with a user are found quickly.
+type activityIndex struct {
+	byUser     map[int]*domain.AuthorActivity
+	tagAuthors map[string][]int
+}
+
+func newActivityIndex(activity []*domain.AuthorActivity) *activityIndex {
+	index := &activityIndex{
+		byUser:     make(map[int]*domain.AuthorActivity, len(activity)),
+		tagAuthors: make(map[string][]int),
+	}
+	for _, a := range activity {
+		index.byUser[a.UserID] = a
+		for _, tag := range foldTags(a.Tags) {
+			index.tagAuthors[tag] = append(index.tagAuthors[tag], a.UserID)
+		}
+	}
+	return index
+}
+
+// rank scores the candidates for userID: users followed by the people the user
+// follows, with their mutual follow counts, and authors who tweet about the
+// same tags as the user. Recent activity breaks ties between them. Excluded
+// users and the user are never suggested. At most limit are returned, best
+// first.
+func rank(userID int, mutual map[int]int, excluded map[int]bool, index *activityIndex, now time.Time, limit int) []*domain.Suggestion {
+	candidates := make(map[int]*domain.Suggestion)
+	candidate := func(id int) *domain.Suggestion {
+		s, ok := candidates[id]
+		if !ok {
+			s = &domain.Suggestion{UserID: id}
+			candidates[id] = s
+		}
+		return s
+	}
+
+	for id, count := range mutual {
+		if id != userID && !excluded[id] {
+			candidate(id).MutualFollows = count
+		}
+	}
+	if own, ok := index.byUser[userID]; ok {
+		for _, tag := range foldTags(own.Tags) {
+			for _, id := range index.tagAuthors[tag] {
+				if id != userID && !excluded[id] {
+					s := candidate(id)
+					s.SharedTags = append(s.SharedTags, tag)
+				}
+			}
+		}
+	}
+
+	ranked := make([]*domain.Suggestion, 0, len(candidates))
+	for id, s := range candidates {
+		s.Score = mutualFollowWeight*float64(s.MutualFollows) + sharedTagWeight*float64(len(s.SharedTags))
+		if a, ok := index.byUser[id]; ok {
+			last := a.LastTweetAt
+			s.LastTweetAt = &last
+			if now.Sub(last) <= 7*24*time.Hour {
+				s.Score += activeWeekWeight
+			} else {
+				s.Score += activeWindowWeight
+			}
+		}
+		sort.Strings(s.SharedTags)
+		ranked = append(ranked, s)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.MutualFollows != b.MutualFollows {
+			return a.MutualFollows > b.MutualFollows
+		}
+		return a.UserID < b.UserID
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// foldTags lower-cases tags and drops duplicates.
+func foldTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	folded := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			folded = append(folded, tag)
+		}
+	}
+	return folded
+}
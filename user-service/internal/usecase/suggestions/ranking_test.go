@@ -0,0 +1,41 @@
+package suggestions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	now := time.Now()
+	index := newActivityIndex([]*domain.AuthorActivity{
+		{UserID: 1, LastTweetAt: now, Tags: []string{"Go", "#chess"}},
+		{UserID: 4, LastTweetAt: now.Add(-time.Hour), Tags: []string{"go"}},
+		{UserID: 5, LastTweetAt: now.Add(-20 * 24 * time.Hour), Tags: []string{"chess", "go"}},
+		{UserID: 6, LastTweetAt: now, Tags: []string{"go"}},
+		{UserID: 7, LastTweetAt: now, Tags: []string{"cooking"}},
+	})
+	mutual := map[int]int{2: 2, 3: 1, 4: 1, 1: 5}
+	excluded := map[int]bool{6: true}
+
+	got := rank(1, mutual, excluded, index, now, 10)
+
+	// 2: 2 mutual = 6; 4: 1 mutual + 1 tag + active week = 6; 5: 2 tags + active window = 4.5;
+	// 3: 1 mutual = 3. 1 is the user, 6 is excluded and 7 shares nothing.
+	want := []int{2, 4, 5, 3}
+	if len(got) != len(want) {
+		t.Fatalf("got %d suggestions, want %d", len(got), len(want))
+	}
+	for i, id := range want {
+		if got[i].UserID != id {
+			t.Fatalf("suggestion %d is user %d, want %d", i, got[i].UserID, id)
+		}
+	}
+	if tags := got[2].SharedTags; len(tags) != 2 || tags[0] != "chess" || tags[1] != "go" {
+		t.Fatalf("got shared tags %v, want [chess go]", tags)
+	}
+
+	if got := rank(1, mutual, excluded, index, now, 2); len(got) != 2 {
+		t.Fatalf("got %d suggestions, want the limit of 2", len(got))
+	}
+}
@@ -0,0 +1,196 @@
+package suggestions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	// Count is how many suggestions a user is shown.
+	Count = 20
+
+	storedSuggestions = 50 // kept per user, some may be gone by the time they are shown
+	suggestionTTL     = 3 * time.Hour
+	candidatePool     = 200
+	activityWindow    = 30 * 24 * time.Hour
+	userBatchSize     = 500
+)
+
+// TweetService is the part of tweet-service suggestions are ranked with.
+type TweetService interface {
+	AuthorActivity(since time.Time) ([]*domain.AuthorActivity, error)
+}
+
+type useCase struct {
+	userRepo       repository.UserRepo
+	followerRepo   repository.FollowerRepo
+	suggestionRepo repository.SuggestionRepo
+	tweets         TweetService
+	logger         *zap.SugaredLogger
+	now            func() time.Time
+
+	mu        sync.Mutex
+	lastIndex *activityIndex // activity of the last run, for users it missed
+}
+
+func NewSuggestionUseCase(
+	userRepo repository.UserRepo,
+	followerRepo repository.FollowerRepo,
+	suggestionRepo repository.SuggestionRepo,
+	tweets TweetService,
+	logger *zap.SugaredLogger,
+) *useCase {
+	return &useCase{
+		userRepo:       userRepo,
+		followerRepo:   followerRepo,
+		suggestionRepo: suggestionRepo,
+		tweets:         tweets,
+		logger:         logger,
+		now:            time.Now,
+	}
+}
+
+// Run computes the suggestions of every user every interval, until ctx is
+// done. The interval should be well below the three hours suggestions are
+// kept. Running it on several instances only repeats the same work.
+func (uc *useCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		uc.RefreshAll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshAll computes and stores the suggestions of every active user and
+// returns for how many users it succeeded.
+func (uc *useCase) RefreshAll() int {
+	started := uc.now()
+	index := uc.loadActivity()
+	uc.mu.Lock()
+	uc.lastIndex = index
+	uc.mu.Unlock()
+
+	refreshed, afterID := 0, 0
+	for {
+		ids, err := uc.userRepo.ListActiveIDs(afterID, userBatchSize)
+		if err != nil {
+			uc.logger.Errorw("Failed to list users for follow suggestions", "error", err)
+			return refreshed
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			if _, err = uc.refresh(id, index); err != nil {
+				uc.logger.Errorw("Failed to compute follow suggestions", "userID", id, "error", err)
+				continue
+			}
+			refreshed++
+		}
+		afterID = ids[len(ids)-1]
+	}
+
+	uc.logger.Infow("Computed follow suggestions", "users", refreshed, "took", uc.now().Sub(started))
+	return refreshed
+}
+
+// Suggestions returns whom the user could follow, best first. They come from
+// the last run of the job; a user it did not cover yet gets them computed now.
+// Users followed or blocked since the run are left out.
+func (uc *useCase) Suggestions(userID int) ([]*dto.SuggestedUser, error) {
+	suggestions, err := uc.suggestionRepo.Get(userID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		uc.mu.Lock()
+		index := uc.lastIndex
+		uc.mu.Unlock()
+		if index == nil {
+			index = uc.loadActivity()
+		}
+		suggestions, err = uc.refresh(userID, index)
+	}
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := uc.unsuggestable(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*dto.SuggestedUser, 0, Count)
+	for _, s := range suggestions {
+		if len(result) == Count {
+			break
+		}
+		if excluded[s.UserID] {
+			continue
+		}
+		// the user may have been suspended or left since the run
+		user, err := uc.userRepo.GetByID(s.UserID)
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if user.CheckActive() != nil {
+			continue
+		}
+		result = append(result, dto.NewSuggestedUser(user, s))
+	}
+	return result, nil
+}
+
+func (uc *useCase) refresh(userID int, index *activityIndex) ([]*domain.Suggestion, error) {
+	mutual, err := uc.followerRepo.ListFriendsOfFriends(userID, candidatePool)
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := uc.unsuggestable(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := rank(userID, mutual, excluded, index, uc.now(), storedSuggestions)
+	if err = uc.suggestionRepo.Store(userID, suggestions, suggestionTTL); err != nil {
+		return nil, err
+	}
+	return suggestions, nil
+}
+
+// unsuggestable returns the users the user follows or is on either side of a
+// block with.
+func (uc *useCase) unsuggestable(userID int) (map[int]bool, error) {
+	ids, err := uc.followerRepo.ListUnsuggestable(userID)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		excluded[id] = true
+	}
+	return excluded, nil
+}
+
+// loadActivity fetches what authors tweeted recently. Without it suggestions
+// are ranked by the social graph alone.
+func (uc *useCase) loadActivity() *activityIndex {
+	activity, err := uc.tweets.AuthorActivity(uc.now().Add(-activityWindow))
+	if err != nil {
+		uc.logger.Warnw("Failed to load author activity for follow suggestions", "error", err)
+		return newActivityIndex(nil)
+	}
+	return newActivityIndex(activity)
+}
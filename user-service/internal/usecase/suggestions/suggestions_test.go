@@ -0,0 +1,58 @@
+package suggestions
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type fakeUserRepo struct {
+	repository.UserRepo
+}
+
+func (fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	return &domain.User{ID: id, Username: "user"}, nil
+}
+
+type fakeFollowerRepo struct {
+	repository.FollowerRepo
+	unsuggestable []int
+}
+
+func (r *fakeFollowerRepo) ListUnsuggestable(userID int) ([]int, error) {
+	return r.unsuggestable, nil
+}
+
+type fakeSuggestionRepo map[int][]*domain.Suggestion
+
+func (r fakeSuggestionRepo) Store(userID int, suggestions []*domain.Suggestion, ttl time.Duration) error {
+	r[userID] = suggestions
+	return nil
+}
+
+func (r fakeSuggestionRepo) Get(userID int) ([]*domain.Suggestion, error) {
+	suggestions, ok := r[userID]
+	if !ok {
+		return nil, domain.ErrRecordNotFound
+	}
+	return suggestions, nil
+}
+
+func TestSuggestionsLeaveOutNewRelations(t *testing.T) {
+	stored := fakeSuggestionRepo{1: {{UserID: 2}, {UserID: 3}, {UserID: 4}}}
+	followers := &fakeFollowerRepo{}
+	uc := NewSuggestionUseCase(fakeUserRepo{}, followers, stored, nil, zap.NewNop().Sugar())
+
+	// 2 was followed and 4 blocked since the suggestions were computed
+	followers.unsuggestable = []int{2, 4}
+	got, err := uc.Suggestions(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != 3 {
+		t.Errorf("got %+v, want only user 3", got)
+	}
+}
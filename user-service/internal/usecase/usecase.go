@@ -3,21 +3,145 @@ package usecase
 import (
 	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
 	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"context"
+	"io"
+	"os"
 )
 
 type UserUseCase interface {
 	Register(dto dto.RegisterUserRequest) error
-	Authorize(input dto.LoginRequest) (string, error)
-	Logout(sessionToken string) error
-	Authorize2FA(email string) string
-	VerifyOTP(email, otp string) (string, error)
+	Authorize(input dto.LoginRequest, clientIP, userAgent string) (string, error)
+	Logout(sessionToken, clientIP, userAgent string) error
+	Authorize2FA(username, clientIP, userAgent string) error
+	VerifyOTP(email, otp, clientIP, userAgent string) (string, error)
 	List() ([]*domain.User, error)
+	ForgotPassword(input dto.ForgotPasswordRequest) error
+	ResetPassword(input dto.ResetPasswordRequest, clientIP, userAgent string) error
+	ChangePassword(userID int, input dto.ChangePasswordRequest, clientIP, userAgent string) (string, error)
+	ChangeUsername(userID int, input dto.ChangeUsernameRequest) (string, error)
+	VerifyEmail(input dto.VerifyEmailRequest) error
+	ResendVerification(input dto.ResendVerificationRequest) error
+	Authenticate(sessionToken string) (*domain.User, error)
+	OIDCProviders() []string
+	BeginOIDCLogin(ctx context.Context, provider string) (string, error)
+	CompleteOIDCLogin(ctx context.Context, provider, state, code, clientIP, userAgent string) (string, error)
+}
+
+type ProfileUseCase interface {
+	GetProfile(id int) (*dto.PublicProfile, error)
+	GetProfileByUsername(username string) (*dto.PublicProfile, error)
+	GetOwnProfile(userID int) (*dto.OwnProfile, error)
+	UpdateProfile(userID int, input dto.UpdateProfileRequest) (*dto.OwnProfile, error)
+	SetProtection(userID int, input dto.SetProtectionRequest) (*dto.OwnProfile, error)
+	UploadAvatar(userID int, r io.Reader) (*dto.OwnProfile, error)
+	UploadBanner(userID int, r io.Reader) (*dto.OwnProfile, error)
+	OpenMedia(key string) (*os.File, string, error)
+}
+
+type DeletionUseCase interface {
+	RequestDeletion(userID int, input dto.DeleteAccountRequest) (*domain.AccountDeletion, error)
+}
+
+type ExportUseCase interface {
+	RequestExport(userID int) (*dto.DataExportStatus, error)
+	GetExport(userID, exportID int) (*dto.DataExportStatus, error)
+	OpenDownload(token string) (*os.File, *domain.DataExport, error)
 }
 
 type FollowerUseCase interface {
-	Follow(followerID, followeeID int) error
+	Follow(followerID, followeeID int) (requested bool, err error)
 	Unfollow(followerID, followeeID int) error
-	GetFollowers(userID int) ([]*domain.User, error)
-	GetFollowing(userID int) ([]*domain.User, error)
+	GetFollowers(userID, viewerID int, cursor string, limit int) ([]*domain.FollowEntry, string, error)
+	GetFollowing(userID, viewerID int, cursor string, limit int) ([]*domain.FollowEntry, string, error)
 	IsFollowing(followerID, followeeID int) (bool, error)
+	IncomingRequests(userID int) ([]*domain.FollowRequest, error)
+	OutgoingRequests(userID int) ([]*domain.FollowRequest, error)
+	ApproveRequest(userID, requesterID int) error
+	RejectRequest(userID, requesterID int) error
+	CancelRequest(userID, targetID int) error
+	Block(userID, targetID int) error
+	Unblock(userID, targetID int) error
+	ListBlocked(userID int) ([]*domain.User, error)
+	Mute(userID, targetID int) error
+	Unmute(userID, targetID int) error
+	ListMuted(userID int) ([]*domain.User, error)
+	Relations(userID int) (*domain.Relations, error)
+}
+
+type NotificationUseCase interface {
+	Notify(event *domain.NotificationEvent) error
+	NotifyMentions(actorID int, tweetID int64, usernames []string) error
+	List(userID, limit, offset int) (*dto.NotificationPage, error)
+	UnreadCount(userID int) (int, error)
+	MarkRead(userID, id int) error
+	MarkAllRead(userID int) error
+	Preferences(userID int) (map[string]bool, error)
+	UpdatePreferences(userID int, changes map[string]bool) (map[string]bool, error)
+}
+
+type SearchUseCase interface {
+	SearchUsers(viewerID int, text string, autocomplete bool, limit, offset int) (*dto.UserSearchResults, error)
+}
+
+type SuggestionUseCase interface {
+	Suggestions(userID int) ([]*dto.SuggestedUser, error)
+}
+
+type MutedWordUseCase interface {
+	Add(userID int, input dto.AddMutedWordRequest) (*domain.MutedWord, error)
+	List(userID int) ([]*domain.MutedWord, error)
+	Remove(userID, id int) error
+}
+
+type AppUseCase interface {
+	CreateApp(ownerID int, input dto.CreateAppRequest) (*domain.DeveloperApp, error)
+	ListApps(ownerID int) ([]*domain.DeveloperApp, error)
+	CreateKey(ownerID, appID int, input dto.CreateAPIKeyRequest) (string, *domain.APIKey, error)
+	ListKeys(ownerID, appID int) ([]*domain.APIKey, error)
+	RevokeKey(ownerID, appID, keyID int) error
+	ResolveAPIKey(rawKey string) (*domain.APIKeyGrant, error)
+}
+
+type WebhookUseCase interface {
+	CreateWebhook(ownerID, appID int, input dto.CreateWebhookRequest) (*dto.CreateWebhookResponse, error)
+	ListWebhooks(ownerID, appID int) ([]*domain.Webhook, error)
+	DeleteWebhook(ownerID, appID, webhookID int) error
+	Deliveries(ownerID, appID, webhookID int, status string, limit, offset int) (*dto.WebhookDeliveryPage, error)
+	Redeliver(ownerID, appID, webhookID int, deliveryID int64) (*domain.WebhookDelivery, error)
+	Dispatch(userID int, event string, data interface{}) error
+}
+
+type SecurityUseCase interface {
+	Events(userID, limit, offset int) (*dto.SecurityEventPage, error)
+}
+
+type AdminUseCase interface {
+	ListUsers(actor *domain.User, ip string) ([]*domain.User, error)
+	Suspend(actor *domain.User, userID int, input dto.SuspendUserRequest, ip string) error
+	Unsuspend(actor *domain.User, userID int, ip string) error
+	AssignRole(actor *domain.User, userID int, input dto.AssignRoleRequest, ip string) error
+	RevokeRole(actor *domain.User, userID int, role, ip string) error
+	RecordAudit(input dto.RecordAuditRequest) (*domain.AuditRecord, error)
+	ListAudit(limit int, beforeID int64) ([]*domain.AuditRecord, error)
+}
+
+type MessageUseCase interface {
+	Send(senderID, recipientID int, body string) (*dto.MessageView, error)
+	Post(senderID, conversationID int, body string) (*dto.MessageView, error)
+	Conversations(userID, limit, offset int) (*dto.ConversationPage, error)
+	Messages(userID, conversationID int, cursor string, limit int) (*dto.MessagePage, error)
+	DeleteMessage(userID, conversationID int, messageID int64) error
+	MarkRead(userID, conversationID int) error
+	Settings(userID int) (*dto.MessageSettings, error)
+	UpdateSettings(userID int, input *dto.UpdateMessageSettingsRequest) (*dto.MessageSettings, error)
+	CreateGroup(creatorID int, input *dto.CreateGroupRequest) (*dto.ConversationView, error)
+	GetConversation(userID, conversationID int) (*dto.ConversationView, error)
+	Rename(userID, conversationID int, name string) (*dto.ConversationView, error)
+	Invite(userID, conversationID, inviteeID int) error
+	Invites(userID int) ([]*dto.ConversationInviteView, error)
+	AcceptInvite(userID, conversationID int) (*dto.ConversationView, error)
+	DeclineInvite(userID, conversationID int) error
+	Leave(userID, conversationID int) error
+	RemoveMember(userID, conversationID, memberID int) error
+	SetRole(userID, conversationID, memberID int, role string) error
 }
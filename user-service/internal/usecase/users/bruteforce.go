@@ -0,0 +1,98 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"fmt"
+	"time"
+)
+
+// BruteForcePolicy controls how failed logins and OTP guesses are throttled.
+type BruteForcePolicy struct {
+	Window              time.Duration // how long failures are remembered
+	AccountFreeAttempts int64         // failures per account before backoff starts
+	IPFreeAttempts      int64         // failures per client IP before backoff starts
+	BaseDelay           time.Duration // first backoff delay, doubled on every further failure
+	MaxDelay            time.Duration
+	LockThreshold       int64 // failures per account that lock it
+	LockDuration        time.Duration
+	MaxOTPAttempts      int64 // wrong guesses before the OTP is invalidated
+	MaxOTPIssues        int64 // OTPs sent to an account within the window
+	IPMaxOTPIssues      int64 // OTPs sent for requests from a client IP within the window
+}
+
+var DefaultBruteForcePolicy = BruteForcePolicy{
+	Window:              15 * time.Minute,
+	AccountFreeAttempts: 3,
+	IPFreeAttempts:      20,
+	BaseDelay:           time.Second,
+	MaxDelay:            5 * time.Minute,
+	LockThreshold:       10,
+	LockDuration:        15 * time.Minute,
+	MaxOTPAttempts:      5,
+	MaxOTPIssues:        5,
+	IPMaxOTPIssues:      20,
+}
+
+func accountKey(username string) string { return fmt.Sprintf("account:%s", username) }
+func otpKey(email string) string        { return fmt.Sprintf("otp:%s", email) }
+func otpIssueKey(email string) string   { return fmt.Sprintf("otp_issue:%s", email) }
+func otpIssueIPKey(ip string) string    { return fmt.Sprintf("otp_issue_ip:%s", ip) }
+func ipKey(ip string) string            { return fmt.Sprintf("ip:%s", ip) }
+func lockKey(key string) string         { return fmt.Sprintf("lock:%s", key) }
+
+// checkThrottle returns a ThrottleError if any of the keys is locked or backing off.
+func (uc *useCase) checkThrottle(keys ...string) error {
+	for _, key := range keys {
+		locked, err := uc.attemptRepo.BlockedFor(lockKey(key))
+		if err != nil {
+			return err
+		}
+		if locked > 0 {
+			return &domain.ThrottleError{RetryAfter: locked, Locked: true}
+		}
+
+		backoff, err := uc.attemptRepo.BlockedFor(key)
+		if err != nil {
+			return err
+		}
+		if backoff > 0 {
+			return &domain.ThrottleError{RetryAfter: backoff}
+		}
+	}
+	return nil
+}
+
+// registerFailure counts a failed attempt for key, starting or extending its
+// backoff once the free attempts are used up and locking it at the threshold.
+func (uc *useCase) registerFailure(key string, freeAttempts int64, lockable bool) (int64, error) {
+	policy := uc.bruteForce
+
+	failures, err := uc.attemptRepo.RegisterFailure(key, policy.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	if lockable && failures >= policy.LockThreshold {
+		uc.logger.Warnw("Locking after repeated failures", "key", key, "failures", failures)
+		return failures, uc.attemptRepo.Block(lockKey(key), policy.LockDuration)
+	}
+
+	if failures > freeAttempts {
+		if err = uc.attemptRepo.Block(key, backoffDelay(policy, failures-freeAttempts)); err != nil {
+			return 0, err
+		}
+	}
+	return failures, nil
+}
+
+// backoffDelay doubles the base delay for every failure past the free attempts.
+func backoffDelay(policy BruteForcePolicy, excess int64) time.Duration {
+	delay := policy.BaseDelay
+	for i := int64(1); i < excess; i++ {
+		delay *= 2
+		if delay >= policy.MaxDelay {
+			return policy.MaxDelay
+		}
+	}
+	return delay
+}
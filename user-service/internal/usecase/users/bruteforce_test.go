@@ -0,0 +1,363 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/emails"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/passwords"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"MussaShaukenov/twitter-clone-go/user-service/pkg/mailer"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// testArgon2Params keep the tests fast; production uses DefaultArgon2Params.
+var testArgon2Params = utils.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+type fakeAttemptRepo struct {
+	failures map[string]int64
+	blocks   map[string]time.Duration
+}
+
+func (r *fakeAttemptRepo) RegisterFailure(key string, window time.Duration) (int64, error) {
+	r.failures[key]++
+	return r.failures[key], nil
+}
+
+func (r *fakeAttemptRepo) ResetFailures(key string) error {
+	delete(r.failures, key)
+	return nil
+}
+
+func (r *fakeAttemptRepo) Block(key string, ttl time.Duration) error {
+	r.blocks[key] = ttl
+	return nil
+}
+
+func (r *fakeAttemptRepo) BlockedFor(key string) (time.Duration, error) {
+	return r.blocks[key], nil
+}
+
+type fakeUserRepo struct {
+	repository.UserRepo
+	users []*domain.User
+}
+
+func (r *fakeUserRepo) find(match func(*domain.User) bool) (*domain.User, error) {
+	for _, user := range r.users {
+		if match(user) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) GetByID(id int) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return u.ID == id })
+}
+
+func (r *fakeUserRepo) GetByUsername(username string) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return u.Username == username })
+}
+
+func (r *fakeUserRepo) GetByEmail(email string) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return strings.EqualFold(u.Email, email) })
+}
+
+func (r *fakeUserRepo) IsFirstLogin(userID int) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeUserRepo) UpdatePassword(id int, hashedPassword string) error {
+	for _, user := range r.users {
+		if user.ID == id {
+			user.Password = hashedPassword
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) MarkEmailVerified(id int, email string) error {
+	for _, user := range r.users {
+		if user.ID == id && user.Email == email {
+			user.EmailVerified = true
+			return nil
+		}
+	}
+	return domain.ErrRecordNotFound
+}
+
+type fakeOTPRepo struct {
+	repository.OTPRepo
+	otps     map[string]string
+	sessions map[string]int
+}
+
+func (r *fakeOTPRepo) StoreOTP(email, code string, ttl time.Duration) error {
+	r.otps[email] = code
+	return nil
+}
+
+func (r *fakeOTPRepo) GetStoreOTP(email string) (string, error) {
+	code, ok := r.otps[email]
+	if !ok {
+		return "", errors.New("no otp")
+	}
+	return code, nil
+}
+
+func (r *fakeOTPRepo) DeleteOTP(email string) error {
+	delete(r.otps, email)
+	return nil
+}
+
+func (r *fakeOTPRepo) CreateSession(userID int, token string, ttl time.Duration) error {
+	r.sessions[token] = userID
+	return nil
+}
+
+func (r *fakeOTPRepo) DeleteUserSessions(userID int) error {
+	for token, owner := range r.sessions {
+		if owner == userID {
+			delete(r.sessions, token)
+		}
+	}
+	return nil
+}
+
+type fakeResetRepo struct {
+	repository.PasswordResetRepo
+	tokens map[string]int
+}
+
+func (r *fakeResetRepo) GetResetToken(tokenHash string) (int, error) {
+	userID, ok := r.tokens[tokenHash]
+	if !ok {
+		return 0, errors.New("no reset token")
+	}
+	return userID, nil
+}
+
+func (r *fakeResetRepo) ConsumeResetToken(tokenHash string) (int, error) {
+	userID, err := r.GetResetToken(tokenHash)
+	delete(r.tokens, tokenHash)
+	return userID, err
+}
+
+type fakeSessionRepo struct {
+	repository.SessionHistoryRepo
+}
+
+func (fakeSessionRepo) Record(userID int, method, ip string) error { return nil }
+
+type fakeSecurityLog struct {
+	events []string
+}
+
+func (l *fakeSecurityLog) Record(userID int, eventType, ip, userAgent string) error {
+	l.events = append(l.events, eventType)
+	return nil
+}
+
+type fakeMailer struct {
+	sent []mailer.Message
+}
+
+func (m *fakeMailer) Send(msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type testUseCase struct {
+	*useCase
+	users    *fakeUserRepo
+	otps     *fakeOTPRepo
+	resets   *fakeResetRepo
+	attempts *fakeAttemptRepo
+	security *fakeSecurityLog
+	mail     *fakeMailer
+}
+
+// newTestUseCase returns a use case with alice, whose password is
+// "correct-Horse-battery-42".
+func newTestUseCase(t *testing.T) *testUseCase {
+	t.Helper()
+	logger := zap.NewNop().Sugar()
+	hasher := utils.NewPasswordHasher(testArgon2Params)
+	hashed, err := hasher.Hash("correct-Horse-battery-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mail := &fakeMailer{}
+	sender, err := emails.NewSender(mail, "http://localhost", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := passwords.NewEmbeddedSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator := passwords.NewValidator(passwords.DefaultPolicy, passwords.NewBreachChecker(source))
+
+	tc := &testUseCase{
+		users:    &fakeUserRepo{users: []*domain.User{{ID: 1, Username: "alice", Email: "alice@example.com", Password: hashed}}},
+		otps:     &fakeOTPRepo{otps: map[string]string{}, sessions: map[string]int{}},
+		resets:   &fakeResetRepo{tokens: map[string]int{}},
+		attempts: &fakeAttemptRepo{failures: map[string]int64{}, blocks: map[string]time.Duration{}},
+		security: &fakeSecurityLog{},
+		mail:     mail,
+	}
+	tc.useCase = NewUserUseCase(tc.users, tc.otps, tc.attempts, tc.resets, nil, nil, nil, fakeSessionRepo{}, nil, nil,
+		tc.security, sender, validator, hasher, "test-verification-secret", logger)
+	return tc
+}
+
+func TestLoginBackoffAndLockout(t *testing.T) {
+	tc := newTestUseCase(t)
+	policy := tc.bruteForce
+	wrong := dto.LoginRequest{Username: "alice", Password: "wrong-password"}
+
+	for i := int64(1); i <= policy.AccountFreeAttempts; i++ {
+		if _, err := tc.Authorize(wrong, "203.0.113.7", ""); !errors.Is(err, domain.ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: got %v, want invalid credentials", i, err)
+		}
+	}
+	if _, blocked := tc.attempts.blocks[accountKey("alice")]; blocked {
+		t.Fatal("backing off within the free attempts")
+	}
+
+	// the next failure starts the backoff, and the one after is refused
+	if _, err := tc.Authorize(wrong, "203.0.113.7", ""); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("got %v, want invalid credentials", err)
+	}
+	if got := tc.attempts.blocks[accountKey("alice")]; got != policy.BaseDelay {
+		t.Errorf("backoff = %v, want %v", got, policy.BaseDelay)
+	}
+	var throttled *domain.ThrottleError
+	if _, err := tc.Authorize(wrong, "203.0.113.7", ""); !errors.As(err, &throttled) || throttled.Locked {
+		t.Fatalf("got %v, want a backoff", err)
+	}
+
+	// failures keep counting up to the lock
+	delete(tc.attempts.blocks, accountKey("alice"))
+	tc.attempts.failures[accountKey("alice")] = policy.LockThreshold - 1
+	if _, err := tc.Authorize(wrong, "198.51.100.1", ""); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("got %v, want invalid credentials", err)
+	}
+	delete(tc.attempts.blocks, accountKey("alice"))
+	_, err := tc.Authorize(dto.LoginRequest{Username: "alice", Password: "correct-Horse-battery-42"}, "198.51.100.1", "")
+	if !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("got %v, want the account locked even for the right password", err)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	policy := DefaultBruteForcePolicy
+	for excess, want := range map[int64]time.Duration{
+		1: time.Second, 2: 2 * time.Second, 5: 16 * time.Second, 9: 256 * time.Second, 10: policy.MaxDelay, 40: policy.MaxDelay,
+	} {
+		if got := backoffDelay(policy, excess); got != want {
+			t.Errorf("backoffDelay(%d) = %v, want %v", excess, got, want)
+		}
+	}
+}
+
+func TestOTPGuessesOutliveReissue(t *testing.T) {
+	tc := newTestUseCase(t)
+	email := "alice@example.com"
+
+	if err := tc.Authorize2FA("alice", "203.0.113.7", ""); err != nil {
+		t.Fatal(err)
+	}
+	for i := int64(1); i < tc.bruteForce.MaxOTPAttempts; i++ {
+		if _, err := tc.VerifyOTP(email, "000000x", "203.0.113.7", ""); !errors.Is(err, ErrInvalidOTP) {
+			t.Fatalf("guess %d: got %v, want invalid OTP", i, err)
+		}
+	}
+	if _, err := tc.VerifyOTP(email, "000000x", "203.0.113.7", ""); !errors.Is(err, ErrOTPInvalidated) {
+		t.Fatalf("got %v, want the OTP invalidated", err)
+	}
+
+	// a new code does not give new guesses
+	if err := tc.Authorize2FA("alice", "203.0.113.7", ""); err != nil {
+		t.Fatal(err)
+	}
+	var throttled *domain.ThrottleError
+	if _, err := tc.VerifyOTP(email, tc.otps.otps[email], "203.0.113.7", ""); !errors.As(err, &throttled) {
+		t.Fatalf("got %v, want verification blocked after a reissue", err)
+	}
+	if tc.attempts.failures[otpKey(email)] != tc.bruteForce.MaxOTPAttempts {
+		t.Errorf("guess count reset to %d", tc.attempts.failures[otpKey(email)])
+	}
+}
+
+func TestOTPIssueLimit(t *testing.T) {
+	tc := newTestUseCase(t)
+
+	for i := int64(1); i <= tc.bruteForce.MaxOTPIssues; i++ {
+		if err := tc.Authorize2FA("alice", "203.0.113.7", ""); err != nil {
+			t.Fatalf("issue %d: %v", i, err)
+		}
+	}
+	var throttled *domain.ThrottleError
+	if err := tc.Authorize2FA("alice", "203.0.113.7", ""); !errors.As(err, &throttled) {
+		t.Fatalf("got %v, want issuing throttled", err)
+	}
+	if got := int64(len(tc.mail.sent)); got != tc.bruteForce.MaxOTPIssues {
+		t.Errorf("sent %d codes, want %d", got, tc.bruteForce.MaxOTPIssues)
+	}
+	if !strings.Contains(tc.mail.sent[0].To, "alice@example.com") {
+		t.Errorf("code sent to %q", tc.mail.sent[0].To)
+	}
+}
+
+func TestOTPIssueLimitPerClient(t *testing.T) {
+	tc := newTestUseCase(t)
+	tc.bruteForce.IPMaxOTPIssues = 2
+	tc.users.users = append(tc.users.users,
+		&domain.User{ID: 2, Username: "bob", Email: "bob@example.com"},
+		&domain.User{ID: 3, Username: "carol", Email: "carol@example.com"})
+
+	for _, username := range []string{"alice", "bob"} {
+		if err := tc.Authorize2FA(username, "203.0.113.7", ""); err != nil {
+			t.Fatalf("issue for %s: %v", username, err)
+		}
+	}
+	var throttled *domain.ThrottleError
+	if err := tc.Authorize2FA("carol", "203.0.113.7", ""); !errors.As(err, &throttled) {
+		t.Fatalf("got %v, want issuing throttled for the client", err)
+	}
+	if err := tc.Authorize2FA("carol", "198.51.100.1", ""); err != nil {
+		t.Fatalf("issue from another client: %v", err)
+	}
+	if got := len(tc.security.events); got != 3 {
+		t.Errorf("recorded %d events, want one per code sent", got)
+	}
+}
+
+func TestBlockedOTPGuessesAreNotRecorded(t *testing.T) {
+	tc := newTestUseCase(t)
+	email := "alice@example.com"
+	if err := tc.Authorize2FA("alice", "203.0.113.7", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := int64(0); i < tc.bruteForce.MaxOTPAttempts+3; i++ {
+		_, _ = tc.VerifyOTP(email, "000000x", "203.0.113.7", "")
+	}
+	failed := 0
+	for _, event := range tc.security.events {
+		if event == domain.SecurityLoginFailed {
+			failed++
+		}
+	}
+	if int64(failed) != tc.bruteForce.MaxOTPAttempts {
+		t.Errorf("recorded %d failed guesses, want %d", failed, tc.bruteForce.MaxOTPAttempts)
+	}
+}
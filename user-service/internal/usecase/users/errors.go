@@ -3,7 +3,22 @@ package users
 import "errors"
 
 var (
-	ErrFailedToRetrieveOTP = errors.New("failed to retrieve OTP")
-	ErrInvalidOTP          = errors.New("invalid OTP")
-	ErrAgeRestrict         = errors.New("You must be 14 years or older to use this service")
+	ErrFailedToRetrieveOTP      = errors.New("failed to retrieve OTP")
+	ErrInvalidOTP               = errors.New("invalid OTP")
+	ErrOTPRequired              = errors.New("verification code sent, verify it to finish signing in")
+	ErrOTPInvalidated           = errors.New("too many wrong OTP guesses, request a new code later")
+	ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrInvalidEmail             = errors.New("invalid email address")
+	ErrUnauthenticated          = errors.New("unauthenticated")
+	ErrWrongCurrentPassword     = errors.New("current password is incorrect")
+	ErrPasswordUnchanged        = errors.New("new password must differ from the current one")
+	ErrInvalidOIDCState         = errors.New("invalid or expired login state")
+	ErrOIDCLoginFailed          = errors.New("sign in with the identity provider failed")
+	ErrOIDCEmailNotVerified     = errors.New("the identity provider did not confirm a verified email")
+	ErrOIDCAgeUnknown           = errors.New("the identity provider did not share a birthdate, register with a password instead")
+	ErrUsernameUnavailable      = errors.New("could not find an available username")
+	ErrUsernameUnchanged        = errors.New("new username must differ from the current one")
+	ErrUsernameCooldown         = errors.New("username was changed recently")
+	ErrAgeRestrict              = errors.New("You must be 14 years or older to use this service")
 )
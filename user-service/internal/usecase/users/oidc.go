@@ -0,0 +1,269 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/oidc"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usernames"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const oidcLoginTTL = 10 * time.Minute
+
+// minimumAge is the age an account holder must have reached.
+const minimumAge = 14
+
+// OIDCProviders lists the names of the configured identity providers.
+func (uc *useCase) OIDCProviders() []string {
+	return uc.providers.Names()
+}
+
+// BeginOIDCLogin starts a login at an external provider and returns the URL to
+// send the user to. The state, nonce and PKCE verifier stay server-side until
+// the provider redirects back.
+func (uc *useCase) BeginOIDCLogin(ctx context.Context, providerName string) (string, error) {
+	provider, err := uc.providers.Get(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.RandomString(48)
+	if err != nil {
+		return "", err
+	}
+
+	login := domain.OIDCLogin{Provider: providerName, Nonce: nonce, CodeVerifier: verifier}
+	if err = uc.oidcStateRepo.StoreLoginState(state, login, oidcLoginTTL); err != nil {
+		return "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		uc.logger.Errorw("Failed to build authorization url", "provider", providerName, "error", err)
+		return "", err
+	}
+	return authURL, nil
+}
+
+// CompleteOIDCLogin finishes a login at an external provider and returns a
+// session token. The identity is resolved to a user by an existing link, then
+// by verified email, and a new account is provisioned when neither matches.
+func (uc *useCase) CompleteOIDCLogin(ctx context.Context, providerName, state, code, clientIP, userAgent string) (string, error) {
+	provider, err := uc.providers.Get(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	login, err := uc.oidcStateRepo.ConsumeLoginState(state)
+	if err != nil || login.Provider != providerName {
+		uc.logger.Warnw("Unknown or expired oidc login state", "provider", providerName)
+		return "", ErrInvalidOIDCState
+	}
+
+	claims, err := provider.Exchange(ctx, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		uc.logger.Warnw("Failed to complete oidc login", "provider", providerName, "error", err)
+		return "", ErrOIDCLoginFailed
+	}
+
+	userID, err := uc.identityRepo.GetUserID(providerName, claims.Subject)
+	switch {
+	case errors.Is(err, domain.ErrRecordNotFound):
+		userID, err = uc.linkIdentity(providerName, claims)
+		if err != nil {
+			return "", err
+		}
+	case err != nil:
+		return "", err
+	}
+
+	uc.logger.Infow("Signed in with external identity", "provider", providerName, "userID", userID)
+	return uc.generateAndStoreSession(userID, domain.SessionMethodOIDC+":"+providerName, clientIP, userAgent)
+}
+
+// linkIdentity links a new external identity to the account with the same
+// email, or to a freshly provisioned account. Only emails the provider has
+// verified are trusted.
+func (uc *useCase) linkIdentity(providerName string, claims *oidc.Claims) (int, error) {
+	email := strings.ToLower(claims.Email)
+	if email == "" || !claims.EmailVerified {
+		uc.logger.Warnw("External identity without a verified email", "provider", providerName)
+		return 0, ErrOIDCEmailNotVerified
+	}
+
+	user, err := uc.userRepo.GetByEmail(email)
+	switch {
+	case errors.Is(err, domain.ErrRecordNotFound):
+		if user, err = uc.provisionUser(claims, email); err != nil {
+			return 0, err
+		}
+	case err != nil:
+		return 0, err
+	case !user.EmailVerified:
+		if err = uc.claimUnverifiedAccount(user); err != nil {
+			return 0, err
+		}
+	}
+
+	identity := &domain.Identity{UserID: user.ID, Provider: providerName, Subject: claims.Subject, Email: email}
+	if err = uc.identityRepo.Link(identity); err != nil {
+		return 0, err
+	}
+	uc.logger.Infow("Linked external identity", "provider", providerName, "userID", user.ID)
+	return user.ID, nil
+}
+
+// claimUnverifiedAccount hands an account whose email was never verified to
+// the owner the provider vouched for. Whoever registered it may not own the
+// address, so their password and sessions stop working.
+func (uc *useCase) claimUnverifiedAccount(user *domain.User) error {
+	hashedPassword, err := uc.randomPasswordHash()
+	if err != nil {
+		return err
+	}
+	if err = uc.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return err
+	}
+	if err = uc.otpRepo.DeleteUserSessions(user.ID); err != nil {
+		return err
+	}
+	return uc.userRepo.MarkEmailVerified(user.ID, user.Email)
+}
+
+// provisionUser creates an account for an external identity. It gets an
+// unusable random password; the user can set one through the reset flow.
+// The age comes from the birthdate claim, and identities without one cannot
+// sign up, as the age rule could not be checked.
+func (uc *useCase) provisionUser(claims *oidc.Claims, email string) (*domain.User, error) {
+	age, ok := ageOn(claims.Birthdate, time.Now())
+	if !ok {
+		uc.logger.Warnw("External identity without a birthdate", "email", email)
+		return nil, ErrOIDCAgeUnknown
+	}
+	if age < minimumAge {
+		return nil, ErrAgeRestrict
+	}
+
+	username, err := uc.availableUsername(claims, email)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := uc.randomPasswordHash()
+	if err != nil {
+		return nil, err
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" {
+		firstName, lastName, _ = strings.Cut(claims.Name, " ")
+	}
+	if firstName == "" {
+		firstName = username
+	}
+
+	user := domain.ConvertFromDto(0, firstName, lastName, email, username, hashedPassword, age)
+	if err = uc.userRepo.Insert(user); err != nil {
+		uc.logger.Errorw("Failed to provision user", "username", username, "error", err)
+		return nil, err
+	}
+	if err = uc.userRepo.MarkEmailVerified(user.ID, email); err != nil {
+		return nil, err
+	}
+	user.EmailVerified = true
+
+	uc.logger.Infow("Provisioned user for external identity", "userID", user.ID, "username", username)
+	return user, nil
+}
+
+// availableUsername derives a username from the identity's preferred username
+// or email and appends a number until it is free.
+func (uc *useCase) availableUsername(claims *oidc.Claims, email string) (string, error) {
+	base := sanitizeUsername(claims.PreferredUsername)
+	if base == "" {
+		local, _, _ := strings.Cut(email, "@")
+		base = sanitizeUsername(local)
+	}
+	if len(base) < 3 {
+		base = "user" + base
+	}
+
+	for i := 0; i < 20; i++ {
+		candidate := base
+		if i > 0 {
+			suffix := fmt.Sprintf("%d", i)
+			if len(candidate)+len(suffix) > usernames.MaxLength {
+				candidate = candidate[:usernames.MaxLength-len(suffix)]
+			}
+			candidate += suffix
+		}
+
+		if usernames.Validate(candidate) != nil {
+			continue
+		}
+		err := uc.checkUsernameAvailable(candidate, 0)
+		if err == nil {
+			return candidate, nil
+		} else if !errors.Is(err, usernames.ErrTaken) {
+			return "", err
+		}
+	}
+	return "", ErrUsernameUnavailable
+}
+
+// ageOn returns the age on the given day of someone born on birthdate, in the
+// YYYY-MM-DD form of the OIDC birthdate claim. A bare year counts as born on
+// its last day, so the age is never overstated. Providers write an unknown
+// year as 0000, which is as good as no birthdate.
+func ageOn(birthdate string, now time.Time) (int, bool) {
+	born, err := time.Parse(time.DateOnly, birthdate)
+	if err != nil {
+		year, yearErr := time.Parse("2006", birthdate)
+		if yearErr != nil {
+			return 0, false
+		}
+		born = year.AddDate(1, 0, -1)
+	}
+	if born.Year() == 0 || born.After(now) {
+		return 0, false
+	}
+
+	age := now.Year() - born.Year()
+	if now.Month() < born.Month() || now.Month() == born.Month() && now.Day() < born.Day() {
+		age--
+	}
+	return age, true
+}
+
+func (uc *useCase) randomPasswordHash() (string, error) {
+	password, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	return uc.hasher.Hash(password)
+}
+
+// sanitizeUsername keeps lowercase letters, digits and underscores.
+func sanitizeUsername(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			b.WriteRune(r)
+		}
+		if b.Len() == usernames.MaxLength {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/oidc"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAgeOn(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tc := []struct {
+		birthdate string
+		age       int
+		ok        bool
+	}{
+		{"2012-03-01", 14, true},
+		{"2012-03-02", 13, true},
+		{"2008-02-29", 18, true},
+		{"2011", 14, true},
+		{"2012", 13, true},
+		{"0000-05-17", 0, false},
+		{"2030-01-01", 0, false},
+		{"17/05/2000", 0, false},
+		{"", 0, false},
+	}
+	for _, c := range tc {
+		age, ok := ageOn(c.birthdate, now)
+		if age != c.age || ok != c.ok {
+			t.Errorf("ageOn(%q) = %d, %v, want %d, %v", c.birthdate, age, ok, c.age, c.ok)
+		}
+	}
+}
+
+func TestProvisionRequiresAge(t *testing.T) {
+	tc := newTestUseCase(t)
+	claims := &oidc.Claims{Subject: "42", Email: "Bob@Example.com", EmailVerified: true}
+
+	if _, err := tc.linkIdentity("example", claims); !errors.Is(err, ErrOIDCAgeUnknown) {
+		t.Errorf("without a birthdate: got %v, want the age unknown", err)
+	}
+	claims.Birthdate = time.Now().AddDate(-13, 0, 0).Format(time.DateOnly)
+	if _, err := tc.linkIdentity("example", claims); !errors.Is(err, ErrAgeRestrict) {
+		t.Errorf("aged 13: got %v, want the age restriction", err)
+	}
+}
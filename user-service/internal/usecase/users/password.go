@@ -0,0 +1,179 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	resetTokenTTL      = 30 * time.Minute
+	resetEmailCooldown = time.Minute
+)
+
+// ForgotPassword emails a reset link to the owner of email. It reports success
+// whether or not the email is registered, so callers cannot probe for accounts.
+func (uc *useCase) ForgotPassword(input dto.ForgotPasswordRequest) error {
+	if err := validateNonEmptyField("Email", input.Email); err != nil {
+		return err
+	}
+
+	user, err := uc.userRepo.GetByEmail(input.Email)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			uc.logger.Infow("Password reset requested for unknown email")
+			return nil
+		}
+		uc.logger.Errorw("Failed to fetch user for password reset", "error", err)
+		return err
+	}
+
+	// Don't let the endpoint be used to flood someone's inbox
+	cooldownKey := fmt.Sprintf("reset:%d", user.ID)
+	waiting, err := uc.attemptRepo.BlockedFor(cooldownKey)
+	if err != nil {
+		return err
+	}
+	if waiting > 0 {
+		uc.logger.Infow("Password reset requested again during cooldown", "userID", user.ID)
+		return nil
+	}
+
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		uc.logger.Errorw("Failed to generate reset token", "userID", user.ID, "error", err)
+		return err
+	}
+	if err = uc.resetRepo.StoreResetToken(user.ID, utils.HashToken(token), resetTokenTTL); err != nil {
+		uc.logger.Errorw("Failed to store reset token", "userID", user.ID, "error", err)
+		return err
+	}
+	if err = uc.attemptRepo.Block(cooldownKey, resetEmailCooldown); err != nil {
+		uc.logger.Errorw("Failed to start reset cooldown", "userID", user.ID, "error", err)
+	}
+
+	if err = uc.emails.SendPasswordReset(user.Email, user.Username, token, resetTokenTTL); err != nil {
+		uc.logger.Errorw("Failed to send password reset email", "userID", user.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// ResetPassword sets a new password for the user a reset token was issued to
+// and signs the user out everywhere.
+func (uc *useCase) ResetPassword(input dto.ResetPasswordRequest, clientIP, userAgent string) error {
+	if err := validateNonEmptyField("Token", input.Token); err != nil {
+		return err
+	}
+	tokenHash := utils.HashToken(input.Token)
+
+	userID, err := uc.resetRepo.GetResetToken(tokenHash)
+	if err != nil {
+		uc.logger.Warnw("Invalid password reset token", "error", err)
+		return ErrInvalidResetToken
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		uc.logger.Errorw("Failed to fetch user for password reset", "userID", userID, "error", err)
+		return err
+	}
+
+	// Validate before using the token up, so a rejected password can be retried
+	if err = uc.passwords.Validate(input.Password, user.Username, user.Email); err != nil {
+		return err
+	}
+	hashedPassword, err := uc.hasher.Hash(input.Password)
+	if err != nil {
+		uc.logger.Errorw("failed to hash password", "error", err)
+		return err
+	}
+
+	if _, err = uc.resetRepo.ConsumeResetToken(tokenHash); err != nil {
+		uc.logger.Warnw("Password reset token was used concurrently", "userID", userID, "error", err)
+		return ErrInvalidResetToken
+	}
+	if err = uc.userRepo.UpdatePassword(userID, hashedPassword); err != nil {
+		uc.logger.Errorw("Failed to update password", "userID", userID, "error", err)
+		return err
+	}
+	uc.recordEvent(userID, domain.SecurityPasswordReset, clientIP, userAgent)
+
+	return uc.afterPasswordChange(user)
+}
+
+// ChangePassword replaces the password of a signed in user who proves they
+// know the current one. All sessions are revoked and a fresh one is returned.
+func (uc *useCase) ChangePassword(userID int, input dto.ChangePasswordRequest, clientIP, userAgent string) (string, error) {
+	if err := validateNonEmptyField("Current Password", input.CurrentPassword); err != nil {
+		return "", err
+	}
+
+	current, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		uc.logger.Errorw("Failed to fetch user for password change", "userID", userID, "error", err)
+		return "", err
+	}
+	// GetByID does not load the password hash
+	user, err := uc.userRepo.GetByUsername(current.Username)
+	if err != nil {
+		uc.logger.Errorw("Failed to fetch user for password change", "userID", userID, "error", err)
+		return "", err
+	}
+
+	// Guessing the current password is throttled like guessing it at login
+	if err = uc.checkThrottle(accountKey(user.Username), ipKey(clientIP)); err != nil {
+		uc.logger.Warnw("Password change throttled", "userID", userID, "ip", clientIP, "error", err)
+		return "", err
+	}
+	matches, err := uc.hasher.Verify(input.CurrentPassword, user.Password)
+	if err != nil {
+		uc.logger.Errorw("Failed to verify password", "userID", userID, "error", err)
+	}
+	if !matches {
+		uc.logger.Warnw("Wrong current password on password change", "userID", userID)
+		uc.registerLoginFailure(user.Username, clientIP)
+		return "", ErrWrongCurrentPassword
+	}
+	if input.NewPassword == input.CurrentPassword {
+		return "", ErrPasswordUnchanged
+	}
+	if err = uc.passwords.Validate(input.NewPassword, user.Username, user.Email); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := uc.hasher.Hash(input.NewPassword)
+	if err != nil {
+		uc.logger.Errorw("failed to hash password", "error", err)
+		return "", err
+	}
+	if err = uc.userRepo.UpdatePassword(userID, hashedPassword); err != nil {
+		uc.logger.Errorw("Failed to update password", "userID", userID, "error", err)
+		return "", err
+	}
+	uc.recordEvent(userID, domain.SecurityPasswordChanged, clientIP, userAgent)
+
+	if err = uc.afterPasswordChange(user); err != nil {
+		return "", err
+	}
+	return uc.generateAndStoreSession(userID, domain.SessionMethodPasswordChange, clientIP, userAgent)
+}
+
+// afterPasswordChange revokes every session of the user, lifts a login lockout
+// and lets the user know the password changed.
+func (uc *useCase) afterPasswordChange(user *domain.User) error {
+	if err := uc.otpRepo.DeleteUserSessions(user.ID); err != nil {
+		uc.logger.Errorw("Failed to revoke sessions after password change", "userID", user.ID, "error", err)
+		return err
+	}
+	if err := uc.attemptRepo.ResetFailures(accountKey(user.Username)); err != nil {
+		uc.logger.Errorw("Failed to reset login failures", "userID", user.ID, "error", err)
+	}
+	if err := uc.emails.SendPasswordChanged(user.Email, user.Username); err != nil {
+		uc.logger.Errorw("Failed to send password changed email", "userID", user.ID, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"errors"
+	"testing"
+)
+
+const newPassword = "Quartz-lantern-meadow-917"
+
+func TestResetPasswordRevokesSessions(t *testing.T) {
+	tc := newTestUseCase(t)
+	tc.otps.sessions["old-session"] = 1
+	tc.otps.sessions["other-user"] = 2
+	tc.resets.tokens[utils.HashToken("reset-token")] = 1
+
+	err := tc.ResetPassword(dto.ResetPasswordRequest{Token: "reset-token", Password: newPassword}, "203.0.113.7", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tc.otps.sessions["old-session"]; ok {
+		t.Error("session survived the password reset")
+	}
+	if _, ok := tc.otps.sessions["other-user"]; !ok {
+		t.Error("revoked another user's session")
+	}
+	if _, err = tc.Authorize(dto.LoginRequest{Username: "alice", Password: newPassword}, "203.0.113.7", ""); err != nil {
+		t.Errorf("cannot sign in with the new password: %v", err)
+	}
+
+	err = tc.ResetPassword(dto.ResetPasswordRequest{Token: "reset-token", Password: newPassword + "x"}, "203.0.113.7", "")
+	if !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("got %v, want the used token refused", err)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	tc := newTestUseCase(t)
+	tc.otps.sessions["old-session"] = 1
+
+	_, err := tc.ChangePassword(1, dto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: newPassword}, "", "")
+	if !errors.Is(err, ErrWrongCurrentPassword) {
+		t.Fatalf("got %v, want the wrong current password refused", err)
+	}
+
+	token, err := tc.ChangePassword(1, dto.ChangePasswordRequest{
+		CurrentPassword: "correct-Horse-battery-42",
+		NewPassword:     newPassword,
+	}, "203.0.113.7", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tc.otps.sessions["old-session"]; ok {
+		t.Error("session survived the password change")
+	}
+	if tc.otps.sessions[token] != 1 {
+		t.Error("no fresh session for the user")
+	}
+}
+
+func TestChangePasswordThrottled(t *testing.T) {
+	tc := newTestUseCase(t)
+	wrong := dto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: newPassword}
+
+	for i := int64(0); i <= tc.bruteForce.AccountFreeAttempts; i++ {
+		if _, err := tc.ChangePassword(1, wrong, "203.0.113.7", ""); !errors.Is(err, ErrWrongCurrentPassword) {
+			t.Fatalf("attempt %d: got %v, want the wrong current password refused", i, err)
+		}
+	}
+	var throttled *domain.ThrottleError
+	_, err := tc.ChangePassword(1, dto.ChangePasswordRequest{
+		CurrentPassword: "correct-Horse-battery-42",
+		NewPassword:     newPassword,
+	}, "203.0.113.7", "")
+	if !errors.As(err, &throttled) {
+		t.Errorf("got %v, want the change throttled like a login", err)
+	}
+}
@@ -0,0 +1,53 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usernames"
+	"fmt"
+	"time"
+)
+
+// ChangeUsername renames the user. The previous username keeps resolving to
+// the account for usernames.GracePeriod and can be taken back in that time.
+func (uc *useCase) ChangeUsername(userID int, input dto.ChangeUsernameRequest) (string, error) {
+	if err := usernames.Validate(input.Username); err != nil {
+		return "", err
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if user.Username == input.Username {
+		return "", ErrUsernameUnchanged
+	}
+	if user.UsernameChangedAt != nil {
+		if next := user.UsernameChangedAt.Add(usernames.ChangeCooldown); time.Now().Before(next) {
+			return "", fmt.Errorf("%w, try again after %s", ErrUsernameCooldown, next.UTC().Format(time.RFC3339))
+		}
+	}
+
+	if err = uc.checkUsernameAvailable(input.Username, userID); err != nil {
+		return "", err
+	}
+	if err = uc.userRepo.ChangeUsername(userID, input.Username); err != nil {
+		return "", err
+	}
+
+	uc.logger.Infow("Changed username", "userID", userID, "from", user.Username, "to", input.Username)
+	return input.Username, nil
+}
+
+// checkUsernameAvailable refuses usernames that look like one held by another
+// account or given up by one within the grace period. userID is 0 for new
+// accounts.
+func (uc *useCase) checkUsernameAvailable(username string, userID int) error {
+	inUse, err := uc.userRepo.UsernameInUse(usernames.Skeleton(username), userID, time.Now().Add(-usernames.GracePeriod))
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return usernames.ErrTaken
+	}
+	return nil
+}
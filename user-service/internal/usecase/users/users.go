@@ -3,25 +3,90 @@ package users
 import (
 	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
 	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/emails"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/oidc"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/passwords"
 	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/usernames"
 	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"go.uber.org/zap"
+	"net/mail"
 	"time"
 )
 
+const otpTTL = 5 * time.Minute
+
+// AccountReactivator cancels the pending deletion of an account.
+type AccountReactivator interface {
+	Reactivate(userID int) error
+}
+
+// SecurityLog records the security events of accounts.
+type SecurityLog interface {
+	Record(userID int, eventType, ip, userAgent string) error
+}
+
 type useCase struct {
-	userRepo repository.UserRepo
-	otpRepo  repository.OTPRepo
-	logger   *zap.SugaredLogger
+	userRepo      repository.UserRepo
+	otpRepo       repository.OTPRepo
+	attemptRepo   repository.AttemptRepo
+	resetRepo     repository.PasswordResetRepo
+	roleRepo      repository.RoleRepo
+	identityRepo  repository.IdentityRepo
+	oidcStateRepo repository.OIDCStateRepo
+	sessionRepo   repository.SessionHistoryRepo
+	providers     *oidc.Registry
+	accounts      AccountReactivator
+	security      SecurityLog
+	emails        *emails.Sender
+	passwords     *passwords.Validator
+	hasher        *utils.PasswordHasher
+	bruteForce    BruteForcePolicy
+	logger        *zap.SugaredLogger
+
+	verificationSecret []byte
 }
 
-func NewUserUseCase(userRepo repository.UserRepo, otpRepo repository.OTPRepo, logger *zap.SugaredLogger) *useCase {
+func NewUserUseCase(
+	userRepo repository.UserRepo,
+	otpRepo repository.OTPRepo,
+	attemptRepo repository.AttemptRepo,
+	resetRepo repository.PasswordResetRepo,
+	roleRepo repository.RoleRepo,
+	identityRepo repository.IdentityRepo,
+	oidcStateRepo repository.OIDCStateRepo,
+	sessionRepo repository.SessionHistoryRepo,
+	providers *oidc.Registry,
+	accounts AccountReactivator,
+	security SecurityLog,
+	emailSender *emails.Sender,
+	passwordValidator *passwords.Validator,
+	hasher *utils.PasswordHasher,
+	verificationSecret string,
+	logger *zap.SugaredLogger,
+) *useCase {
 	return &useCase{
-		userRepo: userRepo,
-		otpRepo:  otpRepo,
-		logger:   logger,
+		userRepo:      userRepo,
+		otpRepo:       otpRepo,
+		attemptRepo:   attemptRepo,
+		resetRepo:     resetRepo,
+		roleRepo:      roleRepo,
+		identityRepo:  identityRepo,
+		oidcStateRepo: oidcStateRepo,
+		sessionRepo:   sessionRepo,
+		providers:     providers,
+		accounts:      accounts,
+		security:      security,
+		emails:        emailSender,
+		passwords:     passwordValidator,
+		hasher:        hasher,
+		bruteForce:    DefaultBruteForcePolicy,
+		logger:        logger,
+
+		verificationSecret: []byte(verificationSecret),
 	}
 }
 
@@ -31,11 +96,20 @@ func (uc *useCase) Register(dto dto.RegisterUserRequest) error {
 	if err != nil {
 		return err
 	}
+	if err = usernames.Validate(dto.Username); err != nil {
+		return err
+	}
+	if err = uc.checkUsernameAvailable(dto.Username, 0); err != nil {
+		return err
+	}
+	if err = uc.passwords.Validate(dto.Password, dto.Username, dto.Email); err != nil {
+		return err
+	}
 
 	user := domain.ConvertFromDto(0, dto.FirstName, dto.LastName, dto.Email, dto.Username, dto.Password, dto.Age)
 
 	// Hash the password before storing
-	hashedPassword, err := utils.HashPassword(user.Password)
+	hashedPassword, err := uc.hasher.Hash(user.Password)
 	if err != nil {
 		uc.logger.Errorw("failed to hash password", "error", err)
 		return err
@@ -48,21 +122,35 @@ func (uc *useCase) Register(dto dto.RegisterUserRequest) error {
 		uc.logger.Errorw("Failed to insert user", "error", err)
 		return err
 	}
+
+	// The account stays limited until the email is verified; a failed send can
+	// be retried through the resend endpoint.
+	if err = uc.issueVerification(user); err != nil {
+		uc.logger.Errorw("Failed to issue email verification", "userID", user.ID, "error", err)
+	}
 	return nil
 }
 
-func (uc *useCase) Authorize(input dto.LoginRequest) (string, error) {
+func (uc *useCase) Authorize(input dto.LoginRequest, clientIP, userAgent string) (string, error) {
 	// Validate input
 	if err := validateLoginInput(input); err != nil {
 		uc.logger.Errorw("Validation failed for Login request", "error", err)
 		return "", err
 	}
+
+	// Refuse early if the account or the client is locked out or backing off
+	if err := uc.checkThrottle(accountKey(input.Username), ipKey(clientIP)); err != nil {
+		uc.logger.Warnw("Login attempt throttled", "username", input.Username, "ip", clientIP, "error", err)
+		return "", err
+	}
+
 	// Get user by username
 	user, err := uc.userRepo.GetByUsername(input.Username)
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrRecordNotFound):
 			uc.logger.Warnw("User not found during authorization", "username", input.Username)
+			uc.registerLoginFailure(input.Username, clientIP)
 			return "", domain.ErrRecordNotFound
 		default:
 			return "", err
@@ -70,11 +158,29 @@ func (uc *useCase) Authorize(input dto.LoginRequest) (string, error) {
 	}
 
 	// Validate the password
-	if !utils.CheckPassword(input.Password, user.Password) {
+	matches, err := uc.hasher.Verify(input.Password, user.Password)
+	if err != nil {
+		uc.logger.Errorw("Failed to verify password", "userID", user.ID, "error", err)
+	}
+	if !matches {
 		uc.logger.Warn("Invalid credentials provided")
+		uc.registerLoginFailure(input.Username, clientIP)
+		uc.recordEvent(user.ID, domain.SecurityLoginFailed, clientIP, userAgent)
 		return "", domain.ErrInvalidCredentials
 	}
 
+	if user.IsSuspended() {
+		uc.logger.Warnw("Suspended user tried to log in", "userID", user.ID)
+		return "", domain.ErrAccountSuspended
+	}
+
+	// Upgrade hashes made with an older algorithm or weaker parameters
+	uc.rehashIfNeeded(user, input.Password)
+
+	if err = uc.attemptRepo.ResetFailures(accountKey(input.Username)); err != nil {
+		uc.logger.Errorw("Failed to reset login failures", "username", input.Username, "error", err)
+	}
+
 	// Check if it's user's first login
 	isFirstLogin, err := uc.userRepo.IsFirstLogin(user.ID)
 	if err != nil {
@@ -83,11 +189,13 @@ func (uc *useCase) Authorize(input dto.LoginRequest) (string, error) {
 	}
 
 	if isFirstLogin {
-		code := uc.Authorize2FA(user.Email)
-		return code, nil
+		if err = uc.Authorize2FA(user.Username, clientIP, userAgent); err != nil {
+			return "", err
+		}
+		return "", ErrOTPRequired
 	}
 
-	token, err := uc.generateAndStoreSession(user.ID)
+	token, err := uc.generateAndStoreSession(user.ID, domain.SessionMethodPassword, clientIP, userAgent)
 	if err != nil {
 		uc.logger.Errorw("Failed to generate and store session", "error", err)
 		return "", err
@@ -95,26 +203,57 @@ func (uc *useCase) Authorize(input dto.LoginRequest) (string, error) {
 	return token, nil
 }
 
-func (uc *useCase) Authorize2FA(username string) string {
+func (uc *useCase) Authorize2FA(username, clientIP, userAgent string) error {
 	// Get user email
 	user, err := uc.userRepo.GetByUsername(username)
 	if err != nil {
 		uc.logger.Errorw("Failed to fetch user by username for 2FA", "username", username, "error", err)
-		return ""
+		return err
 	}
 
-	code := utils.GenerateRandomCode(6)
-	uc.logger.Infow("Generated 2FA code", "email", user.Email, "code", code)
+	// Every code comes with more guesses, and anyone can ask for one to be
+	// mailed and recorded for any account, so how many are sent is limited
+	// for the account and for the client
+	if err = uc.checkThrottle(otpIssueKey(user.Email), otpIssueIPKey(clientIP)); err != nil {
+		uc.logger.Warnw("OTP issuing throttled", "email", user.Email, "ip", clientIP, "error", err)
+		return err
+	}
+	if err = uc.countOTPIssue(otpIssueKey(user.Email), uc.bruteForce.MaxOTPIssues); err != nil {
+		return err
+	}
+	if err = uc.countOTPIssue(otpIssueIPKey(clientIP), uc.bruteForce.IPMaxOTPIssues); err != nil {
+		return err
+	}
+
+	code, err := utils.GenerateRandomCode(6)
+	if err != nil {
+		uc.logger.Errorw("Failed to generate OTP", "email", user.Email, "error", err)
+		return err
+	}
+	uc.logger.Infow("Generated 2FA code", "email", user.Email)
 
 	// Store the OTP
-	err = uc.otpRepo.StoreOTP(user.Email, code)
+	err = uc.otpRepo.StoreOTP(user.Email, code, otpTTL)
 	if err != nil {
 		uc.logger.Errorw("Failed to store OTP", "email", user.Email, "error", err)
+		return err
 	}
-	return code
+
+	// Deliver the OTP by email
+	if err = uc.emails.SendOTP(user.Email, user.Username, code, otpTTL); err != nil {
+		uc.logger.Errorw("Failed to send OTP email", "email", user.Email, "error", err)
+		return err
+	}
+	uc.recordEvent(user.ID, domain.SecurityOTPIssued, clientIP, userAgent)
+	return nil
 }
 
-func (uc *useCase) VerifyOTP(email, otp string) (string, error) {
+func (uc *useCase) VerifyOTP(email, otp, clientIP, userAgent string) (string, error) {
+	if err := uc.checkThrottle(otpKey(email), ipKey(clientIP)); err != nil {
+		uc.logger.Warnw("OTP verification throttled", "email", email, "ip", clientIP, "error", err)
+		return "", err
+	}
+
 	// retrieve the OTP
 	storedOtp, err := uc.otpRepo.GetStoreOTP(email)
 	if err != nil {
@@ -123,9 +262,18 @@ func (uc *useCase) VerifyOTP(email, otp string) (string, error) {
 	}
 
 	// compare the OTPs
-	if storedOtp != otp {
+	if subtle.ConstantTimeCompare([]byte(storedOtp), []byte(otp)) != 1 {
 		uc.logger.Warnw("Invalid OTP provided", "email", email)
-		return "", ErrInvalidOTP
+		return "", uc.registerOTPFailure(email, clientIP, userAgent)
+	}
+
+	// The OTP is single-use
+	if err = uc.otpRepo.DeleteOTP(email); err != nil {
+		uc.logger.Errorw("Failed to delete used OTP", "email", email, "error", err)
+		return "", err
+	}
+	if err = uc.attemptRepo.ResetFailures(otpKey(email)); err != nil {
+		uc.logger.Errorw("Failed to reset OTP failures", "email", email, "error", err)
 	}
 
 	// Generate and return session token
@@ -134,7 +282,8 @@ func (uc *useCase) VerifyOTP(email, otp string) (string, error) {
 		uc.logger.Errorw("Failed to fetch user by email for session generation", "email", email, "error", err)
 		return "", err
 	}
-	token, err := uc.generateAndStoreSession(user.ID)
+	uc.recordEvent(user.ID, domain.SecurityOTPVerified, clientIP, userAgent)
+	token, err := uc.generateAndStoreSession(user.ID, domain.SessionMethodOTP, clientIP, userAgent)
 	if err != nil {
 		uc.logger.Errorw("Failed to generate and store session", "error", err)
 		return "", err
@@ -142,7 +291,107 @@ func (uc *useCase) VerifyOTP(email, otp string) (string, error) {
 	return token, nil
 }
 
-func (uc *useCase) generateAndStoreSession(userID int) (string, error) {
+// rehashIfNeeded stores a fresh hash of password if the user's stored hash is
+// outdated. It only runs right after the password was verified, the one time
+// the plaintext is known. Failing to upgrade does not fail the login.
+func (uc *useCase) rehashIfNeeded(user *domain.User, password string) {
+	if !uc.hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := uc.hasher.Hash(password)
+	if err != nil {
+		uc.logger.Errorw("Failed to rehash password", "userID", user.ID, "error", err)
+		return
+	}
+	if err = uc.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		uc.logger.Errorw("Failed to store rehashed password", "userID", user.ID, "error", err)
+		return
+	}
+	uc.logger.Infow("Upgraded outdated password hash", "userID", user.ID)
+}
+
+func (uc *useCase) registerLoginFailure(username, clientIP string) {
+	if _, err := uc.registerFailure(accountKey(username), uc.bruteForce.AccountFreeAttempts, true); err != nil {
+		uc.logger.Errorw("Failed to register login failure", "username", username, "error", err)
+	}
+	if _, err := uc.registerFailure(ipKey(clientIP), uc.bruteForce.IPFreeAttempts, false); err != nil {
+		uc.logger.Errorw("Failed to register login failure", "ip", clientIP, "error", err)
+	}
+}
+
+// registerOTPFailure counts a wrong OTP guess and invalidates the OTP once the
+// allowed number of guesses is used up. The count outlives the OTP, so asking
+// for a new one does not give more guesses: verifying is blocked for the
+// window instead.
+func (uc *useCase) registerOTPFailure(email, clientIP, userAgent string) error {
+	if _, err := uc.registerFailure(ipKey(clientIP), uc.bruteForce.IPFreeAttempts, false); err != nil {
+		uc.logger.Errorw("Failed to register OTP failure", "ip", clientIP, "error", err)
+	}
+
+	failures, err := uc.attemptRepo.RegisterFailure(otpKey(email), uc.bruteForce.Window)
+	if err != nil {
+		uc.logger.Errorw("Failed to register OTP failure", "email", email, "error", err)
+		return ErrInvalidOTP
+	}
+
+	// Only guesses the throttle counted are recorded, so the guesses that
+	// reach the security log are limited like the guesses themselves
+	if user, err := uc.userRepo.GetByEmail(email); err == nil {
+		uc.recordEvent(user.ID, domain.SecurityLoginFailed, clientIP, userAgent)
+	}
+	if failures < uc.bruteForce.MaxOTPAttempts {
+		return ErrInvalidOTP
+	}
+
+	uc.logger.Warnw("Invalidating OTP after repeated wrong guesses", "email", email, "failures", failures)
+	if err = uc.otpRepo.DeleteOTP(email); err != nil {
+		uc.logger.Errorw("Failed to invalidate OTP", "email", email, "error", err)
+	}
+	if err = uc.attemptRepo.Block(otpKey(email), uc.bruteForce.Window); err != nil {
+		uc.logger.Errorw("Failed to block OTP verification", "email", email, "error", err)
+	}
+	return ErrOTPInvalidated
+}
+
+// countOTPIssue counts an OTP sent under the key, and blocks sending more under
+// it for the window once max were sent.
+func (uc *useCase) countOTPIssue(key string, max int64) error {
+	issued, err := uc.attemptRepo.RegisterFailure(key, uc.bruteForce.Window)
+	if err != nil {
+		uc.logger.Errorw("Failed to count issued OTPs", "key", key, "error", err)
+		return err
+	}
+	if issued >= max {
+		if err = uc.attemptRepo.Block(key, uc.bruteForce.Window); err != nil {
+			uc.logger.Errorw("Failed to throttle OTP issuing", "key", key, "error", err)
+		}
+	}
+	return nil
+}
+
+// generateAndStoreSession signs the user in and records the sign-in in the
+// session history under method, and as a security event.
+func (uc *useCase) generateAndStoreSession(userID int, method, clientIP, userAgent string) (string, error) {
+	// Every way of signing in ends here, so suspended users are stopped here
+	// too, and deactivated users come back
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		uc.logger.Errorw("Failed to fetch user for session", "userID", userID, "error", err)
+		return "", err
+	}
+	if user.IsSuspended() {
+		uc.logger.Warnw("Refused session for suspended user", "userID", userID)
+		return "", domain.ErrAccountSuspended
+	}
+	if user.IsDeactivated() {
+		// Signing in during the grace period cancels the account deletion
+		if err = uc.accounts.Reactivate(userID); err != nil {
+			uc.logger.Warnw("Failed to reactivate user", "userID", userID, "error", err)
+			return "", err
+		}
+	}
+
 	sessionToken, err := utils.GenerateSessionToken(userID)
 	if err != nil {
 		uc.logger.Errorw("Failed to generate session token", "userID", userID, "error", err)
@@ -153,25 +402,89 @@ func (uc *useCase) generateAndStoreSession(userID int) (string, error) {
 		uc.logger.Errorw("Failed to create session", "userID", userID, "error", err)
 		return "", err
 	}
+
+	// A missing history entry must not keep the user from signing in
+	if err = uc.sessionRepo.Record(userID, method, clientIP); err != nil {
+		uc.logger.Errorw("Failed to record session history", "userID", userID, "error", err)
+	}
+	// a new session after a password change is not a sign-in of its own
+	if method != domain.SessionMethodPasswordChange {
+		uc.recordEvent(userID, domain.SecurityLogin, clientIP, userAgent)
+	}
 	return sessionToken, nil
 }
 
-func (uc *useCase) Logout(sessionToken string) error {
+func (uc *useCase) Logout(sessionToken, clientIP, userAgent string) error {
+	// An unknown session is deleted all the same, but there is no account
+	// to record the logout for
+	userID, sessionErr := uc.otpRepo.GetSession(sessionToken)
+
 	// Invalidate the session token in the repository (if using a session store)
 	if err := uc.otpRepo.DeleteSession(sessionToken); err != nil {
 		uc.logger.Errorw("Failed to delete session", "sessionToken", sessionToken, "error", err)
 		return err
 	}
+	if sessionErr == nil {
+		uc.recordEvent(userID, domain.SecurityLogout, clientIP, userAgent)
+	}
 	return nil
 }
 
+// recordEvent adds to the user's security events. What the event is about
+// stands even if it cannot be recorded.
+func (uc *useCase) recordEvent(userID int, eventType, clientIP, userAgent string) {
+	if err := uc.security.Record(userID, eventType, clientIP, userAgent); err != nil {
+		uc.logger.Errorw("Failed to record security event", "userID", userID, "type", eventType, "error", err)
+	}
+}
+
+// Authenticate resolves a session token to the user it belongs to.
+func (uc *useCase) Authenticate(sessionToken string) (*domain.User, error) {
+	if sessionToken == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	userID, err := uc.otpRepo.GetSession(sessionToken)
+	if err != nil {
+		uc.logger.Warnw("Failed to resolve session", "error", err)
+		return nil, ErrUnauthenticated
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, ErrUnauthenticated
+		}
+		uc.logger.Errorw("Failed to fetch session user", "userID", userID, "error", err)
+		return nil, err
+	}
+	if err = user.CheckActive(); err != nil {
+		return nil, err
+	}
+
+	user.Roles, user.Permissions, err = uc.roleRepo.GetUserRoles(user.ID)
+	if err != nil {
+		uc.logger.Errorw("Failed to fetch session user roles", "userID", userID, "error", err)
+		return nil, err
+	}
+	return user, nil
+}
+
 func (uc *useCase) List() ([]*domain.User, error) {
 	users, err := uc.userRepo.List()
 	if err != nil {
 		uc.logger.Errorw("Failed to fetch user list", "error", err)
 		return nil, err
 	}
-	return users, nil
+
+	// Suspended and deactivated accounts are hidden from everyone but admins
+	visible := make([]*domain.User, 0, len(users))
+	for _, user := range users {
+		if user.CheckActive() == nil {
+			visible = append(visible, user)
+		}
+	}
+	return visible, nil
 }
 
 func validateDtoInput(dto dto.RegisterUserRequest) error {
@@ -181,10 +494,13 @@ func validateDtoInput(dto dto.RegisterUserRequest) error {
 	if err := validateNonEmptyField("Last Name", dto.LastName); err != nil {
 		return err
 	}
-	if err := validateNonEmptyField("Email", dto.Email); err != nil {
+	if err := validateEmail(dto.Email); err != nil {
 		return err
 	}
-	if dto.Age < 14 {
+	if err := validateNonEmptyField("Username", dto.Username); err != nil {
+		return err
+	}
+	if dto.Age < minimumAge {
 		return ErrAgeRestrict
 	}
 	return nil
@@ -194,6 +510,17 @@ func validateLoginInput(input dto.LoginRequest) error {
 	return validateNonEmptyField("Username", input.Username)
 }
 
+func validateEmail(email string) error {
+	if err := validateNonEmptyField("Email", email); err != nil {
+		return err
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return ErrInvalidEmail
+	}
+	return nil
+}
+
 func validateNonEmptyField(fieldName, value string) error {
 	if len(value) == 0 {
 		return fmt.Errorf("%s is required", fieldName)
@@ -0,0 +1,111 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	verificationTTL            = 48 * time.Hour
+	verificationResendCooldown = time.Minute
+)
+
+// issueVerification emails the user a signed link that proves ownership of the
+// address. The token embeds the address, so it stops working if the email changes.
+func (uc *useCase) issueVerification(user *domain.User) error {
+	expires := time.Now().Add(verificationTTL).Unix()
+	payload := fmt.Sprintf("%d:%d:%s", user.ID, expires, user.Email)
+	token := utils.SignPayload(uc.verificationSecret, payload)
+
+	if err := uc.emails.SendEmailVerification(user.Email, user.Username, token, verificationTTL); err != nil {
+		uc.logger.Errorw("Failed to send verification email", "userID", user.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (uc *useCase) VerifyEmail(input dto.VerifyEmailRequest) error {
+	if err := validateNonEmptyField("Token", input.Token); err != nil {
+		return err
+	}
+
+	payload, err := utils.VerifySignedPayload(uc.verificationSecret, input.Token)
+	if err != nil {
+		uc.logger.Warnw("Invalid email verification token", "error", err)
+		return ErrInvalidVerificationToken
+	}
+	userID, expires, email, err := parseVerificationPayload(payload)
+	if err != nil {
+		uc.logger.Warnw("Malformed email verification token", "error", err)
+		return ErrInvalidVerificationToken
+	}
+	if time.Now().Unix() > expires {
+		return ErrInvalidVerificationToken
+	}
+
+	err = uc.userRepo.MarkEmailVerified(userID, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		uc.logger.Errorw("Failed to mark email verified", "userID", userID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// ResendVerification sends a fresh verification link. Like ForgotPassword it
+// does not reveal whether the email is registered.
+func (uc *useCase) ResendVerification(input dto.ResendVerificationRequest) error {
+	if err := validateNonEmptyField("Email", input.Email); err != nil {
+		return err
+	}
+
+	user, err := uc.userRepo.GetByEmail(input.Email)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil
+		}
+		uc.logger.Errorw("Failed to fetch user for verification resend", "error", err)
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	cooldownKey := fmt.Sprintf("verification:%d", user.ID)
+	waiting, err := uc.attemptRepo.BlockedFor(cooldownKey)
+	if err != nil {
+		return err
+	}
+	if waiting > 0 {
+		return nil
+	}
+	if err = uc.attemptRepo.Block(cooldownKey, verificationResendCooldown); err != nil {
+		uc.logger.Errorw("Failed to start verification cooldown", "userID", user.ID, "error", err)
+	}
+
+	return uc.issueVerification(user)
+}
+
+func parseVerificationPayload(payload string) (int, int64, string, error) {
+	parts := strings.SplitN(payload, ":", 3)
+	if len(parts) != 3 {
+		return 0, 0, "", ErrInvalidVerificationToken
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, "", err
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	return userID, expires, parts[2], nil
+}
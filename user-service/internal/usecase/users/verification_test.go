@@ -0,0 +1,74 @@
+package users
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sentToken returns the verification code in the last email sent.
+func sentToken(t *testing.T, tc *testUseCase) string {
+	t.Helper()
+	if len(tc.mail.sent) == 0 {
+		t.Fatal("no email sent")
+	}
+	text := tc.mail.sent[len(tc.mail.sent)-1].Text
+	_, after, found := strings.Cut(text, "verification code in the app:")
+	if !found {
+		t.Fatalf("no code in %q", text)
+	}
+	return strings.Fields(after)[0]
+}
+
+func TestVerifyEmail(t *testing.T) {
+	tc := newTestUseCase(t)
+	alice := tc.users.users[0]
+
+	if err := tc.ResendVerification(dto.ResendVerificationRequest{Email: alice.Email}); err != nil {
+		t.Fatal(err)
+	}
+	token := sentToken(t, tc)
+
+	signed := func(payload string) string { return utils.SignPayload(tc.verificationSecret, payload) }
+	expired := signed(fmt.Sprintf("%d:%d:%s", alice.ID, time.Now().Add(-time.Minute).Unix(), alice.Email))
+	for name, bad := range map[string]string{
+		"expired":         expired,
+		"tampered":        strings.Replace(token, token[:4], "AAAA", 1),
+		"other secret":    utils.SignPayload([]byte("another-secret"), fmt.Sprintf("%d:%d:%s", alice.ID, time.Now().Add(time.Hour).Unix(), alice.Email)),
+		"malformed":       signed("not-a-payload"),
+		"empty":           "",
+		"unknown account": signed(fmt.Sprintf("%d:%d:%s", 99, time.Now().Add(time.Hour).Unix(), "bob@example.com")),
+	} {
+		if err := tc.VerifyEmail(dto.VerifyEmailRequest{Token: bad}); err == nil {
+			t.Errorf("%s token accepted", name)
+		}
+	}
+	if alice.EmailVerified {
+		t.Fatal("verified by a bad token")
+	}
+
+	if err := tc.VerifyEmail(dto.VerifyEmailRequest{Token: token}); err != nil {
+		t.Fatalf("issued token refused: %v", err)
+	}
+	if !alice.EmailVerified {
+		t.Error("email not marked verified")
+	}
+}
+
+func TestVerifyEmailAfterAddressChange(t *testing.T) {
+	tc := newTestUseCase(t)
+	alice := tc.users.users[0]
+	if err := tc.ResendVerification(dto.ResendVerificationRequest{Email: alice.Email}); err != nil {
+		t.Fatal(err)
+	}
+	token := sentToken(t, tc)
+
+	alice.Email = "alice@example.org"
+	if err := tc.VerifyEmail(dto.VerifyEmailRequest{Token: token}); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Errorf("got %v, want a token for the old address refused", err)
+	}
+}
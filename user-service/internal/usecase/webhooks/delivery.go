@@ -0,0 +1,191 @@
+package webhooks
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	deliveryTimeout     = 10 * time.Second
+	deliveryLease       = time.Minute // longer than an attempt can take
+	deliveryWorkers     = 8
+	maxDeliveryAttempts = 10
+	maxRetryDelay       = 6 * time.Hour
+	maxResponseBody     = 64 << 10 // read from an answer so the connection is reused
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// NewDeliveryClient returns the client webhooks are delivered with. It does
+// not follow redirects and refuses to connect to loopback, private and
+// link-local addresses, so a webhook cannot reach the services behind the
+// gateway.
+func NewDeliveryClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		// checked on the address dialed, after the name was resolved
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !public(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   deliveryTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func public(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsMulticast()
+}
+
+// Run sends the deliveries that are due every interval, and as soon as new
+// ones are scheduled, until ctx is done.
+func (uc *useCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		uc.DeliverDue()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-uc.wake:
+		}
+	}
+}
+
+// DeliverDue sends every delivery that is due, a few at a time so one slow
+// endpoint does not hold up the others, and returns how many succeeded.
+func (uc *useCase) DeliverDue() int {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	delivered := 0
+	slots := make(chan struct{}, deliveryWorkers)
+
+	for {
+		slots <- struct{}{}
+		delivery, webhook, err := uc.webhookRepo.ClaimDue(time.Now().Add(deliveryLease))
+		if err != nil {
+			if !errors.Is(err, domain.ErrRecordNotFound) {
+				uc.logger.Errorw("Failed to claim due webhook delivery", "error", err)
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if uc.deliver(delivery, webhook) {
+				mu.Lock()
+				delivered++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return delivered
+}
+
+// deliver makes one attempt to send the delivery and reports whether the
+// endpoint accepted it with a 2xx status.
+func (uc *useCase) deliver(delivery *domain.WebhookDelivery, webhook *domain.Webhook) bool {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		uc.fail(delivery, nil, err)
+		return false
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "twitter-clone-webhooks")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := uc.client.Do(req)
+	if err != nil {
+		uc.fail(delivery, nil, err)
+		return false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		status := resp.StatusCode
+		uc.fail(delivery, &status, fmt.Errorf("endpoint answered %s", resp.Status))
+		return false
+	}
+	if err = uc.webhookRepo.Delivered(delivery.ID, resp.StatusCode); err != nil {
+		uc.logger.Errorw("Failed to record webhook delivery", "deliveryID", delivery.ID, "error", err)
+	}
+	return true
+}
+
+// fail schedules a retry with exponential backoff, or moves the delivery to
+// the dead letters after maxDeliveryAttempts.
+func (uc *useCase) fail(delivery *domain.WebhookDelivery, status *int, err error) {
+	attempts := delivery.Attempts + 1
+	giveUp := attempts >= maxDeliveryAttempts
+	delay := utils.RetryDelay(attempts, maxRetryDelay)
+
+	if failErr := uc.webhookRepo.Fail(delivery.ID, status, err.Error(), time.Now().Add(delay), giveUp); failErr != nil {
+		uc.logger.Errorw("Failed to record webhook delivery failure", "deliveryID", delivery.ID, "error", failErr)
+	}
+
+	if giveUp {
+		uc.logger.Warnw("Webhook delivery gave up",
+			"webhookID", delivery.WebhookID, "deliveryID", delivery.ID, "attempts", attempts, "error", err)
+		return
+	}
+	uc.logger.Infow("Webhook delivery failed, will retry",
+		"webhookID", delivery.WebhookID, "deliveryID", delivery.ID, "retryIn", delay, "error", err)
+}
+
+// Sign returns the signature header of a payload sent at timestamp, in Unix
+// seconds: "t=<timestamp>,v1=<signature>", where the signature is the
+// hex-encoded HMAC-SHA256 of "<timestamp>.<payload>" keyed with the secret.
+// Receivers should compute it the same way and reject old timestamps.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	t := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
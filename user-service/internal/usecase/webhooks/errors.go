@@ -0,0 +1,17 @@
+package webhooks
+
+import "errors"
+
+var (
+	ErrAppNotFound      = errors.New("app not found")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	ErrInvalidURL       = errors.New("webhook url must be an absolute http or https url")
+	ErrNoEvents         = errors.New("at least one event is required")
+	ErrUnknownEvent     = errors.New("unknown event")
+	ErrTooManyWebhooks  = errors.New("the app has the most webhooks allowed")
+	ErrInvalidStatus    = errors.New("status must be pending, delivered or dead")
+	ErrInvalidPageSize  = errors.New("limit must be between 1 and 100")
+	ErrInvalidOffset    = errors.New("invalid offset")
+	ErrForbiddenAddress = errors.New("webhooks cannot be delivered to internal addresses")
+)
@@ -0,0 +1,270 @@
+package webhooks
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/dto"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxWebhooksPerApp caps the endpoints one app can register.
+	MaxWebhooksPerApp = 5
+
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	// SecretPrefix starts every webhook secret.
+	SecretPrefix = "whsec_"
+)
+
+type useCase struct {
+	webhookRepo repository.WebhookRepo
+	appRepo     repository.AppRepo
+	client      *http.Client
+	logger      *zap.SugaredLogger
+
+	wake chan struct{} // tells Run new deliveries are waiting
+}
+
+// NewWebhookUseCase returns the webhook registry, which delivers events with
+// client; NewDeliveryClient returns the one to use outside of tests.
+func NewWebhookUseCase(
+	webhookRepo repository.WebhookRepo,
+	appRepo repository.AppRepo,
+	client *http.Client,
+	logger *zap.SugaredLogger,
+) *useCase {
+	return &useCase{
+		webhookRepo: webhookRepo,
+		appRepo:     appRepo,
+		client:      client,
+		logger:      logger,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// CreateWebhook registers an endpoint for an app of the owner. The secret
+// deliveries are signed with is returned only here.
+func (uc *useCase) CreateWebhook(ownerID, appID int, input dto.CreateWebhookRequest) (*dto.CreateWebhookResponse, error) {
+	if err := uc.ownedApp(ownerID, appID); err != nil {
+		return nil, err
+	}
+	endpoint, err := validateURL(input.URL)
+	if err != nil {
+		return nil, err
+	}
+	events, err := validateEvents(input.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := uc.webhookRepo.ListWebhooks(appID)
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooks) >= MaxWebhooksPerApp {
+		return nil, ErrTooManyWebhooks
+	}
+
+	secret, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	webhook := &domain.Webhook{AppID: appID, URL: endpoint, Secret: SecretPrefix + secret, Events: events}
+	if err = uc.webhookRepo.InsertWebhook(webhook); err != nil {
+		return nil, err
+	}
+	uc.logger.Infow("Registered webhook", "appID", appID, "webhookID", webhook.ID, "events", events)
+
+	return &dto.CreateWebhookResponse{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		Secret:    webhook.Secret,
+		CreatedAt: webhook.CreatedAt,
+	}, nil
+}
+
+func (uc *useCase) ListWebhooks(ownerID, appID int) ([]*domain.Webhook, error) {
+	if err := uc.ownedApp(ownerID, appID); err != nil {
+		return nil, err
+	}
+	return uc.webhookRepo.ListWebhooks(appID)
+}
+
+// DeleteWebhook removes a webhook with its delivery log. Pending deliveries
+// are dropped.
+func (uc *useCase) DeleteWebhook(ownerID, appID, webhookID int) error {
+	if err := uc.ownedApp(ownerID, appID); err != nil {
+		return err
+	}
+	err := uc.webhookRepo.DeleteWebhook(appID, webhookID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrWebhookNotFound
+	} else if err != nil {
+		return err
+	}
+	uc.logger.Infow("Deleted webhook", "appID", appID, "webhookID", webhookID)
+	return nil
+}
+
+// Deliveries returns one page of the delivery log of a webhook, newest
+// first. Filtering by the dead status lists the deliveries that gave up.
+func (uc *useCase) Deliveries(ownerID, appID, webhookID int, status string, limit, offset int) (*dto.WebhookDeliveryPage, error) {
+	if limit < 1 || limit > MaxPageSize {
+		return nil, ErrInvalidPageSize
+	}
+	if offset < 0 {
+		return nil, ErrInvalidOffset
+	}
+	switch status {
+	case "", domain.DeliveryPending, domain.DeliveryDelivered, domain.DeliveryDead:
+	default:
+		return nil, ErrInvalidStatus
+	}
+	if _, err := uc.webhook(ownerID, appID, webhookID); err != nil {
+		return nil, err
+	}
+
+	// one extra delivery tells whether there is a next page
+	deliveries, err := uc.webhookRepo.ListDeliveries(webhookID, status, limit+1, offset)
+	if err != nil {
+		return nil, err
+	}
+	page := &dto.WebhookDeliveryPage{Deliveries: deliveries}
+	if len(deliveries) > limit {
+		page.Deliveries = deliveries[:limit]
+		next := offset + limit
+		page.NextOffset = &next
+	}
+	return page, nil
+}
+
+// Redeliver sends the payload of a past delivery again, as a new delivery
+// with its own attempts. Dead deliveries are revived this way.
+func (uc *useCase) Redeliver(ownerID, appID, webhookID int, deliveryID int64) (*domain.WebhookDelivery, error) {
+	if _, err := uc.webhook(ownerID, appID, webhookID); err != nil {
+		return nil, err
+	}
+	delivery, err := uc.webhookRepo.Redeliver(webhookID, deliveryID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, ErrDeliveryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	uc.logger.Infow("Scheduled webhook redelivery", "webhookID", webhookID, "deliveryID", deliveryID,
+		"redeliveryID", delivery.ID)
+	uc.notify()
+	return delivery, nil
+}
+
+// Dispatch schedules an event concerning the user for delivery to the
+// webhooks of the user's apps that subscribe to it.
+func (uc *useCase) Dispatch(userID int, event string, data interface{}) error {
+	if !known(event) {
+		return fmt.Errorf("%w: %q", ErrUnknownEvent, event)
+	}
+	payload, err := json.Marshal(dto.WebhookPayload{
+		Type:       event,
+		UserID:     userID,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		return err
+	}
+
+	scheduled, err := uc.webhookRepo.Enqueue(userID, event, payload)
+	if err != nil {
+		return err
+	}
+	if scheduled > 0 {
+		uc.notify()
+	}
+	return nil
+}
+
+// notify wakes Run up without waiting for it.
+func (uc *useCase) notify() {
+	select {
+	case uc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ownedApp checks the app belongs to the owner. Other users' apps are
+// reported as missing.
+func (uc *useCase) ownedApp(ownerID, appID int) error {
+	app, err := uc.appRepo.GetApp(appID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return ErrAppNotFound
+	} else if err != nil {
+		return err
+	}
+	if app.OwnerID != ownerID {
+		return ErrAppNotFound
+	}
+	return nil
+}
+
+// webhook returns a webhook of an app of the owner.
+func (uc *useCase) webhook(ownerID, appID, webhookID int) (*domain.Webhook, error) {
+	if err := uc.ownedApp(ownerID, appID); err != nil {
+		return nil, err
+	}
+	webhook, err := uc.webhookRepo.GetWebhook(appID, webhookID)
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+func validateURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > 2048 {
+		return "", ErrInvalidURL
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" || parsed.User != nil {
+		return "", ErrInvalidURL
+	}
+	return parsed.String(), nil
+}
+
+// validateEvents rejects unknown events and drops duplicates.
+func validateEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+
+	var result []string
+	seen := map[string]bool{}
+	for _, event := range events {
+		if !known(event) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownEvent, event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func known(event string) bool {
+	for _, candidate := range domain.KnownWebhookEvents {
+		if event == candidate {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package webhooks
+
+import (
+	"MussaShaukenov/twitter-clone-go/user-service/internal/domain"
+	"MussaShaukenov/twitter-clone-go/user-service/internal/repository"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type fakeWebhookRepo struct {
+	repository.WebhookRepo
+	delivered []int64
+	failed    []int64
+	gaveUp    bool
+	retryAt   time.Time
+}
+
+func (r *fakeWebhookRepo) Delivered(id int64, status int) error {
+	r.delivered = append(r.delivered, id)
+	return nil
+}
+
+func (r *fakeWebhookRepo) Fail(id int64, status *int, reason string, retryAt time.Time, giveUp bool) error {
+	r.failed = append(r.failed, id)
+	r.gaveUp = giveUp
+	r.retryAt = retryAt
+	return nil
+}
+
+func TestDeliver(t *testing.T) {
+	payload := []byte(`{"type":"tweet.created"}`)
+	webhook := &domain.Webhook{ID: 1, Secret: "whsec_test"}
+
+	var gotSignature, gotEvent, gotDelivery string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(HeaderSignature)
+		gotEvent = r.Header.Get(HeaderEvent)
+		gotDelivery = r.Header.Get(HeaderDelivery)
+		gotBody, _ = io.ReadAll(r.Body)
+		if strings.HasSuffix(r.URL.Path, "/down") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	repo := &fakeWebhookRepo{}
+	uc := NewWebhookUseCase(repo, nil, server.Client(), zap.NewNop().Sugar())
+
+	webhook.URL = server.URL + "/hook"
+	delivery := &domain.WebhookDelivery{ID: 42, WebhookID: 1, Event: domain.WebhookTweetCreated, Payload: payload}
+	if !uc.deliver(delivery, webhook) {
+		t.Fatalf("delivery failed: %v", repo.failed)
+	}
+	if len(repo.delivered) != 1 || gotEvent != domain.WebhookTweetCreated || gotDelivery != "42" {
+		t.Errorf("got delivered %v, event %q, delivery %q", repo.delivered, gotEvent, gotDelivery)
+	}
+	if string(gotBody) != string(payload) {
+		t.Errorf("got body %s", gotBody)
+	}
+	timestamp, _, _ := strings.Cut(strings.TrimPrefix(gotSignature, "t="), ",")
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || gotSignature != Sign(webhook.Secret, sent, payload) {
+		t.Errorf("signature %q does not match the payload", gotSignature)
+	}
+
+	webhook.URL = server.URL + "/down"
+	delivery.Attempts = maxDeliveryAttempts - 2
+	if uc.deliver(delivery, webhook) || repo.gaveUp || time.Until(repo.retryAt) < time.Hour {
+		t.Errorf("failing endpoint: gave up %v, retry at %v", repo.gaveUp, repo.retryAt)
+	}
+	delivery.Attempts = maxDeliveryAttempts - 1
+	if uc.deliver(delivery, webhook) || !repo.gaveUp {
+		t.Errorf("last attempt did not give up")
+	}
+}
+
+func TestSign(t *testing.T) {
+	// computed independently of Sign, as a receiver would
+	want := "t=1700000000,v1=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+	if got := Sign("secret", 1700000000, []byte(`{}`)); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	for raw, ok := range map[string]bool{
+		"https://example.com/hooks":   true,
+		" http://example.com:8080/ ":  true,
+		"ftp://example.com":           false,
+		"/hooks":                      false,
+		"https://user:pw@example.com": false,
+	} {
+		if _, err := validateURL(raw); (err == nil) != ok {
+			t.Errorf("validateURL(%q) = %v", raw, err)
+		}
+	}
+}
+
+func TestDeliveryClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := NewDeliveryClient().Post(server.URL, "application/json", nil)
+	if err == nil || !strings.Contains(err.Error(), ErrForbiddenAddress.Error()) {
+		t.Errorf("got %v, want the loopback address refused", err)
+	}
+}
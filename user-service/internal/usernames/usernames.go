@@ -0,0 +1,69 @@
+package usernames
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	MinLength = 3
+	MaxLength = 30
+
+	// ChangeCooldown is how long a user has to wait between username changes.
+	ChangeCooldown = 30 * 24 * time.Hour
+	// GracePeriod is how long a previous username keeps pointing to the account
+	// that gave it up. Nobody else can claim it in that time.
+	GracePeriod = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidFormat = errors.New("username must be 3 to 30 letters, digits or underscores")
+	ErrReserved      = errors.New("this username is reserved")
+	ErrTaken         = errors.New("this username is taken or too similar to an existing one")
+)
+
+var format = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// reserved names can not be registered, nor anything that looks like them.
+// They cover the service's own accounts and the words used in URL paths.
+var reserved = []string{
+	"admin", "administrator", "root", "system", "support", "help", "security",
+	"moderator", "staff", "official", "twitter", "api", "internal", "me",
+	"media", "oidc", "settings", "login", "logout", "register", "null", "undefined",
+}
+
+var reservedSkeletons = func() map[string]bool {
+	skeletons := make(map[string]bool, len(reserved))
+	for _, name := range reserved {
+		skeletons[Skeleton(name)] = true
+	}
+	return skeletons
+}()
+
+// confusables folds characters that are easily mistaken for each other.
+// The migration that backfills users.username_skeleton uses the same mapping.
+var confusables = strings.NewReplacer("0", "o", "1", "l", "i", "l")
+
+// Skeleton returns the form two usernames share when they look alike, such as
+// "Bill", "bi11" and "BILL". Only one account may hold a skeleton.
+func Skeleton(username string) string {
+	return confusables.Replace(strings.ToLower(username))
+}
+
+// Validate checks the format of the username and that it is not reserved.
+func Validate(username string) error {
+	if len(username) < MinLength || len(username) > MaxLength || !format.MatchString(username) {
+		return ErrInvalidFormat
+	}
+	if reservedSkeletons[Skeleton(username)] {
+		return ErrReserved
+	}
+	return nil
+}
+
+// IsRejection reports whether err means the username itself was not accepted.
+func IsRejection(err error) bool {
+	return errors.Is(err, ErrInvalidFormat) || errors.Is(err, ErrReserved) || errors.Is(err, ErrTaken)
+}
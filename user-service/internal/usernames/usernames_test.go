@@ -0,0 +1,43 @@
+package usernames
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		username string
+		err      error
+	}{
+		{"alice_99", nil},
+		{"Bob", nil},
+		{"ab", ErrInvalidFormat},
+		{strings.Repeat("a", MaxLength+1), ErrInvalidFormat},
+		{"al ice", ErrInvalidFormat},
+		{"alíce", ErrInvalidFormat},
+		{"admin", ErrReserved},
+		{"ADM1N", ErrReserved},
+		{"r00t", ErrReserved},
+		{"admin_fan", nil},
+	}
+
+	for _, tt := range tests {
+		if err := Validate(tt.username); !errors.Is(err, tt.err) {
+			t.Errorf("Validate(%q): expected %v, got %v", tt.username, tt.err, err)
+		}
+	}
+}
+
+func TestSkeleton(t *testing.T) {
+	alike := []string{"Bill", "bi11", "BILL", "bl1l"}
+	for _, name := range alike {
+		if Skeleton(name) != Skeleton(alike[0]) {
+			t.Errorf("expected %q to look like %q", name, alike[0])
+		}
+	}
+	if Skeleton("bob") == Skeleton("b0bby") {
+		t.Error("different names must have different skeletons")
+	}
+}
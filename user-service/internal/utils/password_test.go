@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams keep the tests fast; production uses DefaultArgon2Params.
+var testParams = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHasherHashAndVerify(t *testing.T) {
+	hasher := NewPasswordHasher(testParams)
+
+	hashed, err := hasher.Hash("correct-Horse-battery-42")
+	if err != nil {
+		t.Fatalf("error hashing password: %v", err)
+	}
+	if !strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("unexpected hash format %q", hashed)
+	}
+
+	matches, err := hasher.Verify("correct-Horse-battery-42", hashed)
+	if err != nil || !matches {
+		t.Errorf("expected password to match, got %v (error %v)", matches, err)
+	}
+	matches, err = hasher.Verify("wrong password", hashed)
+	if err != nil || matches {
+		t.Errorf("expected wrong password not to match, got %v (error %v)", matches, err)
+	}
+}
+
+func TestPasswordHasherVerifiesBcrypt(t *testing.T) {
+	hasher := NewPasswordHasher(testParams)
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("error creating bcrypt hash: %v", err)
+	}
+
+	matches, err := hasher.Verify("password123", string(legacy))
+	if err != nil || !matches {
+		t.Errorf("expected bcrypt hash to match, got %v (error %v)", matches, err)
+	}
+	if !hasher.NeedsRehash(string(legacy)) {
+		t.Errorf("expected bcrypt hash to need a rehash")
+	}
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	hashed, err := NewPasswordHasher(testParams).Hash("password123")
+	if err != nil {
+		t.Fatalf("error hashing password: %v", err)
+	}
+
+	if NewPasswordHasher(testParams).NeedsRehash(hashed) {
+		t.Errorf("expected hash with current parameters not to need a rehash")
+	}
+
+	stronger := testParams
+	stronger.Iterations = 2
+	if !NewPasswordHasher(stronger).NeedsRehash(hashed) {
+		t.Errorf("expected hash with weaker parameters to need a rehash")
+	}
+}
+
+func TestGenerateRandomCode(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		code, err := GenerateRandomCode(6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != 6 || strings.Trim(code, "0123456789") != "" {
+			t.Fatalf("code %q is not 6 digits", code)
+		}
+		seen[code] = true
+	}
+	// codes made in the same instant must not repeat
+	if len(seen) < 19 {
+		t.Errorf("got only %d distinct codes out of 20", len(seen))
+	}
+}
@@ -0,0 +1,15 @@
+package utils
+
+import "time"
+
+// RetryDelay is how long background jobs wait after the given number of
+// failed attempts: a minute after the first, doubling up to maxDelay.
+func RetryDelay(attempts int, maxDelay time.Duration) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 30 {
+		return maxDelay
+	}
+	return min(time.Minute<<(attempts-1), maxDelay)
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	maxDelay := 6 * time.Hour
+	for attempts, want := range map[int]time.Duration{
+		0: time.Minute, 1: time.Minute, 2: 2 * time.Minute, 5: 16 * time.Minute, 9: 256 * time.Minute,
+		10: maxDelay, 40: maxDelay, 70: maxDelay,
+	} {
+		if got := RetryDelay(attempts, maxDelay); got != want {
+			t.Errorf("RetryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidSignature = errors.New("invalid token signature")
+
+// MinSecretLength is the shortest signing secret accepted.
+const MinSecretLength = 32
+
+// placeholderPrefix starts the example secrets once shipped in the env files.
+const placeholderPrefix = "change-me"
+
+// CheckSecret returns an error if secret is too short to be safe, or is still
+// a placeholder anyone reading the repository knows.
+func CheckSecret(secret string) error {
+	if len(secret) < MinSecretLength {
+		return fmt.Errorf("must be at least %d characters", MinSecretLength)
+	}
+	if strings.HasPrefix(strings.ToLower(secret), placeholderPrefix) {
+		return errors.New("must not be the example placeholder")
+	}
+	return nil
+}
+
+// DeriveKey returns a key for one purpose derived from secret, so tokens
+// signed for one purpose never pass for another.
+func DeriveKey(secret, purpose string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignPayload returns payload and its HMAC-SHA256 signature, both base64url
+// encoded and joined with a dot.
+func SignPayload(secret []byte, payload string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + sign(secret, encoded)
+}
+
+// VerifySignedPayload checks a token produced by SignPayload and returns the payload.
+func VerifySignedPayload(secret []byte, token string) (string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, encoded))) {
+		return "", ErrInvalidSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	return string(payload), nil
+}
+
+func sign(secret []byte, data string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
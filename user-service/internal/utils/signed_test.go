@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignedPayload(t *testing.T) {
+	secret := []byte("a-secret-of-thirty-two-characters")
+	token := SignPayload(secret, "7:1700000000:alice@example.com")
+
+	payload, err := VerifySignedPayload(secret, token)
+	if err != nil || payload != "7:1700000000:alice@example.com" {
+		t.Fatalf("VerifySignedPayload = %q, %v", payload, err)
+	}
+
+	encoded, signature, _ := strings.Cut(token, ".")
+	forged := SignPayload(secret, "8:1700000000:mallory@example.com")
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+	for name, tampered := range map[string]string{
+		"other secret":    SignPayload([]byte("another-secret-of-thirty-two-chars"), "7:1700000000:alice@example.com"),
+		"swapped payload": forgedPayload + "." + signature,
+		"no signature":    encoded,
+		"empty secret":    SignPayload(nil, "7:1700000000:alice@example.com"),
+	} {
+		if _, err = VerifySignedPayload(secret, tampered); err != ErrInvalidSignature {
+			t.Errorf("%s: got %v, want ErrInvalidSignature", name, err)
+		}
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	secret := "a-secret-of-thirty-two-characters"
+	if DeriveKey(secret, "data-export-links") == DeriveKey(secret, "other") {
+		t.Error("keys for different purposes match")
+	}
+	if DeriveKey(secret, "data-export-links") == secret {
+		t.Error("derived key is the secret")
+	}
+	if len(DeriveKey(secret, "data-export-links")) < MinSecretLength {
+		t.Error("derived key is shorter than a secret must be")
+	}
+}
+
+func TestCheckSecret(t *testing.T) {
+	for secret, ok := range map[string]bool{
+		"":                                    false,
+		"too-short":                           false,
+		"change-me-email-verification-secret": false,
+		"CHANGE-ME-but-long-enough-to-pass-the-length": false,
+		"a-secret-of-thirty-two-characters":            true,
+	} {
+		if err := CheckSecret(secret); (err == nil) != ok {
+			t.Errorf("CheckSecret(%q) = %v", secret, err)
+		}
+	}
+}
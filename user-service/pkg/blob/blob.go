@@ -0,0 +1,100 @@
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store keeps uploaded files under flat, slash separated keys.
+type Store interface {
+	Put(key string, r io.Reader) error
+	Open(key string) (*os.File, error)
+	Delete(key string) error
+}
+
+// keyPattern allows one directory level and a file name, which keeps keys from
+// escaping the store's directory.
+var keyPattern = regexp.MustCompile(`^[a-z0-9_-]+/[A-Za-z0-9_-]+\.[a-z0-9]+$`)
+
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+type localStore struct {
+	dir string
+}
+
+// NewLocalStore stores blobs as files below dir.
+func NewLocalStore(dir string) (*localStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create blob directory: %w", err)
+	}
+	return &localStore{dir: dir}, nil
+}
+
+// Put writes the blob to a temporary file first and renames it into place, so
+// readers never see a partial file.
+func (s *localStore) Put(key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *localStore) Open(key string) (*os.File, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes the blob. Deleting a missing blob is not an error.
+func (s *localStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *localStore) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,51 @@
+package blob
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = store.Put("avatars/7-abc.png", strings.NewReader("image bytes")); err != nil {
+		t.Fatal(err)
+	}
+	file, err := store.Open("avatars/7-abc.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if string(content) != "image bytes" {
+		t.Errorf("unexpected content %q", content)
+	}
+
+	if err = store.Delete("avatars/7-abc.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Open("avatars/7-abc.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err = store.Delete("avatars/7-abc.png"); err != nil {
+		t.Errorf("deleting a missing blob failed: %v", err)
+	}
+}
+
+func TestLocalStoreRejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../secret.txt", "avatars/../../x.png", "/etc/passwd", "avatars/a/b.png", "avatars/x"} {
+		if err = store.Put(key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("key %q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
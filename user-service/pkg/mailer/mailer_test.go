@@ -0,0 +1,137 @@
+package mailer
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewFileMailer(dir, "no-reply@example.com")
+	if err != nil {
+		t.Fatalf("error creating file mailer: %v", err)
+	}
+
+	err = m.Send(Message{To: "user@example.com", Subject: "Hello", Text: "plain body", HTML: "<p>html body</p>"})
+	if err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("error reading sink dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 message in sink, got %d", len(entries))
+	}
+
+	content, err := os.ReadFile(dir + "/" + entries[0].Name())
+	if err != nil {
+		t.Fatalf("error reading message: %v", err)
+	}
+	for _, want := range []string{"To: user@example.com", "Subject: Hello", "plain body", "<p>html body</p>", "multipart/alternative"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected message to contain %q", want)
+		}
+	}
+}
+
+func TestFileMailerRequiresRecipient(t *testing.T) {
+	m, err := NewFileMailer(t.TempDir(), "no-reply@example.com")
+	if err != nil {
+		t.Fatalf("error creating file mailer: %v", err)
+	}
+	if err = m.Send(Message{Subject: "Hello"}); !errors.Is(err, ErrNoRecipient) {
+		t.Errorf("expected %v, got %v", ErrNoRecipient, err)
+	}
+}
+
+func TestTemplatesRender(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/welcome.txt":  {Data: []byte(`{{define "subject"}}Welcome {{.Name}}{{end}}Hi {{.Name}}`)},
+		"templates/welcome.html": {Data: []byte(`<p>Hi {{.Name}}</p>`)},
+	}
+	templates, err := ParseTemplates(fsys, "templates")
+	if err != nil {
+		t.Fatalf("error parsing templates: %v", err)
+	}
+
+	msg, err := templates.Render("welcome", "user@example.com", map[string]string{"Name": "<Bob>"})
+	if err != nil {
+		t.Fatalf("error rendering template: %v", err)
+	}
+	if msg.Subject != "Welcome <Bob>" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if msg.Text != "Hi <Bob>" {
+		t.Errorf("unexpected text body %q", msg.Text)
+	}
+	if msg.HTML != "<p>Hi &lt;Bob&gt;</p>" {
+		t.Errorf("expected escaped html body, got %q", msg.HTML)
+	}
+}
+
+// flakyMailer fails the first failures sends and records the rest.
+type flakyMailer struct {
+	mu       sync.Mutex
+	failures int
+	sent     []Message
+}
+
+func (m *flakyMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("smtp unavailable")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestQueueRetries(t *testing.T) {
+	transport := &flakyMailer{failures: 2}
+	queue := NewQueue(transport, QueueOptions{Workers: 1, Capacity: 4, MaxRetries: 3, Backoff: time.Millisecond}, zap.NewNop().Sugar())
+
+	if err := queue.Send(Message{To: "user@example.com", Subject: "Hello"}); err != nil {
+		t.Fatalf("error enqueueing message: %v", err)
+	}
+	queue.Close()
+
+	if len(transport.sent) != 1 {
+		t.Fatalf("expected message to be delivered after retries, got %d deliveries", len(transport.sent))
+	}
+	if err := queue.Send(Message{To: "user@example.com"}); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("expected %v, got %v", ErrQueueClosed, err)
+	}
+}
+
+func TestQueueCloseDoesNotWaitForBackoff(t *testing.T) {
+	transport := &flakyMailer{failures: 2}
+	queue := NewQueue(transport, QueueOptions{Workers: 1, Capacity: 4, MaxRetries: 3, Backoff: time.Hour}, zap.NewNop().Sugar())
+
+	if err := queue.Send(Message{To: "user@example.com", Subject: "Hello"}); err != nil {
+		t.Fatalf("error enqueueing message: %v", err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		queue.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close waited for the retry backoff")
+	}
+	if len(transport.sent) != 1 {
+		t.Errorf("expected the retries to be made at close, got %d deliveries", len(transport.sent))
+	}
+}
@@ -0,0 +1,128 @@
+package mailer
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrQueueFull   = errors.New("mail queue is full")
+	ErrQueueClosed = errors.New("mail queue is closed")
+)
+
+type QueueOptions struct {
+	Workers    int
+	Capacity   int
+	MaxRetries int
+	Backoff    time.Duration // delay before the first retry, doubled on every further retry
+}
+
+var DefaultQueueOptions = QueueOptions{
+	Workers:    2,
+	Capacity:   256,
+	MaxRetries: 5,
+	Backoff:    time.Second,
+}
+
+// Queue is a Mailer that hands messages to background workers, which deliver
+// them through the wrapped Mailer and retry failed deliveries with backoff.
+type Queue struct {
+	mailer   Mailer
+	options  QueueOptions
+	logger   *zap.SugaredLogger
+	messages chan Message
+	done     chan struct{} // closed by Close to cut retry waits short
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewQueue(mailer Mailer, options QueueOptions, logger *zap.SugaredLogger) *Queue {
+	q := &Queue{
+		mailer:   mailer,
+		options:  options,
+		logger:   logger,
+		messages: make(chan Message, options.Capacity),
+		done:     make(chan struct{}),
+	}
+	for i := 0; i < options.Workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Send enqueues msg without waiting for it to be delivered.
+func (q *Queue) Send(msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case q.messages <- msg:
+		return nil
+	default:
+		q.logger.Errorw("mail queue is full, dropping message", "to", msg.To, "subject", msg.Subject)
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting messages and waits for the queued ones to be processed.
+// Retries are no longer waited for, so closing does not hang on the backoff.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.messages)
+	close(q.done)
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for msg := range q.messages {
+		q.deliver(msg)
+	}
+}
+
+func (q *Queue) deliver(msg Message) {
+	delay := q.options.Backoff
+	for attempt := 0; ; attempt++ {
+		err := q.mailer.Send(msg)
+		if err == nil {
+			return
+		}
+		if attempt >= q.options.MaxRetries {
+			q.logger.Errorw("giving up on mail delivery", "to", msg.To, "subject", msg.Subject, "attempts", attempt+1, "error", err)
+			return
+		}
+		q.logger.Warnw("mail delivery failed, retrying", "to", msg.To, "subject", msg.Subject, "retryIn", delay, "error", err)
+		q.wait(delay)
+		delay *= 2
+	}
+}
+
+// wait sleeps for d, or until the queue is closed.
+func (q *Queue) wait(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-q.done:
+	}
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPConfig describes the relay to deliver through. Username may be left
+// empty for relays without authentication, such as a local MailHog.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type smtpMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) *smtpMailer {
+	return &smtpMailer{
+		config: config,
+	}
+}
+
+func (m *smtpMailer) Send(msg Message) error {
+	body, err := buildMIME(m.config.From, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	return smtp.SendMail(addr, auth, m.config.From, []string{msg.To}, body)
+}
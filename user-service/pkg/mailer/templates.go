@@ -0,0 +1,77 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Templates renders messages from pairs of "<name>.html" and "<name>.txt"
+// templates. The text template must also define a "subject" template.
+type Templates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+func ParseTemplates(fsys fs.FS, dir string) (*Templates, error) {
+	t := &Templates{
+		html: map[string]*htmltemplate.Template{},
+		text: map[string]*texttemplate.Template{},
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		file := path.Join(dir, entry.Name())
+		switch ext := path.Ext(entry.Name()); ext {
+		case ".html":
+			name := strings.TrimSuffix(entry.Name(), ext)
+			if t.html[name], err = htmltemplate.ParseFS(fsys, file); err != nil {
+				return nil, err
+			}
+		case ".txt":
+			name := strings.TrimSuffix(entry.Name(), ext)
+			if t.text[name], err = texttemplate.ParseFS(fsys, file); err != nil {
+				return nil, err
+			}
+			if t.text[name].Lookup("subject") == nil {
+				return nil, fmt.Errorf("mail template %s does not define a subject", file)
+			}
+		}
+	}
+	return t, nil
+}
+
+// Render executes the templates registered under name and returns a message for to.
+func (t *Templates) Render(name, to string, data interface{}) (Message, error) {
+	text, ok := t.text[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown mail template %q", name)
+	}
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := text.Execute(&textBody, data); err != nil {
+		return Message{}, err
+	}
+	if html, ok := t.html[name]; ok {
+		if err := html.Execute(&htmlBody, data); err != nil {
+			return Message{}, err
+		}
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(textBody.String()),
+		HTML:    htmlBody.String(),
+	}, nil
+}
@@ -3,13 +3,15 @@ package utils
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math/rand"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var ErrInvalidId = errors.New("invalid ID value")
@@ -43,6 +45,92 @@ func WriteJson(w http.ResponseWriter, status int, in interface{}, headers http.H
 	return nil
 }
 
+// trustedProxies are the networks of the proxies, such as the nginx gateway,
+// whose X-Real-IP and X-Forwarded-For headers ClientIP believes.
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies sets the proxies ClientIP takes the client address from,
+// as IP addresses or CIDR ranges. With none set the headers are ignored, as
+// anyone reaching the service directly could set them.
+func SetTrustedProxies(proxies []string) error {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	trustedProxies = networks
+	return nil
+}
+
+func trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client. The headers set by the nginx
+// gateway are only believed when the request came from a trusted proxy.
+func ClientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !trustedProxy(remote) {
+		return remote
+	}
+
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		// proxies append the address they got the request from, so the
+		// client is the last address that is not one of them
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop != "" && (i == 0 || !trustedProxy(hop)) {
+				return hop
+			}
+		}
+	}
+	return remote
+}
+
+// RetryAfterSeconds formats d for the Retry-After header, rounding up to whole seconds.
+func RetryAfterSeconds(d time.Duration) string {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
+
 func GetIdFromQueryParam(w http.ResponseWriter, r *http.Request) (int, error) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
@@ -77,26 +165,3 @@ func GenerateRandomStringOfLength(n int) string {
 
 	return string(result)
 }
-
-func HashPassword(plaintextPassword string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
-	if err != nil {
-		return "", err
-	}
-
-	return string(hash), nil
-}
-
-func MatchesPassword(plaintextPassword string, hashedPassword []byte) (bool, error) {
-	err := bcrypt.CompareHashAndPassword(hashedPassword, []byte(plaintextPassword))
-	if err != nil {
-		switch {
-		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
-			return false, nil
-		default:
-			return false, err
-		}
-	}
-
-	return true, nil
-}
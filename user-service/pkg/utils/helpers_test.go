@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestValidateId(t *testing.T) {
@@ -97,40 +98,89 @@ func TestGenerateRandomStringOfLength(t *testing.T) {
 	}
 }
 
-func TestHashPassword(t *testing.T) {
-	// Define a plaintext password
-	plaintextPassword := "password123"
+func TestClientIP(t *testing.T) {
+	if err := SetTrustedProxies([]string{"172.18.0.2", "10.1.0.0/16"}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetTrustedProxies(nil)
 
-	// Hash the plaintext password
-	hashedPassword, err := HashPassword(plaintextPassword)
-	if err != nil {
-		t.Errorf("error hashing password: %v", err)
+	tc := []struct {
+		name    string
+		headers map[string]string
+		remote  string
+		want    string
+	}{
+		{
+			name:    "real ip header",
+			headers: map[string]string{"X-Real-IP": "10.0.0.1"},
+			remote:  "172.18.0.2:5555",
+			want:    "10.0.0.1",
+		},
+		{
+			name:    "forwarded for header",
+			headers: map[string]string{"X-Forwarded-For": "10.0.0.2, 172.18.0.2"},
+			remote:  "172.18.0.2:5555",
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "forwarded for header with a spoofed first hop",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.9, 10.1.4.4"},
+			remote:  "172.18.0.2:5555",
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "headers from an untrusted client",
+			headers: map[string]string{"X-Real-IP": "10.0.0.1", "X-Forwarded-For": "10.0.0.2"},
+			remote:  "192.168.1.5:5555",
+			want:    "192.168.1.5",
+		},
+		{
+			name:   "remote address",
+			remote: "192.168.1.5:5555",
+			want:   "192.168.1.5",
+		},
 	}
 
-	// Check if the hashed password is not equal to the plaintext password
-	if hashedPassword == plaintextPassword {
-		t.Errorf("expected hashed password to be different from plaintext password")
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			if got := ClientIP(req); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
 	}
 }
 
-func TestMatchesPassword(t *testing.T) {
-	// Define a plaintext password
-	plaintextPassword := "password123"
-
-	// Hash the plaintext password
-	hashedPassword, err := HashPassword(plaintextPassword)
-	if err != nil {
-		t.Errorf("error hashing password: %v", err)
+func TestSetTrustedProxies(t *testing.T) {
+	defer SetTrustedProxies(nil)
+	if err := SetTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Error("expected an invalid proxy to be rejected")
+	}
+	if err := SetTrustedProxies([]string{" 10.0.0.0/8 ", "", "::1"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
 	}
+}
 
-	// Check if the plaintext password matches the hashed password
-	match, err := MatchesPassword(plaintextPassword, []byte(hashedPassword))
-	if err != nil {
-		t.Errorf("error matching password: %v", err)
+func TestRetryAfterSeconds(t *testing.T) {
+	tc := []struct {
+		name string
+		in   time.Duration
+		want string
+	}{
+		{name: "rounds up", in: 1500 * time.Millisecond, want: "2"},
+		{name: "whole seconds", in: 3 * time.Second, want: "3"},
+		{name: "at least one second", in: 10 * time.Millisecond, want: "1"},
 	}
 
-	// Check if the password match is true
-	if !match {
-		t.Errorf("expected password match to be true")
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RetryAfterSeconds(tt.in); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
 	}
 }